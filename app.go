@@ -6,22 +6,20 @@ import (
 	"database/sql"
 	"encoding/base64"
 	"encoding/json"
-	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"time"
 
 	_ "github.com/marcboeker/go-duckdb"
 	_ "github.com/mattn/go-sqlite3"
-	"github.com/paulmach/osm"
-	"github.com/paulmach/osm/osmgeojson"
-	"github.com/sashabaranov/go-openai"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -30,8 +28,57 @@ type App struct {
 	ctx    context.Context
 	db     *sql.DB
 	duckDB *sql.DB
+	// mu guards db. CreateIndex holds it for the whole walk, so nothing that runs during indexing
+	// may take it, getSetting included; the settings indexing needs are read and cached first.
 	mu     sync.RWMutex
 	duckMu sync.RWMutex
+
+	gdalOnce sync.Once
+	gdalInfo GDALInfo
+
+	// gdalSlotsChan limits how many GDAL subprocesses run at once; gdalTimeoutValue caches the
+	// configured timeout
+	gdalSlotsMu      sync.Mutex
+	gdalSlotsChan    chan struct{}
+	gdalTimeoutValue time.Duration
+
+	logger appLogger
+
+	// openAI rate limits and caches getLocationFromOpenAI
+	openAI openAIState
+
+	// bounds caches GetLayerBounds results
+	bounds layerBoundsCache
+
+	// bandStats caches GetRasterBandStats results
+	bandStats bandStatsCache
+
+	// http is the client shared by all outbound requests
+	http httpClientState
+
+	// streams tracks LoadGeospatialFileStreaming loads so they can be cancelled
+	streams layerStreamState
+
+	// extensions caches the file extensions indexing looks for
+	extensions supportedExtensionState
+
+	// jobs tracks the background jobs started with EnqueueJob
+	jobs jobQueueState
+
+	// queries remembers recently generated Overpass queries for the query history
+	queries queryHistoryState
+
+	// overpass limits concurrent Overpass requests and tracks StartOverpassQuery queries
+	overpass overpassQueryState
+
+	// listFilesStmt is prepared on first use by ListIndexedFiles and reused afterwards
+	stmtMu        sync.Mutex
+	listFilesStmt *sql.Stmt
+
+	// indexCancel stops the running CreateIndex walk and indexRun tracks its progress, if any
+	indexMu     sync.Mutex
+	indexCancel context.CancelFunc
+	indexRun    *IndexProgress
 }
 
 // NewApp creates a new App application struct
@@ -43,79 +90,315 @@ func NewApp() *App {
 // so we can call the runtime methods
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
-	a.initDatabase()
-	a.initDuckDB()
+	if err := a.initDatabase(); err != nil {
+		runtime.LogError(ctx, fmt.Sprintf("Failed to initialize database: %v", err))
+	}
+	if err := a.initLogger(); err != nil {
+		runtime.LogWarning(ctx, fmt.Sprintf("Logging to file disabled: %v", err))
+	}
+	if err := a.reconcileIndexRuns(); err != nil {
+		a.logWarn("Could not reconcile unfinished index runs: %v", err)
+	}
+	if err := a.resumeJobs(); err != nil {
+		a.logWarn("Could not resume queued jobs: %v", err)
+	}
+	if err := a.initDuckDB(); err != nil {
+		a.logError("Failed to initialize DuckDB: %v", err)
+	}
+	a.logInfo("Terrabox started")
 }
 
-// initDatabase initializes the SQLite database
-func (a *App) initDatabase() error {
+// shutdown is called when the app is closing. It stops indexing, streamed loads, Overpass queries
+// and jobs, waits for pending writes, marks unfinished index runs and jobs as interrupted and
+// closes the databases.
+func (a *App) shutdown(ctx context.Context) {
+	a.logInfo("Shutting down")
+
+	a.indexMu.Lock()
+	if a.indexCancel != nil {
+		a.indexCancel()
+	}
+	a.indexMu.Unlock()
+
+	a.streams.mu.Lock()
+	for _, cancel := range a.streams.cancels {
+		cancel()
+	}
+	a.streams.mu.Unlock()
+
+	a.overpass.mu.Lock()
+	for _, cancel := range a.overpass.cancels {
+		cancel()
+	}
+	a.overpass.mu.Unlock()
+
+	a.jobs.mu.Lock()
+	a.jobs.closing = true
+	for _, cancel := range a.jobs.cancels {
+		cancel()
+	}
+	a.jobs.mu.Unlock()
+
+	// CreateIndex holds the write lock for the whole walk, so this waits for it to stop
+	a.mu.Lock()
+	if a.db != nil {
+		if _, err := a.db.Exec(
+			"UPDATE index_progress SET status = 'interrupted', end_time = ? WHERE status = 'in_progress'",
+			time.Now().Format(time.RFC3339),
+		); err != nil {
+			a.logWarn("Could not mark unfinished index runs as interrupted: %v", err)
+		}
+		// Save job changes still queued for flushJobs, then leave queued jobs queued so they
+		// start again on the next launch
+		a.jobs.mu.Lock()
+		writes := a.jobs.pending
+		a.jobs.pending = nil
+		a.jobs.mu.Unlock()
+		a.writeJobs(writes)
+		if _, err := a.db.Exec(
+			"UPDATE jobs SET status = ?, finished_at = ? WHERE status = ?",
+			jobInterrupted, time.Now().Format(time.RFC3339), jobRunning,
+		); err != nil {
+			a.logWarn("Could not mark running jobs as interrupted: %v", err)
+		}
+		a.stmtMu.Lock()
+		if a.listFilesStmt != nil {
+			a.listFilesStmt.Close()
+			a.listFilesStmt = nil
+		}
+		a.stmtMu.Unlock()
+		if err := a.db.Close(); err != nil {
+			a.logWarn("Could not close database: %v", err)
+		}
+		a.db = nil
+	}
+	a.mu.Unlock()
+
+	a.duckMu.Lock()
+	if a.duckDB != nil {
+		if err := a.duckDB.Close(); err != nil {
+			a.logWarn("Could not close DuckDB: %v", err)
+		}
+		a.duckDB = nil
+	}
+	a.duckMu.Unlock()
+
+	a.logger.close()
+}
+
+// terraboxDir returns the ~/.terrabox data directory, creating it if needed
+func terraboxDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	dir := filepath.Join(homeDir, ".terrabox")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
 	}
+	return dir, nil
+}
+
+// schemaVersion is recorded in the catalog's PRAGMA user_version; bump it when tables change
+const schemaVersion = 1
+
+// catalogSchema creates the catalog tables; every statement is safe to run again
+const catalogSchema = `
+CREATE TABLE IF NOT EXISTS geo_file_index (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	file_path TEXT NOT NULL,
+	file_name TEXT NOT NULL,
+	file_extension TEXT NOT NULL,
+	file_size INTEGER NOT NULL,
+	created_at INTEGER,
+	modified_at INTEGER,
+	file_type TEXT NOT NULL,
+	layer_name TEXT NOT NULL,
+	crs TEXT,
+	bbox TEXT,
+	num_features INTEGER,
+	num_bands INTEGER,
+	resolution REAL,
+	metadata TEXT,
+	bbox_geom TEXT,
+	centroid_geom TEXT,
+	UNIQUE(file_path, layer_name)
+);
+
+-- geo_file_rtree indexes the extent of every file with a known bbox for FindFilesInBBox. The
+-- triggers keep it in step with geo_file_index and leave out the whole-world placeholder.
+CREATE VIRTUAL TABLE IF NOT EXISTS geo_file_rtree USING rtree(id, min_x, max_x, min_y, max_y);
+
+CREATE TRIGGER IF NOT EXISTS geo_file_rtree_insert AFTER INSERT ON geo_file_index
+WHEN json_valid(NEW.bbox) AND json_array_length(NEW.bbox) = 4
+	AND NOT (json_extract(NEW.bbox, '$[0]') = -180 AND json_extract(NEW.bbox, '$[1]') = -90
+		AND json_extract(NEW.bbox, '$[2]') = 180 AND json_extract(NEW.bbox, '$[3]') = 90)
+BEGIN
+	INSERT OR REPLACE INTO geo_file_rtree VALUES (NEW.id,
+		json_extract(NEW.bbox, '$[0]'), json_extract(NEW.bbox, '$[2]'),
+		json_extract(NEW.bbox, '$[1]'), json_extract(NEW.bbox, '$[3]'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS geo_file_rtree_update AFTER UPDATE OF id, bbox ON geo_file_index
+BEGIN
+	DELETE FROM geo_file_rtree WHERE id = OLD.id;
+	INSERT INTO geo_file_rtree
+	SELECT NEW.id, json_extract(NEW.bbox, '$[0]'), json_extract(NEW.bbox, '$[2]'),
+		json_extract(NEW.bbox, '$[1]'), json_extract(NEW.bbox, '$[3]')
+	WHERE json_valid(NEW.bbox) AND json_array_length(NEW.bbox) = 4
+		AND NOT (json_extract(NEW.bbox, '$[0]') = -180 AND json_extract(NEW.bbox, '$[1]') = -90
+			AND json_extract(NEW.bbox, '$[2]') = 180 AND json_extract(NEW.bbox, '$[3]') = 90);
+END;
+
+CREATE TRIGGER IF NOT EXISTS geo_file_rtree_delete AFTER DELETE ON geo_file_index
+BEGIN
+	DELETE FROM geo_file_rtree WHERE id = OLD.id;
+END;
+
+CREATE TABLE IF NOT EXISTS index_progress (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	start_time TEXT NOT NULL,
+	end_time TEXT,
+	total_files INTEGER DEFAULT 0,
+	processed_files INTEGER DEFAULT 0,
+	status TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS workspaces (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS workspace_layers (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+	file_path TEXT NOT NULL,
+	layer_name TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	UNIQUE(workspace_id, file_path, layer_name)
+);
+
+CREATE TABLE IF NOT EXISTS workspace_queries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	workspace_id INTEGER NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	query TEXT NOT NULL,
+	created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS settings (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL,
+	updated_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS layer_styles (
+	file_path TEXT NOT NULL,
+	layer_name TEXT NOT NULL,
+	style TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	PRIMARY KEY (file_path, layer_name)
+);
+
+CREATE TABLE IF NOT EXISTS recent_items (
+	path TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	used_at INTEGER NOT NULL,
+	PRIMARY KEY (path, kind)
+);
+
+CREATE TABLE IF NOT EXISTS file_tags (
+	file_path TEXT NOT NULL,
+	layer_name TEXT NOT NULL,
+	tag TEXT NOT NULL COLLATE NOCASE,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (file_path, layer_name, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_file_tags_tag ON file_tags(tag);
+
+CREATE TABLE IF NOT EXISTS file_favorites (
+	file_path TEXT NOT NULL,
+	layer_name TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	PRIMARY KEY (file_path, layer_name)
+);
+
+CREATE TABLE IF NOT EXISTS bookmarks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	lat REAL NOT NULL,
+	lon REAL NOT NULL,
+	zoom REAL NOT NULL,
+	bbox TEXT,
+	created_at TEXT NOT NULL,
+	last_used_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS query_history (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	query TEXT NOT NULL,
+	source TEXT NOT NULL,
+	description TEXT,
+	bbox TEXT,
+	tiles INTEGER NOT NULL DEFAULT 0,
+	executed_at TEXT NOT NULL,
+	duration_ms INTEGER NOT NULL,
+	success INTEGER NOT NULL,
+	feature_count INTEGER NOT NULL DEFAULT 0,
+	error TEXT
+);
 
-	dbDir := filepath.Join(homeDir, ".terrabox")
-	if err := os.MkdirAll(dbDir, 0755); err != nil {
+CREATE TABLE IF NOT EXISTS jobs (
+	id TEXT PRIMARY KEY,
+	kind TEXT NOT NULL,
+	params TEXT NOT NULL,
+	status TEXT NOT NULL,
+	progress REAL NOT NULL DEFAULT 0,
+	result TEXT,
+	error TEXT,
+	created_at TEXT NOT NULL,
+	started_at TEXT,
+	finished_at TEXT
+);
+`
+
+// initDatabase initializes the SQLite database
+func (a *App) initDatabase() error {
+	dbDir, err := terraboxDir()
+	if err != nil {
 		return err
 	}
 
 	dbPath := filepath.Join(dbDir, "terrabox.db")
-	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on")
+	// Recursive triggers make INSERT OR REPLACE fire the delete trigger that cleans geo_file_rtree
+	db, err := sql.Open("sqlite3", dbPath+"?_foreign_keys=on&_recursive_triggers=on")
 	if err != nil {
 		return err
 	}
 
 	a.db = db
 
-	// Create tables
-	createTables := `
-	CREATE TABLE IF NOT EXISTS geo_file_index (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		file_path TEXT NOT NULL,
-		file_name TEXT NOT NULL,
-		file_extension TEXT NOT NULL,
-		file_size INTEGER NOT NULL,
-		created_at INTEGER,
-		modified_at INTEGER,
-		file_type TEXT NOT NULL,
-		layer_name TEXT NOT NULL,
-		crs TEXT,
-		bbox TEXT,
-		num_features INTEGER,
-		num_bands INTEGER,
-		resolution REAL,
-		metadata TEXT,
-		bbox_geom TEXT,
-		centroid_geom TEXT,
-		UNIQUE(file_path, layer_name)
-	);
-
-	CREATE TABLE IF NOT EXISTS index_progress (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		start_time TEXT NOT NULL,
-		end_time TEXT,
-		total_files INTEGER DEFAULT 0,
-		processed_files INTEGER DEFAULT 0,
-		status TEXT NOT NULL
-	);
-	`
+	if _, err := db.Exec(catalogSchema); err != nil {
+		return err
+	}
+	if err := syncFileRTree(db); err != nil {
+		return err
+	}
 
-	_, err = db.Exec(createTables)
+	_, err = db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion))
 	return err
 }
 
 // initDuckDB initializes the DuckDB database with spatial extension
 func (a *App) initDuckDB() error {
-	homeDir, err := os.UserHomeDir()
+	dbDir, err := terraboxDir()
 	if err != nil {
 		return err
 	}
 
-	dbDir := filepath.Join(homeDir, ".terrabox")
-	if err := os.MkdirAll(dbDir, 0755); err != nil {
-		return err
-	}
-
 	dbPath := filepath.Join(dbDir, "terrabox.duckdb")
 	db, err := sql.Open("duckdb", dbPath)
 	if err != nil {
@@ -149,7 +432,7 @@ func (a *App) initDuckDB() error {
 	_, err = db.Exec(spatialSetup)
 	if err != nil {
 		// Log the error but continue - spatial extension might need to be installed differently
-		fmt.Printf("Warning: Could not setup DuckDB spatial extension: %v\n", err)
+		a.logWarn("Could not set up DuckDB spatial extension: %v", err)
 	}
 
 	return nil
@@ -179,9 +462,88 @@ type GeoFileIndex struct {
 	Resolution   float64 `json:"resolution"`
 	BBoxGeom     string  `json:"bbox_geom"`
 	CentroidGeom string  `json:"centroid_geom"`
+	// GeometryTypes lists the geometry types of a vector layer; empty for a layer without
+	// geometries and null when unknown
+	GeometryTypes []string `json:"geometry_types"`
+	Missing       bool     `json:"missing,omitempty"`
+	ScanError     string   `json:"scan_error,omitempty"`
+	// ResolutionUnit is the unit of Resolution, e.g. meters or degrees, and ResolutionMeters the
+	// resolution in meters at the dataset's centroid; both are empty when unknown
+	ResolutionUnit   string   `json:"resolution_unit,omitempty"`
+	ResolutionMeters *float64 `json:"resolution_meters,omitempty"`
+	// Tags and Favorite are set by the user and kept across re-indexing
+	Tags     []string `json:"tags"`
+	Favorite bool     `json:"favorite"`
+	// DistanceMeters is set by FindNearestDatasets: 0 when the point is inside the bbox
+	DistanceMeters *float64 `json:"distance_meters,omitempty"`
 }
 
-// ListIndexedFiles returns a list of indexed geospatial files
+// geoFileIndexColumns is the column list scanned by scanGeoFileIndex
+const geoFileIndexColumns = `id, file_name, layer_name, file_path, file_extension, file_size,
+		       created_at, file_type, crs, bbox, metadata, modified_at,
+		       num_bands, num_features, resolution, bbox_geom, centroid_geom`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanGeoFileIndex scans one geo_file_index row selected with geoFileIndexColumns
+func scanGeoFileIndex(row rowScanner) (GeoFileIndex, error) {
+	var file GeoFileIndex
+	var createdAt, modifiedAt sql.NullInt64
+	var crs, bbox, metadata, bboxGeom, centroidGeom sql.NullString
+	var numBands, numFeatures sql.NullInt64
+	var resolution sql.NullFloat64
+
+	err := row.Scan(
+		&file.ID, &file.FileName, &file.LayerName, &file.FilePath,
+		&file.FileExt, &file.FileSize, &createdAt, &file.FileType,
+		&crs, &bbox, &metadata, &modifiedAt, &numBands, &numFeatures,
+		&resolution, &bboxGeom, &centroidGeom,
+	)
+	if err != nil {
+		return file, err
+	}
+
+	if createdAt.Valid {
+		file.CreatedAt = createdAt.Int64
+	}
+	if modifiedAt.Valid {
+		file.ModifiedAt = modifiedAt.Int64
+	}
+	if crs.Valid {
+		file.CRS = crs.String
+	}
+	if bbox.Valid {
+		file.BBox = bbox.String
+	}
+	if metadata.Valid {
+		file.Metadata = metadata.String
+		file.GeometryTypes = indexedGeometryTypes(metadata.String)
+		file.ResolutionUnit, file.ResolutionMeters = indexedResolutionUnit(metadata.String)
+	}
+	if bboxGeom.Valid {
+		file.BBoxGeom = bboxGeom.String
+	}
+	if centroidGeom.Valid {
+		file.CentroidGeom = centroidGeom.String
+	}
+	if numBands.Valid {
+		file.NumBands = int(numBands.Int64)
+	}
+	if numFeatures.Valid {
+		file.NumFeatures = int(numFeatures.Int64)
+	}
+	if resolution.Valid {
+		file.Resolution = resolution.Float64
+	}
+
+	return file, nil
+}
+
+// ListIndexedFiles returns a list of indexed geospatial files. Rows that can't be read are still
+// returned, with whatever was scanned and ScanError set, so corrupt entries show up in the UI.
 func (a *App) ListIndexedFiles() ([]GeoFileIndex, error) {
 	if a.db == nil {
 		return []GeoFileIndex{}, fmt.Errorf("database not initialized")
@@ -190,75 +552,61 @@ func (a *App) ListIndexedFiles() ([]GeoFileIndex, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
-	query := `
-		SELECT id, file_name, layer_name, file_path, file_extension, file_size,
-		       created_at, file_type, crs, bbox, metadata, modified_at,
-		       num_bands, num_features, resolution, bbox_geom, centroid_geom
-		FROM geo_file_index
-		ORDER BY modified_at DESC
-	`
+	stmt, err := a.listIndexedFilesStmt()
+	if err != nil {
+		return []GeoFileIndex{}, err
+	}
 
-	rows, err := a.db.Query(query)
+	rows, err := stmt.Query()
 	if err != nil {
 		return []GeoFileIndex{}, err
 	}
 	defer rows.Close()
 
 	var files []GeoFileIndex
+	scanErrors := 0
 	for rows.Next() {
-		var file GeoFileIndex
-		var createdAt, modifiedAt sql.NullInt64
-		var crs, bbox, metadata, bboxGeom, centroidGeom sql.NullString
-		var numBands, numFeatures sql.NullInt64
-		var resolution sql.NullFloat64
-
-		err := rows.Scan(
-			&file.ID, &file.FileName, &file.LayerName, &file.FilePath,
-			&file.FileExt, &file.FileSize, &createdAt, &file.FileType,
-			&crs, &bbox, &metadata, &modifiedAt, &numBands, &numFeatures,
-			&resolution, &bboxGeom, &centroidGeom,
-		)
+		file, err := scanGeoFileIndex(rows)
 		if err != nil {
-			continue
-		}
-
-		if createdAt.Valid {
-			file.CreatedAt = createdAt.Int64
-		}
-		if modifiedAt.Valid {
-			file.ModifiedAt = modifiedAt.Int64
-		}
-		if crs.Valid {
-			file.CRS = crs.String
-		}
-		if bbox.Valid {
-			file.BBox = bbox.String
-		}
-		if metadata.Valid {
-			file.Metadata = metadata.String
-		}
-		if bboxGeom.Valid {
-			file.BBoxGeom = bboxGeom.String
-		}
-		if centroidGeom.Valid {
-			file.CentroidGeom = centroidGeom.String
-		}
-		if numBands.Valid {
-			file.NumBands = int(numBands.Int64)
-		}
-		if numFeatures.Valid {
-			file.NumFeatures = int(numFeatures.Int64)
-		}
-		if resolution.Valid {
-			file.Resolution = resolution.Float64
+			scanErrors++
+			file.ScanError = err.Error()
+			a.logWarn("Could not read index entry %d (%s): %v", file.ID, file.FilePath, err)
 		}
 
 		files = append(files, file)
 	}
+	if err := rows.Err(); err != nil {
+		return files, fmt.Errorf("failed to list indexed files: %v", err)
+	}
+	if scanErrors > 0 {
+		a.logWarn("%d of %d index entries could not be read", scanErrors, len(files))
+	}
+	if err := a.attachTags(files); err != nil {
+		return files, err
+	}
 
 	return files, nil
 }
 
+// listIndexedFilesStmt returns the prepared ListIndexedFiles query, preparing it on first use
+func (a *App) listIndexedFilesStmt() (*sql.Stmt, error) {
+	a.stmtMu.Lock()
+	defer a.stmtMu.Unlock()
+
+	if a.listFilesStmt == nil {
+		stmt, err := a.db.Prepare(`
+			SELECT ` + geoFileIndexColumns + `
+			FROM geo_file_index
+			ORDER BY modified_at DESC
+		`)
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare file listing: %v", err)
+		}
+		a.listFilesStmt = stmt
+	}
+	return a.listFilesStmt, nil
+}
+
 // GetFileInfo returns information about a specific file
 func (a *App) GetFileInfo(filePath string) (map[string]interface{}, error) {
 	info, err := os.Stat(filePath)
@@ -275,11 +623,35 @@ func (a *App) GetFileInfo(filePath string) (map[string]interface{}, error) {
 		"modified":    info.ModTime().Unix(),
 		"permissions": info.Mode().String(),
 	}
+	if strings.EqualFold(filepath.Ext(filePath), ".shp") {
+		addShapefileComponents(result, filePath)
+	}
 
 	return result, nil
 }
 
-// ListDirectory returns the contents of a directory
+// addShapefileComponents describes a .shp entry as the whole shapefile: its combined size, its
+// component files and any required components that are missing
+func addShapefileComponents(entry map[string]interface{}, shpPath string) {
+	components := resolveShapefileComponents(shpPath)
+	entry["size"] = combinedFileSize(components)
+	entry["components"] = fileNames(components)
+	if missing := missingShapefileComponents(shpPath); len(missing) > 0 {
+		entry["missing_components"] = missing
+	}
+}
+
+// fileNames returns the base names of paths
+func fileNames(paths []string) []string {
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+	}
+	return names
+}
+
+// ListDirectory returns the contents of a directory. A shapefile is listed once, as its .shp
+// with the combined size of all its component files.
 func (a *App) ListDirectory(dirPath string) ([]map[string]interface{}, error) {
 	entries, err := os.ReadDir(dirPath)
 	if err != nil {
@@ -294,6 +666,10 @@ func (a *App) ListDirectory(dirPath string) ([]map[string]interface{}, error) {
 		}
 
 		fullPath := filepath.Join(dirPath, entry.Name())
+		isShp := !entry.IsDir() && strings.EqualFold(filepath.Ext(fullPath), ".shp")
+		if !entry.IsDir() && !isShp && shapefileMainFile(fullPath) != "" {
+			continue
+		}
 		result := map[string]interface{}{
 			"name":        entry.Name(),
 			"path":        fullPath,
@@ -303,6 +679,9 @@ func (a *App) ListDirectory(dirPath string) ([]map[string]interface{}, error) {
 			"modified":    info.ModTime().Unix(),
 			"permissions": info.Mode().String(),
 		}
+		if isShp {
+			addShapefileComponents(result, fullPath)
+		}
 		results = append(results, result)
 	}
 
@@ -352,9 +731,11 @@ func (a *App) ReadFileAsBase64(filePath string) (string, error) {
 	return string(content), nil
 }
 
-// SearchFiles searches for files with a given pattern
+// SearchFiles searches for files with a given pattern. Shapefile components are reported as
+// their .shp, once per shapefile.
 func (a *App) SearchFiles(pattern string, directory string) ([]string, error) {
 	var matches []string
+	seen := map[string]bool{}
 
 	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -366,7 +747,13 @@ func (a *App) SearchFiles(pattern string, directory string) ([]string, error) {
 			return nil
 		}
 
-		if matched {
+		if matched && !info.IsDir() {
+			if shp := shapefileMainFile(path); shp != "" {
+				path = shp
+			}
+		}
+		if matched && !seen[path] {
+			seen[path] = true
 			matches = append(matches, path)
 		}
 
@@ -390,8 +777,23 @@ type FileMetadata struct {
 	Metadata    map[string]interface{} `json:"metadata"`
 }
 
-// extractFileMetadata extracts metadata from a geospatial file
-func (a *App) extractFileMetadata(filePath string) (*FileMetadata, error) {
+// ExtractMetadata reads a file's metadata the way indexing does, without adding it to the index,
+// so files can be previewed before they're indexed. The whole metadata map is returned,
+// including fields, layers and band details.
+func (a *App) ExtractMetadata(filePath string) (*FileMetadata, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, fmt.Errorf("file not found: %v", err)
+	}
+	metadata, err := a.extractFileMetadataWithin(context.Background(), filePath, a.extractionTimeout())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata of %s: %v", filePath, err)
+	}
+	a.flagSuspectCRS(metadata)
+	return metadata, nil
+}
+
+// extractFileMetadata extracts metadata from a geospatial file, giving up once ctx is cancelled
+func (a *App) extractFileMetadata(ctx context.Context, filePath string) (*FileMetadata, error) {
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return nil, err
@@ -413,17 +815,17 @@ func (a *App) extractFileMetadata(filePath string) (*FileMetadata, error) {
 	// Enhanced metadata extraction based on file type
 	switch fileType {
 	case "vector":
-		if err := a.extractVectorMetadata(filePath, metadata); err != nil {
+		if err := a.runExtractor(ctx, a.extractVectorMetadata, filePath, metadata); err != nil {
 			// Log error but continue with basic metadata
 			metadata.Metadata["extraction_error"] = err.Error()
 		}
 	case "raster":
-		if err := a.extractRasterMetadata(filePath, metadata); err != nil {
+		if err := a.runExtractor(ctx, a.extractRasterMetadata, filePath, metadata); err != nil {
 			// Log error but continue with basic metadata
 			metadata.Metadata["extraction_error"] = err.Error()
 		}
 	case "point_cloud":
-		if err := a.extractPointCloudMetadata(filePath, metadata); err != nil {
+		if err := a.runExtractor(ctx, a.extractPointCloudMetadata, filePath, metadata); err != nil {
 			// Log error but continue with basic metadata
 			metadata.Metadata["extraction_error"] = err.Error()
 		}
@@ -432,17 +834,43 @@ func (a *App) extractFileMetadata(filePath string) (*FileMetadata, error) {
 	return metadata, nil
 }
 
+// runExtractor calls a metadata extractor, turning a panic on malformed input into an error so
+// one corrupt file can't bring down indexing
+func (a *App) runExtractor(ctx context.Context, extract func(context.Context, string, *FileMetadata) error, filePath string, metadata *FileMetadata) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			a.logDebug("Metadata extractor panicked on %s: %v\n%s", filePath, r, debug.Stack())
+			err = fmt.Errorf("metadata extractor crashed: %v", r)
+		}
+	}()
+	return extract(ctx, filePath, metadata)
+}
+
 // extractVectorMetadata extracts metadata from vector files
-func (a *App) extractVectorMetadata(filePath string, metadata *FileMetadata) error {
+func (a *App) extractVectorMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
 	case ".geojson":
-		return a.extractGeoJSONMetadata(filePath, metadata)
+		return a.extractGeoJSONMetadata(ctx, filePath, metadata)
 	case ".shp":
 		return a.extractShapefileMetadata(filePath, metadata)
+	case ".gpkg":
+		return a.extractGeoPackageMetadata(filePath, metadata)
 	case ".kml":
 		return a.extractKMLMetadata(filePath, metadata)
+	case ".gpx":
+		return a.extractGPXMetadata(filePath, metadata)
+	case ".topojson":
+		return a.extractTopoJSONMetadata(filePath, metadata)
+	case ".fgb":
+		return a.extractFlatGeobufMetadata(filePath, metadata)
+	case ".parquet":
+		return a.extractParquetMetadata(filePath, metadata)
+	case ".gml":
+		return a.extractGMLMetadata(ctx, filePath, metadata)
+	case ".dxf":
+		return a.extractDXFMetadata(ctx, filePath, metadata)
 	default:
 		// For unsupported vector formats, use basic detection
 		metadata.NumFeatures = 1 // Placeholder
@@ -453,7 +881,41 @@ func (a *App) extractVectorMetadata(filePath string, metadata *FileMetadata) err
 }
 
 // extractGeoJSONMetadata extracts metadata from GeoJSON files
-func (a *App) extractGeoJSONMetadata(filePath string, metadata *FileMetadata) error {
+func (a *App) extractGeoJSONMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	metadata.Metadata["format"] = "GeoJSON"
+
+	// Stream FeatureCollections to count features and collect their geometry types
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	var types geometryTypeSet
+	fields := []FieldInfo{}
+	count := 0
+	err = readGeoJSONFeatures(bufio.NewReader(file), func(feature map[string]interface{}) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if count == 0 {
+			properties, _ := feature["properties"].(map[string]interface{})
+			fields = propertyFields(properties)
+		}
+		count++
+		geometry, _ := feature["geometry"].(map[string]interface{})
+		types.add(geometry)
+		return nil
+	})
+	file.Close()
+	if err == nil {
+		metadata.NumFeatures = count
+		metadata.Metadata["fields"] = fields
+		types.store(metadata)
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
 	// Read and parse GeoJSON file (simplified implementation)
 	content, err := os.ReadFile(filePath)
 	if err != nil {
@@ -481,6 +943,26 @@ func (a *App) extractShapefileMetadata(filePath string, metadata *FileMetadata)
 	metadata.Metadata["format"] = "Shapefile"
 	metadata.CRS = "EPSG:4326"
 
+	components := resolveShapefileComponents(filePath)
+	metadata.FileSize = combinedFileSize(components)
+	metadata.Metadata["components"] = fileNames(components)
+	if missing := missingShapefileComponents(filePath); len(missing) > 0 {
+		metadata.Metadata["missing_components"] = missing
+		a.logWarn("Shapefile %s is missing %s", filePath, strings.Join(missing, ", "))
+	}
+
+	types, err := shapefileGeometryTypes(filePath)
+	if err != nil {
+		return err
+	}
+	metadata.Metadata["geometry_types"] = types
+
+	fields, err := shapefileFields(filePath)
+	if err != nil {
+		return err
+	}
+	metadata.Metadata["fields"] = fields
+
 	return nil
 }
 
@@ -500,12 +982,14 @@ func (a *App) extractKMLMetadata(filePath string, metadata *FileMetadata) error
 }
 
 // extractRasterMetadata extracts metadata from raster files
-func (a *App) extractRasterMetadata(filePath string, metadata *FileMetadata) error {
+func (a *App) extractRasterMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
 	case ".tif", ".tiff":
-		return a.extractGeoTIFFMetadata(filePath, metadata)
+		return a.extractGeoTIFFMetadata(ctx, filePath, metadata)
+	case ".vrt":
+		return a.extractVRTMetadata(ctx, filePath, metadata)
 	default:
 		// For other raster formats, use basic metadata
 		metadata.NumBands = 3     // Placeholder for RGB
@@ -517,18 +1001,60 @@ func (a *App) extractRasterMetadata(filePath string, metadata *FileMetadata) err
 }
 
 // extractGeoTIFFMetadata extracts metadata from GeoTIFF files
-func (a *App) extractGeoTIFFMetadata(filePath string, metadata *FileMetadata) error {
-	// In a real implementation, you would use GDAL bindings
-	// For now, set placeholder values
-	metadata.NumBands = 1
-	metadata.Resolution = 30.0 // Placeholder resolution in meters
+func (a *App) extractGeoTIFFMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
 	metadata.Metadata["format"] = "GeoTIFF"
 
+	if _, err := a.requireGDAL(); err != nil {
+		// Without GDAL, set placeholder values
+		metadata.NumBands = 1
+		metadata.Resolution = 30.0 // Placeholder resolution in meters
+		return nil
+	}
+	_, err := a.extractRasterGrid(ctx, filePath, metadata)
+	return err
+}
+
+// extractVRTMetadata extracts metadata from GDAL virtual rasters, noting how many files they
+// combine
+func (a *App) extractVRTMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	metadata.Metadata["format"] = "VRT"
+
+	info, err := a.extractRasterGrid(ctx, filePath, metadata)
+	if err != nil {
+		return err
+	}
+	if len(info.Files) > 1 {
+		metadata.Metadata["source_files"] = len(info.Files) - 1
+	}
 	return nil
 }
 
+// extractRasterGrid reads a raster's bands, pixel size and extent with gdalinfo. Like LAS, a
+// projected extent is kept as native_bbox rather than stored as lon/lat.
+func (a *App) extractRasterGrid(ctx context.Context, filePath string, metadata *FileMetadata) (*gdalRasterInfo, error) {
+	info, err := a.readRasterInfoContext(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	metadata.NumBands = len(info.Bands)
+	if len(info.GeoTransform) == 6 {
+		metadata.Resolution = math.Abs(info.GeoTransform[1])
+	}
+	metadata.CRS = wktCRS(info.CoordinateSystem.WKT)
+	if bbox, ok := info.nativeBounds(); ok {
+		if isGeographicWKT(info.CoordinateSystem.WKT) {
+			metadata.BBox = bbox
+		} else {
+			metadata.Metadata["native_bbox"] = bbox
+		}
+	}
+	a.storeResolutionUnit(metadata)
+
+	return info, nil
+}
+
 // extractPointCloudMetadata extracts metadata from point cloud files
-func (a *App) extractPointCloudMetadata(filePath string, metadata *FileMetadata) error {
+func (a *App) extractPointCloudMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
@@ -542,11 +1068,31 @@ func (a *App) extractPointCloudMetadata(filePath string, metadata *FileMetadata)
 	return nil
 }
 
-// extractLASMetadata extracts metadata from LAS files
+// extractLASMetadata extracts metadata from the LAS header. LAZ shares the header layout.
 func (a *App) extractLASMetadata(filePath string, metadata *FileMetadata) error {
-	// In a real implementation, you would use a LAS library
-	metadata.NumFeatures = 1000000 // Placeholder point count
 	metadata.Metadata["format"] = "LAS"
+	if strings.ToLower(filepath.Ext(filePath)) == ".laz" {
+		metadata.Metadata["format"] = "LAZ"
+	}
+
+	header, err := readLASHeader(filePath)
+	if err != nil {
+		return err
+	}
+
+	metadata.NumFeatures = int(header.PointCount)
+	metadata.Metadata["version"] = fmt.Sprintf("%d.%d", header.VersionMajor, header.VersionMinor)
+	metadata.Metadata["point_format"] = header.PointFormat
+	metadata.Metadata["z_min"] = header.Min[2]
+	metadata.Metadata["z_max"] = header.Max[2]
+	if header.CRS != "" {
+		metadata.CRS = header.CRS
+	}
+	if header.Geographic {
+		metadata.BBox = []float64{header.Min[0], header.Min[1], header.Max[0], header.Max[1]}
+	} else {
+		metadata.Metadata["native_bbox"] = []float64{header.Min[0], header.Min[1], header.Max[0], header.Max[1]}
+	}
 
 	return nil
 }
@@ -556,32 +1102,75 @@ func (a *App) CreateIndex(path string, includeImages bool, includeCSV bool) erro
 	if a.db == nil {
 		return fmt.Errorf("database not initialized")
 	}
+	a.recordRecentItem(path, recentDirectory)
+	a.rememberIndexedDirectory(path, includeImages, includeCSV)
+	// Read and cache the settings used during the walk before taking a.mu
+	a.gdalTimeout()
+	a.gdalSlots()
+	extensions := a.indexedExtensions(includeImages, includeCSV)
+	extractionTimeout := a.extractionTimeout()
+	qualityChecks := a.indexQualityChecks()
 
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	// Clear existing index
-	_, err := a.db.Exec("DELETE FROM geo_file_index")
+	run, err := a.beginIndexRun()
 	if err != nil {
+		return fmt.Errorf("failed to record index run: %v", err)
+	}
+
+	// Let shutdown cancel the walk
+	ctx, cancel := context.WithCancel(context.Background())
+	a.indexMu.Lock()
+	a.indexCancel = cancel
+	a.indexRun = run
+	a.indexMu.Unlock()
+	defer func() {
+		a.indexMu.Lock()
+		a.indexCancel = nil
+		a.indexRun = nil
+		a.indexMu.Unlock()
+		cancel()
+	}()
+
+	start := time.Now()
+	extractionErrors := 0
+	complete := IndexCompleteEvent{RunID: run.ID, Path: path, TimedOutFiles: []string{}}
+	fail := func(status string, err error) error {
+		a.finishIndexRun(run, status)
+		complete.Status = status
+		complete.Error = err.Error()
+		complete.DurationMs = time.Since(start).Milliseconds()
+		a.emitEvent(indexErrorEvent, IndexErrorEvent{RunID: run.ID, Error: err.Error()})
+		a.emitEvent(indexCompleteEvent, complete)
 		return err
 	}
 
-	// Define supported extensions
-	extensions := []string{
-		".shp", ".geojson", ".kml", ".tif", ".tiff", ".gpkg", ".gdb",
-		".las", ".laz", ".ply", ".xyz", ".asc", ".geopackage",
+	// Clear existing index
+	_, err = a.db.Exec("DELETE FROM geo_file_index")
+	if err != nil {
+		return fail("failed", err)
 	}
 
-	if includeImages {
-		extensions = append(extensions, ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".jp2")
+	a.logInfo("Indexing %s", path)
+	total, err := countIndexableFiles(ctx, path, extensions)
+	if err != nil {
+		return fail("interrupted", err)
 	}
-
-	if includeCSV {
-		extensions = append(extensions, ".csv", ".xlsx", ".xls")
+	a.indexMu.Lock()
+	run.TotalFiles = total
+	a.indexMu.Unlock()
+	if _, err := a.db.Exec("UPDATE index_progress SET total_files = ? WHERE id = ?", total, run.ID); err != nil {
+		a.logWarn("Could not store the file count of index run %d: %v", run.ID, err)
 	}
+	a.emitEvent(indexStartEvent, IndexStartEvent{RunID: run.ID, Path: path, TotalFiles: total})
+	batch := &indexBatch{db: a.db, progressID: run.ID}
 
 	// Walk through directory
-	return filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+	err = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return fmt.Errorf("indexing cancelled")
+		}
 		if err != nil {
 			return nil // Continue on errors
 		}
@@ -592,21 +1181,33 @@ func (a *App) CreateIndex(path string, includeImages bool, includeCSV bool) erro
 
 		// Check if file has supported extension
 		ext := strings.ToLower(filepath.Ext(filePath))
-		supported := false
-		for _, supportedExt := range extensions {
-			if ext == supportedExt {
-				supported = true
-				break
-			}
-		}
-
-		if !supported {
+		if !extensions[ext] {
 			return nil
 		}
 
 		// Extract detailed metadata
-		metadata, err := a.extractFileMetadata(filePath)
+		result := IndexFileEvent{RunID: run.ID, FilePath: filePath, Result: indexFileOK}
+		metadata, err := a.extractFileMetadataWithin(ctx, filePath, extractionTimeout)
+		if ctx.Err() != nil {
+			return fmt.Errorf("indexing cancelled")
+		}
 		if err != nil {
+			result.Error = err.Error()
+			note := map[string]interface{}{"extraction_error": err.Error()}
+			if errors.Is(err, errExtractionTimeout) {
+				result.Result = indexFileTimeout
+				note = map[string]interface{}{"extraction_timeout": err.Error()}
+				complete.TimedOutFiles = append(complete.TimedOutFiles, filePath)
+				a.indexMu.Lock()
+				run.TimedOutFiles = append(run.TimedOutFiles, filePath)
+				a.indexMu.Unlock()
+				a.logWarn("Skipped metadata of %s: %v", filePath, err)
+			} else {
+				result.Result = indexFileError
+				extractionErrors++
+				a.logWarn("Metadata extraction failed for %s: %v", filePath, err)
+			}
+
 			// Continue with basic metadata if extraction fails
 			metadata = &FileMetadata{
 				FileSize:    info.Size(),
@@ -618,64 +1219,87 @@ func (a *App) CreateIndex(path string, includeImages bool, includeCSV bool) erro
 				NumFeatures: 0,
 				NumBands:    0,
 				Resolution:  0.0,
-				Metadata:    map[string]interface{}{"extraction_error": err.Error()},
+				Metadata:    note,
+			}
+		} else {
+			a.flagSuspectCRS(metadata)
+			a.scoreIndexedLayer(ctx, filePath, metadata, qualityChecks, extractionTimeout)
+			if message, ok := metadata.Metadata["extraction_error"].(string); ok {
+				result.Result = indexFileError
+				result.Error = message
+				extractionErrors++
 			}
 		}
 
 		fileName := info.Name()
 
-		// Convert bbox to JSON string
-		bboxJSON := fmt.Sprintf("[%f,%f,%f,%f]", metadata.BBox[0], metadata.BBox[1], metadata.BBox[2], metadata.BBox[3])
-
-		// Convert metadata to JSON string
-		metadataJSON := "{}"
-		if len(metadata.Metadata) > 0 {
-			// Simple JSON serialization (in production, use proper JSON marshaling)
-			metadataJSON = fmt.Sprintf(`{"format":"%s"}`, metadata.Metadata["format"])
-		}
+		bboxJSON, metadataJSON := a.encodeIndexMetadata(filePath, metadata)
 
 		// Insert into database
-		query := `
-			INSERT INTO geo_file_index
-			(file_path, file_name, file_extension, file_size, created_at, modified_at,
-			 file_type, layer_name, crs, bbox, num_features, num_bands, resolution, metadata)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-		`
-
-		_, err = a.db.Exec(query,
+		err = batch.insert(
 			filePath, fileName, ext, metadata.FileSize, metadata.CreatedAt, metadata.ModifiedAt,
 			metadata.FileType, fileName, metadata.CRS, bboxJSON, metadata.NumFeatures,
 			metadata.NumBands, metadata.Resolution, metadataJSON,
 		)
 
-		return err
-	})
-}
+		if err != nil {
+			return err
+		}
 
-// determineFileType determines the file type based on extension
-func (a *App) determineFileType(ext string) string {
-	vectorExts := []string{".shp", ".geojson", ".kml", ".gpkg", ".gdb", ".csv"}
-	rasterExts := []string{".tif", ".tiff", ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".jp2"}
-	pointCloudExts := []string{".las", ".laz", ".ply", ".xyz", ".asc"}
+		// Files created during the walk can take the count past the total
+		a.indexMu.Lock()
+		run.ProcessedFiles = batch.count()
+		if run.TotalFiles < run.ProcessedFiles {
+			run.TotalFiles = run.ProcessedFiles
+		}
+		result.FileType = metadata.FileType
+		result.ProcessedFiles, result.TotalFiles = run.ProcessedFiles, run.TotalFiles
+		a.indexMu.Unlock()
 
-	for _, vecExt := range vectorExts {
-		if ext == vecExt {
-			return "vector"
+		a.emitEvent(indexFileEvent, result)
+		if result.Result != indexFileOK {
+			a.emitEvent(indexErrorEvent, IndexErrorEvent{RunID: run.ID, FilePath: filePath, Error: result.Error})
 		}
+		return nil
+	})
+	if err == nil {
+		err = batch.commit()
+	} else {
+		// Keep the batches committed so far; only the batch in flight is lost
+		batch.rollback()
 	}
 
-	for _, rasExt := range rasterExts {
-		if ext == rasExt {
-			return "raster"
+	a.indexMu.Lock()
+	run.ProcessedFiles = batch.committed
+	a.indexMu.Unlock()
+
+	complete.IndexedFiles = batch.committed
+	complete.ExtractionErrors = extractionErrors
+	if err != nil {
+		status := "failed"
+		if ctx.Err() != nil {
+			status = "interrupted"
 		}
+		a.logError("Indexing %s failed after %d files: %v", path, batch.committed, err)
+		return fail(status, err)
 	}
 
-	for _, pcExt := range pointCloudExts {
-		if ext == pcExt {
-			return "point_cloud"
+	a.finishIndexRun(run, "completed")
+	complete.Status = "completed"
+	complete.DurationMs = time.Since(start).Milliseconds()
+	a.emitEvent(indexCompleteEvent, complete)
+
+	a.logInfo("Indexed %d files in %s (%d metadata errors, %d timed out) in %s", batch.committed, path, extractionErrors, len(complete.TimedOutFiles), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// determineFileType determines the file type based on extension
+func (a *App) determineFileType(ext string) string {
+	for _, entry := range a.supportedExtensions() {
+		if entry.Extension == ext {
+			return entry.FileType
 		}
 	}
-
 	return "other"
 }
 
@@ -687,6 +1311,9 @@ type IndexProgress struct {
 	TotalFiles     int    `json:"total_files"`
 	ProcessedFiles int    `json:"processed_files"`
 	Status         string `json:"status"`
+	// TimedOutFiles lists the files whose metadata took too long and was skipped; it is only
+	// kept for the run in memory
+	TimedOutFiles []string `json:"timed_out_files,omitempty"`
 }
 
 // GetIndexProgress returns indexing progress for a given ID
@@ -716,11 +1343,119 @@ func (a *App) GetIndexProgress(progressID int) (*IndexProgress, error) {
 		return nil, err
 	}
 
-	if endTime.Valid {
-		progress.EndTime = endTime.String
+	if endTime.Valid {
+		progress.EndTime = endTime.String
+	}
+
+	return &progress, nil
+}
+
+// staleIndexRunAge is how old an in_progress run must be before startup treats it as abandoned.
+// Younger rows may belong to a CreateIndexProgress call that is about to be picked up by CreateIndex.
+const staleIndexRunAge = time.Minute
+
+// reconcileIndexRuns marks runs left in_progress by a previous session (a crash or a kill
+// that skipped shutdown) as interrupted
+func (a *App) reconcileIndexRuns() error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rows, err := a.db.Query("SELECT id, start_time FROM index_progress WHERE status = 'in_progress'")
+	if err != nil {
+		return err
+	}
+	var stale []int
+	for rows.Next() {
+		var id int
+		var startTime string
+		if err := rows.Scan(&id, &startTime); err != nil {
+			rows.Close()
+			return err
+		}
+		// Timestamps are compared as times since the stored offsets may differ
+		started, err := time.Parse(time.RFC3339, startTime)
+		if err != nil || time.Since(started) > staleIndexRunAge {
+			stale = append(stale, id)
+		}
+	}
+	rows.Close()
+
+	now := time.Now().Format(time.RFC3339)
+	for _, id := range stale {
+		if _, err := a.db.Exec("UPDATE index_progress SET status = 'interrupted', end_time = ? WHERE id = ?", now, id); err != nil {
+			return err
+		}
+	}
+	if len(stale) > 0 {
+		a.logInfo("Marked %d unfinished index runs as interrupted", len(stale))
+	}
+	return nil
+}
+
+// beginIndexRun picks up the run just created by CreateIndexProgress, or records a new one.
+// Callers must hold a.mu.
+func (a *App) beginIndexRun() (*IndexProgress, error) {
+	run := &IndexProgress{Status: "in_progress"}
+
+	err := a.db.QueryRow(
+		"SELECT id, start_time FROM index_progress WHERE status = 'in_progress' AND processed_files = 0 ORDER BY id DESC LIMIT 1",
+	).Scan(&run.ID, &run.StartTime)
+	if err == nil {
+		if started, err := time.Parse(time.RFC3339, run.StartTime); err == nil && time.Since(started) <= staleIndexRunAge {
+			return run, nil
+		}
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	run.StartTime = time.Now().Format(time.RFC3339)
+	result, err := a.db.Exec(
+		"INSERT INTO index_progress (start_time, status, total_files, processed_files) VALUES (?, 'in_progress', 0, 0)",
+		run.StartTime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	run.ID = int(id)
+	return run, nil
+}
+
+// finishIndexRun stores the final counts and status of a run. Callers must hold a.mu.
+func (a *App) finishIndexRun(run *IndexProgress, status string) {
+	a.indexMu.Lock()
+	run.Status = status
+	run.EndTime = time.Now().Format(time.RFC3339)
+	snapshot := *run
+	a.indexMu.Unlock()
+
+	_, err := a.db.Exec(
+		"UPDATE index_progress SET status = ?, end_time = ?, total_files = ?, processed_files = ? WHERE id = ?",
+		snapshot.Status, snapshot.EndTime, snapshot.TotalFiles, snapshot.ProcessedFiles, snapshot.ID,
+	)
+	if err != nil {
+		a.logWarn("Could not update index run %d: %v", snapshot.ID, err)
 	}
+}
 
-	return &progress, nil
+// GetActiveIndexRun returns the index run currently executing in this app, or nil when none is.
+// It is answered from memory so it never waits on the indexer's database lock.
+func (a *App) GetActiveIndexRun() (*IndexProgress, error) {
+	a.indexMu.Lock()
+	defer a.indexMu.Unlock()
+
+	if a.indexRun == nil {
+		return nil, nil
+	}
+	snapshot := *a.indexRun
+	return &snapshot, nil
 }
 
 // CreateIndexProgress creates a new indexing progress record
@@ -811,12 +1546,51 @@ func (a *App) SelectDataFile() (string, error) {
 	return selectedPath, nil
 }
 
+// nativeLoader is a pure-Go reader that converts a file to a GeoJSON FeatureCollection
+type nativeLoader struct {
+	load      func(string) (map[string]interface{}, error)
+	preferred bool // use even when GDAL is installed
+}
+
+// nativeLoaders are keyed by extension. Preferred loaders always handle their format;
+// the rest are used only when GDAL is unavailable or fails.
+var nativeLoaders = map[string]nativeLoader{
+	".shp":      {load: readShapefile},
+	".kml":      {load: readKML, preferred: true},
+	".kmz":      {load: readKMZ, preferred: true},
+	".gpx":      {load: readGPX, preferred: true},
+	".topojson": {load: readTopoJSON, preferred: true},
+	".fgb":      {load: readFlatGeobuf, preferred: true},
+	".parquet":  {load: readParquet, preferred: true},
+}
+
 // LoadGeospatialFile loads a geospatial file and converts it to GeoJSON
 // This is the UNIFIED function for loading all geospatial formats using GDAL
 func (a *App) LoadGeospatialFile(filePath string) (map[string]interface{}, error) {
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("file does not exist: %s", filePath)
+	return a.loadGeospatialFileRecorded(filePath, "")
+}
+
+// LoadGeospatialFileWithProgress is LoadGeospatialFile for large files: while ogr2ogr runs it
+// emits gdal:progress events tagged with progressID so the UI can show a progress bar
+func (a *App) LoadGeospatialFileWithProgress(filePath string, progressID string) (map[string]interface{}, error) {
+	return a.loadGeospatialFileRecorded(filePath, progressID)
+}
+
+// loadGeospatialFileRecorded adds successfully loaded files to the recent files list
+func (a *App) loadGeospatialFileRecorded(filePath string, progressID string) (map[string]interface{}, error) {
+	geojson, err := a.loadGeospatialFile(filePath, progressID)
+	if err == nil {
+		a.recordRecentItem(filePath, recentFile)
+	}
+	return geojson, err
+}
+
+// loadGeospatialFile reports ogr2ogr progress when progressID is set
+func (a *App) loadGeospatialFile(filePath string, progressID string) (map[string]interface{}, error) {
+	// Check the file exists and is safe to pass to GDAL
+	filePath, err := gdalInputPath(filePath)
+	if err != nil {
+		return nil, err
 	}
 
 	ext := strings.ToLower(filepath.Ext(filePath))
@@ -826,11 +1600,41 @@ func (a *App) LoadGeospatialFile(filePath string) (map[string]interface{}, error
 		return a.loadCSVWithGDAL(filePath)
 	}
 
+	// Formats with a preferred native reader don't need GDAL at all
+	loader, hasNative := nativeLoaders[ext]
+	if hasNative && loader.preferred {
+		return loader.load(filePath)
+	}
+
+	gdal, err := a.requireGDAL()
+	if err != nil {
+		// Without GDAL, fall back to a pure-Go reader where we have one
+		if hasNative {
+			return loader.load(filePath)
+		}
+		return nil, err
+	}
+
 	// For all other formats (SHP, KML, KMZ, GPKG, etc.), use ogr2ogr directly
-	cmd := exec.Command("ogr2ogr", "-f", "GeoJSON", "/dev/stdout", filePath)
-	output, err := cmd.Output()
+	var output []byte
+	if progressID == "" {
+		output, _, err = a.execGDAL(context.Background(), gdal.Ogr2ogrPath, "", "-f", "GeoJSON", "/dev/stdout", filePath)
+	} else {
+		output, err = a.ogr2ogrGeoJSONWithProgress(gdal.Ogr2ogrPath, progressID, filePath)
+	}
 	if err != nil {
-		// If ogr2ogr fails, try ogrinfo to get basic info
+		a.logWarn("ogr2ogr failed for %s: %v", filePath, err)
+		if errors.Is(err, errGDALTimeout) {
+			// ogrinfo would most likely hang on the same file
+			return nil, err
+		}
+
+		// If ogr2ogr fails, try a native reader, then ogrinfo to get basic info
+		if hasNative {
+			if geojson, err := loader.load(filePath); err == nil {
+				return geojson, nil
+			}
+		}
 		return a.loadFileWithOgrInfo(filePath)
 	}
 
@@ -958,6 +1762,11 @@ func (a *App) loadCSVWithGDAL(filePath string) (map[string]interface{}, error) {
 	latField := headers[latIdx]
 	lngField := headers[lngIdx]
 
+	gdal, err := a.requireGDAL()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create VRT file for GDAL to read the CSV
 	vrtContent := fmt.Sprintf(`<OGRVRTDataSource>
     <OGRVRTLayer name="%s">
@@ -966,7 +1775,7 @@ func (a *App) loadCSVWithGDAL(filePath string) (map[string]interface{}, error) {
         <LayerSRS>WGS84</LayerSRS>
         <GeometryField encoding="PointFromColumns" x="%s" y="%s"/>
     </OGRVRTLayer>
-</OGRVRTDataSource>`, filepath.Base(filePath), filePath, lngField, latField)
+</OGRVRTDataSource>`, xmlEscape(filepath.Base(filePath)), xmlEscape(filePath), xmlEscape(lngField), xmlEscape(latField))
 
 	// Create temporary VRT file
 	tmpDir := os.TempDir()
@@ -977,10 +1786,10 @@ func (a *App) loadCSVWithGDAL(filePath string) (map[string]interface{}, error) {
 	defer os.Remove(vrtPath) // Clean up VRT file after use
 
 	// Use ogr2ogr to convert the VRT (CSV with geometry) to GeoJSON
-	cmd := exec.Command("ogr2ogr", "-f", "GeoJSON", "/dev/stdout", vrtPath)
-	output, err := cmd.CombinedOutput()
+	output, stderr, err := a.execGDAL(context.Background(), gdal.Ogr2ogrPath, "", "-f", "GeoJSON", "/dev/stdout", vrtPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert CSV to GeoJSON using GDAL: %v, output: %s", err, string(output))
+		a.logWarn("ogr2ogr failed for CSV %s: %v", filePath, err)
+		return nil, fmt.Errorf("failed to convert CSV to GeoJSON using GDAL: %v, output: %s", err, string(stderr))
 	}
 
 	// Parse the GeoJSON output
@@ -1008,14 +1817,19 @@ func (a *App) loadFileWithOgrInfo(filePath string) (map[string]interface{}, erro
 		},
 	}
 
+	props := result["properties"].(map[string]interface{})
+
+	gdal, err := a.CheckGDALAvailable()
+	if err != nil || !gdal.Available {
+		props["message"] = gdal.Message
+		return result, nil
+	}
+
 	// Try to get basic info with ogrinfo
-	cmd := exec.Command("ogrinfo", "-so", filePath)
-	output, err := cmd.Output()
+	output, _, err := a.execGDAL(context.Background(), gdal.OgrinfoPath, "", "-so", filePath)
 	if err == nil {
 		// Add the ogrinfo output as metadata
-		if props, ok := result["properties"].(map[string]interface{}); ok {
-			props["ogrinfo"] = string(output)
-		}
+		props["ogrinfo"] = string(output)
 	}
 
 	return result, nil
@@ -1035,18 +1849,35 @@ type OverpassResponse struct {
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
-// QueryOverpassAPI executes an Overpass Turbo query and returns GeoJSON
+// overpassEndpoint is the public Overpass API interpreter used for all queries
+const overpassEndpoint = "https://overpass-api.de/api/interpreter"
+
+// QueryOverpassAPI executes an Overpass Turbo query and returns GeoJSON. The query is added to
+// the query history.
 func (a *App) QueryOverpassAPI(query string) (*OverpassResponse, error) {
-	// Overpass API endpoint
-	url := "https://overpass-api.de/api/interpreter"
+	start := time.Now()
+	resp, err := a.runOverpassQuery(context.Background(), query)
+	a.recordQuery(a.queryHistoryEntry(query), start, resp, err)
+	return resp, err
+}
 
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+// runOverpassQuery executes an Overpass query and converts the response to GeoJSON. It waits
+// for one of the Overpass request slots and gives up when ctx is cancelled.
+func (a *App) runOverpassQuery(ctx context.Context, query string) (*OverpassResponse, error) {
+	release, err := a.acquireOverpassSlot(ctx)
+	if err != nil {
+		return &OverpassResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to execute query: %v", err),
+		}, nil
 	}
+	defer release()
+
+	// Overpass API endpoint
+	url := overpassEndpoint
 
 	// Create request
-	req, err := http.NewRequest("POST", url, strings.NewReader(query))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(query))
 	if err != nil {
 		return &OverpassResponse{
 			Success: false,
@@ -1056,11 +1887,14 @@ func (a *App) QueryOverpassAPI(query string) (*OverpassResponse, error) {
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.Header.Set("User-Agent", "Terrabox-Desktop/1.0")
+	req.Header.Set("User-Agent", a.userAgent())
 
 	// Execute request
-	resp, err := client.Do(req)
+	a.logInfo("Sending Overpass query (%d bytes)", len(query))
+	start := time.Now()
+	resp, err := a.httpClient().Do(req)
 	if err != nil {
+		a.logError("Overpass request failed: %v", err)
 		return &OverpassResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to execute query: %v", err),
@@ -1071,11 +1905,13 @@ func (a *App) QueryOverpassAPI(query string) (*OverpassResponse, error) {
 	// Read response
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
+		a.logError("Failed to read Overpass response: %v", err)
 		return &OverpassResponse{
 			Success: false,
 			Error:   fmt.Sprintf("Failed to read response: %v", err),
 		}, nil
 	}
+	a.logInfo("Overpass response: HTTP %d, %d bytes in %s", resp.StatusCode, len(body), time.Since(start).Round(time.Millisecond))
 
 	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
@@ -1085,198 +1921,67 @@ func (a *App) QueryOverpassAPI(query string) (*OverpassResponse, error) {
 		}, nil
 	}
 
-	// Check if response is JSON or XML based on content
-	bodyStr := string(body)
-
-	// Optional debug logging (can be removed in production)
-	// fmt.Printf("Response body length: %d\n", len(body))
-	// previewLen := 200
-	// if len(bodyStr) < previewLen {
-	// 	previewLen = len(bodyStr)
-	// }
-	// fmt.Printf("Response starts with: %s\n", bodyStr[:previewLen])
-
-	// Better JSON detection - check if it starts with { and contains "elements"
-	trimmed := strings.TrimSpace(bodyStr)
-	isJSON := len(trimmed) > 0 && trimmed[0] == '{' && strings.Contains(bodyStr, `"elements"`)
-
-	if isJSON {
-		// For JSON responses, we'll convert directly to a simple GeoJSON structure
-		// since the JSON format already includes geometry information
-		var overpassJSON map[string]interface{}
-		if err := json.Unmarshal(body, &overpassJSON); err != nil {
-			return &OverpassResponse{
-				Success: false,
-				Error:   fmt.Sprintf("Failed to parse JSON response: %v", err),
-			}, nil
-		}
-
-		// Create a simple GeoJSON FeatureCollection
-		geojsonData := map[string]interface{}{
-			"type":     "FeatureCollection",
-			"features": []map[string]interface{}{},
-		}
-
-		if elements, ok := overpassJSON["elements"].([]interface{}); ok {
-			features := []map[string]interface{}{}
-
-			for _, element := range elements {
-				if elemMap, ok := element.(map[string]interface{}); ok {
-					elemType, _ := elemMap["type"].(string)
-
-					// Create GeoJSON feature for each element
-					feature := map[string]interface{}{
-						"type": "Feature",
-						"properties": map[string]interface{}{
-							"id":   elemMap["id"],
-							"type": elemType,
-						},
-					}
-
-					// Add tags as properties
-					if tags, ok := elemMap["tags"].(map[string]interface{}); ok {
-						for k, v := range tags {
-							feature["properties"].(map[string]interface{})[k] = v
-						}
-					}
-
-					// Handle geometry based on type
-					switch elemType {
-					case "node":
-						if lat, ok := elemMap["lat"].(float64); ok {
-							if lon, ok := elemMap["lon"].(float64); ok {
-								feature["geometry"] = map[string]interface{}{
-									"type":        "Point",
-									"coordinates": []float64{lon, lat},
-								}
-								features = append(features, feature)
-							}
-						}
-					case "way":
-						if geometry, ok := elemMap["geometry"].([]interface{}); ok {
-							coordinates := [][]float64{}
-							for _, geomPoint := range geometry {
-								if gp, ok := geomPoint.(map[string]interface{}); ok {
-									if lat, ok := gp["lat"].(float64); ok {
-										if lon, ok := gp["lon"].(float64); ok {
-											coordinates = append(coordinates, []float64{lon, lat})
-										}
-									}
-								}
-							}
-							if len(coordinates) > 0 {
-								// Determine if it's a line or polygon
-								isPolygon := len(coordinates) > 2 &&
-									coordinates[0][0] == coordinates[len(coordinates)-1][0] &&
-									coordinates[0][1] == coordinates[len(coordinates)-1][1]
-
-								if isPolygon {
-									// Convert coordinates to interface slice for JSON
-									coords := make([]interface{}, len(coordinates))
-									for i, coord := range coordinates {
-										coords[i] = coord
-									}
-									feature["geometry"] = map[string]interface{}{
-										"type":        "Polygon",
-										"coordinates": []interface{}{coords},
-									}
-								} else {
-									feature["geometry"] = map[string]interface{}{
-										"type":        "LineString",
-										"coordinates": coordinates,
-									}
-								}
-								features = append(features, feature)
-							}
-						}
-					}
-				}
-			}
-			geojsonData["features"] = features
-		}
-
-		// Convert to FeatureCollection format expected by the rest of the code
-		geojsonBytes, err := json.Marshal(geojsonData)
-		if err != nil {
-			return &OverpassResponse{
-				Success: false,
-				Error:   fmt.Sprintf("Failed to marshal GeoJSON: %v", err),
-			}, nil
-		}
+	previewLen := 200
+	if len(body) < previewLen {
+		previewLen = len(body)
+	}
+	a.logDebug("Overpass response starts with: %s", body[:previewLen])
 
-		var geojsonMap map[string]interface{}
-		if err := json.Unmarshal(geojsonBytes, &geojsonMap); err != nil {
+	// Overpass reports queries that ran out of time or memory in a remark, with whatever it had
+	// found so far, rather than an HTTP error
+	format := overpassFormat(body)
+	if remark := overpassRemark(body, format); remark != "" {
+		if err := overpassRemarkError(remark); err != nil {
+			a.logWarn("Overpass query failed: %s", remark)
 			return &OverpassResponse{
 				Success: false,
-				Error:   fmt.Sprintf("Failed to convert GeoJSON to map: %v", err),
+				Error:   err.Error(),
 			}, nil
 		}
+		a.logInfo("Overpass remark: %s", remark)
+	}
 
-		// Create metadata
-		metadata := map[string]interface{}{
-			"query_time":    time.Now().Format(time.RFC3339),
-			"feature_count": len(geojsonData["features"].([]map[string]interface{})),
-			"query_length":  len(string(body)),
-			"api_endpoint":  "https://overpass-api.de/api/interpreter",
-			"format":        "json",
-		}
-
+	fc, err := overpassToGeoJSON(body, format)
+	if err != nil {
 		return &OverpassResponse{
-			Success:  true,
-			Data:     geojsonMap,
-			Metadata: metadata,
+			Success: false,
+			Error:   err.Error(),
 		}, nil
-	} else {
-		// Handle XML response (fallback for older queries)
-		osmData := &osm.OSM{}
-		if err := xml.Unmarshal(body, osmData); err != nil {
-			return &OverpassResponse{
-				Success: false,
-				Error:   fmt.Sprintf("Failed to parse OSM XML data: %v", err),
-			}, nil
-		}
-
-		// Convert to GeoJSON
-		fc, err := osmgeojson.Convert(osmData)
-		if err != nil {
-			return &OverpassResponse{
-				Success: false,
-				Error:   fmt.Sprintf("Failed to convert to GeoJSON: %v", err),
-			}, nil
-		}
-
-		// Convert FeatureCollection to map for JSON response
-		geojsonBytes, err := json.Marshal(fc)
-		if err != nil {
-			return &OverpassResponse{
-				Success: false,
-				Error:   fmt.Sprintf("Failed to marshal GeoJSON: %v", err),
-			}, nil
-		}
-
-		var geojsonMap map[string]interface{}
-		if err := json.Unmarshal(geojsonBytes, &geojsonMap); err != nil {
-			return &OverpassResponse{
-				Success: false,
-				Error:   fmt.Sprintf("Failed to convert GeoJSON to map: %v", err),
-			}, nil
-		}
+	}
 
-		// Create metadata
-		metadata := map[string]interface{}{
-			"query_time":    time.Now().Format(time.RFC3339),
-			"feature_count": len(fc.Features),
-			"query_length":  len(string(body)),
-			"api_endpoint":  "https://overpass-api.de/api/interpreter",
-			"format":        "xml",
-		}
+	// Convert FeatureCollection to map for JSON response
+	geojsonBytes, err := json.Marshal(fc)
+	if err != nil {
+		return &OverpassResponse{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to marshal GeoJSON: %v", err),
+		}, nil
+	}
 
+	var geojsonMap map[string]interface{}
+	if err := json.Unmarshal(geojsonBytes, &geojsonMap); err != nil {
 		return &OverpassResponse{
-			Success:  true,
-			Data:     geojsonMap,
-			Metadata: metadata,
+			Success: false,
+			Error:   fmt.Sprintf("Failed to convert GeoJSON to map: %v", err),
 		}, nil
 	}
+
+	// Create metadata
+	metadata := map[string]interface{}{
+		"query_time":    time.Now().Format(time.RFC3339),
+		"feature_count": len(fc.Features),
+		"query_length":  len(body),
+		"query":         query,
+		"api_endpoint":  overpassEndpoint,
+		"format":        format,
+	}
+	markEmptyOverpassResult(metadata, len(fc.Features), overpassQueryBBox(query))
+
+	return &OverpassResponse{
+		Success:  true,
+		Data:     geojsonMap,
+		Metadata: metadata,
+	}, nil
 }
 
 // GetOverpassQueryTemplates returns common Overpass query templates
@@ -1487,23 +2192,109 @@ out geom;`,
 	return templates
 }
 
+// GeneratedQuery is an Overpass query built from a description, with what it was built from
+type GeneratedQuery struct {
+	Query      string    `json:"query"`
+	BBox       []float64 `json:"bbox"` // [west, south, east, north]
+	Categories []string  `json:"categories"`
+	// Source is "openai" or "fallback"
+	Source string `json:"source"`
+	// FallbackReason explains why OpenAI wasn't used
+	FallbackReason string `json:"fallback_reason,omitempty"`
+}
+
 // GenerateOverpassQuery generates an AI-assisted Overpass query using OpenAI API
 func (a *App) GenerateOverpassQuery(description string, bbox []float64) (string, error) {
+	generated, err := a.GenerateOverpassQueryDryRun(description, bbox)
+	if err != nil {
+		return "", err
+	}
+	return generated.Query, nil
+}
+
+// GenerateOverpassQueryDryRun builds the query GenerateOverpassQuery would return, along with the
+// bbox and categories it resolved to, so it can be reviewed and edited before running
+func (a *App) GenerateOverpassQueryDryRun(description string, bbox []float64) (*GeneratedQuery, error) {
+	generated, err := a.generateOverpassQuery(description, bbox)
+	if err != nil {
+		return nil, err
+	}
+	a.rememberGeneratedQuery(generated.Query, generated.Source, description)
+	return generated, nil
+}
+
+// generateOverpassQuery asks OpenAI for the area and categories of a description, falling back
+// to keywords when that fails
+func (a *App) generateOverpassQuery(description string, bbox []float64) (*GeneratedQuery, error) {
+	if len(bbox) != 4 {
+		return nil, fmt.Errorf("bbox must be [west, south, east, north]")
+	}
+
 	// Use OpenAI API to generate the query directly
 	locationData, err := a.getLocationFromOpenAI(description, bbox)
 	if err != nil {
 		// Fallback to pattern-based query generation if OpenAI fails
-		return a.generateFallbackQuery(description, bbox)
+		a.logInfo("Using keyword-based Overpass query: %v", err)
+		query, queryErr := a.generateFallbackQuery(description, bbox)
+		if queryErr != nil {
+			return nil, queryErr
+		}
+		return &GeneratedQuery{
+			Query:          query,
+			BBox:           fallbackBBox(bbox),
+			Categories:     nonNilStrings(a.fallbackCategories(description)),
+			Source:         querySourceFallback,
+			FallbackReason: err.Error(),
+		}, nil
+	}
+
+	generated := &GeneratedQuery{
+		BBox:       locationData.BoundingBox[:],
+		Categories: nonNilStrings(locationData.Categories),
+		Source:     querySourceOpenAI,
 	}
 
 	// If AI generated a direct query, use it
 	if locationData.DirectQuery != "" {
-		return locationData.DirectQuery, nil
+		generated.Query = locationData.DirectQuery
+		return generated, nil
 	}
 
 	// Otherwise, build query from categories (legacy path)
-	query := a.buildOverpassQuery(locationData, description)
-	return query, nil
+	generated.Query = a.buildOverpassQuery(locationData, description)
+	return generated, nil
+}
+
+// RunGeneratedOverpassQuery generates a query from a description and runs it. The query, its
+// source and the categories used are included in the response metadata.
+func (a *App) RunGeneratedOverpassQuery(description string, bbox []float64) (*OverpassResponse, error) {
+	generated, err := a.GenerateOverpassQueryDryRun(description, bbox)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := a.runOverpassQuery(context.Background(), generated.Query)
+	a.recordQuery(QueryHistoryEntry{Query: generated.Query, Source: generated.Source, Description: description,
+		BBox: generated.BBox}, start, resp, err)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Metadata == nil {
+		resp.Metadata = map[string]interface{}{"query": generated.Query}
+	}
+	resp.Metadata["query_source"] = generated.Source
+	resp.Metadata["categories"] = generated.Categories
+	resp.Metadata["bbox"] = generated.BBox
+	return resp, nil
+}
+
+// nonNilStrings returns an empty slice instead of nil so it encodes as [] rather than null
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
 }
 
 // LocationData represents the parsed location and query information from OpenAI
@@ -1514,86 +2305,28 @@ type LocationData struct {
 	DirectQuery string     `json:"-"` // Direct Overpass query generated by AI (not from JSON)
 }
 
-// getLocationFromOpenAI uses OpenAI API to parse location and generate bounding box
-func (a *App) getLocationFromOpenAI(description string, fallbackBbox []float64) (*LocationData, error) {
-	// Get OpenAI API key from environment
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
-	}
-
-	client := openai.NewClient(apiKey)
-
-	// Create prompt for direct Overpass query generation
-	prompt := fmt.Sprintf(`Generate an Overpass API query for this request: "%s"
-
-Use the bounding box: [%.6f, %.6f, %.6f, %.6f] (format: west, south, east, north)
-
-Generate a complete Overpass query following these rules:
-1. Start with: [out:json][timeout:25];
-2. Use the bounding box in format: (south,west,north,east)
-3. Include node, way, and relation queries where appropriate
-4. End with: out geom;
-
-Common OSM tags:
-- Beaches: natural=beach, with surface=sand for sandy beaches
-- Restaurants/Food: amenity=restaurant, amenity=cafe, amenity=fast_food
-- Parks: leisure=park, landuse=recreation_ground
-- Schools: amenity=school, amenity=university
-- Shops: shop=* (with specific shop type)
-- Roads: highway=* (with specific highway type)
-- Coastlines: natural=coastline
-- Water bodies: natural=water, waterway=riverbank
-- Islands: place=island, place=archipelago (include natural=coastline for island outlines)
-- National parks: boundary=national_park, boundary=protected_area, leisure=nature_reserve
-- Administrative boundaries: boundary=administrative, admin_level=2 (countries), admin_level=4 (states), admin_level=6 (counties), admin_level=8 (cities)
-- Postal codes: boundary=postal_code
-- Land use: landuse=residential, landuse=commercial, landuse=industrial, landuse=forest
-- Buildings: building=yes, building=* (with specific building type)
-- Public transport: public_transport=*, railway=station, highway=bus_stop
-- Healthcare: amenity=hospital, amenity=clinic, amenity=pharmacy
-- Tourism: tourism=* (hotel, attraction, museum, etc.)
-
-Return ONLY the complete Overpass query, no explanations or additional text.`, description, fallbackBbox[0], fallbackBbox[1], fallbackBbox[2], fallbackBbox[3])
-
-	resp, err := client.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: openai.GPT3Dot5Turbo,
-			Messages: []openai.ChatCompletionMessage{
-				{
-					Role:    openai.ChatMessageRoleUser,
-					Content: prompt,
-				},
-			},
-			MaxTokens: 200,
-		},
-	)
-
-	if err != nil {
-		return nil, fmt.Errorf("OpenAI API error: %v", err)
-	}
-
-	if len(resp.Choices) == 0 {
-		return nil, fmt.Errorf("no response from OpenAI")
-	}
-
-	// The response is now the complete Overpass query
-	content := strings.TrimSpace(resp.Choices[0].Message.Content)
+// generateFallbackQuery creates a query using map center when OpenAI fails
+func (a *App) generateFallbackQuery(description string, bbox []float64) (string, error) {
+	area := fallbackBBox(bbox)
+	west, south, east, north := area[0], area[1], area[2], area[3]
 
-	// Return the direct query in LocationData
-	locationData := &LocationData{
-		Location:    "ai_generated",
-		DirectQuery: content,
-		BoundingBox: [4]float64{fallbackBbox[0], fallbackBbox[1], fallbackBbox[2], fallbackBbox[3]},
-		Categories:  []string{}, // Empty since we're using direct query
+	// Keyword-based query generation from the shared category table
+	selectors := a.overpassCategorySelectors(a.fallbackCategories(description))
+	if len(selectors) == 0 {
+		// Generic fallback - try to match something reasonable
+		description = strings.ToLower(description)
+		selectors = []string{
+			fmt.Sprintf(`node["name"~"%s",i]`, description),
+			fmt.Sprintf(`way["name"~"%s",i]`, description),
+			fmt.Sprintf(`relation["name"~"%s",i]`, description),
+		}
 	}
 
-	return locationData, nil
+	return overpassUnionQuery(selectors, fmt.Sprintf("%.6f,%.6f,%.6f,%.6f", south, west, north, east)), nil
 }
 
-// generateFallbackQuery creates a query using map center when OpenAI fails
-func (a *App) generateFallbackQuery(description string, bbox []float64) (string, error) {
+// fallbackBBox is the area searched by generateFallbackQuery: a small box around the map center
+func fallbackBBox(bbox []float64) []float64 {
 	// bbox is assumed to be in [west, south, east, north] format from frontend
 	// but we need to convert to proper lat/lon for calculation
 	west := bbox[0]
@@ -1619,66 +2352,7 @@ func (a *App) generateFallbackQuery(description string, bbox []float64) (string,
 	if south > north {
 		south, north = north, south
 	}
-
-	description = strings.ToLower(description)
-
-	// Simple keyword-based query generation with JSON output and relations
-	if strings.Contains(description, "beach") && (strings.Contains(description, "sandy") || strings.Contains(description, "sand")) {
-		return fmt.Sprintf(`[out:json][timeout:25];
-(
-  node["natural"="beach"]["surface"="sand"](%.6f,%.6f,%.6f,%.6f);
-  way["natural"="beach"]["surface"="sand"](%.6f,%.6f,%.6f,%.6f);
-  relation["natural"="beach"]["surface"="sand"](%.6f,%.6f,%.6f,%.6f);
-);
-out geom;`, south, west, north, east, south, west, north, east, south, west, north, east), nil
-	} else if strings.Contains(description, "beach") {
-		return fmt.Sprintf(`[out:json][timeout:25];
-(
-  node["natural"="beach"](%.6f,%.6f,%.6f,%.6f);
-  way["natural"="beach"](%.6f,%.6f,%.6f,%.6f);
-  relation["natural"="beach"](%.6f,%.6f,%.6f,%.6f);
-);
-out geom;`, south, west, north, east, south, west, north, east, south, west, north, east), nil
-	} else if strings.Contains(description, "restaurant") || strings.Contains(description, "food") {
-		return fmt.Sprintf(`[out:json][timeout:25];
-(
-  node["amenity"="restaurant"](%.6f,%.6f,%.6f,%.6f);
-  node["amenity"="cafe"](%.6f,%.6f,%.6f,%.6f);
-  node["amenity"="fast_food"](%.6f,%.6f,%.6f,%.6f);
-  way["amenity"="restaurant"](%.6f,%.6f,%.6f,%.6f);
-  way["amenity"="cafe"](%.6f,%.6f,%.6f,%.6f);
-);
-out geom;`, south, west, north, east, south, west, north, east, south, west, north, east, south, west, north, east, south, west, north, east), nil
-	} else if strings.Contains(description, "school") || strings.Contains(description, "education") {
-		return fmt.Sprintf(`[out:json][timeout:25];
-(
-  node["amenity"="school"](%.6f,%.6f,%.6f,%.6f);
-  node["amenity"="university"](%.6f,%.6f,%.6f,%.6f);
-  way["amenity"="school"](%.6f,%.6f,%.6f,%.6f);
-  way["amenity"="university"](%.6f,%.6f,%.6f,%.6f);
-  relation["amenity"="school"](%.6f,%.6f,%.6f,%.6f);
-  relation["amenity"="university"](%.6f,%.6f,%.6f,%.6f);
-);
-out geom;`, south, west, north, east, south, west, north, east, south, west, north, east, south, west, north, east, south, west, north, east, south, west, north, east), nil
-	} else if strings.Contains(description, "park") || strings.Contains(description, "recreation") {
-		return fmt.Sprintf(`[out:json][timeout:25];
-(
-  way["leisure"="park"](%.6f,%.6f,%.6f,%.6f);
-  relation["leisure"="park"](%.6f,%.6f,%.6f,%.6f);
-  way["landuse"="recreation_ground"](%.6f,%.6f,%.6f,%.6f);
-  relation["landuse"="recreation_ground"](%.6f,%.6f,%.6f,%.6f);
-);
-out geom;`, south, west, north, east, south, west, north, east, south, west, north, east, south, west, north, east), nil
-	} else {
-		// Generic fallback - try to match something reasonable
-		return fmt.Sprintf(`[out:json][timeout:25];
-(
-  node["name"~"%s",i](%.6f,%.6f,%.6f,%.6f);
-  way["name"~"%s",i](%.6f,%.6f,%.6f,%.6f);
-  relation["name"~"%s",i](%.6f,%.6f,%.6f,%.6f);
-);
-out geom;`, description, south, west, north, east, description, south, west, north, east, description, south, west, north, east), nil
-	}
+	return []float64{west, south, east, north}
 }
 
 // SaveFile opens a save dialog and saves content to the selected file
@@ -1717,21 +2391,53 @@ func (a *App) SaveFile(defaultFilename string, content string) (string, error) {
 		return "", fmt.Errorf("failed to write file: %v", err)
 	}
 
-	runtime.LogInfo(a.ctx, fmt.Sprintf("Saved file to: %s", filepath))
+	a.logInfo("Saved file to: %s", filepath)
 	return filepath, nil
 }
 
-// SaveEditedOSMData saves edited OSM data to a file
-func (a *App) SaveEditedOSMData(geojsonData map[string]interface{}, filename string) error {
-	// Create a directory for edited OSM data if it doesn't exist
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return fmt.Errorf("failed to get home directory: %v", err)
-	}
+// SaveOptions controls how SaveEditedOSMData writes GeoJSON
+type SaveOptions struct {
+	// Precision is the number of decimal places kept for coordinates; 0 uses the default (7)
+	Precision int `json:"precision"`
+	// WholeUnits rounds coordinates to whole units and ignores Precision
+	WholeUnits bool `json:"whole_units"`
+	// FullPrecision skips rounding and ignores Precision and WholeUnits
+	FullPrecision bool `json:"full_precision"`
+	// Pretty indents the output; it roughly doubles the file size
+	Pretty bool `json:"pretty"`
+	// Append merges into an existing file; features with the same id are replaced by the new ones
+	Append bool `json:"append"`
+	// Directory is where the file is written; empty uses GetOutputDirectory
+	Directory string `json:"directory"`
+	// ValidateOSM refuses to save edits that ValidateOSMEdits finds OSM would reject
+	ValidateOSM bool `json:"validate_osm"`
+}
+
+// SaveResult reports where data was saved, the total feature count and how much rounding shrank it
+type SaveResult struct {
+	FilePath     string `json:"file_path"`
+	FeatureCount int    `json:"feature_count"`
+	Replaced     int    `json:"replaced"`
+	// DuplicatesRemoved counts repeated OSM elements dropped while appending
+	DuplicatesRemoved int   `json:"duplicates_removed"`
+	OriginalSize      int64 `json:"original_size"`
+	Size              int64 `json:"size"`
+}
 
-	osmDir := filepath.Join(homeDir, "TerraboxOSM")
-	if err := os.MkdirAll(osmDir, 0755); err != nil {
-		return fmt.Errorf("failed to create OSM directory: %v", err)
+// SaveEditedOSMData saves edited OSM data to a file, streaming features one at a time
+func (a *App) SaveEditedOSMData(geojsonData map[string]interface{}, filename string, options SaveOptions) (*SaveResult, error) {
+	// Write to the caller's directory, or the configured one
+	osmDir := options.Directory
+	if osmDir == "" {
+		dir, err := a.outputDir()
+		if err != nil {
+			return nil, err
+		}
+		osmDir = dir
+	}
+	osmDir, err := prepareOutputDir(osmDir)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate filename if not provided
@@ -1739,6 +2445,10 @@ func (a *App) SaveEditedOSMData(geojsonData map[string]interface{}, filename str
 		filename = fmt.Sprintf("osm_edit_%s.geojson", time.Now().Format("20060102_150405"))
 	}
 
+	if filename != filepath.Base(filename) {
+		return nil, fmt.Errorf("filename must not contain a directory: %s", filename)
+	}
+
 	// Ensure .geojson extension
 	if !strings.HasSuffix(filename, ".geojson") {
 		filename += ".geojson"
@@ -1746,21 +2456,156 @@ func (a *App) SaveEditedOSMData(geojsonData map[string]interface{}, filename str
 
 	filePath := filepath.Join(osmDir, filename)
 
-	// Marshal the GeoJSON data
-	jsonData, err := json.MarshalIndent(geojsonData, "", "  ")
+	validationErrors, err := a.ValidateGeoJSON(geojsonData)
 	if err != nil {
-		return fmt.Errorf("failed to marshal GeoJSON: %v", err)
+		return nil, err
+	}
+	if err := validationFailure(validationErrors); err != nil {
+		return nil, err
+	}
+	if options.ValidateOSM {
+		issues, err := a.ValidateOSMEdits(geojsonData)
+		if err != nil {
+			return nil, err
+		}
+		if err := osmValidationFailure(issues); err != nil {
+			return nil, err
+		}
+	}
+	features, err := geojsonFeatures(geojsonData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+
+	// Round coordinates unless full precision was requested
+	var precision int
+	if !options.FullPrecision {
+		if precision, err = resolvePrecision(options.Precision, options.WholeUnits); err != nil {
+			return nil, err
+		}
+	}
+
+	// Stream into a temporary file so a failed save never leaves a truncated file behind
+	tmpPath := filePath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %v", err)
+	}
+	defer os.Remove(tmpPath)
+
+	// Only a FeatureCollection's own members (name, crs, ...) carry over to the saved envelope
+	var members map[string]interface{}
+	if geojsonData["type"] == "FeatureCollection" {
+		members = geojsonData
+	}
+
+	out := bufio.NewWriter(file)
+	writer, err := newGeoJSONWriter(out, members, options.Pretty)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	// In append mode, copy the existing features first, dropping any that the new data replaces
+	existingCount, replaced, duplicates := 0, 0, 0
+	if options.Append {
+		// Data merged from several Overpass queries repeats elements that matched more than one
+		unique := make([]interface{}, len(features))
+		for i, feature := range features {
+			unique[i] = feature
+		}
+		unique = dedupeOSMFeatures(unique)
+		duplicates = len(features) - len(unique)
+		features = features[:0]
+		for _, feature := range unique {
+			features = append(features, feature.(map[string]interface{}))
+		}
+
+		existing, err := os.Open(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			file.Close()
+			return nil, fmt.Errorf("failed to open existing file: %v", err)
+		}
+		if err == nil {
+			newIDs := make(map[string]bool, len(features))
+			for _, feature := range features {
+				if key, ok := featureKey(feature); ok {
+					newIDs[key] = true
+				}
+			}
+
+			err = readGeoJSONFeatures(bufio.NewReader(existing), func(feature map[string]interface{}) error {
+				if key, ok := featureKey(feature); ok && newIDs[key] {
+					replaced++
+					return nil
+				}
+				existingCount++
+				_, err := writer.WriteFeature(feature)
+				return err
+			})
+			existing.Close()
+			if err != nil {
+				file.Close()
+				return nil, fmt.Errorf("failed to append to %s: %v", filename, err)
+			}
+		}
+	}
+
+	// Track how much rounding saved so the original size can be reported without a second pass
+	var roundingSavings int64
+	for _, feature := range features {
+		var originalFeatureSize int64
+		if geometry, ok := feature["geometry"].(map[string]interface{}); ok && geometry != nil {
+			// Edited polygons may have been drawn in either direction; save them per RFC 7946
+			orientGeometry(geometry)
+
+			if !options.FullPrecision {
+				if originalFeatureSize, err = writer.encodedSize(feature); err != nil {
+					file.Close()
+					return nil, fmt.Errorf("failed to marshal GeoJSON: %v", err)
+				}
+				roundGeometry(geometry, precision)
+			}
+		}
+
+		size, err := writer.WriteFeature(feature)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if originalFeatureSize > 0 {
+			roundingSavings += originalFeatureSize - size
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := out.Flush(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write file: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write file: %v", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return nil, fmt.Errorf("failed to write file: %v", err)
 	}
 
-	// Write to file
-	if err := os.WriteFile(filePath, jsonData, 0644); err != nil {
-		return fmt.Errorf("failed to write file: %v", err)
+	result := &SaveResult{
+		FilePath:          filePath,
+		FeatureCount:      existingCount + len(features),
+		Replaced:          replaced,
+		DuplicatesRemoved: duplicates,
+		OriginalSize:      writer.Written() + roundingSavings,
+		Size:              writer.Written(),
 	}
 
 	// Log success
-	runtime.LogInfo(a.ctx, fmt.Sprintf("Saved edited OSM data to: %s", filePath))
+	a.logInfo("Saved edited OSM data to: %s (%d features, %d -> %d bytes)", filePath, result.FeatureCount, result.OriginalSize, result.Size)
 
-	return nil
+	return result, nil
 }
 
 // buildOverpassQuery constructs the Overpass query from OpenAI location data
@@ -1770,103 +2615,36 @@ func (a *App) buildOverpassQuery(locationData *LocationData, description string)
 	// but Overpass API expects (south, west, north, east)
 	west, south, east, north := bbox[0], bbox[1], bbox[2], bbox[3]
 
-	// Check if this is an island/archipelago request - prioritize coastline queries
-	isIslandRequest := false
-	for _, category := range locationData.Categories {
-		if category == "island" || category == "islands" || category == "archipelago" || category == "isle" {
-			isIslandRequest = true
-			break
-		}
-	}
-
-	// Build query based on detected categories with comprehensive coverage (nodes, ways, relations)
-	var queries []string
-
-	// For island requests, prioritize coastline and place tags
-	if isIslandRequest {
-		queries = append(queries, fmt.Sprintf(`  way["natural"="coastline"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-		queries = append(queries, fmt.Sprintf(`  relation["place"="island"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-		queries = append(queries, fmt.Sprintf(`  way["place"="island"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-		queries = append(queries, fmt.Sprintf(`  relation["place"="archipelago"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-	} else {
-		// Regular category processing for non-island requests
-		for _, category := range locationData.Categories {
-			switch category {
-			case "restaurant", "cafe", "fast_food":
-				queries = append(queries, fmt.Sprintf(`  node["amenity"="%s"](%.6f,%.6f,%.6f,%.6f);`, category, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["amenity"="%s"](%.6f,%.6f,%.6f,%.6f);`, category, south, west, north, east))
-			case "school", "university":
-				queries = append(queries, fmt.Sprintf(`  node["amenity"="%s"](%.6f,%.6f,%.6f,%.6f);`, category, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["amenity"="%s"](%.6f,%.6f,%.6f,%.6f);`, category, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["amenity"="%s"](%.6f,%.6f,%.6f,%.6f);`, category, south, west, north, east))
-			case "shop":
-				queries = append(queries, fmt.Sprintf(`  node["shop"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["shop"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["shop"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "park", "leisure":
-				queries = append(queries, fmt.Sprintf(`  way["leisure"="park"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["leisure"="park"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["landuse"="recreation_ground"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["landuse"="recreation_ground"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "highway":
-				queries = append(queries, fmt.Sprintf(`  way["highway"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["highway"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "beach":
-				queries = append(queries, fmt.Sprintf(`  node["natural"="beach"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["natural"="beach"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["natural"="beach"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "beach_sandy":
-				queries = append(queries, fmt.Sprintf(`  node["natural"="beach"]["surface"="sand"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["natural"="beach"]["surface"="sand"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["natural"="beach"]["surface"="sand"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "beach_pebbles":
-				queries = append(queries, fmt.Sprintf(`  node["natural"="beach"]["surface"="pebbles"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["natural"="beach"]["surface"="pebbles"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "boundary", "administrative", "land_outline", "outline", "border":
-				queries = append(queries, fmt.Sprintf(`  relation["boundary"="administrative"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["admin_level"~"^[2-8]$"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "landuse", "land_use":
-				queries = append(queries, fmt.Sprintf(`  way["landuse"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["landuse"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "coastline", "coast", "water", "shoreline":
-				queries = append(queries, fmt.Sprintf(`  way["natural"="coastline"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["natural"="water"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["natural"="water"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "protected_area", "national_park", "nature_reserve":
-				queries = append(queries, fmt.Sprintf(`  relation["boundary"="protected_area"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["boundary"="national_park"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["boundary"="protected_area"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "postal_code", "zip_code":
-				queries = append(queries, fmt.Sprintf(`  relation["boundary"="postal_code"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["postal_code"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			case "island", "islands", "archipelago", "isle":
-				queries = append(queries, fmt.Sprintf(`  relation["place"="island"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  relation["place"="archipelago"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["place"="island"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-				queries = append(queries, fmt.Sprintf(`  way["natural"="coastline"](%.6f,%.6f,%.6f,%.6f);`, south, west, north, east))
-			}
-		}
-	}
+	selectors := a.overpassCategorySelectors(locationData.Categories)
 
 	// If no specific categories detected, try searching by name or common POIs
-	if len(queries) == 0 {
+	if len(selectors) == 0 {
 		// Search for places with names matching the description (case-insensitive)
 		searchTerm := strings.TrimSpace(description)
 		if searchTerm != "" {
-			queries = append(queries, fmt.Sprintf(`  node["name"~"%s",i](%.6f,%.6f,%.6f,%.6f);`, searchTerm, south, west, north, east))
-			queries = append(queries, fmt.Sprintf(`  way["name"~"%s",i](%.6f,%.6f,%.6f,%.6f);`, searchTerm, south, west, north, east))
-			queries = append(queries, fmt.Sprintf(`  relation["name"~"%s",i](%.6f,%.6f,%.6f,%.6f);`, searchTerm, south, west, north, east))
+			selectors = append(selectors, fmt.Sprintf(`node["name"~"%s",i]`, searchTerm))
+			selectors = append(selectors, fmt.Sprintf(`way["name"~"%s",i]`, searchTerm))
+			selectors = append(selectors, fmt.Sprintf(`relation["name"~"%s",i]`, searchTerm))
 		}
 	}
 
+	return overpassUnionQuery(selectors, fmt.Sprintf("%.6f,%.6f,%.6f,%.6f", south, west, north, east))
+}
+
+// overpassUnionQuery applies filter (e.g. a bbox or around:...) to every selector and wraps them
+// in a JSON query that returns full geometry
+func overpassUnionQuery(selectors []string, filter string) string {
+	lines := make([]string, len(selectors))
+	for i, selector := range selectors {
+		lines[i] = fmt.Sprintf("  %s(%s);", selector, filter)
+	}
+
 	// Construct the final query with JSON output and full geometry
-	query := fmt.Sprintf(`[out:json][timeout:25];
+	return fmt.Sprintf(`[out:json][timeout:25];
 (
 %s
 );
-out geom;`, strings.Join(queries, "\n"))
-
-	return query
+out geom;`, strings.Join(lines, "\n"))
 }
 
 // DuckDB-related functions
@@ -1939,7 +2717,7 @@ func (a *App) LoadGeoJSONToDuckDB(geojsonData map[string]interface{}, fileName s
 	`
 	_, err = a.duckDB.Exec(insertMetadata, tableName, filePath, fileName, "geojson", rowCount, "Unknown", 4326)
 	if err != nil {
-		fmt.Printf("Warning: Could not insert metadata: %v\n", err)
+		a.logWarn("Could not insert DuckDB table metadata for %s: %v", tableName, err)
 	}
 
 	return tableName, nil
@@ -2102,7 +2880,7 @@ func (a *App) DropDuckDBTable(tableName string) error {
 	// Remove from metadata
 	_, err = a.duckDB.Exec("DELETE FROM duckdb_geo_tables WHERE table_name = ?", tableName)
 	if err != nil {
-		fmt.Printf("Warning: Could not remove metadata: %v\n", err)
+		a.logWarn("Could not remove DuckDB table metadata for %s: %v", tableName, err)
 	}
 
 	return nil
@@ -2182,7 +2960,7 @@ func (a *App) loadCSVToDuckDB(filePath string, fileName string) (string, error)
 	`
 	_, err = a.duckDB.Exec(insertMetadata, tableName, filePath, fileName, "csv", rowCount, "None", 0)
 	if err != nil {
-		fmt.Printf("Warning: Could not insert metadata: %v\n", err)
+		a.logWarn("Could not insert DuckDB table metadata for %s: %v", tableName, err)
 	}
 
 	return tableName, nil
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// newTestApp returns an App whose catalog is a fresh database under a temporary home directory
+func newTestApp(tb testing.TB) *App {
+	tb.Helper()
+	tb.Setenv("HOME", tb.TempDir())
+
+	a := NewApp()
+	if err := a.initDatabase(); err != nil {
+		tb.Fatalf("failed to initialize database: %v", err)
+	}
+	tb.Cleanup(func() { a.db.Close() })
+	return a
+}
+
+// testIndexRow returns the insertIndexQuery arguments for a vector file covering bbox
+func testIndexRow(i int, bbox []float64) []interface{} {
+	path := fmt.Sprintf("/data/file%06d.geojson", i)
+	bboxJSON := fmt.Sprintf("[%f,%f,%f,%f]", bbox[0], bbox[1], bbox[2], bbox[3])
+	return []interface{}{path, fmt.Sprintf("file%06d.geojson", i), ".geojson", 1024, 0, 0,
+		"vector", "", "EPSG:4326", bboxJSON, 10, 0, 0.0, "{}"}
+}
+
+// randomBBox returns a bbox of up to 2 degrees somewhere on the globe
+func randomBBox(rng *rand.Rand) []float64 {
+	minX := rng.Float64()*358 - 180
+	minY := rng.Float64()*178 - 90
+	return []float64{minX, minY, minX + rng.Float64()*2, minY + rng.Float64()*2}
+}
+
+// seedIndex adds n files with random extents to the catalog
+func seedIndex(tb testing.TB, a *App, n int) {
+	tb.Helper()
+	rng := rand.New(rand.NewSource(1))
+	batch := &indexBatch{db: a.db}
+	for i := 0; i < n; i++ {
+		if err := batch.insert(testIndexRow(i, randomBBox(rng))...); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	if err := batch.commit(); err != nil {
+		tb.Fatal(err)
+	}
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultAttributePageSize = 100
+	maxAttributePageSize     = 10000
+)
+
+// attributeFIDKey holds the feature id in each attribute row, so a row can be linked back to
+// its geometry. It is 0-based feature order for shapefiles and GeoJSON without ids, the primary
+// key for GeoPackage, and the OGR FID otherwise.
+const attributeFIDKey = "_fid"
+
+var (
+	ogrLayerNamePattern    = regexp.MustCompile(`(?m)^Layer name: (.+?)\r?$`)
+	ogrFeatureCountPattern = regexp.MustCompile(`(?m)^Feature Count: (-?\d+)`)
+)
+
+// GetLayerAttributes returns one page of attribute rows and the layer's total feature count
+// without loading geometries. layerName selects a layer in multi-layer formats and may be empty.
+func (a *App) GetLayerAttributes(filePath string, layerName string, offset int, limit int) ([]map[string]interface{}, int, error) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = defaultAttributePageSize
+	}
+	if limit > maxAttributePageSize {
+		limit = maxAttributePageSize
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, 0, fmt.Errorf("file not found: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".shp", ".dbf":
+		return shapefileAttributes(filePath, offset, limit)
+	case ".geojson", ".json":
+		return geoJSONAttributes(filePath, offset, limit)
+	case ".gpkg":
+		return geoPackageAttributes(filePath, layerName, offset, limit)
+	}
+	return a.ogrAttributes(filePath, layerName, offset, limit)
+}
+
+// shapefileAttributes seeks straight to the requested records in the .dbf
+func shapefileAttributes(filePath string, offset int, limit int) ([]map[string]interface{}, int, error) {
+	rows := []map[string]interface{}{}
+	dbfPath := filePath
+	if !strings.EqualFold(filepath.Ext(filePath), ".dbf") {
+		dbfPath = shapefileSidecar(filePath, ".dbf")
+		if dbfPath == "" {
+			return rows, 0, nil
+		}
+	}
+
+	file, err := os.Open(dbfPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read DBF: %v", err)
+	}
+	defer file.Close()
+
+	fields, headerLength, recordLength, numRecords, err := readDBFFileHeader(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset >= numRecords {
+		return rows, numRecords, nil
+	}
+	count := limit
+	if offset+count > numRecords {
+		count = numRecords - offset
+	}
+
+	if _, err := file.Seek(int64(headerLength)+int64(offset)*int64(recordLength), io.SeekStart); err != nil {
+		return nil, 0, fmt.Errorf("failed to read DBF: %v", err)
+	}
+	data := make([]byte, count*recordLength)
+	n, err := io.ReadFull(file, data)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, 0, fmt.Errorf("failed to read DBF: %v", err)
+	}
+
+	for i := 0; (i+1)*recordLength <= n; i++ {
+		record := data[i*recordLength : (i+1)*recordLength]
+		row := map[string]interface{}{attributeFIDKey: offset + i}
+		// Byte 0 is the deletion flag; values follow back to back
+		pos := 1
+		for _, field := range fields {
+			if pos+field.Length > len(record) {
+				break
+			}
+			row[field.Name] = parseDBFValue(field, record[pos:pos+field.Length])
+			pos += field.Length
+		}
+		rows = append(rows, row)
+	}
+	return rows, numRecords, nil
+}
+
+// geoJSONAttributes streams the file, keeping only the properties of the requested features
+func geoJSONAttributes(filePath string, offset int, limit int) ([]map[string]interface{}, int, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	rows := []map[string]interface{}{}
+	total := 0
+	err = readGeoJSONFeatures(bufio.NewReader(file), func(feature map[string]interface{}) error {
+		if total >= offset && len(rows) < limit {
+			row := map[string]interface{}{}
+			if properties, ok := feature["properties"].(map[string]interface{}); ok {
+				for key, value := range properties {
+					row[key] = value
+				}
+			}
+			row[attributeFIDKey] = total
+			if id, ok := feature["id"]; ok && id != nil {
+				row[attributeFIDKey] = id
+			}
+			rows = append(rows, row)
+		}
+		total++
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read GeoJSON: %v", err)
+	}
+	return rows, total, nil
+}
+
+// geoPackageAttributes pages through a feature table with SQL, leaving out the geometry column
+func geoPackageAttributes(filePath string, layerName string, offset int, limit int) ([]map[string]interface{}, int, error) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(filePath)+"?mode=ro")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer db.Close()
+
+	table, geometryColumn, err := geoPackageLayer(db, layerName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	columns, err := db.Query("PRAGMA table_info(" + sqlIdent(table) + ")")
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read columns of %s: %v", table, err)
+	}
+	fid := "rowid"
+	var names []string
+	for columns.Next() {
+		var cid, notNull, pk int
+		var name, columnType string
+		var defaultValue sql.NullString
+		if err := columns.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+			columns.Close()
+			return nil, 0, fmt.Errorf("failed to read columns of %s: %v", table, err)
+		}
+		switch {
+		case pk > 0:
+			fid = sqlIdent(name)
+		case !strings.EqualFold(name, geometryColumn):
+			names = append(names, name)
+		}
+	}
+	columns.Close()
+
+	var total int
+	if err := db.QueryRow("SELECT COUNT(*) FROM " + sqlIdent(table)).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count features in %s: %v", table, err)
+	}
+
+	selectList := []string{fid}
+	for _, name := range names {
+		selectList = append(selectList, sqlIdent(name))
+	}
+	rows, err := db.Query(fmt.Sprintf("SELECT %s FROM %s ORDER BY %s LIMIT ? OFFSET ?",
+		strings.Join(selectList, ", "), sqlIdent(table), fid), limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read features of %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(selectList))
+		pointers := make([]interface{}, len(values))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, 0, fmt.Errorf("failed to read features of %s: %v", table, err)
+		}
+		row := map[string]interface{}{attributeFIDKey: values[0]}
+		for i, name := range names {
+			if b, ok := values[i+1].([]byte); ok {
+				values[i+1] = string(b)
+			}
+			row[name] = values[i+1]
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read features of %s: %v", table, err)
+	}
+	return result, total, nil
+}
+
+// geoPackageLayer resolves a feature table and its geometry column; an empty name picks the only
+// layer of a single-layer GeoPackage
+func geoPackageLayer(db *sql.DB, layerName string) (string, string, error) {
+	rows, err := db.Query("SELECT table_name, column_name FROM gpkg_geometry_columns ORDER BY table_name")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read GeoPackage layers: %v", err)
+	}
+	defer rows.Close()
+
+	var tables, geometryColumns []string
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return "", "", fmt.Errorf("failed to read GeoPackage layers: %v", err)
+		}
+		if table == layerName {
+			return table, column, nil
+		}
+		tables = append(tables, table)
+		geometryColumns = append(geometryColumns, column)
+	}
+	if layerName == "" && len(tables) == 1 {
+		return tables[0], geometryColumns[0], nil
+	}
+	if layerName == "" {
+		return "", "", fmt.Errorf("the GeoPackage has %d layers; choose one of %s", len(tables), strings.Join(tables, ", "))
+	}
+	return "", "", fmt.Errorf("layer %q not found in the GeoPackage", layerName)
+}
+
+// ogrAttributes pages through any other format with ogr2ogr's SQLite dialect, dropping geometries
+func (a *App) ogrAttributes(filePath string, layerName string, offset int, limit int) ([]map[string]interface{}, int, error) {
+	summary, err := a.ogrinfoSummary(filePath, layerName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if layerName == "" {
+		match := ogrLayerNamePattern.FindStringSubmatch(summary)
+		if match == nil {
+			return nil, 0, fmt.Errorf("no layers found in %s", filepath.Base(filePath))
+		}
+		layerName = match[1]
+	}
+	total := -1
+	if match := ogrFeatureCountPattern.FindStringSubmatch(summary); match != nil {
+		total, _ = strconv.Atoi(match[1])
+	}
+
+	input, err := gdalInputPath(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	query := fmt.Sprintf("SELECT ROWID AS %s, * FROM %s LIMIT %d OFFSET %d",
+		sqlIdent(attributeFIDKey), sqlIdent(layerName), limit, offset)
+	output, err := a.runGDALTool("ogr2ogr", "-f", "GeoJSON", "/dev/stdout", input,
+		"-dialect", "SQLite", "-sql", query, "-nlt", "NONE")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var collection struct {
+		Features []struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(output, &collection); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse ogr2ogr output: %v", err)
+	}
+	rows := make([]map[string]interface{}, 0, len(collection.Features))
+	for _, feature := range collection.Features {
+		row := feature.Properties
+		if row == nil {
+			row = map[string]interface{}{}
+		}
+		rows = append(rows, row)
+	}
+	return rows, total, nil
+}
+
+// sqlIdent quotes an SQL identifier such as a table or column name
+func sqlIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
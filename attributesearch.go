@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultAttributeSearchLimit = 1000
+	maxAttributeSearchLimit     = 10000
+)
+
+// AttributeSearchOptions controls how FindFeaturesByAttribute compares values
+type AttributeSearchOptions struct {
+	// Match is "exact" (the default) or "contains" for a substring (LIKE '%value%') match
+	Match string `json:"match"`
+	// CaseInsensitive ignores letter case in both match modes
+	CaseInsensitive bool `json:"case_insensitive"`
+	// Limit caps the number of features returned: 0 uses the default (1000)
+	Limit int `json:"limit"`
+}
+
+// FindFeaturesByAttribute returns the features whose field equals value as a GeoJSON
+// FeatureCollection, e.g. the feature named "Main Street"
+func (a *App) FindFeaturesByAttribute(filePath string, layerName string, field string, value string) (map[string]interface{}, error) {
+	return a.FindFeaturesByAttributeWithOptions(filePath, layerName, field, value, AttributeSearchOptions{})
+}
+
+// FindFeaturesByAttributeWithOptions is FindFeaturesByAttribute with substring and case-insensitive
+// matching. The collection also carries match_count, the total number of matches, and truncated
+// when only the first Limit features were returned. GDAL evaluates the filter where it can, so
+// attribute indexes in the file are used.
+func (a *App) FindFeaturesByAttributeWithOptions(filePath string, layerName string, field string, value string, options AttributeSearchOptions) (map[string]interface{}, error) {
+	if strings.TrimSpace(field) == "" {
+		return nil, fmt.Errorf("no field given")
+	}
+	switch options.Match {
+	case "":
+		options.Match = "exact"
+	case "exact", "contains":
+	default:
+		return nil, fmt.Errorf("unknown match mode %q (expected exact or contains)", options.Match)
+	}
+	if options.Limit <= 0 {
+		options.Limit = defaultAttributeSearchLimit
+	}
+	if options.Limit > maxAttributeSearchLimit {
+		options.Limit = maxAttributeSearchLimit
+	}
+
+	filePath, err := gdalInputPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == ".geojson" || ext == ".json" {
+		return searchGeoJSONFile(filePath, field, value, options)
+	}
+
+	loader, hasNative := nativeLoaders[ext]
+	if !hasNative || !loader.preferred {
+		if _, gdalErr := a.requireGDAL(); gdalErr == nil {
+			result, err := a.searchWithOGR(filePath, layerName, field, value, options)
+			if err == nil || !hasNative {
+				return result, err
+			}
+			a.logWarn("GDAL attribute search failed for %s, scanning features instead: %v", filePath, err)
+		} else if !hasNative {
+			return nil, gdalErr
+		}
+	}
+
+	collection, err := loader.load(filePath)
+	if err != nil {
+		return nil, err
+	}
+	features, err := geojsonFeatures(collection)
+	if err != nil {
+		return nil, err
+	}
+	result := newAttributeSearchResult()
+	for _, feature := range features {
+		result.add(feature, field, value, options)
+	}
+	return result.collection(), nil
+}
+
+// searchGeoJSONFile streams the file so that only matching features are kept in memory
+func searchGeoJSONFile(filePath string, field string, value string, options AttributeSearchOptions) (map[string]interface{}, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	result := newAttributeSearchResult()
+	err = readGeoJSONFeatures(bufio.NewReader(file), func(feature map[string]interface{}) error {
+		result.add(feature, field, value, options)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoJSON: %v", err)
+	}
+	return result.collection(), nil
+}
+
+// attributeSearchResult collects matches from an in-process scan
+type attributeSearchResult struct {
+	features []interface{}
+	matches  int
+}
+
+func newAttributeSearchResult() *attributeSearchResult {
+	return &attributeSearchResult{features: []interface{}{}}
+}
+
+// add keeps the feature if it matches and there is still room under the limit
+func (r *attributeSearchResult) add(feature map[string]interface{}, field string, value string, options AttributeSearchOptions) {
+	properties, _ := feature["properties"].(map[string]interface{})
+	if !attributeMatches(properties[field], value, options) {
+		return
+	}
+	r.matches++
+	if len(r.features) < options.Limit {
+		r.features = append(r.features, feature)
+	}
+}
+
+func (r *attributeSearchResult) collection() map[string]interface{} {
+	return map[string]interface{}{
+		"type":        "FeatureCollection",
+		"features":    r.features,
+		"match_count": r.matches,
+		"truncated":   r.matches > len(r.features),
+	}
+}
+
+// attributeMatches compares a property value, in its string form, against the search value
+func attributeMatches(property interface{}, value string, options AttributeSearchOptions) bool {
+	if property == nil {
+		return false
+	}
+	text := fmt.Sprint(property)
+	if options.CaseInsensitive {
+		text = strings.ToLower(text)
+		value = strings.ToLower(value)
+	}
+	if options.Match == "contains" {
+		return strings.Contains(text, value)
+	}
+	return text == value
+}
+
+// searchWithOGR counts matches with ogrinfo and fetches the first Limit of them with ogr2ogr,
+// both with the same -where filter
+func (a *App) searchWithOGR(filePath string, layerName string, field string, value string, options AttributeSearchOptions) (map[string]interface{}, error) {
+	fields, err := a.GetLayerFields(filePath, layerName)
+	if err != nil {
+		return nil, err
+	}
+	var info *FieldInfo
+	for i := range fields {
+		if fields[i].Name == field {
+			info = &fields[i]
+			break
+		}
+	}
+	if info == nil {
+		return nil, fmt.Errorf("field %q not found", field)
+	}
+	where := ogrAttributeFilter(*info, value, options)
+
+	summary, err := a.ogrinfoSummary(filePath, layerName, "-where", where)
+	if err != nil {
+		return nil, err
+	}
+	if layerName == "" {
+		if match := ogrLayerNamePattern.FindStringSubmatch(summary); match != nil {
+			layerName = match[1]
+		}
+	}
+	matches := -1
+	if match := ogrFeatureCountPattern.FindStringSubmatch(summary); match != nil {
+		matches, _ = strconv.Atoi(match[1])
+	}
+
+	args := []string{"-f", "GeoJSON", "/dev/stdout", filePath}
+	if layerName != "" {
+		args = append(args, layerName)
+	}
+	args = append(args, "-where", where, "-limit", strconv.Itoa(options.Limit))
+	output, err := a.runGDALTool("ogr2ogr", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var collection map[string]interface{}
+	if err := json.Unmarshal(output, &collection); err != nil {
+		return nil, fmt.Errorf("failed to parse GeoJSON: %v", err)
+	}
+	features, _ := collection["features"].([]interface{})
+	if features == nil {
+		features = []interface{}{}
+		collection["features"] = features
+	}
+	if matches < len(features) {
+		matches = len(features)
+	}
+	collection["match_count"] = matches
+	collection["truncated"] = matches > len(features)
+	return collection, nil
+}
+
+// ogrAttributeFilter builds an OGR SQL WHERE clause. Numbers are compared as numbers for an
+// exact match on numeric fields; everything else is compared as text, with LIKE/ILIKE for
+// substring and case-insensitive matches.
+func ogrAttributeFilter(field FieldInfo, value string, options AttributeSearchOptions) string {
+	column := sqlIdent(field.Name)
+	numeric := field.Type == "int" || field.Type == "real"
+
+	if options.Match == "exact" && !options.CaseInsensitive {
+		if _, err := strconv.ParseFloat(value, 64); err == nil && numeric {
+			return fmt.Sprintf("%s = %s", column, value)
+		}
+		return fmt.Sprintf("%s = %s", column, sqlString(value))
+	}
+
+	if numeric {
+		column = fmt.Sprintf("CAST(%s AS CHARACTER)", column)
+	}
+	pattern := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+	if options.Match == "contains" {
+		pattern = "%" + pattern + "%"
+	}
+	operator := "LIKE"
+	if options.CaseInsensitive {
+		operator = "ILIKE"
+	}
+	return fmt.Sprintf(`%s %s %s ESCAPE '\'`, column, operator, sqlString(pattern))
+}
+
+// sqlString quotes an SQL string literal
+func sqlString(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
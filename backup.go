@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// BackupDatabase copies the catalog database, with tags, bookmarks, workspaces and settings, to
+// dstPath using SQLite's online backup API, so it is consistent even while the app is running
+func (a *App) BackupDatabase(dstPath string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	dstPath, err := filepath.Abs(dstPath)
+	if err != nil {
+		return fmt.Errorf("invalid backup path: %v", err)
+	}
+	if info, err := os.Stat(dstPath); err == nil && info.IsDir() {
+		return fmt.Errorf("%s is a directory", dstPath)
+	}
+
+	// Write next to the destination and rename, so a failed backup never leaves a partial file
+	tmpPath := dstPath + ".tmp"
+	os.Remove(tmpPath)
+	defer os.Remove(tmpPath)
+
+	dst, err := sql.Open("sqlite3", tmpPath)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %v", err)
+	}
+	a.mu.RLock()
+	err = copySQLite(dst, a.db)
+	a.mu.RUnlock()
+	if closeErr := dst.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to back up database: %v", err)
+	}
+
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return fmt.Errorf("failed to write %s: %v", dstPath, err)
+	}
+	a.logInfo("Backed up database to %s", dstPath)
+	return nil
+}
+
+// RestoreDatabase replaces the catalog database with a backup made by BackupDatabase. The file
+// must be a readable Terrabox database no newer than this version of the app. The current
+// database is first backed up to ~/.terrabox/backups.
+func (a *App) RestoreDatabase(srcPath string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	src, err := openTerraboxBackup(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dir, err := terraboxDir()
+	if err != nil {
+		return fmt.Errorf("failed to locate data directory: %v", err)
+	}
+	backupDir := filepath.Join(dir, "backups")
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %v", err)
+	}
+	safetyCopy := filepath.Join(backupDir, fmt.Sprintf("terrabox_%s.db", time.Now().Format("20060102_150405")))
+	if err := a.BackupDatabase(safetyCopy); err != nil {
+		return fmt.Errorf("failed to back up the current database, nothing was restored: %v", err)
+	}
+
+	if err := a.replaceCatalog(src, safetyCopy); err != nil {
+		return err
+	}
+	// The restored settings table replaces what the caches were read from
+	a.reloadSettings()
+
+	a.logInfo("Restored database from %s (previous database saved to %s)", srcPath, safetyCopy)
+	return nil
+}
+
+// replaceCatalog copies src over the catalog, upgrades it to the current schema and drops the
+// in-memory state read from the old one
+func (a *App) replaceCatalog(src *sql.DB, safetyCopy string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.stmtMu.Lock()
+	if a.listFilesStmt != nil {
+		a.listFilesStmt.Close()
+		a.listFilesStmt = nil
+	}
+	a.stmtMu.Unlock()
+
+	if err := copySQLite(a.db, src); err != nil {
+		return fmt.Errorf("failed to restore database (the previous one is in %s): %v", safetyCopy, err)
+	}
+	// Older backups lack the tables added since; add them and record the current version
+	if _, err := a.db.Exec(catalogSchema); err != nil {
+		return fmt.Errorf("failed to upgrade restored database: %v", err)
+	}
+	if err := syncFileRTree(a.db); err != nil {
+		return fmt.Errorf("failed to upgrade restored database: %v", err)
+	}
+	if _, err := a.db.Exec(fmt.Sprintf("PRAGMA user_version = %d", schemaVersion)); err != nil {
+		return fmt.Errorf("failed to upgrade restored database: %v", err)
+	}
+
+	a.bounds.mu.Lock()
+	a.bounds.entries = nil
+	a.bounds.mu.Unlock()
+
+	// The restored catalog has its own job history, which is loaded again when next needed
+	a.jobs.mu.Lock()
+	a.jobs.jobs, a.jobs.loaded, a.jobs.pending = nil, false, nil
+	a.jobs.mu.Unlock()
+	return nil
+}
+
+// openTerraboxBackup opens a backup read-only and checks that it is an intact Terrabox database
+func openTerraboxBackup(path string) (*sql.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("backup not found: %v", err)
+	}
+	db, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %v", err)
+	}
+
+	var check string
+	if err := db.QueryRow("PRAGMA quick_check").Scan(&check); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s is not a SQLite database: %v", filepath.Base(path), err)
+	}
+	if check != "ok" {
+		db.Close()
+		return nil, fmt.Errorf("%s is damaged: %s", filepath.Base(path), check)
+	}
+
+	var tables int
+	db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name IN ('geo_file_index', 'settings')").Scan(&tables)
+	if tables != 2 {
+		db.Close()
+		return nil, fmt.Errorf("%s is not a Terrabox database", filepath.Base(path))
+	}
+
+	var version int
+	db.QueryRow("PRAGMA user_version").Scan(&version)
+	if version > schemaVersion {
+		db.Close()
+		return nil, fmt.Errorf("%s was made by a newer version of Terrabox (schema %d, this version supports %d)",
+			filepath.Base(path), version, schemaVersion)
+	}
+	return db, nil
+}
+
+// copySQLite overwrites dst with the contents of src using the SQLite backup API
+func copySQLite(dst *sql.DB, src *sql.DB) error {
+	ctx := context.Background()
+	dstConn, err := dst.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dstConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return dstConn.Raw(func(dstDriver interface{}) error {
+		return srcConn.Raw(func(srcDriver interface{}) error {
+			dstSQLite, ok := dstDriver.(*sqlite3.SQLiteConn)
+			srcSQLite, ok2 := srcDriver.(*sqlite3.SQLiteConn)
+			if !ok || !ok2 {
+				return fmt.Errorf("not a SQLite connection")
+			}
+			backup, err := dstSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return err
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Close()
+				return err
+			}
+			return backup.Finish()
+		})
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreReloadsCachedSettings(t *testing.T) {
+	a := newTestApp(t)
+
+	if err := a.SetLogLevel("debug"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetGDALTimeout(30); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetGDALConcurrency(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.saveSupportedExtensions([]SupportedExtension{{".geojson", "vector", ""}}); err != nil {
+		t.Fatal(err)
+	}
+	backupPath := filepath.Join(t.TempDir(), "terrabox.db")
+	if err := a.BackupDatabase(backupPath); err != nil {
+		t.Fatalf("BackupDatabase failed: %v", err)
+	}
+
+	// Change every cached setting after the backup, then restore it
+	if err := a.SetLogLevel("error"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetGDALTimeout(90); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SetGDALConcurrency(6); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.saveSupportedExtensions([]SupportedExtension{{".shp", "vector", ""}, {".tif", "raster", ""}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.RestoreDatabase(backupPath); err != nil {
+		t.Fatalf("RestoreDatabase failed: %v", err)
+	}
+
+	if level := a.GetLogLevel(); level != "debug" {
+		t.Errorf("log level = %s after restore, want debug", level)
+	}
+	if timeout := a.GetGDALTimeout(); timeout != 30 {
+		t.Errorf("GDAL timeout = %d after restore, want 30", timeout)
+	}
+	if limit := a.GetGDALConcurrency(); limit != 2 {
+		t.Errorf("GDAL concurrency = %d after restore, want 2", limit)
+	}
+	if list := a.supportedExtensions(); len(list) != 1 || list[0].Extension != ".geojson" {
+		t.Errorf("supported extensions = %v after restore, want only .geojson", list)
+	}
+}
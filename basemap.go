@@ -0,0 +1,355 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxBasemapTiles stops CacheBasemapTiles from starting an accidentally huge download
+const maxBasemapTiles = 20000
+
+// maxBasemapZoom is the deepest zoom level CacheBasemapTiles accepts
+const maxBasemapZoom = 19
+
+// basemapDownloadConcurrency keeps tile downloads polite towards public tile servers
+const basemapDownloadConcurrency = 4
+
+// basemapProgressEvent is emitted while CacheBasemapTiles downloads
+const basemapProgressEvent = "basemap:progress"
+
+// basemapRoute is where the asset server serves cached tiles: /basemap-cache/<id>/<z>/<x>/<y>
+const basemapRoute = "/basemap-cache/"
+
+// basemapSubdomains are substituted for {s} in tile URL templates, in turn
+var basemapSubdomains = []string{"a", "b", "c"}
+
+// BasemapProgress is the payload of basemap:progress events
+type BasemapProgress struct {
+	CacheID    string `json:"cache_id"`
+	Total      int    `json:"total"`
+	Downloaded int    `json:"downloaded"`
+	Skipped    int    `json:"skipped"`
+	Failed     int    `json:"failed"`
+	Bytes      int64  `json:"bytes"`
+	Done       bool   `json:"done"`
+	Error      string `json:"error,omitempty"`
+}
+
+// BasemapCache describes one offline tile set. LocalURL is the template the map uses offline.
+type BasemapCache struct {
+	ID        string    `json:"id"`
+	TileURL   string    `json:"tile_url"`
+	LocalURL  string    `json:"local_url"`
+	BBox      []float64 `json:"bbox"`
+	MinZoom   int       `json:"min_zoom"`
+	MaxZoom   int       `json:"max_zoom"`
+	Tiles     int       `json:"tiles"`
+	Bytes     int64     `json:"bytes"`
+	UpdatedAt string    `json:"updated_at"`
+}
+
+// CacheBasemapTiles downloads the XYZ tiles covering bbox ([west, south, east, north]) from
+// minZoom to maxZoom into ~/.terrabox/basemap_cache, for use without internet. tileURL is a
+// template such as https://{s}.tile.openstreetmap.org/{z}/{x}/{y}.png. Tiles already cached are
+// skipped, so an interrupted download can be resumed by calling it again.
+func (a *App) CacheBasemapTiles(bbox []float64, minZoom, maxZoom int, tileURL string) error {
+	if !validBBox(bbox) || bbox[0] < -180 || bbox[2] > 180 || bbox[1] < -90 || bbox[3] > 90 {
+		return fmt.Errorf("bbox must be [west, south, east, north] in degrees")
+	}
+	if minZoom < 0 || maxZoom > maxBasemapZoom || minZoom > maxZoom {
+		return fmt.Errorf("zoom levels must satisfy 0 <= minZoom <= maxZoom <= %d", maxBasemapZoom)
+	}
+	for _, placeholder := range []string{"{z}", "{x}", "{y}"} {
+		if !strings.Contains(tileURL, placeholder) {
+			return fmt.Errorf("tile URL must contain {z}, {x} and {y}")
+		}
+	}
+
+	total := 0
+	for z := minZoom; z <= maxZoom; z++ {
+		minX, minY, maxX, maxY := basemapTileRange(bbox, z)
+		total += (maxX - minX + 1) * (maxY - minY + 1)
+	}
+	if total > maxBasemapTiles {
+		return fmt.Errorf("the area needs %d tiles, more than the %d allowed; choose a smaller area or fewer zoom levels", total, maxBasemapTiles)
+	}
+
+	id := basemapCacheID(tileURL)
+	dir, err := basemapCacheDir(id)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create basemap cache: %v", err)
+	}
+
+	progress := BasemapProgress{CacheID: id, Total: total}
+	var mu sync.Mutex
+	var lastError string
+	var counter uint64
+	a.logInfo("Caching %d basemap tiles from %s", total, tileURL)
+	start := time.Now()
+
+	slots := make(chan struct{}, basemapDownloadConcurrency)
+	var wg sync.WaitGroup
+	for z := minZoom; z <= maxZoom; z++ {
+		minX, minY, maxX, maxY := basemapTileRange(bbox, z)
+		for x := minX; x <= maxX; x++ {
+			for y := minY; y <= maxY; y++ {
+				tilePath := filepath.Join(dir, strconv.Itoa(z), strconv.Itoa(x), strconv.Itoa(y))
+				if info, err := os.Stat(tilePath); err == nil {
+					mu.Lock()
+					progress.Skipped++
+					progress.Bytes += info.Size()
+					mu.Unlock()
+					continue
+				}
+
+				subdomain := basemapSubdomains[atomic.AddUint64(&counter, 1)%uint64(len(basemapSubdomains))]
+				url := strings.NewReplacer("{s}", subdomain, "{z}", strconv.Itoa(z),
+					"{x}", strconv.Itoa(x), "{y}", strconv.Itoa(y)).Replace(tileURL)
+
+				wg.Add(1)
+				slots <- struct{}{}
+				go func(url, tilePath string) {
+					defer wg.Done()
+					defer func() { <-slots }()
+
+					size, err := a.downloadBasemapTile(url, tilePath)
+
+					mu.Lock()
+					defer mu.Unlock()
+					if err != nil {
+						progress.Failed++
+						lastError = err.Error()
+					} else {
+						progress.Downloaded++
+						progress.Bytes += size
+					}
+					a.emitEvent(basemapProgressEvent, progress)
+				}(url, tilePath)
+			}
+		}
+	}
+	wg.Wait()
+
+	cache := BasemapCache{
+		ID:        id,
+		TileURL:   tileURL,
+		BBox:      bbox,
+		MinZoom:   minZoom,
+		MaxZoom:   maxZoom,
+		Tiles:     progress.Downloaded + progress.Skipped,
+		Bytes:     progress.Bytes,
+		UpdatedAt: time.Now().Format(time.RFC3339),
+	}
+	if err := writeBasemapCacheInfo(dir, cache); err != nil {
+		a.logWarn("Could not save basemap cache info: %v", err)
+	}
+
+	progress.Done = true
+	var result error
+	if progress.Failed > 0 {
+		result = fmt.Errorf("%d of %d tiles failed, run again to retry them: %s", progress.Failed, total, lastError)
+		progress.Error = result.Error()
+	}
+	a.emitEvent(basemapProgressEvent, progress)
+	a.logInfo("Cached %d basemap tiles (%d already present, %d failed, %d bytes) in %s",
+		progress.Downloaded, progress.Skipped, progress.Failed, progress.Bytes, time.Since(start).Round(time.Millisecond))
+	return result
+}
+
+// GetBasemapCaches lists the offline tile sets with their size on disk
+func (a *App) GetBasemapCaches() ([]BasemapCache, error) {
+	root, err := basemapCacheDir("")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read basemap cache: %v", err)
+	}
+
+	caches := []BasemapCache{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(root, entry.Name(), "cache.json"))
+		if err != nil {
+			continue
+		}
+		var cache BasemapCache
+		if json.Unmarshal(data, &cache) != nil {
+			continue
+		}
+		cache.ID = entry.Name()
+		cache.LocalURL = basemapRoute + cache.ID + "/{z}/{x}/{y}"
+		cache.Bytes = directorySize(filepath.Join(root, entry.Name()))
+		caches = append(caches, cache)
+	}
+	return caches, nil
+}
+
+// DeleteBasemapCache removes an offline tile set
+func (a *App) DeleteBasemapCache(id string) error {
+	if !isBasemapCacheID(id) {
+		return fmt.Errorf("basemap cache %q not found", id)
+	}
+	dir, err := basemapCacheDir(id)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("basemap cache %q not found", id)
+	}
+	return os.RemoveAll(dir)
+}
+
+// downloadBasemapTile saves one tile, renaming it into place once complete
+func (a *App) downloadBasemapTile(url string, tilePath string) (int64, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", a.userAgent())
+
+	resp, err := a.httpClient().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("%s: HTTP %d", url, resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(tilePath), 0755); err != nil {
+		return 0, err
+	}
+	tmpPath := tilePath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("%s: %v", url, err)
+	}
+	return size, os.Rename(tmpPath, tilePath)
+}
+
+// basemapTileHandler serves cached tiles at /basemap-cache/<id>/<z>/<x>/<y> through the Wails
+// asset server, so the map keeps working offline
+func (a *App) basemapTileHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, basemapRoute) {
+			http.NotFound(w, r)
+			return
+		}
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, basemapRoute), "/")
+		if len(parts) != 4 || !isBasemapCacheID(parts[0]) {
+			http.NotFound(w, r)
+			return
+		}
+		// Accept a file extension on y, as in {y}.png templates
+		parts[3] = strings.TrimSuffix(parts[3], filepath.Ext(parts[3]))
+		for _, part := range parts[1:] {
+			if _, err := strconv.Atoi(part); err != nil {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		dir, err := basemapCacheDir(parts[0])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join(dir, parts[1], parts[2], parts[3]))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", http.DetectContentType(data))
+		w.Header().Set("Cache-Control", "max-age=86400")
+		w.Write(data)
+	})
+}
+
+// basemapTileRange returns the tile columns and rows covering bbox at zoom z
+func basemapTileRange(bbox []float64, z int) (int, int, int, int) {
+	minX, maxY := lonLatToTile(bbox[0], bbox[1], z)
+	maxX, minY := lonLatToTile(bbox[2], bbox[3], z)
+	return minX, minY, maxX, maxY
+}
+
+// lonLatToTile converts a position to Web Mercator XYZ tile indices
+func lonLatToTile(lon, lat float64, z int) (int, int) {
+	n := float64(int(1) << z)
+	lat = math.Max(-85.05112878, math.Min(85.05112878, lat))
+	rad := lat * math.Pi / 180
+	x := int(math.Floor((lon + 180) / 360 * n))
+	y := int(math.Floor((1 - math.Log(math.Tan(rad)+1/math.Cos(rad))/math.Pi) / 2 * n))
+	last := int(n) - 1
+	if x > last {
+		x = last
+	}
+	if y > last {
+		y = last
+	}
+	if x < 0 {
+		x = 0
+	}
+	if y < 0 {
+		y = 0
+	}
+	return x, y
+}
+
+// basemapCacheID names a tile source's directory, so tiles from different sources never mix
+func basemapCacheID(tileURL string) string {
+	sum := sha256.Sum256([]byte(tileURL))
+	return hex.EncodeToString(sum[:6])
+}
+
+func isBasemapCacheID(id string) bool {
+	if len(id) != 12 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
+// basemapCacheDir returns ~/.terrabox/basemap_cache, or the directory of one tile set
+func basemapCacheDir(id string) (string, error) {
+	dir, err := terraboxDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate data directory: %v", err)
+	}
+	return filepath.Join(dir, "basemap_cache", id), nil
+}
+
+// writeBasemapCacheInfo records a tile set's source and coverage in cache.json
+func writeBasemapCacheInfo(dir string, cache BasemapCache) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "cache.json"), data, 0644)
+}
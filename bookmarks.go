@@ -0,0 +1,141 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bookmark is a saved map location: a centre and zoom, or a bbox to fit
+type Bookmark struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Lat        float64   `json:"lat"`
+	Lon        float64   `json:"lon"`
+	Zoom       float64   `json:"zoom"`
+	BBox       []float64 `json:"bbox,omitempty"`
+	CreatedAt  string    `json:"created_at"`
+	LastUsedAt string    `json:"last_used_at"`
+}
+
+// AddBookmark saves a map centre and zoom level and returns the bookmark's ID
+func (a *App) AddBookmark(name string, lat, lon, zoom float64) (int, error) {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return 0, fmt.Errorf("lat/lon (%g, %g) is out of range", lat, lon)
+	}
+	if zoom < 0 || zoom > 24 {
+		return 0, fmt.Errorf("zoom must be between 0 and 24")
+	}
+	return a.insertBookmark(name, lat, lon, zoom, nil)
+}
+
+// AddBookmarkBBox saves an area as [minLon, minLat, maxLon, maxLat] for the map to fit instead
+// of a fixed zoom. Its centre is stored as lat/lon as well.
+func (a *App) AddBookmarkBBox(name string, bbox []float64) (int, error) {
+	if !validBBox(bbox) || bbox[0] < -180 || bbox[2] > 180 || bbox[1] < -90 || bbox[3] > 90 {
+		return 0, fmt.Errorf("bbox must be [minLon, minLat, maxLon, maxLat] in degrees")
+	}
+	return a.insertBookmark(name, (bbox[1]+bbox[3])/2, (bbox[0]+bbox[2])/2, 0, bbox)
+}
+
+func (a *App) insertBookmark(name string, lat, lon, zoom float64, bbox []float64) (int, error) {
+	if a.db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("bookmark name is required")
+	}
+
+	var bboxJSON interface{}
+	if bbox != nil {
+		data, err := json.Marshal(bbox)
+		if err != nil {
+			return 0, fmt.Errorf("failed to encode bbox: %v", err)
+		}
+		bboxJSON = string(data)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now().Format(time.RFC3339)
+	result, err := a.db.Exec(`INSERT INTO bookmarks (name, lat, lon, zoom, bbox, created_at, last_used_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, name, lat, lon, zoom, bboxJSON, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to add bookmark: %v", err)
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// ListBookmarks returns all bookmarks, most recently used first
+func (a *App) ListBookmarks() ([]Bookmark, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	rows, err := a.db.Query(`SELECT id, name, lat, lon, zoom, bbox, created_at, last_used_at
+		FROM bookmarks ORDER BY last_used_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bookmarks: %v", err)
+	}
+	defer rows.Close()
+
+	bookmarks := []Bookmark{}
+	for rows.Next() {
+		var b Bookmark
+		var bbox sql.NullString
+		if err := rows.Scan(&b.ID, &b.Name, &b.Lat, &b.Lon, &b.Zoom, &bbox, &b.CreatedAt, &b.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to read bookmark: %v", err)
+		}
+		if bbox.Valid {
+			json.Unmarshal([]byte(bbox.String), &b.BBox)
+		}
+		bookmarks = append(bookmarks, b)
+	}
+	return bookmarks, rows.Err()
+}
+
+// UseBookmark marks a bookmark as just used, moving it to the top of ListBookmarks
+func (a *App) UseBookmark(id int) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result, err := a.db.Exec("UPDATE bookmarks SET last_used_at = ? WHERE id = ?", time.Now().Format(time.RFC3339), id)
+	if err != nil {
+		return fmt.Errorf("failed to update bookmark: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("bookmark %d not found", id)
+	}
+	return nil
+}
+
+// DeleteBookmark removes a bookmark
+func (a *App) DeleteBookmark(id int) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result, err := a.db.Exec("DELETE FROM bookmarks WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete bookmark: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("bookmark %d not found", id)
+	}
+	return nil
+}
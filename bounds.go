@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ogrExtentPattern = regexp.MustCompile(`(?m)^Extent: \(([-\d.eE+]+), ([-\d.eE+]+)\) - \(([-\d.eE+]+), ([-\d.eE+]+)\)`)
+	wktIDPattern     = regexp.MustCompile(`ID\["EPSG",\s*(\d+)\]\]\s*$`)
+)
+
+// layerBoundsCache remembers WGS84 bounds per file and layer until the file changes
+type layerBoundsCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedBounds
+}
+
+type cachedBounds struct {
+	modTime time.Time
+	size    int64
+	bbox    []float64
+}
+
+// GetLayerBounds returns a layer's extent as [minLon, minLat, maxLon, maxLat] without reading its
+// features, so the map can zoom to a layer before it is loaded. The bbox comes from the index
+// when it has a real one, otherwise from the file header (shapefile, FlatGeobuf, LAS), gdalinfo
+// for rasters or `ogrinfo -so`, and is reprojected to WGS84.
+func (a *App) GetLayerBounds(filePath string, layerName string) ([]float64, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("file not found: %v", err)
+	}
+	key := filePath + "\x00" + layerName
+
+	a.bounds.mu.Lock()
+	cached, ok := a.bounds.entries[key]
+	a.bounds.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return append([]float64(nil), cached.bbox...), nil
+	}
+
+	bbox, crs, ok := a.indexedBounds(filePath, layerName)
+	if !ok {
+		bbox, crs, err = a.readLayerBounds(filePath, layerName)
+		if err != nil {
+			return nil, err
+		}
+	}
+	bbox, err = a.bboxToWGS84(bbox, crs)
+	if err != nil {
+		return nil, err
+	}
+
+	a.bounds.mu.Lock()
+	if a.bounds.entries == nil {
+		a.bounds.entries = map[string]cachedBounds{}
+	}
+	a.bounds.entries[key] = cachedBounds{modTime: info.ModTime(), size: info.Size(), bbox: bbox}
+	a.bounds.mu.Unlock()
+	return append([]float64(nil), bbox...), nil
+}
+
+// indexedBounds returns the bbox and CRS stored at index time, unless it is the global
+// placeholder used when extraction couldn't determine one
+func (a *App) indexedBounds(filePath string, layerName string) ([]float64, string, bool) {
+	if a.db == nil {
+		return nil, "", false
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	query := "SELECT COALESCE(bbox, ''), COALESCE(crs, '') FROM geo_file_index WHERE file_path = ?"
+	args := []interface{}{filePath}
+	if layerName != "" {
+		query += " AND layer_name = ?"
+		args = append(args, layerName)
+	}
+	var raw, crs string
+	if err := a.db.QueryRow(query+" LIMIT 1", args...).Scan(&raw, &crs); err != nil {
+		return nil, "", false
+	}
+	var bbox []float64
+	if json.Unmarshal([]byte(raw), &bbox) != nil || !validBBox(bbox) || isDefaultBBox(bbox) {
+		return nil, "", false
+	}
+	return bbox, crs, true
+}
+
+// validBBox reports whether bbox is four finite numbers with min <= max
+func validBBox(bbox []float64) bool {
+	if len(bbox) != 4 {
+		return false
+	}
+	for _, v := range bbox {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return bbox[0] <= bbox[2] && bbox[1] <= bbox[3]
+}
+
+// isDefaultBBox reports whether bbox is the whole-world placeholder set before extraction
+func isDefaultBBox(bbox []float64) bool {
+	return len(bbox) == 4 && bbox[0] == -180 && bbox[1] == -90 && bbox[2] == 180 && bbox[3] == 90
+}
+
+// readLayerBounds reads the bbox, in the layer's own CRS, from the cheapest source for the format
+func (a *App) readLayerBounds(filePath string, layerName string) ([]float64, string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".shp":
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+		data := make([]byte, 100)
+		if _, err := io.ReadFull(file, data); err != nil {
+			return nil, "", fmt.Errorf("failed to read shapefile header: %v", err)
+		}
+		header, err := readShapefileHeader(data)
+		if err != nil {
+			return nil, "", err
+		}
+		return header.BBox[:], readShapefileCRS(filePath), nil
+	case ".fgb":
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+		header, err := readFlatGeobufHeader(file)
+		if err != nil {
+			return nil, "", err
+		}
+		if len(header.Envelope) < 4 {
+			return nil, "", fmt.Errorf("%s has no extent in its header", filepath.Base(filePath))
+		}
+		return header.Envelope[:4], header.CRS, nil
+	case ".las", ".laz":
+		header, err := readLASHeader(filePath)
+		if err != nil {
+			return nil, "", err
+		}
+		return []float64{header.Min[0], header.Min[1], header.Max[0], header.Max[1]}, header.CRS, nil
+	}
+
+	if a.determineFileType(strings.ToLower(filepath.Ext(filePath))) == "raster" {
+		info, err := a.readRasterInfo(filePath)
+		if err != nil {
+			return nil, "", err
+		}
+		bbox, ok := info.bounds()
+		if !ok {
+			return nil, "", fmt.Errorf("%s is not georeferenced", filepath.Base(filePath))
+		}
+		return bbox, "EPSG:4326", nil
+	}
+
+	summary, err := a.ogrinfoSummary(filePath, layerName)
+	if err != nil {
+		return nil, "", err
+	}
+	return parseOgrinfoExtent(summary)
+}
+
+// parseOgrinfoExtent reads the first layer's Extent line and SRS WKT from `ogrinfo -so` output
+func parseOgrinfoExtent(summary string) ([]float64, string, error) {
+	match := ogrExtentPattern.FindStringSubmatch(summary)
+	if match == nil {
+		return nil, "", fmt.Errorf("ogrinfo reported no extent")
+	}
+	bbox := make([]float64, 4)
+	for i := range bbox {
+		bbox[i], _ = strconv.ParseFloat(match[i+1], 64)
+	}
+
+	// The WKT follows "Layer SRS WKT:" and may span several lines
+	var wkt strings.Builder
+	depth := 0
+	if i := strings.Index(summary, "Layer SRS WKT:\n"); i >= 0 {
+		for _, line := range strings.Split(summary[i+len("Layer SRS WKT:\n"):], "\n") {
+			line = strings.TrimSpace(line)
+			wkt.WriteString(line)
+			depth += strings.Count(line, "[") - strings.Count(line, "]")
+			if depth <= 0 {
+				break
+			}
+		}
+	}
+	return bbox, wktCRS(wkt.String()), nil
+}
+
+// wktCRS shortens a WKT CRS to EPSG:nnnn when it carries an EPSG identifier. Anything that isn't
+// WKT, such as "(unknown)", becomes "".
+func wktCRS(wkt string) string {
+	wkt = strings.TrimSpace(wkt)
+	if !strings.Contains(wkt, "[") {
+		return ""
+	}
+	if m := wktIDPattern.FindStringSubmatch(wkt); m != nil {
+		return "EPSG:" + m[1]
+	}
+	if m := prjAuthorityPattern.FindStringSubmatch(wkt); m != nil {
+		return "EPSG:" + m[1]
+	}
+	return wkt
+}
+
+// bboxToWGS84 reprojects a bbox by transforming its corners and edge midpoints. Without a CRS
+// the bbox is taken as lon/lat if it fits, since that is what GeoJSON and KML use.
+func (a *App) bboxToWGS84(bbox []float64, crs string) ([]float64, error) {
+	if !validBBox(bbox) {
+		return nil, fmt.Errorf("invalid bounding box %v", bbox)
+	}
+	lonLat := bbox[0] >= -180 && bbox[2] <= 180 && bbox[1] >= -90 && bbox[3] <= 90
+	if crs == "" {
+		if !lonLat {
+			return nil, fmt.Errorf("the layer's CRS is unknown and its extent is not in degrees")
+		}
+		return bbox, nil
+	}
+	if normalized, err := normalizeCRS(crs); err == nil && (normalized == "EPSG:4326" || normalized == "EPSG:4979") {
+		return bbox, nil
+	}
+
+	midX, midY := (bbox[0]+bbox[2])/2, (bbox[1]+bbox[3])/2
+	points := [][]float64{
+		{bbox[0], bbox[1]}, {midX, bbox[1]}, {bbox[2], bbox[1]},
+		{bbox[0], midY}, {bbox[2], midY},
+		{bbox[0], bbox[3]}, {midX, bbox[3]}, {bbox[2], bbox[3]},
+	}
+	transformed, err := a.TransformCoordinates(points, crs, "EPSG:4326")
+	if err != nil {
+		return nil, fmt.Errorf("failed to reproject extent to WGS84: %v", err)
+	}
+	result := []float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range transformed {
+		if len(p) < 2 || math.IsNaN(p[0]) || math.IsNaN(p[1]) || math.IsInf(p[0], 0) || math.IsInf(p[1], 0) {
+			continue
+		}
+		result[0], result[1] = math.Min(result[0], p[0]), math.Min(result[1], p[1])
+		result[2], result[3] = math.Max(result[2], p[0]), math.Max(result[3], p[1])
+	}
+	if !validBBox(result) {
+		return nil, fmt.Errorf("failed to reproject extent to WGS84")
+	}
+	return result, nil
+}
+
+// CombinedExtent is the union of several layers' extents, with the layers that had none
+type CombinedExtent struct {
+	BBox     []float64        `json:"bbox"`
+	Included int              `json:"included"`
+	Excluded []ExcludedExtent `json:"excluded"`
+}
+
+// ExcludedExtent names a layer left out of a combined extent and why
+type ExcludedExtent struct {
+	FilePath string `json:"file_path"`
+	Reason   string `json:"reason"`
+}
+
+// CombinedBounds returns one WGS84 extent covering every file's indexed bbox, for "zoom to all
+// data". Files without a known extent are skipped and logged.
+func (a *App) CombinedBounds(filePaths []string) ([]float64, error) {
+	extent, err := a.CombinedBoundsDetailed(filePaths)
+	if err != nil {
+		return nil, err
+	}
+	for _, excluded := range extent.Excluded {
+		a.logWarn("Left %s out of the combined extent: %s", excluded.FilePath, excluded.Reason)
+	}
+	return extent.BBox, nil
+}
+
+// CombinedBoundsDetailed is CombinedBounds that also lists the files that were left out
+func (a *App) CombinedBoundsDetailed(filePaths []string) (CombinedExtent, error) {
+	if a.db == nil {
+		return CombinedExtent{}, fmt.Errorf("database not initialized")
+	}
+
+	extent := CombinedExtent{Excluded: []ExcludedExtent{}}
+	union := []float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, filePath := range filePaths {
+		bbox, crs, ok := a.indexedBounds(filePath, "")
+		if !ok {
+			extent.Excluded = append(extent.Excluded, ExcludedExtent{FilePath: filePath, Reason: "no extent in the index"})
+			continue
+		}
+		bbox, err := a.bboxToWGS84(bbox, crs)
+		if err != nil {
+			extent.Excluded = append(extent.Excluded, ExcludedExtent{FilePath: filePath, Reason: err.Error()})
+			continue
+		}
+		union[0], union[1] = math.Min(union[0], bbox[0]), math.Min(union[1], bbox[1])
+		union[2], union[3] = math.Max(union[2], bbox[2]), math.Max(union[3], bbox[3])
+		extent.Included++
+	}
+
+	if extent.Included == 0 {
+		return extent, fmt.Errorf("none of the %d layers has a known extent", len(filePaths))
+	}
+	extent.BBox = union
+	return extent, nil
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// spatialiteDriver is SQLite with the SpatiaLite extension loaded on connect
+const spatialiteDriver = "sqlite3_spatialite"
+
+func init() {
+	sql.Register(spatialiteDriver, &sqlite3.SQLiteDriver{Extensions: []string{"mod_spatialite"}})
+}
+
+// catalogExportSchema is the table and views written by ExportCatalogDB
+const catalogExportSchema = `
+	CREATE TABLE catalog (
+		id INTEGER PRIMARY KEY,
+		file_path TEXT NOT NULL,
+		file_name TEXT NOT NULL,
+		file_extension TEXT NOT NULL,
+		file_type TEXT NOT NULL,
+		layer_name TEXT NOT NULL,
+		file_size INTEGER,
+		created_at TEXT,
+		modified_at TEXT,
+		crs TEXT,
+		min_x REAL,
+		min_y REAL,
+		max_x REAL,
+		max_y REAL,
+		num_features INTEGER,
+		num_bands INTEGER,
+		resolution REAL,
+		metadata TEXT,
+		bbox_wkt TEXT
+	);
+	CREATE INDEX catalog_file_type ON catalog(file_type);
+	CREATE INDEX catalog_bounds ON catalog(min_x, max_x, min_y, max_y);
+
+	CREATE VIEW catalog_vector AS SELECT * FROM catalog WHERE file_type = 'vector';
+	CREATE VIEW catalog_raster AS SELECT * FROM catalog WHERE file_type = 'raster';
+	CREATE VIEW catalog_by_format AS
+		SELECT file_extension, COUNT(*) AS layers, COUNT(DISTINCT file_path) AS files, SUM(file_size) AS total_size
+		FROM catalog GROUP BY file_extension ORDER BY layers DESC;
+	CREATE VIEW catalog_by_crs AS
+		SELECT COALESCE(crs, 'unknown') AS crs, COUNT(*) AS layers FROM catalog GROUP BY 1 ORDER BY layers DESC;
+
+	CREATE TABLE catalog_info (
+		name TEXT PRIMARY KEY,
+		description TEXT NOT NULL
+	);
+`
+
+// catalogExportDocs is written to catalog_info so the export explains itself
+var catalogExportDocs = [][2]string{
+	{"catalog", "One row per indexed layer, copied from the Terrabox catalog"},
+	{"catalog.id", "Terrabox catalog id"},
+	{"catalog.file_path", "Absolute path of the file on the machine that exported the catalog"},
+	{"catalog.file_type", "vector, raster or point_cloud"},
+	{"catalog.layer_name", "Layer within the file; equal to the file name for single-layer formats"},
+	{"catalog.file_size", "Size in bytes"},
+	{"catalog.created_at", "File creation time, RFC 3339"},
+	{"catalog.modified_at", "File modification time, RFC 3339"},
+	{"catalog.crs", "Coordinate reference system of the data, e.g. EPSG:4326"},
+	{"catalog.min_x", "Bounding box west edge (min_y south, max_x east, max_y north)"},
+	{"catalog.metadata", "Format-specific metadata as JSON"},
+	{"catalog.bbox_wkt", "Bounding box as a WKT polygon in EPSG:4326; NULL when the bbox is not in degrees"},
+	{"catalog_vector", "View: vector layers only"},
+	{"catalog_raster", "View: raster layers only"},
+	{"catalog_by_format", "View: layer and file counts and total size per file extension"},
+	{"catalog_by_crs", "View: layer count per CRS"},
+}
+
+// ExportCatalogDB writes the catalog to a standalone SQLite database that QGIS, Python or any
+// SQLite client can query. When SpatiaLite is installed the catalog table also gets a geom
+// column with a spatial index. Every table, view and column is described in catalog_info.
+func (a *App) ExportCatalogDB(dstPath string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	dstPath, err := filepath.Abs(dstPath)
+	if err != nil {
+		return fmt.Errorf("invalid export path: %v", err)
+	}
+	if info, err := os.Stat(dstPath); err == nil && info.IsDir() {
+		return fmt.Errorf("%s is a directory", dstPath)
+	}
+
+	// Build next to the destination and rename, so a failed export never leaves a partial file
+	tmpPath := dstPath + ".tmp"
+	os.Remove(tmpPath)
+	defer os.Remove(tmpPath)
+
+	dst, spatial, err := openCatalogExport(tmpPath)
+	if err != nil {
+		return err
+	}
+	count, err := a.writeCatalogExport(dst, spatial)
+	if closeErr := dst.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close export: %v", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return fmt.Errorf("failed to write %s: %v", dstPath, err)
+	}
+	a.logInfo("Exported %d catalog entries to %s (SpatiaLite: %t)", count, dstPath, spatial)
+	return nil
+}
+
+// openCatalogExport creates the export database, with SpatiaLite if it can be loaded
+func openCatalogExport(path string) (*sql.DB, bool, error) {
+	db, err := sql.Open(spatialiteDriver, path)
+	if err == nil {
+		if err = db.Ping(); err == nil {
+			if _, err = db.Exec("SELECT InitSpatialMetadata(1)"); err == nil {
+				return db, true, nil
+			}
+		}
+		db.Close()
+		os.Remove(path)
+	}
+
+	db, err = sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create export database: %v", err)
+	}
+	return db, false, nil
+}
+
+// writeCatalogExport copies geo_file_index into dst and returns the number of rows
+func (a *App) writeCatalogExport(dst *sql.DB, spatial bool) (int, error) {
+	if _, err := dst.Exec(catalogExportSchema); err != nil {
+		return 0, fmt.Errorf("failed to create export schema: %v", err)
+	}
+
+	tx, err := dst.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start export: %v", err)
+	}
+	defer tx.Rollback()
+
+	insert, err := tx.Prepare(`
+		INSERT INTO catalog (id, file_path, file_name, file_extension, file_type, layer_name, file_size,
+			created_at, modified_at, crs, min_x, min_y, max_x, max_y, num_features, num_bands,
+			resolution, metadata, bbox_wkt)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare export: %v", err)
+	}
+	defer insert.Close()
+
+	a.mu.RLock()
+	rows, err := a.db.Query("SELECT " + geoFileIndexColumns + " FROM geo_file_index ORDER BY id")
+	if err != nil {
+		a.mu.RUnlock()
+		return 0, fmt.Errorf("failed to read catalog: %v", err)
+	}
+
+	count := 0
+	for rows.Next() {
+		file, err := scanGeoFileIndex(rows)
+		if err != nil {
+			a.logWarn("Skipping unreadable catalog row in export: %v", err)
+			continue
+		}
+
+		var bounds [4]interface{}
+		var wkt interface{}
+		var bbox []float64
+		if json.Unmarshal([]byte(file.BBox), &bbox) == nil && len(bbox) == 4 {
+			for i, v := range bbox {
+				bounds[i] = v
+			}
+			if bbox[0] >= -180 && bbox[2] <= 180 && bbox[1] >= -90 && bbox[3] <= 90 {
+				wkt = fmt.Sprintf("POLYGON((%[1]g %[2]g, %[3]g %[2]g, %[3]g %[4]g, %[1]g %[4]g, %[1]g %[2]g))",
+					bbox[0], bbox[1], bbox[2], bbox[3])
+			}
+		}
+
+		_, err = insert.Exec(file.ID, file.FilePath, file.FileName, file.FileExt, file.FileType, file.LayerName,
+			file.FileSize, unixToRFC3339(file.CreatedAt), unixToRFC3339(file.ModifiedAt), nullIfEmpty(file.CRS),
+			bounds[0], bounds[1], bounds[2], bounds[3], file.NumFeatures, file.NumBands, file.Resolution,
+			nullIfEmpty(file.Metadata), wkt)
+		if err != nil {
+			rows.Close()
+			a.mu.RUnlock()
+			return 0, fmt.Errorf("failed to export %s: %v", file.FilePath, err)
+		}
+		count++
+	}
+	err = rows.Err()
+	rows.Close()
+	a.mu.RUnlock()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read catalog: %v", err)
+	}
+
+	docs := append([][2]string{
+		{"exported_at", time.Now().Format(time.RFC3339)},
+		{"layer_count", fmt.Sprint(count)},
+	}, catalogExportDocs...)
+	if spatial {
+		docs = append(docs, [2]string{"catalog.geom", "bbox_wkt as a SpatiaLite POLYGON (SRID 4326) with a spatial index"})
+	}
+	for _, doc := range docs {
+		if _, err := tx.Exec("INSERT INTO catalog_info (name, description) VALUES (?, ?)", doc[0], doc[1]); err != nil {
+			return 0, fmt.Errorf("failed to write catalog_info: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to write export: %v", err)
+	}
+
+	if spatial {
+		for _, stmt := range []string{
+			"SELECT AddGeometryColumn('catalog', 'geom', 4326, 'POLYGON', 'XY')",
+			"UPDATE catalog SET geom = GeomFromText(bbox_wkt, 4326) WHERE bbox_wkt IS NOT NULL",
+			"SELECT CreateSpatialIndex('catalog', 'geom')",
+		} {
+			if _, err := dst.Exec(stmt); err != nil {
+				return 0, fmt.Errorf("failed to add SpatiaLite geometry: %v", err)
+			}
+		}
+	}
+	return count, nil
+}
+
+// unixToRFC3339 formats a Unix timestamp, or returns nil for 0
+func unixToRFC3339(seconds int64) interface{} {
+	if seconds == 0 {
+		return nil
+	}
+	return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+}
+
+// nullIfEmpty stores empty strings as NULL
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// GenerateContours traces contour lines from a DEM every interval units of elevation, starting
+// from 0. Each LineString carries its elevation in properties.
+func (a *App) GenerateContours(demPath string, interval float64) (map[string]interface{}, error) {
+	return a.GenerateContoursWithBase(demPath, interval, 0)
+}
+
+// GenerateContoursWithBase is GenerateContours with contour levels offset from base, e.g. an
+// interval of 10 with a base of 5 gives ..., -5, 5, 15, ...
+func (a *App) GenerateContoursWithBase(demPath string, interval, base float64) (map[string]interface{}, error) {
+	if math.IsNaN(interval) || math.IsInf(interval, 0) || interval <= 0 {
+		return nil, fmt.Errorf("contour interval must be a positive number")
+	}
+	if math.IsNaN(base) || math.IsInf(base, 0) {
+		return nil, fmt.Errorf("contour base must be a finite number")
+	}
+	demPath, err := gdalInputPath(demPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "terrabox-contours-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// gdal_contour writes in the DEM's CRS; ogr2ogr then reprojects to WGS84 for the map
+	contoursPath := filepath.Join(tmpDir, "contours.gpkg")
+	_, err = a.runGDALTool("gdal_contour",
+		"-b", "1",
+		"-a", "elevation",
+		"-i", strconv.FormatFloat(interval, 'f', -1, 64),
+		"-off", strconv.FormatFloat(base, 'f', -1, 64),
+		"-f", "GPKG",
+		demPath, contoursPath,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	geojsonPath := filepath.Join(tmpDir, "contours.geojson")
+	_, err = a.runGDALTool("ogr2ogr",
+		"-f", "GeoJSON",
+		"-t_srs", "EPSG:4326",
+		"-lco", "RFC7946=YES",
+		geojsonPath, contoursPath,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(geojsonPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read contours: %v", err)
+	}
+	var contours map[string]interface{}
+	if err := json.Unmarshal(content, &contours); err != nil {
+		return nil, fmt.Errorf("failed to parse contours: %v", err)
+	}
+
+	features, _ := contours["features"].([]interface{})
+	contours["interval"] = interval
+	contours["base"] = base
+	a.logInfo("Generated %d contour lines from %s every %g", len(features), demPath, interval)
+	return contours, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGenerateContoursRejectsInvalidLevels(t *testing.T) {
+	a := NewApp()
+	tests := []struct {
+		name     string
+		interval float64
+		base     float64
+		want     string
+	}{
+		{"zero interval", 0, 0, "interval"},
+		{"negative interval", -10, 0, "interval"},
+		{"NaN interval", math.NaN(), 0, "interval"},
+		{"infinite interval", math.Inf(1), 0, "interval"},
+		{"negative infinite interval", math.Inf(-1), 0, "interval"},
+		{"NaN base", 10, math.NaN(), "base"},
+		{"infinite base", 10, math.Inf(1), "base"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := a.GenerateContoursWithBase("/nonexistent/dem.tif", tt.interval, tt.base)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want one about the %s", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// WGS84 ellipsoid and UTM constants
+const (
+	wgs84SemiMajor    = 6378137.0
+	wgs84Flattening   = 1 / 298.257223563
+	utmScaleFactor    = 0.9996
+	utmFalseEasting   = 500000.0
+	utmFalseNorthing  = 10000000.0
+	webMercatorMaxLat = 85.0511287798066
+)
+
+var epsgCodePattern = regexp.MustCompile(`(?i)^\s*(?:epsg:)?\s*(\d{4,6})\s*$`)
+
+// normalizeCRS turns "4326", "epsg:4326" or "EPSG:4326" into "EPSG:4326". WKT, PROJJSON and PROJ
+// strings are passed through for GDAL to interpret; anything else is rejected.
+func normalizeCRS(crs string) (string, error) {
+	trimmed := strings.TrimSpace(crs)
+	if trimmed == "" {
+		return "", fmt.Errorf("CRS is empty")
+	}
+	if m := epsgCodePattern.FindStringSubmatch(trimmed); m != nil {
+		return "EPSG:" + m[1], nil
+	}
+
+	upper := strings.ToUpper(trimmed)
+	for _, prefix := range []string{"GEOGCS[", "PROJCS[", "GEOGCRS[", "PROJCRS[", "GEODCRS[", "COMPD_CS[", "COMPOUNDCRS[", "+PROJ=", "{", "ESRI:", "IGNF:", "OGC:"} {
+		if strings.HasPrefix(upper, prefix) {
+			return trimmed, nil
+		}
+	}
+	return "", fmt.Errorf("unrecognized CRS %q; use an EPSG code such as EPSG:4326", crs)
+}
+
+// epsgCode returns the numeric code of an EPSG:nnnn CRS
+func epsgCode(crs string) (int, bool) {
+	if !strings.HasPrefix(crs, "EPSG:") {
+		return 0, false
+	}
+	code, err := strconv.Atoi(strings.TrimPrefix(crs, "EPSG:"))
+	return code, err == nil
+}
+
+// TransformCoordinates reprojects [x, y(, z)] positions from one CRS to another. WGS84, Web
+// Mercator and WGS84 UTM zones are converted natively; other CRSs go through GDAL.
+func (a *App) TransformCoordinates(points [][]float64, fromCRS, toCRS string) ([][]float64, error) {
+	from, err := normalizeCRS(fromCRS)
+	if err != nil {
+		return nil, err
+	}
+	to, err := normalizeCRS(toCRS)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range points {
+		if len(p) < 2 || math.IsNaN(p[0]) || math.IsNaN(p[1]) || math.IsInf(p[0], 0) || math.IsInf(p[1], 0) {
+			return nil, fmt.Errorf("point %d is not a valid [x, y] position", i)
+		}
+	}
+
+	if transformed, ok := transformNative(points, from, to); ok {
+		return transformed, nil
+	}
+	return a.gdalTransformPoints(from, to, points)
+}
+
+// transformNative converts between the CRSs that don't need PROJ. ok is false if either side
+// isn't supported.
+func transformNative(points [][]float64, from, to string) ([][]float64, bool) {
+	fromCode, ok1 := epsgCode(from)
+	toCode, ok2 := epsgCode(to)
+	if !ok1 || !ok2 || !nativeCRS(fromCode) || !nativeCRS(toCode) {
+		return nil, false
+	}
+
+	transformed := make([][]float64, len(points))
+	for i, p := range points {
+		out := make([]float64, len(p))
+		copy(out, p)
+		if fromCode != toCode {
+			lon, lat := toWGS84(fromCode, p[0], p[1])
+			out[0], out[1] = fromWGS84(toCode, lon, lat)
+		}
+		transformed[i] = out
+	}
+	return transformed, true
+}
+
+// nativeCRS reports whether an EPSG code is WGS84, Web Mercator or a WGS84 UTM zone
+func nativeCRS(code int) bool {
+	_, _, isUTM := utmZoneFromEPSG(code)
+	return code == 4326 || code == 3857 || isUTM
+}
+
+// utmZoneFromEPSG splits 326zz/327zz into zone and hemisphere
+func utmZoneFromEPSG(code int) (zone int, south bool, ok bool) {
+	switch {
+	case code >= 32601 && code <= 32660:
+		return code - 32600, false, true
+	case code >= 32701 && code <= 32760:
+		return code - 32700, true, true
+	}
+	return 0, false, false
+}
+
+func toWGS84(code int, x, y float64) (float64, float64) {
+	if code == 3857 {
+		return webMercatorToLonLat(x, y)
+	}
+	if zone, south, ok := utmZoneFromEPSG(code); ok {
+		return utmToLonLat(x, y, zone, south)
+	}
+	return x, y
+}
+
+func fromWGS84(code int, lon, lat float64) (float64, float64) {
+	if code == 3857 {
+		return lonLatToWebMercator(lon, lat)
+	}
+	if zone, south, ok := utmZoneFromEPSG(code); ok {
+		return lonLatToUTM(lon, lat, zone, south)
+	}
+	return lon, lat
+}
+
+// lonLatToWebMercator projects to EPSG:3857, clamping latitude to the projection's limit
+func lonLatToWebMercator(lon, lat float64) (float64, float64) {
+	lat = math.Max(-webMercatorMaxLat, math.Min(webMercatorMaxLat, lat))
+	x := wgs84SemiMajor * lon * math.Pi / 180
+	y := wgs84SemiMajor * math.Log(math.Tan(math.Pi/4+lat*math.Pi/360))
+	return x, y
+}
+
+func webMercatorToLonLat(x, y float64) (float64, float64) {
+	lon := x / wgs84SemiMajor * 180 / math.Pi
+	lat := (2*math.Atan(math.Exp(y/wgs84SemiMajor)) - math.Pi/2) * 180 / math.Pi
+	return lon, lat
+}
+
+// utmCentralMeridian returns the central longitude of a UTM zone in degrees
+func utmCentralMeridian(zone int) float64 {
+	return float64(zone-1)*6 - 180 + 3
+}
+
+// lonLatToUTM projects to a UTM zone with the transverse Mercator series from Snyder (1987),
+// accurate to millimetres within the zone
+func lonLatToUTM(lon, lat float64, zone int, south bool) (float64, float64) {
+	e2 := wgs84Flattening * (2 - wgs84Flattening)
+	ep2 := e2 / (1 - e2)
+	phi := lat * math.Pi / 180
+	lambda := (lon - utmCentralMeridian(zone)) * math.Pi / 180
+
+	sinPhi, cosPhi, tanPhi := math.Sin(phi), math.Cos(phi), math.Tan(phi)
+	n := wgs84SemiMajor / math.Sqrt(1-e2*sinPhi*sinPhi)
+	t := tanPhi * tanPhi
+	c := ep2 * cosPhi * cosPhi
+	A := cosPhi * lambda
+	m := meridianArc(phi, e2)
+
+	x := utmScaleFactor*n*(A+(1-t+c)*math.Pow(A, 3)/6+(5-18*t+t*t+72*c-58*ep2)*math.Pow(A, 5)/120) + utmFalseEasting
+	y := utmScaleFactor * (m + n*tanPhi*(A*A/2+(5-t+9*c+4*c*c)*math.Pow(A, 4)/24+(61-58*t+t*t+600*c-330*ep2)*math.Pow(A, 6)/720))
+	if south {
+		y += utmFalseNorthing
+	}
+	return x, y
+}
+
+// utmToLonLat inverts lonLatToUTM
+func utmToLonLat(x, y float64, zone int, south bool) (float64, float64) {
+	e2 := wgs84Flattening * (2 - wgs84Flattening)
+	ep2 := e2 / (1 - e2)
+	if south {
+		y -= utmFalseNorthing
+	}
+
+	m := y / utmScaleFactor
+	mu := m / (wgs84SemiMajor * (1 - e2/4 - 3*e2*e2/64 - 5*e2*e2*e2/256))
+	e1 := (1 - math.Sqrt(1-e2)) / (1 + math.Sqrt(1-e2))
+	phi1 := mu + (3*e1/2-27*math.Pow(e1, 3)/32)*math.Sin(2*mu) +
+		(21*e1*e1/16-55*math.Pow(e1, 4)/32)*math.Sin(4*mu) +
+		(151*math.Pow(e1, 3)/96)*math.Sin(6*mu) +
+		(1097*math.Pow(e1, 4)/512)*math.Sin(8*mu)
+
+	sinPhi1, cosPhi1, tanPhi1 := math.Sin(phi1), math.Cos(phi1), math.Tan(phi1)
+	n1 := wgs84SemiMajor / math.Sqrt(1-e2*sinPhi1*sinPhi1)
+	t1 := tanPhi1 * tanPhi1
+	c1 := ep2 * cosPhi1 * cosPhi1
+	r1 := wgs84SemiMajor * (1 - e2) / math.Pow(1-e2*sinPhi1*sinPhi1, 1.5)
+	d := (x - utmFalseEasting) / (n1 * utmScaleFactor)
+
+	phi := phi1 - (n1*tanPhi1/r1)*(d*d/2-(5+3*t1+10*c1-4*c1*c1-9*ep2)*math.Pow(d, 4)/24+
+		(61+90*t1+298*c1+45*t1*t1-252*ep2-3*c1*c1)*math.Pow(d, 6)/720)
+	lambda := (d - (1+2*t1+c1)*math.Pow(d, 3)/6 + (5-2*c1+28*t1-3*c1*c1+8*ep2+24*t1*t1)*math.Pow(d, 5)/120) / cosPhi1
+
+	return utmCentralMeridian(zone) + lambda*180/math.Pi, phi * 180 / math.Pi
+}
+
+// meridianArc is the distance along the meridian from the equator to latitude phi (radians)
+func meridianArc(phi, e2 float64) float64 {
+	e4, e6 := e2*e2, e2*e2*e2
+	return wgs84SemiMajor * ((1-e2/4-3*e4/64-5*e6/256)*phi -
+		(3*e2/8+3*e4/32+45*e6/1024)*math.Sin(2*phi) +
+		(15*e4/256+45*e6/1024)*math.Sin(4*phi) -
+		(35*e6/3072)*math.Sin(6*phi))
+}
+
+// UTMZoneSuggestion is the UTM zone that best fits a bounding box
+type UTMZoneSuggestion struct {
+	Code       string `json:"code"`
+	Zone       int    `json:"zone"`
+	Hemisphere string `json:"hemisphere"`
+	Warning    string `json:"warning,omitempty"`
+}
+
+// SuggestUTMZone returns the EPSG code of the WGS84 UTM zone containing the centre of a
+// [minLon, minLat, maxLon, maxLat] bbox, e.g. EPSG:32633 for UTM 33N
+func (a *App) SuggestUTMZone(bbox []float64) (string, error) {
+	suggestion, err := suggestUTMZone(bbox)
+	if err != nil {
+		return "", err
+	}
+	if suggestion.Warning != "" {
+		a.logWarn("%s", suggestion.Warning)
+	}
+	return suggestion.Code, nil
+}
+
+// SuggestUTMZoneDetailed is SuggestUTMZone with the zone, hemisphere and a warning when the bbox
+// spans several zones or lies outside UTM's latitude range
+func (a *App) SuggestUTMZoneDetailed(bbox []float64) (UTMZoneSuggestion, error) {
+	return suggestUTMZone(bbox)
+}
+
+func suggestUTMZone(bbox []float64) (UTMZoneSuggestion, error) {
+	if len(bbox) != 4 {
+		return UTMZoneSuggestion{}, fmt.Errorf("bbox must be [minLon, minLat, maxLon, maxLat]")
+	}
+	minLon, minLat, maxLon, maxLat := bbox[0], bbox[1], bbox[2], bbox[3]
+	if minLat < -90 || maxLat > 90 || minLat > maxLat || minLon < -180 || maxLon > 180 {
+		return UTMZoneSuggestion{}, fmt.Errorf("bbox is not in longitude/latitude degrees")
+	}
+
+	// A bbox with minLon > maxLon crosses the antimeridian
+	spanLon := maxLon - minLon
+	if spanLon < 0 {
+		spanLon += 360
+	}
+	lon := minLon + spanLon/2
+	if lon > 180 {
+		lon -= 360
+	}
+	lat := (minLat + maxLat) / 2
+
+	zone := utmZone(lon, lat)
+	suggestion := UTMZoneSuggestion{Zone: zone, Hemisphere: "N", Code: fmt.Sprintf("EPSG:%d", 32600+zone)}
+	if lat < 0 {
+		suggestion.Hemisphere = "S"
+		suggestion.Code = fmt.Sprintf("EPSG:%d", 32700+zone)
+	}
+
+	var warnings []string
+	if first, last := utmZone(minLon, lat), utmZone(maxLon, lat); first != last || spanLon > 6 {
+		warnings = append(warnings, fmt.Sprintf("the area spans UTM zones %d to %d; distances far from zone %d will be distorted", first, last, zone))
+	}
+	if minLat < 0 && maxLat > 0 {
+		warnings = append(warnings, "the area crosses the equator; northings on the other side of it will be negative or exceed 10,000 km")
+	}
+	if maxLat > 84 || minLat < -80 {
+		warnings = append(warnings, "UTM is not defined beyond 84°N and 80°S; consider a polar stereographic CRS")
+	}
+	suggestion.Warning = strings.Join(warnings, "; ")
+
+	return suggestion, nil
+}
+
+// utmZone returns the UTM zone for a position, including the Norway and Svalbard exceptions
+func utmZone(lon, lat float64) int {
+	if lat >= 56 && lat < 64 && lon >= 3 && lon < 12 {
+		return 32
+	}
+	if lat >= 72 && lat < 84 && lon >= 0 && lon < 42 {
+		switch {
+		case lon < 9:
+			return 31
+		case lon < 21:
+			return 33
+		case lon < 33:
+			return 35
+		default:
+			return 37
+		}
+	}
+	zone := int(math.Floor((lon+180)/6)) + 1
+	if zone > 60 {
+		zone = 60
+	}
+	if zone < 1 {
+		zone = 1
+	}
+	return zone
+}
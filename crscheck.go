@@ -0,0 +1,222 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+const (
+	// areaOfUseMargin is how far, in degrees, an extent may stray outside its CRS's area of use
+	areaOfUseMargin = 1.0
+	// webMercatorMaxExtent is the largest x or y EPSG:3857 can produce, in metres
+	webMercatorMaxExtent = 20037508.342789244
+)
+
+// CRSCheckResult reports whether a dataset's extent fits the CRS it declares. SuggestedCRS is
+// set when the coordinates clearly belong to another CRS.
+type CRSCheckResult struct {
+	FilePath     string    `json:"file_path"`
+	CRS          string    `json:"crs"`
+	BBox         []float64 `json:"bbox"`
+	Consistent   bool      `json:"consistent"`
+	Issues       []string  `json:"issues"`
+	SuggestedCRS string    `json:"suggested_crs,omitempty"`
+}
+
+// CheckCRSConsistency compares a dataset's extent with the area of use of its declared CRS, to
+// catch mislabelled files such as UTM metres declared as EPSG:4326
+func (a *App) CheckCRSConsistency(filePath string) (CRSCheckResult, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return CRSCheckResult{}, fmt.Errorf("file not found: %v", err)
+	}
+	bbox, crs, ok := a.indexedBounds(filePath, "")
+	if !ok {
+		var err error
+		bbox, crs, err = a.readLayerBounds(filePath, "")
+		if err != nil {
+			return CRSCheckResult{}, fmt.Errorf("failed to read extent: %v", err)
+		}
+	}
+	if !validBBox(bbox) {
+		return CRSCheckResult{}, fmt.Errorf("%s has no usable extent", filePath)
+	}
+
+	result := a.checkCRSExtent(bbox, crs)
+	result.FilePath = filePath
+	if !result.Consistent {
+		a.logWarn("Suspect CRS %s for %s: %s", crs, filePath, strings.Join(result.Issues, "; "))
+	}
+	return result, nil
+}
+
+// flagSuspectCRS records a CRS check in indexed metadata
+func (a *App) flagSuspectCRS(metadata *FileMetadata) {
+	bbox := metadata.BBox
+	if isDefaultBBox(bbox) {
+		// Projected files keep their real extent aside until it is reprojected
+		native, ok := metadata.Metadata["native_bbox"].([]float64)
+		if !ok {
+			return
+		}
+		bbox = native
+	}
+	if !validBBox(bbox) {
+		return
+	}
+
+	result := a.checkCRSExtent(bbox, metadata.CRS)
+	if result.Consistent {
+		return
+	}
+	metadata.Metadata["crs_suspect"] = true
+	metadata.Metadata["crs_issues"] = result.Issues
+	if result.SuggestedCRS != "" {
+		metadata.Metadata["suggested_crs"] = result.SuggestedCRS
+	}
+}
+
+// checkCRSExtent tests a bbox in crs units against the CRS's unit and area of use
+func (a *App) checkCRSExtent(bbox []float64, crs string) CRSCheckResult {
+	result := CRSCheckResult{CRS: crs, BBox: bbox, Issues: []string{}}
+	inDegrees := bbox[0] >= -180 && bbox[2] <= 360 && bbox[1] >= -90 && bbox[3] <= 90
+
+	if crs == "" {
+		result.Issues = append(result.Issues, "no CRS is declared")
+		if inDegrees {
+			result.SuggestedCRS = "EPSG:4326"
+		} else {
+			result.SuggestedCRS = guessProjectedCRS(bbox, &result)
+		}
+		return result
+	}
+
+	info := a.crsAreaOfUse(crs)
+	if info == nil {
+		// Unknown or custom CRS: nothing to compare against
+		result.Consistent = true
+		return result
+	}
+
+	if strings.HasPrefix(info.Type, "geographic") {
+		if !inDegrees {
+			result.Issues = append(result.Issues, fmt.Sprintf(
+				"%s is geographic but the extent %s is not in degrees, so the data is probably projected",
+				info.Code, formatBBox(bbox)))
+			result.SuggestedCRS = guessProjectedCRS(bbox, &result)
+			return result
+		}
+		if !bboxWithinArea(bbox, info.BBox) {
+			result.Issues = append(result.Issues, fmt.Sprintf("the extent %s lies outside the area of use of %s (%s)",
+				formatBBox(bbox), info.Code, info.AreaOfUse))
+		}
+		result.Consistent = len(result.Issues) == 0
+		return result
+	}
+
+	// Projected. A small extent that fits in degrees means lon/lat labelled as metres.
+	if inDegrees && bbox[2]-bbox[0] <= 360 && bbox[3]-bbox[1] <= 180 {
+		result.Issues = append(result.Issues, fmt.Sprintf(
+			"%s is projected in %ss but the extent %s looks like longitude/latitude degrees",
+			info.Code, info.Unit, formatBBox(bbox)))
+		result.SuggestedCRS = "EPSG:4326"
+		return result
+	}
+	if code, ok := epsgCode(info.Code); ok && nativeCRS(code) {
+		if _, _, isUTM := utmZoneFromEPSG(code); isUTM && !utmLike(bbox) {
+			result.Issues = append(result.Issues, fmt.Sprintf(
+				"the extent %s is outside the easting and northing range of %s", formatBBox(bbox), info.Code))
+		} else if lonLat, err := a.bboxToWGS84(bbox, info.Code); err != nil {
+			result.Issues = append(result.Issues, fmt.Sprintf("the extent %s cannot be placed in %s", formatBBox(bbox), info.Code))
+		} else if !bboxWithinArea(lonLat, info.BBox) {
+			result.Issues = append(result.Issues, fmt.Sprintf("the extent %s lies outside the area of use of %s (%s)",
+				formatBBox(lonLat), info.Code, info.AreaOfUse))
+		}
+	}
+	result.Consistent = len(result.Issues) == 0
+	return result
+}
+
+// crsAreaOfUse looks up a CRS's type, unit and area of use from the built-in list, then proj.db
+func (a *App) crsAreaOfUse(crs string) *CRSInfo {
+	normalized, err := normalizeCRS(crs)
+	if err != nil {
+		return nil
+	}
+	if m := wktIDPattern.FindStringSubmatch(normalized); m != nil {
+		normalized = "EPSG:" + m[1]
+	}
+	for _, info := range builtinCRS() {
+		if info.Code == normalized {
+			return &info
+		}
+	}
+
+	auth, number, ok := strings.Cut(normalized, ":")
+	if !ok {
+		return nil
+	}
+	path := findProjDB()
+	if path == "" {
+		return nil
+	}
+	info, err := lookupProjDB(path, strings.ToUpper(auth), number)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			a.logWarn("Could not read %s: %v", path, err)
+		}
+		return nil
+	}
+	if len(info.BBox) != 4 {
+		return nil
+	}
+	return info
+}
+
+// guessProjectedCRS names the CRS that non-degree coordinates most likely belong to, noting
+// in the result when only the family can be told apart
+func guessProjectedCRS(bbox []float64, result *CRSCheckResult) string {
+	switch {
+	case utmLike(bbox):
+		result.Issues = append(result.Issues,
+			"the values look like UTM eastings and northings; choose the zone the data covers (EPSG:326zz north, EPSG:327zz south)")
+		return ""
+	case math.Abs(bbox[0]) <= webMercatorMaxExtent && math.Abs(bbox[2]) <= webMercatorMaxExtent &&
+		math.Abs(bbox[1]) <= webMercatorMaxExtent && math.Abs(bbox[3]) <= webMercatorMaxExtent:
+		return "EPSG:3857"
+	}
+	return ""
+}
+
+// utmLike reports whether a bbox fits the easting and northing range of a UTM zone
+func utmLike(bbox []float64) bool {
+	return bbox[0] >= 100000 && bbox[2] <= 900000 && bbox[1] >= 0 && bbox[3] <= utmFalseNorthing
+}
+
+// bboxWithinArea reports whether a lon/lat bbox lies inside an area of use, allowing
+// areaOfUseMargin degrees of slack. Areas with west > east cross the antimeridian.
+func bboxWithinArea(bbox []float64, area []float64) bool {
+	if len(area) != 4 {
+		return true
+	}
+	if bbox[1] < area[1]-areaOfUseMargin || bbox[3] > area[3]+areaOfUseMargin {
+		return false
+	}
+	west, east := area[0]-areaOfUseMargin, area[2]+areaOfUseMargin
+	if west <= -180 && east >= 180 {
+		return true
+	}
+	inside := func(lon float64) bool {
+		if area[0] > area[2] {
+			return lon >= west || lon <= east
+		}
+		return lon >= west && lon <= east
+	}
+	return inside(bbox[0]) && inside(bbox[2])
+}
+
+func formatBBox(bbox []float64) string {
+	return fmt.Sprintf("[%.6g, %.6g, %.6g, %.6g]", bbox[0], bbox[1], bbox[2], bbox[3])
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxCRSResults caps SearchCRS results
+const maxCRSResults = 50
+
+// CRSInfo describes a coordinate reference system for CRS pickers
+type CRSInfo struct {
+	Code       string    `json:"code"`
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	AreaOfUse  string    `json:"area_of_use,omitempty"`
+	BBox       []float64 `json:"bbox,omitempty"`
+	Unit       string    `json:"unit,omitempty"`
+	Deprecated bool      `json:"deprecated,omitempty"`
+	Source     string    `json:"source"`
+}
+
+// projDataDirs are checked for proj.db when PROJ_DATA/PROJ_LIB aren't set
+var projDataDirs = []string{
+	"/opt/homebrew/share/proj",
+	"/usr/local/share/proj",
+	"/usr/share/proj",
+	"/Applications/QGIS.app/Contents/Resources/proj",
+	`C:\OSGeo4W\share\proj`,
+	`C:\OSGeo4W64\share\proj`,
+}
+
+// crsSearchAliases map common names to the spelling used in the EPSG registry
+var crsSearchAliases = map[string]string{
+	"web mercator":    "pseudo-mercator",
+	"google mercator": "pseudo-mercator",
+	"wgs84":           "wgs 84",
+	"wgs 1984":        "wgs 84",
+	"lambert 93":      "lambert-93",
+}
+
+var (
+	projDBOnce sync.Once
+	projDBPath string
+)
+
+// findProjDB locates PROJ's proj.db, which is installed alongside GDAL
+func findProjDB() string {
+	projDBOnce.Do(func() {
+		var dirs []string
+		for _, env := range []string{"PROJ_DATA", "PROJ_LIB"} {
+			if value := os.Getenv(env); value != "" {
+				dirs = append(dirs, filepath.SplitList(value)...)
+			}
+		}
+		dirs = append(dirs, projDataDirs...)
+		for _, dir := range dirs {
+			candidate := filepath.Join(dir, "proj.db")
+			if _, err := os.Stat(candidate); err == nil {
+				projDBPath = candidate
+				return
+			}
+		}
+	})
+	return projDBPath
+}
+
+// SearchCRS finds CRSs by EPSG code or by words in their name, e.g. "32633", "utm 33n" or
+// "web mercator". Results come from the PROJ database when installed, otherwise from a built-in
+// list of common CRSs.
+func (a *App) SearchCRS(query string) ([]CRSInfo, error) {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return []CRSInfo{}, nil
+	}
+	for alias, name := range crsSearchAliases {
+		query = strings.ReplaceAll(query, alias, name)
+	}
+
+	if path := findProjDB(); path != "" {
+		results, err := searchProjDB(path, query)
+		if err == nil {
+			return results, nil
+		}
+		a.logWarn("Could not search %s, using built-in CRS list: %v", path, err)
+	}
+	return searchBuiltinCRS(query), nil
+}
+
+// GetCRSInfo looks up a single CRS such as "EPSG:32633" or "32633"
+func (a *App) GetCRSInfo(code string) (*CRSInfo, error) {
+	normalized, err := normalizeCRS(code)
+	if err != nil {
+		return nil, err
+	}
+	auth, number, ok := strings.Cut(normalized, ":")
+	if !ok {
+		return nil, fmt.Errorf("expected an authority code such as EPSG:4326, got %q", code)
+	}
+
+	if path := findProjDB(); path != "" {
+		info, err := lookupProjDB(path, strings.ToUpper(auth), number)
+		if err == nil {
+			return info, nil
+		}
+		if err != sql.ErrNoRows {
+			a.logWarn("Could not read %s, using built-in CRS list: %v", path, err)
+		}
+	}
+
+	for _, info := range builtinCRS() {
+		if info.Code == normalized {
+			return &info, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown CRS %s", normalized)
+}
+
+// projCRSQuery selects geodetic and projected CRSs with their area of use and axis unit
+const projCRSQuery = `
+	SELECT c.auth_name, c.code, c.name, c.kind, c.deprecated,
+	       COALESCE(e.name, ''), e.west_lon, e.south_lat, e.east_lon, e.north_lat,
+	       COALESCE(u.name, '')
+	FROM (
+		SELECT auth_name, code, name, 'projected' AS kind, coordinate_system_auth_name AS cs_auth,
+		       coordinate_system_code AS cs_code, deprecated, 'projected_crs' AS tbl
+		FROM projected_crs
+		UNION ALL
+		SELECT auth_name, code, name, type, coordinate_system_auth_name, coordinate_system_code,
+		       deprecated, 'geodetic_crs'
+		FROM geodetic_crs
+	) c
+	LEFT JOIN usage us ON us.object_table_name = c.tbl AND us.object_auth_name = c.auth_name AND us.object_code = c.code
+	LEFT JOIN extent e ON e.auth_name = us.extent_auth_name AND e.code = us.extent_code
+	LEFT JOIN axis ax ON ax.coordinate_system_auth_name = c.cs_auth AND ax.coordinate_system_code = c.cs_code
+	                 AND ax.coordinate_system_order = 1
+	LEFT JOIN unit_of_measure u ON u.auth_name = ax.uom_auth_name AND u.code = ax.uom_code
+`
+
+// openProjDB opens proj.db read-only
+func openProjDB(path string) (*sql.DB, error) {
+	return sql.Open("sqlite3", "file:"+filepath.ToSlash(path)+"?mode=ro")
+}
+
+func searchProjDB(path, query string) ([]CRSInfo, error) {
+	db, err := openProjDB(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	code := strings.TrimPrefix(query, "epsg:")
+	where := []string{}
+	args := []interface{}{}
+	if _, err := strconv.Atoi(code); err == nil {
+		where = append(where, "c.code = ?")
+		args = append(args, code)
+	} else {
+		for _, word := range strings.Fields(query) {
+			where = append(where, "c.name LIKE ?")
+			args = append(args, "%"+word+"%")
+		}
+	}
+
+	rows, err := db.Query(projCRSQuery+`
+		WHERE c.auth_name = 'EPSG' AND `+strings.Join(where, " AND ")+`
+		GROUP BY c.auth_name, c.code
+		ORDER BY c.deprecated, length(c.name), c.name
+		LIMIT ?`, append(args, maxCRSResults)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []CRSInfo{}
+	for rows.Next() {
+		info, err := scanProjCRS(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, info)
+	}
+	return results, rows.Err()
+}
+
+func lookupProjDB(path, auth, code string) (*CRSInfo, error) {
+	db, err := openProjDB(path)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	info, err := scanProjCRS(db.QueryRow(projCRSQuery+" WHERE c.auth_name = ? AND c.code = ? LIMIT 1", auth, code))
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+func scanProjCRS(row rowScanner) (CRSInfo, error) {
+	var auth, code, name, kind, area, unit string
+	var deprecated bool
+	var west, south, east, north sql.NullFloat64
+	if err := row.Scan(&auth, &code, &name, &kind, &deprecated, &area, &west, &south, &east, &north, &unit); err != nil {
+		return CRSInfo{}, err
+	}
+
+	info := CRSInfo{
+		Code:       auth + ":" + code,
+		Name:       name,
+		Type:       kind,
+		AreaOfUse:  area,
+		Unit:       unit,
+		Deprecated: deprecated,
+		Source:     "proj",
+	}
+	if west.Valid && south.Valid && east.Valid && north.Valid {
+		info.BBox = []float64{west.Float64, south.Float64, east.Float64, north.Float64}
+	}
+	return info, nil
+}
+
+// searchBuiltinCRS matches the query against the built-in list
+func searchBuiltinCRS(query string) []CRSInfo {
+	code := strings.TrimPrefix(query, "epsg:")
+	words := strings.Fields(query)
+
+	results := []CRSInfo{}
+	for _, info := range builtinCRS() {
+		if info.Code == "EPSG:"+code {
+			results = append(results, info)
+			continue
+		}
+		name := strings.ToLower(info.Name)
+		matched := true
+		for _, word := range words {
+			if !strings.Contains(name, word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			results = append(results, info)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return len(results[i].Name) < len(results[j].Name) })
+	if len(results) > maxCRSResults {
+		results = results[:maxCRSResults]
+	}
+	return results
+}
+
+// builtinCRS lists common CRSs and every WGS84 UTM zone, for machines without PROJ
+func builtinCRS() []CRSInfo {
+	list := []CRSInfo{
+		{Code: "EPSG:4326", Name: "WGS 84", Type: "geographic 2D", AreaOfUse: "World", BBox: []float64{-180, -90, 180, 90}, Unit: "degree"},
+		{Code: "EPSG:4979", Name: "WGS 84", Type: "geographic 3D", AreaOfUse: "World", BBox: []float64{-180, -90, 180, 90}, Unit: "degree"},
+		{Code: "EPSG:3857", Name: "WGS 84 / Pseudo-Mercator", Type: "projected", AreaOfUse: "World between 85.06°S and 85.06°N", BBox: []float64{-180, -85.06, 180, 85.06}, Unit: "metre"},
+		{Code: "EPSG:4269", Name: "NAD83", Type: "geographic 2D", AreaOfUse: "North America", BBox: []float64{167.65, 14.92, -40.73, 86.45}, Unit: "degree"},
+		{Code: "EPSG:4258", Name: "ETRS89", Type: "geographic 2D", AreaOfUse: "Europe - ETRF by country", BBox: []float64{-16.1, 32.88, 40.18, 84.73}, Unit: "degree"},
+		{Code: "EPSG:3035", Name: "ETRS89-extended / LAEA Europe", Type: "projected", AreaOfUse: "Europe - LCC & LAEA", BBox: []float64{-35.58, 24.6, 44.83, 84.73}, Unit: "metre"},
+		{Code: "EPSG:27700", Name: "OSGB36 / British National Grid", Type: "projected", AreaOfUse: "UK - Britain and UKCS 49°45'N to 61°N, 9°W to 2°E", BBox: []float64{-9.01, 49.75, 2.01, 61.01}, Unit: "metre"},
+		{Code: "EPSG:2154", Name: "RGF93 v1 / Lambert-93", Type: "projected", AreaOfUse: "France", BBox: []float64{-9.86, 41.15, 10.38, 51.56}, Unit: "metre"},
+		{Code: "EPSG:5070", Name: "NAD83 / Conus Albers", Type: "projected", AreaOfUse: "USA - CONUS - onshore", BBox: []float64{-124.79, 24.41, -66.91, 49.38}, Unit: "metre"},
+		{Code: "EPSG:3395", Name: "WGS 84 / World Mercator", Type: "projected", AreaOfUse: "World between 80°S and 84°N", BBox: []float64{-180, -80, 180, 84}, Unit: "metre"},
+	}
+	for zone := 1; zone <= 60; zone++ {
+		west := float64(zone-1)*6 - 180
+		list = append(list,
+			CRSInfo{
+				Code: fmt.Sprintf("EPSG:%d", 32600+zone), Name: fmt.Sprintf("WGS 84 / UTM zone %dN", zone), Type: "projected",
+				AreaOfUse: fmt.Sprintf("Between %g° and %g°, northern hemisphere", west, west+6), BBox: []float64{west, 0, west + 6, 84}, Unit: "metre",
+			},
+			CRSInfo{
+				Code: fmt.Sprintf("EPSG:%d", 32700+zone), Name: fmt.Sprintf("WGS 84 / UTM zone %dS", zone), Type: "projected",
+				AreaOfUse: fmt.Sprintf("Between %g° and %g°, southern hemisphere", west, west+6), BBox: []float64{west, -80, west + 6, 0}, Unit: "metre",
+			},
+		)
+	}
+	for i := range list {
+		list[i].Source = "builtin"
+	}
+	return list
+}
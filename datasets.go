@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// rasterSidecarSuffixes are appended to a raster's full name by GDAL (statistics, overviews, masks)
+var rasterSidecarSuffixes = []string{".aux.xml", ".ovr", ".msk"}
+
+// worldFileExts maps raster extensions to their world file extensions
+var worldFileExts = map[string][]string{
+	".tif":  {".tfw", ".tifw", ".wld"},
+	".tiff": {".tfw", ".tiffw", ".wld"},
+	".jpg":  {".jgw", ".jpgw", ".wld"},
+	".jpeg": {".jgw", ".jpegw", ".wld"},
+	".png":  {".pgw", ".pngw", ".wld"},
+	".jp2":  {".j2w", ".wld"},
+	".gif":  {".gfw", ".wld"},
+	".bmp":  {".bpw", ".wld"},
+}
+
+// pathTables are the tables whose rows refer to a dataset by file_path
+var pathTables = []string{"geo_file_index", "file_tags", "file_favorites", "layer_styles", "workspace_layers"}
+
+// datasetFiles returns the files that make up the dataset at path: a shapefile's components, or
+// a raster with its world file, .prj and GDAL sidecars. Other formats are a single file.
+func datasetFiles(path string) []string {
+	if shapefileComponentExt(path) != "" {
+		return resolveShapefileComponents(path)
+	}
+
+	files := []string{path}
+	ext := strings.ToLower(filepath.Ext(path))
+	worldExts, isRaster := worldFileExts[ext]
+	if !isRaster {
+		return files
+	}
+	var candidates []string
+	for _, suffix := range rasterSidecarSuffixes {
+		candidates = append(candidates, path+suffix)
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	for _, worldExt := range append(worldExts, ".prj") {
+		candidates = append(candidates, base+worldExt, base+strings.ToUpper(worldExt))
+	}
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		if _, err := os.Stat(candidate); err == nil {
+			files = append(files, candidate)
+		}
+	}
+	return files
+}
+
+// CopyDataset copies a dataset with all its sidecar files into dstDir and returns the new path.
+// Index rows, tags and styles are copied too. Existing files are only replaced when overwrite is set.
+func (a *App) CopyDataset(srcPath string, dstDir string, overwrite bool) (string, error) {
+	files, targets, err := planDatasetTransfer(srcPath, dstDir, overwrite)
+	if err != nil {
+		return "", err
+	}
+	for i, file := range files {
+		if err := copyFile(file, targets[i]); err != nil {
+			return "", fmt.Errorf("failed to copy %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	if err := a.copyDatasetRows(files[0], targets[0]); err != nil {
+		return targets[0], err
+	}
+	a.logInfo("Copied %s (%d files) to %s", files[0], len(files), dstDir)
+	return targets[0], nil
+}
+
+// MoveDataset moves a dataset with all its sidecar files into dstDir and returns the new path.
+// Index rows, tags, styles and workspace layers follow it to the new path. If any file or the
+// index can't be moved, the files already moved are put back.
+func (a *App) MoveDataset(srcPath string, dstDir string, overwrite bool) (string, error) {
+	files, targets, err := planDatasetTransfer(srcPath, dstDir, overwrite)
+	if err != nil {
+		return "", err
+	}
+	for i, file := range files {
+		if err := moveFile(file, targets[i]); err != nil {
+			a.undoDatasetMove(files[:i], targets[:i])
+			return "", fmt.Errorf("failed to move %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	if err := a.renameDatasetRows(files[0], targets[0]); err != nil {
+		a.undoDatasetMove(files, targets)
+		return "", err
+	}
+	a.logInfo("Moved %s (%d files) to %s", files[0], len(files), dstDir)
+	return targets[0], nil
+}
+
+// undoDatasetMove moves targets back to the files they came from, in reverse order
+func (a *App) undoDatasetMove(files, targets []string) {
+	for i := len(files) - 1; i >= 0; i-- {
+		if err := moveFile(targets[i], files[i]); err != nil {
+			a.logError("Could not move %s back to %s: %v", targets[i], files[i], err)
+		}
+	}
+}
+
+// DeleteDataset deletes a dataset with all its sidecar files and removes it from the index
+func (a *App) DeleteDataset(filePath string) error {
+	if _, err := os.Stat(filePath); err != nil {
+		return fmt.Errorf("file not found: %v", err)
+	}
+	files := datasetFiles(filePath)
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete %s: %v", filepath.Base(file), err)
+		}
+	}
+
+	if a.db != nil {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if err := a.deleteIndexRows(files[0]); err != nil {
+			return err
+		}
+	}
+	a.logInfo("Deleted %s (%d files)", files[0], len(files))
+	return nil
+}
+
+// deleteIndexRows removes a file's index entries with its tags, favorite and styles.
+// Workspaces keep the layer so they can show it as missing. The caller holds a.mu.
+func (a *App) deleteIndexRows(filePath string) error {
+	for _, table := range []string{"geo_file_index", "file_tags", "file_favorites", "layer_styles"} {
+		if _, err := a.db.Exec("DELETE FROM "+table+" WHERE file_path = ?", filePath); err != nil {
+			return fmt.Errorf("failed to remove %s from the index: %v", filePath, err)
+		}
+	}
+	return nil
+}
+
+// planDatasetTransfer lists a dataset's files and their targets in dstDir, refusing to replace
+// existing files unless overwrite is set
+func planDatasetTransfer(srcPath string, dstDir string, overwrite bool) ([]string, []string, error) {
+	if _, err := os.Stat(srcPath); err != nil {
+		return nil, nil, fmt.Errorf("file not found: %v", err)
+	}
+	info, err := os.Stat(dstDir)
+	if err != nil || !info.IsDir() {
+		return nil, nil, fmt.Errorf("destination %s is not a directory", dstDir)
+	}
+
+	files := datasetFiles(srcPath)
+	targets := make([]string, len(files))
+	for i, file := range files {
+		targets[i] = filepath.Join(dstDir, filepath.Base(file))
+		if sameFile(file, targets[i]) {
+			return nil, nil, fmt.Errorf("%s is already in %s", filepath.Base(files[0]), dstDir)
+		}
+		if _, err := os.Stat(targets[i]); err == nil && !overwrite {
+			return nil, nil, fmt.Errorf("%s already exists", targets[i])
+		}
+	}
+	return files, targets, nil
+}
+
+// sameFile reports whether two paths name the same file
+func sameFile(a, b string) bool {
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// copyFile copies src to dst, keeping the file mode
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// moveFile renames src to dst, copying and deleting when they are on different volumes
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyFile(src, dst); err != nil {
+		os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// copyDatasetRows duplicates the index rows, tags, favorite and styles of src under dst
+func (a *App) copyDatasetRows(src, dst string) error {
+	if a.db == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	statements := []string{
+		`INSERT OR REPLACE INTO geo_file_index (file_path, file_name, file_extension, file_size, created_at,
+			modified_at, file_type, layer_name, crs, bbox, num_features, num_bands, resolution, metadata,
+			bbox_geom, centroid_geom)
+		SELECT ?, file_name, file_extension, file_size, created_at, modified_at, file_type, layer_name, crs,
+			bbox, num_features, num_bands, resolution, metadata, bbox_geom, centroid_geom
+		FROM geo_file_index WHERE file_path = ?`,
+		`INSERT OR IGNORE INTO file_tags (file_path, layer_name, tag, created_at)
+		SELECT ?, layer_name, tag, created_at FROM file_tags WHERE file_path = ?`,
+		`INSERT OR IGNORE INTO file_favorites (file_path, layer_name, created_at)
+		SELECT ?, layer_name, created_at FROM file_favorites WHERE file_path = ?`,
+		`INSERT OR REPLACE INTO layer_styles (file_path, layer_name, style, updated_at)
+		SELECT ?, layer_name, style, updated_at FROM layer_styles WHERE file_path = ?`,
+	}
+	for _, statement := range statements {
+		if _, err := a.db.Exec(statement, dst, src); err != nil {
+			return fmt.Errorf("failed to update the index for %s: %v", dst, err)
+		}
+	}
+	return nil
+}
+
+// renameDatasetRows points every row that refers to src at dst, replacing rows already at dst
+func (a *App) renameDatasetRows(src, dst string) error {
+	if a.db == nil {
+		return nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to update the index for %s: %v", dst, err)
+	}
+	defer tx.Rollback()
+	for _, table := range pathTables {
+		if _, err := tx.Exec("UPDATE OR REPLACE "+table+" SET file_path = ? WHERE file_path = ?", dst, src); err != nil {
+			return fmt.Errorf("failed to update the index for %s: %v", dst, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update the index for %s: %v", dst, err)
+	}
+	return nil
+}
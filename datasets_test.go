@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveDatasetFailureRestoresFiles(t *testing.T) {
+	a := newTestApp(t)
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+
+	names := []string{"dem.tif", "dem.tfw", "dem.prj"}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(srcDir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srcPath := filepath.Join(srcDir, "dem.tif")
+	if _, err := a.db.Exec(insertIndexQuery, srcPath, "dem.tif", ".tif", 3, 0, 0,
+		"raster", "dem", "EPSG:4326", "[0,0,1,1]", 0, 1, 1.0, "{}"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The .prj, moved last, can't replace a non-empty directory
+	if err := os.MkdirAll(filepath.Join(dstDir, "dem.prj", "keep"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := a.MoveDataset(srcPath, dstDir, true); err == nil {
+		t.Fatal("MoveDataset succeeded onto a directory")
+	}
+
+	for _, name := range names {
+		if _, err := os.Stat(filepath.Join(srcDir, name)); err != nil {
+			t.Errorf("%s was not moved back: %v", name, err)
+		}
+		if name == "dem.prj" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dstDir, name)); err == nil {
+			t.Errorf("%s was left in the destination", name)
+		}
+	}
+	var indexed string
+	if err := a.db.QueryRow("SELECT file_path FROM geo_file_index").Scan(&indexed); err != nil {
+		t.Fatal(err)
+	}
+	if indexed != srcPath {
+		t.Errorf("index points at %s, want %s", indexed, srcPath)
+	}
+}
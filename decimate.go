@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// maxDecimatedFeatures caps maxFeatures, beyond which the map is no longer responsive anyway
+const maxDecimatedFeatures = 1000000
+
+// LoadGeospatialFileDecimated loads a point layer keeping at most maxFeatures points, thinned on
+// a grid so sparse areas stay represented while dense clusters are capped. A non-empty bbox
+// ([west, south, east, north]) keeps only the points inside it, so a zoomed-in view can ask for
+// the full set of its area; maxFeatures 0 disables thinning. Features that aren't single points
+// are kept as they are. The collection carries decimated, original_count (the number of points
+// before thinning) and kept_count.
+func (a *App) LoadGeospatialFileDecimated(filePath string, maxFeatures int, bbox []float64) (map[string]interface{}, error) {
+	if err := checkDecimation(maxFeatures, bbox); err != nil {
+		return nil, err
+	}
+	filePath, err := gdalInputPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var points, others []map[string]interface{}
+	err = a.readLayerFeatures(context.Background(), filePath, "", func(feature map[string]interface{}) error {
+		geometry, _ := feature["geometry"].(map[string]interface{})
+		position, ok := pointPosition(geometry)
+		switch {
+		case !ok:
+			others = append(others, feature)
+		case len(bbox) == 0 || positionInBBox(position, bbox):
+			points = append(points, feature)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := decimatedCollection(points, others, maxFeatures)
+	a.recordRecentItem(filePath, recentFile)
+	a.logInfo("Loaded %d of %d points from %s", len(points)-droppedPoints(result), len(points), filePath)
+	return result, nil
+}
+
+// DecimatePoints thins the points of an in-memory collection, e.g. a large Overpass node result,
+// the way LoadGeospatialFileDecimated thins a file
+func (a *App) DecimatePoints(geojson map[string]interface{}, maxFeatures int) (map[string]interface{}, error) {
+	if err := checkDecimation(maxFeatures, nil); err != nil {
+		return nil, err
+	}
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, err
+	}
+
+	var points, others []map[string]interface{}
+	for _, feature := range features {
+		geometry, _ := feature["geometry"].(map[string]interface{})
+		if _, ok := pointPosition(geometry); ok {
+			points = append(points, feature)
+		} else {
+			others = append(others, feature)
+		}
+	}
+	return decimatedCollection(points, others, maxFeatures), nil
+}
+
+// QueryOverpassAPIDecimated is QueryOverpassAPI with the node results thinned to at most
+// maxFeatures points. The response metadata also carries decimated and original_count.
+func (a *App) QueryOverpassAPIDecimated(query string, maxFeatures int) (*OverpassResponse, error) {
+	if err := checkDecimation(maxFeatures, nil); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	resp, err := a.runOverpassQuery(context.Background(), query)
+	a.recordQuery(a.queryHistoryEntry(query), start, resp, err)
+	if err != nil || resp == nil || resp.Data == nil {
+		return resp, err
+	}
+
+	data, err := a.DecimatePoints(resp.Data, maxFeatures)
+	if err != nil {
+		return resp, nil
+	}
+	resp.Data = data
+	if resp.Metadata == nil {
+		resp.Metadata = map[string]interface{}{}
+	}
+	resp.Metadata["decimated"] = data["decimated"]
+	resp.Metadata["original_count"] = data["original_count"]
+	return resp, nil
+}
+
+// checkDecimation validates the arguments shared by the decimating loaders
+func checkDecimation(maxFeatures int, bbox []float64) error {
+	if maxFeatures < 0 || maxFeatures > maxDecimatedFeatures {
+		return fmt.Errorf("maxFeatures must be between 0 (no limit) and %d, got %d", maxDecimatedFeatures, maxFeatures)
+	}
+	if len(bbox) > 0 && (!validBBox(bbox) || bbox[0] > bbox[2] || bbox[1] > bbox[3]) {
+		return fmt.Errorf("bbox must have 4 values [west, south, east, north]")
+	}
+	return nil
+}
+
+// decimatedCollection thins points with thinPoints and returns them, followed by the other
+// features, as a FeatureCollection
+func decimatedCollection(points, others []map[string]interface{}, maxFeatures int) map[string]interface{} {
+	kept := points
+	if maxFeatures > 0 && len(points) > maxFeatures {
+		kept = thinPoints(points, maxFeatures)
+	}
+
+	features := make([]interface{}, 0, len(kept)+len(others))
+	for _, feature := range kept {
+		features = append(features, feature)
+	}
+	for _, feature := range others {
+		features = append(features, feature)
+	}
+	return map[string]interface{}{
+		"type":           "FeatureCollection",
+		"features":       features,
+		"decimated":      len(kept) < len(points),
+		"original_count": len(points),
+		"kept_count":     len(kept),
+	}
+}
+
+// droppedPoints returns how many points a decimated collection left out
+func droppedPoints(collection map[string]interface{}) int {
+	original, _ := collection["original_count"].(int)
+	kept, _ := collection["kept_count"].(int)
+	return original - kept
+}
+
+// thinPoints keeps limit of the points, spread over a grid of about limit cells across their
+// extent. Cells take turns giving up one point each, so every occupied cell is represented
+// before any cell gets a second point, and the kept points stay in their original order.
+func thinPoints(points []map[string]interface{}, limit int) []map[string]interface{} {
+	positions := make([][]float64, len(points))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i, feature := range points {
+		geometry, _ := feature["geometry"].(map[string]interface{})
+		p, _ := pointPosition(geometry)
+		positions[i] = p
+		minX, minY = math.Min(minX, p[0]), math.Min(minY, p[1])
+		maxX, maxY = math.Max(maxX, p[0]), math.Max(maxY, p[1])
+	}
+
+	side := int(math.Ceil(math.Sqrt(float64(limit))))
+	width := math.Max(maxX-minX, 1e-12) / float64(side)
+	height := math.Max(maxY-minY, 1e-12) / float64(side)
+
+	cells := map[int][]int{}
+	var order []int
+	for i, p := range positions {
+		col := min(int((p[0]-minX)/width), side-1)
+		row := min(int((p[1]-minY)/height), side-1)
+		key := row*side + col
+		if _, ok := cells[key]; !ok {
+			order = append(order, key)
+		}
+		cells[key] = append(cells[key], i)
+	}
+
+	// Shuffle each cell so its points are a sample of the whole cell rather than the first few
+	// read; the fixed seed keeps the result the same from one load to the next
+	random := rand.New(rand.NewSource(1))
+	for _, key := range order {
+		members := cells[key]
+		random.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+	}
+
+	// Cells drop out of the rotation once they run out of points
+	picked := make([]int, 0, limit)
+	active := order
+	for round := 0; len(picked) < limit && len(active) > 0; round++ {
+		remaining := active[:0]
+		for _, key := range active {
+			members := cells[key]
+			picked = append(picked, members[round])
+			if len(picked) == limit {
+				break
+			}
+			if round+1 < len(members) {
+				remaining = append(remaining, key)
+			}
+		}
+		active = remaining
+	}
+
+	sort.Ints(picked)
+	kept := make([]map[string]interface{}, len(picked))
+	for i, index := range picked {
+		kept[i] = points[index]
+	}
+	return kept
+}
+
+// pointPosition returns the x and y of a Point geometry
+func pointPosition(geometry map[string]interface{}) ([]float64, bool) {
+	if geometry == nil || geometry["type"] != "Point" {
+		return nil, false
+	}
+	switch coords := geometry["coordinates"].(type) {
+	case []float64:
+		if validPosition(coords) {
+			return coords[:2], true
+		}
+	case []interface{}:
+		if len(coords) >= 2 {
+			x, okX := coords[0].(float64)
+			y, okY := coords[1].(float64)
+			if okX && okY && validPosition([]float64{x, y}) {
+				return []float64{x, y}, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// positionInBBox reports whether p lies within a [west, south, east, north] bbox
+func positionInBBox(p []float64, bbox []float64) bool {
+	return p[0] >= bbox[0] && p[0] <= bbox[2] && p[1] >= bbox[1] && p[1] <= bbox[3]
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxDensityCells caps the grid size so a tiny cell size can't produce millions of polygons
+const maxDensityCells = 250000
+
+// minDensityCellSize is the smallest cell size in meters, below GPS accuracy a density is noise
+const minDensityCellSize = 1.0
+
+// maxDensitySmoothingRadius caps the kernel radius, in cells
+const maxDensitySmoothingRadius = 20
+
+// GenerateDensityGrid bins the points of a layer into square cells of cellSizeMeters and returns
+// a polygon FeatureCollection with a count property per non-empty cell, for use as a heatmap
+func (a *App) GenerateDensityGrid(geojson map[string]interface{}, cellSizeMeters float64) (map[string]interface{}, error) {
+	return generateDensityGrid(geojson, cellSizeMeters, 0)
+}
+
+// GenerateSmoothedDensityGrid is GenerateDensityGrid with a Gaussian kernel of radiusCells cells
+// applied to the counts. Each cell gets a smoothed property next to its raw count.
+func (a *App) GenerateSmoothedDensityGrid(geojson map[string]interface{}, cellSizeMeters float64, radiusCells int) (map[string]interface{}, error) {
+	if radiusCells < 0 || radiusCells > maxDensitySmoothingRadius {
+		return nil, fmt.Errorf("smoothing radius must be between 0 and %d cells, got %d", maxDensitySmoothingRadius, radiusCells)
+	}
+	return generateDensityGrid(geojson, cellSizeMeters, radiusCells)
+}
+
+// generateDensityGrid computes the grid in a Lambert azimuthal equal-area projection centred on
+// the points, so every cell covers the same ground area
+func generateDensityGrid(geojson map[string]interface{}, cellSize float64, radius int) (map[string]interface{}, error) {
+	if math.IsNaN(cellSize) || math.IsInf(cellSize, 0) || cellSize < minDensityCellSize {
+		return nil, fmt.Errorf("cell size must be at least %g meters", minDensityCellSize)
+	}
+
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, err
+	}
+
+	var points [][]float64
+	for _, feature := range features {
+		geometry, _ := feature["geometry"].(map[string]interface{})
+		points = append(points, collectPoints(geometry)...)
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no point geometries to grid")
+	}
+
+	var sumLon, sumLat float64
+	for _, p := range points {
+		sumLon += p[0]
+		sumLat += p[1]
+	}
+	proj := newEqualAreaProjection(sumLon/float64(len(points)), sumLat/float64(len(points)))
+
+	projected := make([][2]float64, len(points))
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for i, p := range points {
+		x, y := proj.forward(p[0], p[1])
+		projected[i] = [2]float64{x, y}
+		minX, minY = math.Min(minX, x), math.Min(minY, y)
+		maxX, maxY = math.Max(maxX, x), math.Max(maxY, y)
+	}
+
+	// Snap the origin to the cell size and pad by the kernel radius so smoothing isn't clipped
+	originX := math.Floor(minX/cellSize)*cellSize - float64(radius)*cellSize
+	originY := math.Floor(minY/cellSize)*cellSize - float64(radius)*cellSize
+	// Sized in float64 first, so a huge grid is rejected before the int conversion can overflow
+	colsF := math.Floor((maxX-originX)/cellSize) + 1 + float64(radius)
+	rowsF := math.Floor((maxY-originY)/cellSize) + 1 + float64(radius)
+	if colsF*rowsF > maxDensityCells {
+		return nil, fmt.Errorf("a %.0fm grid over this layer needs %.0f x %.0f cells (limit %d); use a larger cell size",
+			cellSize, colsF, rowsF, maxDensityCells)
+	}
+	cols, rows := int(colsF), int(rowsF)
+
+	counts := make([]float64, cols*rows)
+	for _, p := range projected {
+		col := int((p[0] - originX) / cellSize)
+		row := int((p[1] - originY) / cellSize)
+		counts[row*cols+col]++
+	}
+
+	values := counts
+	if radius > 0 {
+		values = smoothGrid(counts, cols, rows, radius)
+	}
+
+	cellArea := cellSize * cellSize / 1e6
+	out := []interface{}{}
+	maxCount := 0.0
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			i := row*cols + col
+			if counts[i] == 0 && values[i] < 1e-9 {
+				continue
+			}
+			maxCount = math.Max(maxCount, counts[i])
+
+			x0, y0 := originX+float64(col)*cellSize, originY+float64(row)*cellSize
+			ring := [][]float64{
+				proj.inverse(x0, y0),
+				proj.inverse(x0+cellSize, y0),
+				proj.inverse(x0+cellSize, y0+cellSize),
+				proj.inverse(x0, y0+cellSize),
+				proj.inverse(x0, y0),
+			}
+
+			props := map[string]interface{}{
+				"count":         int(counts[i]),
+				"density_sq_km": counts[i] / cellArea,
+				"row":           row,
+				"col":           col,
+			}
+			if radius > 0 {
+				props["smoothed"] = values[i]
+			}
+
+			out = append(out, map[string]interface{}{
+				"type":       "Feature",
+				"properties": props,
+				"geometry": map[string]interface{}{
+					"type":        "Polygon",
+					"coordinates": [][][]float64{ring},
+				},
+			})
+		}
+	}
+
+	return map[string]interface{}{
+		"type":             "FeatureCollection",
+		"features":         out,
+		"cell_size_meters": cellSize,
+		"smoothing_radius": radius,
+		"point_count":      len(points),
+		"max_count":        int(maxCount),
+	}, nil
+}
+
+// collectPoints returns the positions of Point, MultiPoint and GeometryCollection members
+func collectPoints(geometry map[string]interface{}) [][]float64 {
+	if geometry == nil {
+		return nil
+	}
+
+	switch geometry["type"] {
+	case "Point":
+		var coord []float64
+		if decodeCoordinates(geometry["coordinates"], &coord) == nil && validPosition(coord) {
+			return [][]float64{coord}
+		}
+	case "MultiPoint":
+		var coords [][]float64
+		if decodeCoordinates(geometry["coordinates"], &coords) == nil {
+			var points [][]float64
+			for _, coord := range coords {
+				if validPosition(coord) {
+					points = append(points, coord)
+				}
+			}
+			return points
+		}
+	case "GeometryCollection":
+		geometries, _ := geometry["geometries"].([]interface{})
+		var points [][]float64
+		for _, g := range geometries {
+			if child, ok := g.(map[string]interface{}); ok {
+				points = append(points, collectPoints(child)...)
+			}
+		}
+		return points
+	}
+	return nil
+}
+
+// smoothGrid convolves the counts with a normalized Gaussian kernel (sigma = radius/2), so the
+// smoothed values still sum to the number of points
+func smoothGrid(counts []float64, cols, rows, radius int) []float64 {
+	sigma := float64(radius) / 2
+	size := 2*radius + 1
+	kernel := make([]float64, size*size)
+	total := 0.0
+	for dy := -radius; dy <= radius; dy++ {
+		for dx := -radius; dx <= radius; dx++ {
+			if dx*dx+dy*dy > radius*radius {
+				continue
+			}
+			w := math.Exp(-float64(dx*dx+dy*dy) / (2 * sigma * sigma))
+			kernel[(dy+radius)*size+dx+radius] = w
+			total += w
+		}
+	}
+	for i := range kernel {
+		kernel[i] /= total
+	}
+
+	smoothed := make([]float64, len(counts))
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			count := counts[row*cols+col]
+			if count == 0 {
+				continue
+			}
+			for dy := -radius; dy <= radius; dy++ {
+				r := row + dy
+				if r < 0 || r >= rows {
+					continue
+				}
+				for dx := -radius; dx <= radius; dx++ {
+					c := col + dx
+					if c < 0 || c >= cols {
+						continue
+					}
+					smoothed[r*cols+c] += count * kernel[(dy+radius)*size+dx+radius]
+				}
+			}
+		}
+	}
+	return smoothed
+}
+
+// equalAreaProjection is a spherical Lambert azimuthal equal-area projection in meters
+type equalAreaProjection struct {
+	lon0, sinLat0, cosLat0 float64
+}
+
+func newEqualAreaProjection(lon0, lat0 float64) equalAreaProjection {
+	phi0 := lat0 * math.Pi / 180
+	return equalAreaProjection{lon0: lon0 * math.Pi / 180, sinLat0: math.Sin(phi0), cosLat0: math.Cos(phi0)}
+}
+
+// forward projects lon/lat degrees to x/y meters
+func (p equalAreaProjection) forward(lon, lat float64) (float64, float64) {
+	lambda := lon*math.Pi/180 - p.lon0
+	phi := lat * math.Pi / 180
+	denom := 1 + p.sinLat0*math.Sin(phi) + p.cosLat0*math.Cos(phi)*math.Cos(lambda)
+	if denom <= 1e-12 {
+		// Antipode of the centre; not reachable for any realistic layer
+		return 0, 0
+	}
+	k := math.Sqrt(2 / denom)
+	x := earthRadiusMeters * k * math.Cos(phi) * math.Sin(lambda)
+	y := earthRadiusMeters * k * (p.cosLat0*math.Sin(phi) - p.sinLat0*math.Cos(phi)*math.Cos(lambda))
+	return x, y
+}
+
+// inverse converts x/y meters back to a [lon, lat] position in degrees
+func (p equalAreaProjection) inverse(x, y float64) []float64 {
+	rho := math.Hypot(x, y)
+	if rho < 1e-9 {
+		return []float64{p.lon0 * 180 / math.Pi, math.Asin(p.sinLat0) * 180 / math.Pi}
+	}
+	c := 2 * math.Asin(math.Min(1, rho/(2*earthRadiusMeters)))
+	sinC, cosC := math.Sin(c), math.Cos(c)
+	phi := math.Asin(cosC*p.sinLat0 + y*sinC*p.cosLat0/rho)
+	lambda := p.lon0 + math.Atan2(x*sinC, rho*p.cosLat0*cosC-y*p.sinLat0*sinC)
+	return []float64{lambda * 180 / math.Pi, phi * 180 / math.Pi}
+}
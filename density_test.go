@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGenerateDensityGridCellSize(t *testing.T) {
+	// Two points a few hundred kilometers apart
+	layer := map[string]interface{}{
+		"type": "FeatureCollection",
+		"features": []interface{}{
+			map[string]interface{}{"type": "Feature", "properties": map[string]interface{}{},
+				"geometry": map[string]interface{}{"type": "Point", "coordinates": []interface{}{0.0, 0.0}}},
+			map[string]interface{}{"type": "Feature", "properties": map[string]interface{}{},
+				"geometry": map[string]interface{}{"type": "Point", "coordinates": []interface{}{3.0, 3.0}}},
+		},
+	}
+
+	tests := []struct {
+		cellSize float64
+		errPart  string
+	}{
+		{cellSize: 1e-5, errPart: "at least"},
+		{cellSize: 0, errPart: "at least"},
+		{cellSize: math.NaN(), errPart: "at least"},
+		{cellSize: math.Inf(1), errPart: "at least"},
+		// About 330000 x 330000 cells, far past the limit
+		{cellSize: 1, errPart: "limit"},
+		{cellSize: 50000},
+	}
+
+	for _, tt := range tests {
+		result, err := generateDensityGrid(layer, tt.cellSize, 0)
+		if tt.errPart == "" {
+			if err != nil {
+				t.Errorf("cell size %g: %v", tt.cellSize, err)
+			} else if features, _ := geojsonFeatures(result); len(features) != 2 {
+				t.Errorf("cell size %g: got %d cells, want 2", tt.cellSize, len(features))
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+			t.Errorf("cell size %g: error = %v, want one mentioning %q", tt.cellSize, err, tt.errPart)
+		}
+	}
+}
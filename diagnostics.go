@@ -0,0 +1,232 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	goruntime "runtime"
+	"strings"
+	"time"
+)
+
+// defaultRecentLogLines is returned by GetRecentLogs when no line count is given
+const defaultRecentLogLines = 200
+
+// redactedValue replaces secrets in exported diagnostics
+const redactedValue = "[REDACTED]"
+
+// secretPatterns match API keys and tokens that may have ended up in logs or settings
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/\-]+=*`),
+	regexp.MustCompile(`(?i)((?:api[_-]?key|token|secret|password)["']?\s*[:=]\s*["']?)[^\s"'&,}]+`),
+}
+
+// urlPasswordPattern matches the password in a URL such as an authenticated proxy's
+var urlPasswordPattern = regexp.MustCompile(`(://[^:/@\s]+:)[^@\s/]+@`)
+
+// secretSettingWords mark settings whose value must never be exported
+var secretSettingWords = []string{"key", "token", "secret", "password"}
+
+// GetRecentLogs returns the last lines of the log, oldest first, reading into rotated files if needed
+func (a *App) GetRecentLogs(lines int) ([]string, error) {
+	if lines <= 0 {
+		lines = defaultRecentLogLines
+	}
+
+	files := a.logger.files()
+	if len(files) == 0 {
+		return []string{}, fmt.Errorf("logging to file is not enabled")
+	}
+
+	var recent []string
+	for _, path := range files {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return recent, fmt.Errorf("failed to read log file: %v", err)
+		}
+
+		fileLines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+		if len(fileLines) == 1 && fileLines[0] == "" {
+			continue
+		}
+		recent = append(fileLines, recent...)
+		if len(recent) >= lines {
+			break
+		}
+	}
+
+	if len(recent) > lines {
+		recent = recent[len(recent)-lines:]
+	}
+	for i, line := range recent {
+		recent[i] = redactSecrets(line)
+	}
+	if recent == nil {
+		recent = []string{}
+	}
+	return recent, nil
+}
+
+// ExportDiagnostics zips the logs, settings and system information into a single file for bug
+// reports and returns its path. API keys and tokens are redacted.
+func (a *App) ExportDiagnostics() (string, error) {
+	dir, err := terraboxDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get data directory: %v", err)
+	}
+	outDir := filepath.Join(dir, "diagnostics")
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create diagnostics directory: %v", err)
+	}
+
+	// Written to a temporary file first, so a failure doesn't leave a truncated zip behind
+	zipPath := filepath.Join(outDir, fmt.Sprintf("terrabox-diagnostics-%s.zip", time.Now().Format("20060102_150405")))
+	err = writeViaTempFile(zipPath, func(tmpPath string) error {
+		file, err := os.Create(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to create diagnostics file: %v", err)
+		}
+		err = a.writeDiagnostics(file)
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("failed to write diagnostics file: %v", closeErr)
+		}
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	a.logInfo("Exported diagnostics to %s", zipPath)
+	return zipPath, nil
+}
+
+// writeDiagnostics writes the diagnostics zip: logs, redacted settings and system details
+func (a *App) writeDiagnostics(w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	// Logs, oldest rotation included
+	for _, path := range a.logger.files() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if err := writeZipEntry(archive, "logs/"+filepath.Base(path), []byte(redactSecrets(string(content)))); err != nil {
+			return err
+		}
+	}
+
+	settings, err := a.redactedSettings()
+	if err != nil {
+		settings = map[string]string{"error": err.Error()}
+	}
+	if err := writeZipJSON(archive, "settings.json", settings); err != nil {
+		return err
+	}
+
+	gdal, _ := a.CheckGDALAvailable()
+	system := map[string]interface{}{
+		"generated_at":          time.Now().Format(time.RFC3339),
+		"schema_version":        a.catalogSchemaVersion(),
+		"os":                    goruntime.GOOS,
+		"arch":                  goruntime.GOARCH,
+		"go_version":            goruntime.Version(),
+		"log_level":             a.GetLogLevel(),
+		"gdal":                  gdal,
+		"openai_key_configured": os.Getenv("OPENAI_API_KEY") != "",
+	}
+	if err := writeZipJSON(archive, "system.json", system); err != nil {
+		return err
+	}
+
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write diagnostics file: %v", err)
+	}
+	return nil
+}
+
+// redactedSettings returns every setting with secret-looking values masked
+func (a *App) redactedSettings() (map[string]string, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	rows, err := a.db.Query("SELECT key, value FROM settings ORDER BY key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %v", err)
+	}
+	defer rows.Close()
+
+	settings := map[string]string{}
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to read settings: %v", err)
+		}
+
+		settings[key] = redactSecrets(value)
+		lower := strings.ToLower(key)
+		for _, word := range secretSettingWords {
+			if strings.Contains(lower, word) {
+				settings[key] = redactedValue
+				break
+			}
+		}
+	}
+	return settings, nil
+}
+
+// catalogSchemaVersion reads PRAGMA user_version from the catalog database
+func (a *App) catalogSchemaVersion() int {
+	if a.db == nil {
+		return 0
+	}
+	var version int
+	a.db.QueryRow("PRAGMA user_version").Scan(&version)
+	return version
+}
+
+// redactSecrets masks the configured OpenAI key and anything that looks like an API key or token
+func redactSecrets(text string) string {
+	if key := strings.TrimSpace(os.Getenv("OPENAI_API_KEY")); key != "" {
+		text = strings.ReplaceAll(text, key, redactedValue)
+	}
+	for i, pattern := range secretPatterns {
+		if i == 0 {
+			text = pattern.ReplaceAllString(text, redactedValue)
+		} else {
+			text = pattern.ReplaceAllString(text, "${1}"+redactedValue)
+		}
+	}
+	text = urlPasswordPattern.ReplaceAllString(text, "${1}"+redactedValue+"@")
+	return text
+}
+
+func writeZipEntry(archive *zip.Writer, name string, content []byte) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to diagnostics: %v", name, err)
+	}
+	if _, err := w.Write(content); err != nil {
+		return fmt.Errorf("failed to add %s to diagnostics: %v", name, err)
+	}
+	return nil
+}
+
+func writeZipJSON(archive *zip.Writer, name string, value interface{}) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", name, err)
+	}
+	return writeZipEntry(archive, name, content)
+}
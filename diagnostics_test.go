@@ -0,0 +1,86 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingWriter passes limit bytes on to w and then fails, like a disk filling up
+type failingWriter struct {
+	w     io.Writer
+	limit int
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.limit {
+		n, _ := f.w.Write(p[:f.limit])
+		f.limit = 0
+		return n, errors.New("no space left on device")
+	}
+	f.limit -= len(p)
+	return f.w.Write(p)
+}
+
+func TestExportDiagnostics(t *testing.T) {
+	a := newTestApp(t)
+
+	zipPath, err := a.ExportDiagnostics()
+	if err != nil {
+		t.Fatalf("ExportDiagnostics failed: %v", err)
+	}
+
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("exported diagnostics is not a valid zip: %v", err)
+	}
+	defer archive.Close()
+	names := map[string]bool{}
+	for _, file := range archive.File {
+		names[file.Name] = true
+	}
+	for _, name := range []string{"settings.json", "system.json"} {
+		if !names[name] {
+			t.Errorf("diagnostics zip is missing %s", name)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(zipPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("temporary file %s left behind", entry.Name())
+		}
+	}
+}
+
+func TestWriteDiagnosticsFailure(t *testing.T) {
+	a := newTestApp(t)
+
+	// Half the zip reaches the temporary file before the write fails
+	dstPath := filepath.Join(t.TempDir(), "diagnostics.zip")
+	err := writeViaTempFile(dstPath, func(tmpPath string) error {
+		file, err := os.Create(tmpPath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return a.writeDiagnostics(&failingWriter{w: file, limit: 64})
+	})
+	if err == nil {
+		t.Fatal("writeDiagnostics succeeded on a failing writer")
+	}
+	entries, err := os.ReadDir(filepath.Dir(dstPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("failed export left %d files behind", len(entries))
+	}
+}
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/paulmach/orb"
+)
+
+// DissolveOptions controls how DissolveByAttributeWithOptions merges features
+type DissolveOptions struct {
+	// Aggregate combines numeric attributes: "sum" (the default) or "mean"
+	Aggregate string `json:"aggregate"`
+	// Tolerance snaps vertices closer than this, in layer units, onto each other so slivers and
+	// tiny gaps between neighbours close: 0 uses 1e-7 (about 1cm in degrees), negative disables
+	Tolerance float64 `json:"tolerance"`
+}
+
+// DissolveByAttribute merges the polygons that share a value of field into one polygon or
+// multipolygon per value, summing numeric attributes, e.g. census tracts into counties
+func (a *App) DissolveByAttribute(geojson map[string]interface{}, field string) (map[string]interface{}, error) {
+	return a.DissolveByAttributeWithOptions(geojson, field, DissolveOptions{})
+}
+
+// DissolveByAttributeWithOptions is DissolveByAttribute with a choice of sum or mean for numeric
+// attributes and of the snapping tolerance. Polygons of the same value are merged into their
+// union, so shared boundaries and overlaps disappear and only disjoint areas stay separate parts.
+// Other attributes are kept when every merged feature agrees on them. Each feature gets
+// dissolved_count, and the collection carries input_count, output_count and skipped_count for
+// non-polygon features.
+func (a *App) DissolveByAttributeWithOptions(geojson map[string]interface{}, field string, options DissolveOptions) (map[string]interface{}, error) {
+	if strings.TrimSpace(field) == "" {
+		return nil, fmt.Errorf("no field given")
+	}
+	switch options.Aggregate {
+	case "":
+		options.Aggregate = "sum"
+	case "sum", "mean":
+	default:
+		return nil, fmt.Errorf("unknown aggregate %q (expected sum or mean)", options.Aggregate)
+	}
+	if options.Tolerance == 0 {
+		options.Tolerance = math.Pow10(-defaultCoordinatePrecision)
+	}
+	options.Tolerance = math.Max(options.Tolerance, 0)
+
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, err
+	}
+
+	type dissolveGroup struct {
+		value      interface{}
+		polygons   []orb.Polygon
+		properties []map[string]interface{}
+	}
+	groups := map[string]*dissolveGroup{}
+	var order []string
+	skipped := 0
+	for i, feature := range features {
+		g, err := orbGeometry(feature["geometry"])
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+		polygons := collectPolygons(g)
+		if len(polygons) == 0 {
+			skipped++
+			continue
+		}
+
+		properties, _ := feature["properties"].(map[string]interface{})
+		value := properties[field]
+		key := fmt.Sprintf("%#v", value)
+		group, ok := groups[key]
+		if !ok {
+			group = &dissolveGroup{value: value}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.polygons = append(group.polygons, polygons...)
+		group.properties = append(group.properties, properties)
+	}
+
+	out := make([]interface{}, 0, len(order))
+	for _, key := range order {
+		group := groups[key]
+		properties := aggregateProperties(group.properties, field, options.Aggregate)
+		properties[field] = group.value
+		properties["dissolved_count"] = len(group.properties)
+
+		var geometry interface{}
+		if merged := unionPolygons(group.polygons, options.Tolerance); merged != nil {
+			geometry = merged
+		}
+		out = append(out, map[string]interface{}{
+			"type":       "Feature",
+			"properties": properties,
+			"geometry":   geometry,
+		})
+	}
+
+	a.logInfo("Dissolved %d features into %d by %s", len(features)-skipped, len(out), field)
+	return map[string]interface{}{
+		"type":          "FeatureCollection",
+		"features":      out,
+		"input_count":   len(features),
+		"output_count":  len(out),
+		"skipped_count": skipped,
+	}, nil
+}
+
+// collectPolygons returns the polygons of a geometry, looking inside collections
+func collectPolygons(g orb.Geometry) []orb.Polygon {
+	switch g := g.(type) {
+	case orb.Polygon:
+		return []orb.Polygon{g}
+	case orb.MultiPolygon:
+		return g
+	case orb.Collection:
+		var polygons []orb.Polygon
+		for _, member := range g {
+			polygons = append(polygons, collectPolygons(member)...)
+		}
+		return polygons
+	}
+	return nil
+}
+
+// aggregateProperties combines the attributes of merged features: numeric fields are summed or
+// averaged, and any other field is kept only when all features have the same value
+func aggregateProperties(all []map[string]interface{}, field string, aggregate string) map[string]interface{} {
+	names := map[string]bool{}
+	for _, properties := range all {
+		for name := range properties {
+			names[name] = true
+		}
+	}
+
+	result := map[string]interface{}{}
+	for name := range names {
+		if name == field {
+			continue
+		}
+		sum, count, numeric, same := 0.0, 0, true, true
+		for _, properties := range all {
+			value := properties[name]
+			if !reflect.DeepEqual(value, all[0][name]) {
+				same = false
+			}
+			if value == nil {
+				continue
+			}
+			if n, ok := numericAttribute(value); ok {
+				sum += n
+				count++
+			} else {
+				numeric = false
+			}
+		}
+
+		switch {
+		case numeric && count > 0 && aggregate == "mean":
+			result[name] = sum / float64(count)
+		case numeric && count > 0:
+			result[name] = sum
+		case same:
+			result[name] = all[0][name]
+		}
+	}
+	return result
+}
+
+// numericAttribute returns a number-typed attribute as a float64. Numeric strings such as
+// "06037" are codes, not quantities, so they don't count.
+func numericAttribute(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// unionPolygons merges polygons into their union. Vertices within tolerance are snapped together
+// and every edge is split where it crosses another edge or passes through another ring's vertex,
+// so boundary pieces only meet at their ends. A piece is kept when the area just to its right,
+// outside the polygon it came from, isn't covered by any polygon; that drops shared boundaries,
+// edges inside overlaps and holes filled by other polygons. The kept pieces are traced into rings
+// and assembled into a GeoJSON Polygon or MultiPolygon, or nil when nothing is left.
+func unionPolygons(polygons []orb.Polygon, tolerance float64) map[string]interface{} {
+	snap := newVertexSnapper(tolerance)
+	var segments [][2][2]float64
+	var shapes []*joinShape
+	for _, polygon := range polygons {
+		var snappedPolygon orb.Polygon
+		for r, ring := range polygon {
+			snapped := make([][2]float64, 0, len(ring)+1)
+			for _, p := range ring {
+				q := snap.snap(p)
+				if len(snapped) == 0 || snapped[len(snapped)-1] != q {
+					snapped = append(snapped, q)
+				}
+			}
+			if len(snapped) > 0 && snapped[0] != snapped[len(snapped)-1] {
+				snapped = append(snapped, snapped[0])
+			}
+			area := 0.0
+			if len(snapped) >= 4 {
+				area = signedArea2(snapped)
+			}
+			if area == 0 {
+				// Without its exterior the polygon's holes have nothing to cut
+				if r == 0 {
+					break
+				}
+				continue
+			}
+			// Exteriors counter-clockwise and holes clockwise, so every ring has its area on the left
+			if (r == 0) != (area > 0) {
+				for i, j := 0, len(snapped)-1; i < j; i, j = i+1, j-1 {
+					snapped[i], snapped[j] = snapped[j], snapped[i]
+				}
+			}
+
+			orbRing := make(orb.Ring, len(snapped))
+			for i, p := range snapped {
+				orbRing[i] = orb.Point(p)
+				if i > 0 {
+					segments = append(segments, [2][2]float64{snapped[i-1], p})
+				}
+			}
+			snappedPolygon = append(snappedPolygon, orbRing)
+		}
+		if shape := newJoinShape(snappedPolygon); shape != nil && len(snappedPolygon) > 0 {
+			shapes = append(shapes, shape)
+		}
+	}
+
+	index := newJoinIndex(shapes)
+	covered := func(p orb.Point) bool {
+		for _, i := range index.candidates(orb.Bound{Min: p, Max: p}) {
+			if shapes[i].bound.Contains(p) && shapes[i].inPolygon(p) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Same-direction duplicates, such as the boundary of two identical polygons, are kept once
+	cuts := segmentCrossings(segments, snap)
+	edges := map[[2][2]float64]int{}
+	for i, segment := range segments {
+		from, to := segment[0], segment[1]
+		dx, dy := to[0]-from[0], to[1]-from[1]
+		along := func(p [2]float64) float64 { return (p[0]-from[0])*dx + (p[1]-from[1])*dy }
+		end := along(to)
+		points := snap.split(from, to)
+		for _, p := range cuts[i] {
+			if t := along(p); t > 0 && t < end {
+				points = append(points, p)
+			}
+		}
+		sort.SliceStable(points, func(x, y int) bool { return along(points[x]) < along(points[y]) })
+
+		prev := from
+		for _, p := range points {
+			if p == prev {
+				continue
+			}
+			// Probe just right of the piece's midpoint, a small fraction of its length away
+			ex, ey := p[0]-prev[0], p[1]-prev[1]
+			probe := orb.Point{(prev[0]+p[0])/2 + ey*1e-5, (prev[1]+p[1])/2 - ex*1e-5}
+			if !covered(probe) {
+				edges[[2][2]float64{prev, p}] = 1
+			}
+			prev = p
+		}
+	}
+
+	traced := traceRings(edges)
+	minArea := tolerance * tolerance
+	var shapeRings [][][]float64
+	for _, ring := range traced {
+		if math.Abs(signedArea2(ring)) <= minArea {
+			continue
+		}
+		// assembleShapePolygon follows the shapefile convention of clockwise exteriors
+		positions := make([][]float64, len(ring))
+		for i, p := range ring {
+			positions[len(ring)-1-i] = []float64{p[0], p[1]}
+		}
+		shapeRings = append(shapeRings, positions)
+	}
+	if len(shapeRings) == 0 {
+		return nil
+	}
+
+	geometry := assembleShapePolygon(shapeRings)
+	switch coords := geometry["coordinates"].(type) {
+	case [][][]float64:
+		enforceWinding(coords)
+	case [][][][]float64:
+		for _, polygon := range coords {
+			enforceWinding(polygon)
+		}
+	}
+	return geometry
+}
+
+// segmentCrossings finds where segments properly cross and returns, per segment index, the
+// crossing points to split it at. Each crossing is snapped, so both segments split at the same
+// vertex. Segments are swept in x order so only those with overlapping x ranges are compared.
+func segmentCrossings(segments [][2][2]float64, snap *vertexSnapper) map[int][][2]float64 {
+	minX := func(s [2][2]float64) float64 { return math.Min(s[0][0], s[1][0]) }
+	order := make([]int, len(segments))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return minX(segments[order[i]]) < minX(segments[order[j]]) })
+
+	cuts := map[int][][2]float64{}
+	for n, i := range order {
+		a, b := segments[i][0], segments[i][1]
+		maxX := math.Max(a[0], b[0])
+		minY, maxY := math.Min(a[1], b[1]), math.Max(a[1], b[1])
+		for _, j := range order[n+1:] {
+			if minX(segments[j]) > maxX {
+				break
+			}
+			c, d := segments[j][0], segments[j][1]
+			if math.Max(c[1], d[1]) < minY || math.Min(c[1], d[1]) > maxY {
+				continue
+			}
+			if p, ok := crossingPoint(a, b, c, d); ok {
+				q := snap.snap(orb.Point(p))
+				cuts[i] = append(cuts[i], q)
+				cuts[j] = append(cuts[j], q)
+			}
+		}
+	}
+	return cuts
+}
+
+// crossingPoint returns where segments a-b and c-d cross at a point inside both. Touching and
+// collinear segments don't cross; snapping and vertex splits already join those.
+func crossingPoint(a, b, c, d [2]float64) ([2]float64, bool) {
+	d1 := orientation(c[:], d[:], a[:])
+	d2 := orientation(c[:], d[:], b[:])
+	d3 := orientation(a[:], b[:], c[:])
+	d4 := orientation(a[:], b[:], d[:])
+	if !((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) || !((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return [2]float64{}, false
+	}
+	t := d1 / (d1 - d2)
+	return [2]float64{a[0] + t*(b[0]-a[0]), a[1] + t*(b[1]-a[1])}, true
+}
+
+// traceRings walks the directed boundary edges into closed rings. Where several edges leave a
+// vertex the sharpest left turn is taken, which keeps the area on the left and splits rings that
+// touch at a single point.
+func traceRings(edges map[[2][2]float64]int) [][][2]float64 {
+	next := map[[2]float64][][2]float64{}
+	for edge := range edges {
+		next[edge[0]] = append(next[edge[0]], edge[1])
+	}
+	starts := make([][2]float64, 0, len(next))
+	for from, targets := range next {
+		starts = append(starts, from)
+		sort.Slice(targets, func(i, j int) bool { return lessPosition(targets[i], targets[j]) })
+	}
+	sort.Slice(starts, func(i, j int) bool { return lessPosition(starts[i], starts[j]) })
+
+	take := func(from [2]float64, i int) [2]float64 {
+		targets := next[from]
+		to := targets[i]
+		next[from] = append(targets[:i], targets[i+1:]...)
+		return to
+	}
+
+	var rings [][][2]float64
+	for _, start := range starts {
+		for len(next[start]) > 0 {
+			ring := [][2]float64{start}
+			prev, current := start, take(start, 0)
+			for steps := 0; steps <= len(edges); steps++ {
+				ring = append(ring, current)
+				if current == start || len(next[current]) == 0 {
+					break
+				}
+				best, bestTurn := 0, math.Inf(-1)
+				dx, dy := current[0]-prev[0], current[1]-prev[1]
+				for i, to := range next[current] {
+					ex, ey := to[0]-current[0], to[1]-current[1]
+					if turn := math.Atan2(dx*ey-dy*ex, dx*ex+dy*ey); turn > bestTurn {
+						best, bestTurn = i, turn
+					}
+				}
+				prev, current = current, take(current, best)
+			}
+			if current == start && len(ring) >= 4 {
+				rings = append(rings, ring)
+			}
+		}
+	}
+	return rings
+}
+
+// vertexSnapper merges vertices within a tolerance of each other, remembering every distinct
+// vertex so edges can be split where another ring's vertex lies on them
+type vertexSnapper struct {
+	tolerance float64
+	cells     map[[2]int64][][2]float64
+	sorted    [][2]float64
+	dirty     bool
+}
+
+func newVertexSnapper(tolerance float64) *vertexSnapper {
+	return &vertexSnapper{tolerance: tolerance, cells: map[[2]int64][][2]float64{}}
+}
+
+// cell returns the grid cell of p; cells are one tolerance wide
+func (s *vertexSnapper) cell(p [2]float64) [2]int64 {
+	if s.tolerance == 0 {
+		return [2]int64{int64(math.Float64bits(p[0])), int64(math.Float64bits(p[1]))}
+	}
+	return [2]int64{int64(math.Floor(p[0] / s.tolerance)), int64(math.Floor(p[1] / s.tolerance))}
+}
+
+// snap returns the first vertex seen within tolerance of p, or p itself
+func (s *vertexSnapper) snap(point orb.Point) [2]float64 {
+	p := [2]float64{point[0], point[1]}
+	c := s.cell(p)
+	if s.tolerance > 0 {
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for _, q := range s.cells[[2]int64{c[0] + dx, c[1] + dy}] {
+					if math.Hypot(q[0]-p[0], q[1]-p[1]) <= s.tolerance {
+						return q
+					}
+				}
+			}
+		}
+	} else if existing := s.cells[c]; len(existing) > 0 {
+		return existing[0]
+	}
+	s.cells[c] = append(s.cells[c], p)
+	s.sorted = append(s.sorted, p)
+	s.dirty = true
+	return p
+}
+
+// split returns the points from a (exclusive) to b (inclusive), with every known vertex that lies
+// within tolerance of the segment inserted in order
+func (s *vertexSnapper) split(a, b [2]float64) [][2]float64 {
+	if s.dirty {
+		sort.Slice(s.sorted, func(i, j int) bool { return lessPosition(s.sorted[i], s.sorted[j]) })
+		s.dirty = false
+	}
+	minX, maxX := math.Min(a[0], b[0])-s.tolerance, math.Max(a[0], b[0])+s.tolerance
+	minY, maxY := math.Min(a[1], b[1])-s.tolerance, math.Max(a[1], b[1])+s.tolerance
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	length2 := dx*dx + dy*dy
+
+	type stop struct {
+		t float64
+		p [2]float64
+	}
+	var stops []stop
+	first := sort.Search(len(s.sorted), func(i int) bool { return s.sorted[i][0] >= minX })
+	for _, p := range s.sorted[first:] {
+		if p[0] > maxX {
+			break
+		}
+		if p[1] < minY || p[1] > maxY || p == a || p == b {
+			continue
+		}
+		t := ((p[0]-a[0])*dx + (p[1]-a[1])*dy) / length2
+		if t <= 0 || t >= 1 {
+			continue
+		}
+		if math.Hypot(a[0]+t*dx-p[0], a[1]+t*dy-p[1]) <= s.tolerance {
+			stops = append(stops, stop{t, p})
+		}
+	}
+	sort.Slice(stops, func(i, j int) bool { return stops[i].t < stops[j].t })
+
+	points := make([][2]float64, 0, len(stops)+1)
+	for _, st := range stops {
+		points = append(points, st.p)
+	}
+	return append(points, b)
+}
+
+// signedArea2 is ringSignedArea for [2]float64 rings
+func signedArea2(ring [][2]float64) float64 {
+	area := 0.0
+	for i := 0; i < len(ring)-1; i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return area / 2
+}
+
+// lessPosition orders positions by x, then y
+func lessPosition(a, b [2]float64) bool {
+	return a[0] < b[0] || (a[0] == b[0] && a[1] < b[1])
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// squareRing returns a counter-clockwise GeoJSON ring for an axis-aligned rectangle
+func squareRing(minX, minY, maxX, maxY float64) string {
+	return fmt.Sprintf("[[%g,%g],[%g,%g],[%g,%g],[%g,%g],[%g,%g]]",
+		minX, minY, maxX, minY, maxX, maxY, minX, maxY, minX, minY)
+}
+
+func TestDissolveUnion(t *testing.T) {
+	tests := []struct {
+		name     string
+		polygons []string // Polygon coordinates, all dissolved into one feature
+		parts    int
+		holes    int
+		area     float64
+	}{
+		{
+			name:     "shared edge",
+			polygons: []string{"[" + squareRing(0, 0, 10, 10) + "]", "[" + squareRing(10, 0, 20, 10) + "]"},
+			parts:    1,
+			area:     200,
+		},
+		{
+			name:     "overlapping",
+			polygons: []string{"[" + squareRing(0, 0, 10, 10) + "]", "[" + squareRing(5, 5, 15, 15) + "]"},
+			parts:    1,
+			area:     175,
+		},
+		{
+			name:     "crossing without shared vertices",
+			polygons: []string{"[" + squareRing(0, 4, 10, 6) + "]", "[" + squareRing(4, 0, 6, 10) + "]"},
+			parts:    1,
+			area:     36,
+		},
+		{
+			name:     "identical",
+			polygons: []string{"[" + squareRing(0, 0, 10, 10) + "]", "[" + squareRing(0, 0, 10, 10) + "]"},
+			parts:    1,
+			area:     100,
+		},
+		{
+			name:     "contained",
+			polygons: []string{"[" + squareRing(0, 0, 10, 10) + "]", "[" + squareRing(2, 2, 4, 4) + "]"},
+			parts:    1,
+			area:     100,
+		},
+		{
+			name:     "hole filled by another polygon",
+			polygons: []string{"[" + squareRing(0, 0, 10, 10) + "," + squareRing(4, 4, 6, 6) + "]", "[" + squareRing(3, 3, 7, 7) + "]"},
+			parts:    1,
+			area:     100,
+		},
+		{
+			name:     "hole partly filled",
+			polygons: []string{"[" + squareRing(0, 0, 10, 10) + "," + squareRing(4, 4, 6, 6) + "]", "[" + squareRing(5, 5, 7, 7) + "]"},
+			parts:    1,
+			holes:    1,
+			area:     97,
+		},
+		{
+			name:     "disjoint",
+			polygons: []string{"[" + squareRing(0, 0, 10, 10) + "]", "[" + squareRing(20, 0, 30, 10) + "]"},
+			parts:    2,
+			area:     200,
+		},
+		{
+			name:     "gap within tolerance",
+			polygons: []string{"[" + squareRing(0, 0, 10, 10) + "]", "[" + squareRing(10.00000001, 0, 20, 10) + "]"},
+			parts:    1,
+			area:     200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			features := ""
+			for i, coordinates := range tt.polygons {
+				if i > 0 {
+					features += ","
+				}
+				features += `{"type":"Feature","properties":{"county":"A"},"geometry":{"type":"Polygon","coordinates":` + coordinates + `}}`
+			}
+			var input map[string]interface{}
+			if err := json.Unmarshal([]byte(`{"type":"FeatureCollection","features":[`+features+`]}`), &input); err != nil {
+				t.Fatal(err)
+			}
+
+			a := NewApp()
+			result, err := a.DissolveByAttribute(input, "county")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if issues, err := a.ValidateGeometry(result); err != nil || len(issues) > 0 {
+				t.Errorf("dissolved geometry is invalid: %v %v", issues, err)
+			}
+
+			out, _ := geojsonFeatures(result)
+			if len(out) != 1 {
+				t.Fatalf("got %d features, want 1", len(out))
+			}
+			var polygons [][][][]float64
+			geometry := out[0]["geometry"].(map[string]interface{})
+			if geometry["type"] == "Polygon" {
+				var rings [][][]float64
+				decodeCoordinates(geometry["coordinates"], &rings)
+				polygons = append(polygons, rings)
+			} else {
+				decodeCoordinates(geometry["coordinates"], &polygons)
+			}
+
+			holes, area := 0, 0.0
+			for _, rings := range polygons {
+				holes += len(rings) - 1
+				for _, ring := range rings {
+					area += ringSignedArea(ring)
+				}
+			}
+			if len(polygons) != tt.parts || holes != tt.holes {
+				t.Errorf("got %d parts with %d holes, want %d with %d: %v", len(polygons), holes, tt.parts, tt.holes, polygons)
+			}
+			if math.Abs(area-tt.area) > 1e-6 {
+				t.Errorf("area = %g, want %g", area, tt.area)
+			}
+		})
+	}
+}
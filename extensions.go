@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// supportedExtensionsSettingKey stores the extension list as JSON in the settings table once the
+// user has changed it
+const supportedExtensionsSettingKey = "supported_extensions"
+
+// SupportedExtension maps a file extension to the type it is indexed as. Extensions in the
+// "images" or "csv" group are only indexed when CreateIndex is asked to include that group.
+type SupportedExtension struct {
+	Extension string `json:"extension"`
+	FileType  string `json:"file_type"`
+	Group     string `json:"group,omitempty"`
+}
+
+// supportedFileTypes are the file types an extension can map to
+var supportedFileTypes = map[string]bool{"vector": true, "raster": true, "point_cloud": true, "other": true}
+
+var extensionPattern = regexp.MustCompile(`^\.[a-z0-9_]+$`)
+
+// defaultSupportedExtensions is the list used until the user changes it
+var defaultSupportedExtensions = []SupportedExtension{
+	{".shp", "vector", ""}, {".geojson", "vector", ""}, {".topojson", "vector", ""}, {".fgb", "vector", ""},
+	{".parquet", "vector", ""}, {".kml", "vector", ""}, {".gpx", "vector", ""}, {".gpkg", "vector", ""},
+	{".gdb", "vector", ""}, {".gml", "vector", ""}, {".dxf", "vector", ""}, {".geopackage", "other", ""},
+	{".tif", "raster", ""}, {".tiff", "raster", ""}, {".vrt", "raster", ""},
+	{".las", "point_cloud", ""}, {".laz", "point_cloud", ""}, {".ply", "point_cloud", ""},
+	{".xyz", "point_cloud", ""}, {".asc", "point_cloud", ""},
+	{".png", "raster", "images"}, {".jpg", "raster", "images"}, {".jpeg", "raster", "images"},
+	{".gif", "raster", "images"}, {".bmp", "raster", "images"}, {".jp2", "raster", "images"},
+	{".csv", "vector", "csv"}, {".xlsx", "other", "csv"}, {".xls", "other", "csv"},
+}
+
+// supportedExtensionState caches the extension list read from settings
+type supportedExtensionState struct {
+	mu     sync.Mutex
+	loaded bool
+	list   []SupportedExtension
+}
+
+// supportedExtensions returns the configured extension list, reading it from settings on first use
+func (a *App) supportedExtensions() []SupportedExtension {
+	a.extensions.mu.Lock()
+	if a.extensions.loaded {
+		list := a.extensions.list
+		a.extensions.mu.Unlock()
+		return list
+	}
+	a.extensions.mu.Unlock()
+
+	list := defaultSupportedExtensions
+	if value, found, err := a.getSetting(supportedExtensionsSettingKey); err == nil && found {
+		var stored []SupportedExtension
+		if err := json.Unmarshal([]byte(value), &stored); err != nil {
+			a.logWarn("Ignoring stored extension list: %v", err)
+		} else {
+			list = stored
+		}
+	}
+
+	a.extensions.mu.Lock()
+	defer a.extensions.mu.Unlock()
+	if !a.extensions.loaded {
+		a.extensions.list = list
+		a.extensions.loaded = true
+	}
+	return a.extensions.list
+}
+
+// saveSupportedExtensions stores and caches a changed extension list
+func (a *App) saveSupportedExtensions(list []SupportedExtension) error {
+	sort.Slice(list, func(i, j int) bool { return list[i].Extension < list[j].Extension })
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to encode extension list: %v", err)
+	}
+	if err := a.setSetting(supportedExtensionsSettingKey, string(data)); err != nil {
+		return err
+	}
+
+	a.extensions.mu.Lock()
+	a.extensions.list = list
+	a.extensions.loaded = true
+	a.extensions.mu.Unlock()
+	return nil
+}
+
+// indexedExtensions returns the extensions CreateIndex looks for
+func (a *App) indexedExtensions(includeImages bool, includeCSV bool) map[string]bool {
+	extensions := map[string]bool{}
+	for _, entry := range a.supportedExtensions() {
+		switch {
+		case entry.Group == "images" && !includeImages:
+		case entry.Group == "csv" && !includeCSV:
+		default:
+			extensions[entry.Extension] = true
+		}
+	}
+	return extensions
+}
+
+// normalizeExtension lower-cases an extension and adds the leading dot
+func normalizeExtension(ext string) (string, error) {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	if !extensionPattern.MatchString(ext) {
+		return "", fmt.Errorf("invalid extension %q", ext)
+	}
+	return ext, nil
+}
+
+// GetSupportedExtensions returns the extensions indexing looks for and the type of each, sorted
+func (a *App) GetSupportedExtensions() []SupportedExtension {
+	list := append([]SupportedExtension(nil), a.supportedExtensions()...)
+	sort.Slice(list, func(i, j int) bool { return list[i].Extension < list[j].Extension })
+	return list
+}
+
+// AddSupportedExtension adds an extension such as ".dgn" to the index as a vector, raster,
+// point_cloud or other file, or changes the type of one already listed. The next CreateIndex
+// picks it up.
+func (a *App) AddSupportedExtension(ext string, fileType string) error {
+	ext, err := normalizeExtension(ext)
+	if err != nil {
+		return err
+	}
+	fileType = strings.ToLower(strings.TrimSpace(fileType))
+	if !supportedFileTypes[fileType] {
+		return fmt.Errorf("unknown file type %q (expected vector, raster, point_cloud or other)", fileType)
+	}
+
+	list := append([]SupportedExtension(nil), a.supportedExtensions()...)
+	found := false
+	for i := range list {
+		if list[i].Extension == ext {
+			list[i].FileType = fileType
+			found = true
+		}
+	}
+	if !found {
+		list = append(list, SupportedExtension{Extension: ext, FileType: fileType})
+	}
+	if err := a.saveSupportedExtensions(list); err != nil {
+		return err
+	}
+	a.logInfo("Indexing %s files as %s", ext, fileType)
+	return nil
+}
+
+// RemoveSupportedExtension stops indexing an extension. Files already in the index stay until
+// the next CreateIndex.
+func (a *App) RemoveSupportedExtension(ext string) error {
+	ext, err := normalizeExtension(ext)
+	if err != nil {
+		return err
+	}
+
+	list := []SupportedExtension{}
+	for _, entry := range a.supportedExtensions() {
+		if entry.Extension != ext {
+			list = append(list, entry)
+		}
+	}
+	if len(list) == len(a.supportedExtensions()) {
+		return fmt.Errorf("%s is not a supported extension", ext)
+	}
+	if err := a.saveSupportedExtensions(list); err != nil {
+		return err
+	}
+	a.logInfo("No longer indexing %s files", ext)
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// defaultExtractionTimeout is how long CreateIndex waits for one file's metadata
+const defaultExtractionTimeout = 30 * time.Second
+
+// extractionTimeoutSettingKey stores the configured extraction timeout, in seconds, in the
+// settings table
+const extractionTimeoutSettingKey = "index_extraction_timeout_seconds"
+
+// errExtractionTimeout is wrapped by the error returned when a file's metadata takes too long
+var errExtractionTimeout = errors.New("metadata extraction timed out")
+
+// extractionTimeout returns how long CreateIndex waits for one file's metadata
+func (a *App) extractionTimeout() time.Duration {
+	if value, found, err := a.getSetting(extractionTimeoutSettingKey); err == nil && found {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultExtractionTimeout
+}
+
+// GetExtractionTimeout returns how many seconds indexing spends on one file's metadata before
+// skipping it
+func (a *App) GetExtractionTimeout() int {
+	return int(a.extractionTimeout().Seconds())
+}
+
+// SetExtractionTimeout changes how many seconds indexing spends on one file's metadata before
+// skipping it
+func (a *App) SetExtractionTimeout(seconds int) error {
+	if seconds < 1 || seconds > 60*60 {
+		return fmt.Errorf("extraction timeout must be between 1 second and 1 hour")
+	}
+	return a.setSetting(extractionTimeoutSettingKey, strconv.Itoa(seconds))
+}
+
+// extractFileMetadataWithin runs extractFileMetadata, giving up after timeout or once ctx is
+// cancelled. Giving up cancels the extraction, which kills any GDAL process it started and stops
+// native readers at their next check, so an abandoned file doesn't keep running in the background.
+func (a *App) extractFileMetadataWithin(ctx context.Context, filePath string, timeout time.Duration) (*FileMetadata, error) {
+	parent := ctx
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		metadata *FileMetadata
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		metadata, err := a.extractFileMetadata(ctx, filePath)
+		done <- result{metadata, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.metadata, r.err
+	case <-ctx.Done():
+		if parent.Err() != nil {
+			return nil, fmt.Errorf("metadata extraction cancelled: %w", parent.Err())
+		}
+		return nil, fmt.Errorf("%w after %s", errExtractionTimeout, timeout)
+	}
+}
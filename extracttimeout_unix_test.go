@@ -0,0 +1,85 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestExtractFileMetadataWithinKillsGDAL(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		cancel  bool
+		want    error
+	}{
+		{name: "timeout", timeout: 300 * time.Millisecond, want: errExtractionTimeout},
+		// Shutdown cancels the caller's context long before the extraction timeout
+		{name: "cancelled", timeout: time.Minute, cancel: true, want: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			pidFile := filepath.Join(dir, "ogrinfo.pid")
+			// ogrinfo answers the version probe, then hangs on the file itself
+			ogrinfo := "#!/bin/sh\nif [ \"$1\" = --version ]; then echo 'GDAL 3.8.4, released 2024/02/08'; exit 0; fi\n" +
+				"echo $$ > " + pidFile + "\nexec sleep 60\n"
+			if err := os.WriteFile(filepath.Join(dir, "ogrinfo"), []byte(ogrinfo), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "ogr2ogr"), []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+			gml := filepath.Join(dir, "roads.gml")
+			if err := os.WriteFile(gml, []byte("<gml:FeatureCollection/>"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			a := NewApp()
+			// Only the extraction timeout or the caller should stop ogrinfo here
+			a.gdalTimeoutValue = time.Minute
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel {
+				time.AfterFunc(300*time.Millisecond, cancel)
+			}
+			start := time.Now()
+			_, err := a.extractFileMetadataWithin(ctx, gml, tt.timeout)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("extractFileMetadataWithin error = %v, want %v", err, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("extractFileMetadataWithin took %s", elapsed)
+			}
+
+			var pid int
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				if data, err := os.ReadFile(pidFile); err == nil {
+					if pid, err = strconv.Atoi(strings.TrimSpace(string(data))); err == nil && !processAlive(pid) {
+						return
+					}
+				}
+				if time.Now().After(deadline) {
+					if pid > 0 {
+						syscall.Kill(pid, syscall.SIGKILL)
+					}
+					t.Fatalf("ogrinfo (pid %d) is still running after the extraction stopped", pid)
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FieldInfo describes one attribute column of a vector layer. Type is string, int, real, date
+// or bool where the source type maps onto one of those, otherwise the source type in lower case.
+// Nullable is omitted when the format doesn't say.
+type FieldInfo struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	Width     int    `json:"width,omitempty"`
+	Precision int    `json:"precision,omitempty"`
+	Nullable  *bool  `json:"nullable,omitempty"`
+}
+
+// ogrFieldPattern matches field lines of `ogrinfo -so`, e.g. "name: String (80.0) NOT NULL"
+var ogrFieldPattern = regexp.MustCompile(`^(\S.*?): (\w+)(?:\((\w+)\))? \((\d+)\.(\d+)\)(.*)$`)
+
+// isoDatePattern recognises dates and timestamps stored as GeoJSON strings
+var isoDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?)?$`)
+
+// GetLayerFields returns the attribute schema of a vector layer without loading its features.
+// The schema stored at index time is used when there is one; otherwise it is read from the file
+// and saved to the index. layerName selects a layer in multi-layer formats and may be empty.
+func (a *App) GetLayerFields(filePath string, layerName string) ([]FieldInfo, error) {
+	if metadata, ok := a.indexedMetadata(filePath); ok {
+		if fields, ok := storedLayerFields(metadata, layerName); ok {
+			return fields, nil
+		}
+	}
+
+	fields, err := a.readLayerFields(filePath, layerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.db != nil {
+		err := a.updateIndexedMetadata(filePath, func(metadata map[string]interface{}) {
+			if layerName == "" {
+				metadata["fields"] = fields
+				return
+			}
+			layers, _ := metadata["layer_fields"].(map[string]interface{})
+			if layers == nil {
+				layers = map[string]interface{}{}
+			}
+			layers[layerName] = fields
+			metadata["layer_fields"] = layers
+		})
+		if err != nil {
+			a.logWarn("Failed to store field schema for %s: %v", filePath, err)
+		}
+	}
+	return fields, nil
+}
+
+// storedLayerFields decodes the schema saved in index metadata, preferring the named layer's
+func storedLayerFields(metadata map[string]interface{}, layerName string) ([]FieldInfo, bool) {
+	var stored interface{}
+	if layers, ok := metadata["layer_fields"].(map[string]interface{}); ok && layerName != "" {
+		stored = layers[layerName]
+	}
+	if stored == nil {
+		stored = metadata["fields"]
+	}
+	if stored == nil {
+		return nil, false
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return nil, false
+	}
+	var fields []FieldInfo
+	if err := json.Unmarshal(data, &fields); err != nil || fields == nil {
+		return nil, false
+	}
+	return fields, true
+}
+
+// readLayerFields reads the schema natively where possible and from ogrinfo otherwise
+func (a *App) readLayerFields(filePath string, layerName string) ([]FieldInfo, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, fmt.Errorf("file not found: %v", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".shp", ".dbf":
+		return shapefileFields(filePath)
+	case ".geojson", ".json":
+		return geoJSONFields(filePath)
+	case ".fgb":
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		header, err := readFlatGeobufHeader(file)
+		if err != nil {
+			return nil, err
+		}
+		return flatGeobufFields(header.Columns), nil
+	case ".gpkg":
+		layers, err := geoPackageFields(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if fields, ok := layers[layerName]; ok {
+			return fields, nil
+		}
+		if layerName == "" && len(layers) == 1 {
+			for _, fields := range layers {
+				return fields, nil
+			}
+		}
+		return nil, fmt.Errorf("layer %q not found in %s", layerName, filepath.Base(filePath))
+	}
+	return a.ogrinfoFields(filePath, layerName)
+}
+
+// shapefileFields reads the field descriptors from the .dbf header
+func shapefileFields(filePath string) ([]FieldInfo, error) {
+	dbfPath := filePath
+	if !strings.EqualFold(filepath.Ext(filePath), ".dbf") {
+		dbfPath = shapefileSidecar(filePath, ".dbf")
+		if dbfPath == "" {
+			// A shapefile without a .dbf has no attributes
+			return []FieldInfo{}, nil
+		}
+	}
+
+	file, err := os.Open(dbfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DBF: %v", err)
+	}
+	defer file.Close()
+
+	dbfFields, _, _, _, err := readDBFFileHeader(file)
+	if err != nil {
+		return nil, err
+	}
+	fields := make([]FieldInfo, 0, len(dbfFields))
+	for _, field := range dbfFields {
+		info := FieldInfo{Name: field.Name, Width: field.Length, Precision: field.Decimals}
+		switch field.Type {
+		case 'C':
+			info.Type = "string"
+		case 'N':
+			info.Type = "int"
+			if field.Decimals > 0 {
+				info.Type = "real"
+			}
+		case 'F':
+			info.Type = "real"
+		case 'D':
+			info.Type = "date"
+		case 'L':
+			info.Type = "bool"
+		default:
+			info.Type = strings.ToLower(string(field.Type))
+		}
+		fields = append(fields, info)
+	}
+	return fields, nil
+}
+
+// geoJSONFields infers the schema from the first feature's properties
+func geoJSONFields(filePath string) ([]FieldInfo, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fields := []FieldInfo{}
+	errFirstFeature := fmt.Errorf("first feature read")
+	err = readGeoJSONFeatures(bufio.NewReader(file), func(feature map[string]interface{}) error {
+		properties, _ := feature["properties"].(map[string]interface{})
+		fields = propertyFields(properties)
+		return errFirstFeature
+	})
+	if err != nil && err != errFirstFeature {
+		return nil, fmt.Errorf("failed to read GeoJSON: %v", err)
+	}
+	return fields, nil
+}
+
+// propertyFields describes a GeoJSON properties object. Only explicit nulls say anything about
+// nullability, and a null value's type is unknown, so it is reported as string.
+func propertyFields(properties map[string]interface{}) []FieldInfo {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fields := make([]FieldInfo, 0, len(names))
+	for _, name := range names {
+		info := FieldInfo{Name: name}
+		switch value := properties[name].(type) {
+		case nil:
+			info.Type = "string"
+			nullable := true
+			info.Nullable = &nullable
+		case bool:
+			info.Type = "bool"
+		case float64:
+			info.Type = "real"
+			if value == float64(int64(value)) {
+				info.Type = "int"
+			}
+		case string:
+			info.Type = "string"
+			if isoDatePattern.MatchString(value) {
+				info.Type = "date"
+			}
+		default:
+			info.Type = "json"
+		}
+		fields = append(fields, info)
+	}
+	return fields
+}
+
+// flatGeobufFields maps FlatGeobuf header columns, which declare their nullability
+func flatGeobufFields(columns []fgbColumn) []FieldInfo {
+	fields := make([]FieldInfo, 0, len(columns))
+	for _, col := range columns {
+		info := FieldInfo{Name: col.Name}
+		switch col.Type {
+		case fgbColByte, fgbColUByte, fgbColShort, fgbColUShort, fgbColInt, fgbColUInt, fgbColLong, fgbColULong:
+			info.Type = "int"
+		case fgbColFloat, fgbColDouble:
+			info.Type = "real"
+		case fgbColBool:
+			info.Type = "bool"
+		case fgbColDateTime:
+			info.Type = "date"
+		case fgbColJSON:
+			info.Type = "json"
+		case fgbColBinary:
+			info.Type = "binary"
+		default:
+			info.Type = "string"
+		}
+		nullable := col.Nullable
+		info.Nullable = &nullable
+		fields = append(fields, info)
+	}
+	return fields
+}
+
+// geoPackageFields reads the columns of every feature table, leaving out the primary key and
+// geometry column
+func geoPackageFields(filePath string) (map[string][]FieldInfo, error) {
+	db, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(filePath)+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("SELECT table_name, column_name FROM gpkg_geometry_columns")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GeoPackage layers: %v", err)
+	}
+	geometryColumns := map[string]string{}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read GeoPackage layers: %v", err)
+		}
+		geometryColumns[table] = column
+	}
+	rows.Close()
+
+	layers := map[string][]FieldInfo{}
+	for table, geometryColumn := range geometryColumns {
+		columns, err := db.Query("PRAGMA table_info(" + sqlIdent(table) + ")")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read columns of %s: %v", table, err)
+		}
+		fields := []FieldInfo{}
+		for columns.Next() {
+			var cid, notNull, pk int
+			var name, columnType string
+			var defaultValue sql.NullString
+			if err := columns.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk); err != nil {
+				columns.Close()
+				return nil, fmt.Errorf("failed to read columns of %s: %v", table, err)
+			}
+			if pk > 0 || strings.EqualFold(name, geometryColumn) {
+				continue
+			}
+			info := geoPackageField(name, columnType)
+			nullable := notNull == 0
+			info.Nullable = &nullable
+			fields = append(fields, info)
+		}
+		columns.Close()
+		layers[table] = fields
+	}
+	return layers, nil
+}
+
+// geoPackageField maps a GeoPackage column type such as TEXT(40) or DATETIME
+func geoPackageField(name string, columnType string) FieldInfo {
+	info := FieldInfo{Name: name}
+	base := strings.ToUpper(strings.TrimSpace(columnType))
+	if i := strings.Index(base, "("); i >= 0 {
+		fmt.Sscanf(base[i:], "(%d)", &info.Width)
+		base = strings.TrimSpace(base[:i])
+	}
+	switch base {
+	case "TEXT":
+		info.Type = "string"
+	case "INTEGER", "INT", "MEDIUMINT", "SMALLINT", "TINYINT":
+		info.Type = "int"
+	case "REAL", "DOUBLE", "FLOAT":
+		info.Type = "real"
+	case "DATE", "DATETIME":
+		info.Type = "date"
+	case "BOOLEAN":
+		info.Type = "bool"
+	case "BLOB":
+		info.Type = "binary"
+	default:
+		info.Type = strings.ToLower(base)
+	}
+	return info
+}
+
+// ogrinfoFields reads the schema from the `ogrinfo -so` summary of the layer, or of the first
+// layer when layerName is empty
+func (a *App) ogrinfoFields(filePath string, layerName string) ([]FieldInfo, error) {
+	output, err := a.ogrinfoSummary(filePath, layerName)
+	if err != nil {
+		return nil, err
+	}
+	return parseOgrinfoFields(output), nil
+}
+
+// ogrinfoSummary runs `ogrinfo -so` on one layer, or on all layers when layerName is empty.
+// Extra options such as -where go before the file name.
+func (a *App) ogrinfoSummary(filePath string, layerName string, options ...string) (string, error) {
+	return a.ogrinfoSummaryContext(context.Background(), filePath, layerName, options...)
+}
+
+// ogrinfoSummaryContext is ogrinfoSummary with ogrinfo killed once ctx is cancelled
+func (a *App) ogrinfoSummaryContext(ctx context.Context, filePath string, layerName string, options ...string) (string, error) {
+	input, err := gdalInputPath(filePath)
+	if err != nil {
+		return "", err
+	}
+	args := append([]string{"-ro", "-so"}, options...)
+	if layerName == "" {
+		args = append(args, "-al", input)
+	} else {
+		args = append(args, input, layerName)
+	}
+	output, err := a.runGDALToolContext(ctx, "ogrinfo", "", args...)
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// parseOgrinfoFields picks the field lines of the first layer out of `ogrinfo -so` output
+func parseOgrinfoFields(output string) []FieldInfo {
+	fields := []FieldInfo{}
+	layers := 0
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "Layer name:") {
+			layers++
+			if layers > 1 {
+				break
+			}
+			continue
+		}
+		match := ogrFieldPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		info := FieldInfo{Name: match[1]}
+		fmt.Sscan(match[4], &info.Width)
+		fmt.Sscan(match[5], &info.Precision)
+		switch {
+		case match[3] == "Boolean":
+			info.Type = "bool"
+		case match[3] == "JSON":
+			info.Type = "json"
+		case match[2] == "String":
+			info.Type = "string"
+		case match[2] == "Integer" || match[2] == "Integer64":
+			info.Type = "int"
+		case match[2] == "Real":
+			info.Type = "real"
+		case match[2] == "Date" || match[2] == "DateTime" || match[2] == "Time":
+			info.Type = "date"
+		default:
+			info.Type = strings.ToLower(match[2])
+		}
+		nullable := !strings.Contains(match[6], "NOT NULL")
+		info.Nullable = &nullable
+		fields = append(fields, info)
+	}
+	return fields
+}
@@ -0,0 +1,662 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// FlatGeobuf geometry type codes (GeometryType enum in header.fbs)
+const (
+	fgbUnknown            = 0
+	fgbPoint              = 1
+	fgbLineString         = 2
+	fgbPolygon            = 3
+	fgbMultiPoint         = 4
+	fgbMultiLineString    = 5
+	fgbMultiPolygon       = 6
+	fgbGeometryCollection = 7
+)
+
+// FlatGeobuf column type codes (ColumnType enum in header.fbs)
+const (
+	fgbColByte = iota
+	fgbColUByte
+	fgbColBool
+	fgbColShort
+	fgbColUShort
+	fgbColInt
+	fgbColUInt
+	fgbColLong
+	fgbColULong
+	fgbColFloat
+	fgbColDouble
+	fgbColString
+	fgbColJSON
+	fgbColDateTime
+	fgbColBinary
+)
+
+// fgbNodeItemSize is the size of one packed R-tree node: 4 doubles for the envelope + uint64 offset
+const fgbNodeItemSize = 40
+
+var fgbGeometryTypeNames = map[byte]string{
+	fgbUnknown:            "Unknown",
+	fgbPoint:              "Point",
+	fgbLineString:         "LineString",
+	fgbPolygon:            "Polygon",
+	fgbMultiPoint:         "MultiPoint",
+	fgbMultiLineString:    "MultiLineString",
+	fgbMultiPolygon:       "MultiPolygon",
+	fgbGeometryCollection: "GeometryCollection",
+}
+
+// fgbColumn is an attribute column declared in the FlatGeobuf header
+type fgbColumn struct {
+	Name     string
+	Type     byte
+	Nullable bool
+}
+
+// fgbHeader holds the decoded FlatGeobuf header plus the byte offsets of the index and features
+type fgbHeader struct {
+	Name           string
+	Envelope       []float64
+	GeometryType   byte
+	HasZ           bool
+	Columns        []fgbColumn
+	FeaturesCount  uint64
+	IndexNodeSize  uint16
+	CRS            string
+	indexOffset    int64
+	featuresOffset int64
+}
+
+// fbTable wraps a flatbuffers table with slot-based accessors, standing in for flatc-generated code
+type fbTable struct {
+	flatbuffers.Table
+}
+
+func newFBTable(buf []byte, pos flatbuffers.UOffsetT) fbTable {
+	return fbTable{flatbuffers.Table{Bytes: buf, Pos: pos}}
+}
+
+// field returns the offset of the field in the given vtable slot, or 0 when absent
+func (t fbTable) field(slot int) flatbuffers.UOffsetT {
+	return flatbuffers.UOffsetT(t.Offset(flatbuffers.VOffsetT(4 + 2*slot)))
+}
+
+func (t fbTable) str(slot int) string {
+	if o := t.field(slot); o != 0 {
+		return t.String(o + t.Pos)
+	}
+	return ""
+}
+
+func (t fbTable) byteField(slot int, def byte) byte {
+	if o := t.field(slot); o != 0 {
+		return t.GetByte(o + t.Pos)
+	}
+	return def
+}
+
+func (t fbTable) uint16Field(slot int, def uint16) uint16 {
+	if o := t.field(slot); o != 0 {
+		return t.GetUint16(o + t.Pos)
+	}
+	return def
+}
+
+func (t fbTable) int32Field(slot int, def int32) int32 {
+	if o := t.field(slot); o != 0 {
+		return t.GetInt32(o + t.Pos)
+	}
+	return def
+}
+
+func (t fbTable) uint64Field(slot int, def uint64) uint64 {
+	if o := t.field(slot); o != 0 {
+		return t.GetUint64(o + t.Pos)
+	}
+	return def
+}
+
+func (t fbTable) float64s(slot int) []float64 {
+	o := t.field(slot)
+	if o == 0 {
+		return nil
+	}
+	start, n := t.Vector(o), t.VectorLen(o)
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = t.GetFloat64(start + flatbuffers.UOffsetT(i*8))
+	}
+	return values
+}
+
+func (t fbTable) uint32s(slot int) []uint32 {
+	o := t.field(slot)
+	if o == 0 {
+		return nil
+	}
+	start, n := t.Vector(o), t.VectorLen(o)
+	values := make([]uint32, n)
+	for i := range values {
+		values[i] = t.GetUint32(start + flatbuffers.UOffsetT(i*4))
+	}
+	return values
+}
+
+func (t fbTable) bytesField(slot int) []byte {
+	if o := t.field(slot); o != 0 {
+		return t.ByteVector(o + t.Pos)
+	}
+	return nil
+}
+
+func (t fbTable) table(slot int) (fbTable, bool) {
+	o := t.field(slot)
+	if o == 0 {
+		return fbTable{}, false
+	}
+	return newFBTable(t.Bytes, t.Indirect(o+t.Pos)), true
+}
+
+func (t fbTable) tables(slot int) []fbTable {
+	o := t.field(slot)
+	if o == 0 {
+		return nil
+	}
+	start, n := t.Vector(o), t.VectorLen(o)
+	tables := make([]fbTable, n)
+	for i := range tables {
+		tables[i] = newFBTable(t.Bytes, t.Indirect(start+flatbuffers.UOffsetT(i*4)))
+	}
+	return tables
+}
+
+// readFlatGeobufHeader reads the magic bytes and header and computes where the index and features start
+func readFlatGeobufHeader(file *os.File) (*fgbHeader, error) {
+	prefix := make([]byte, 12)
+	if _, err := file.ReadAt(prefix, 0); err != nil {
+		return nil, fmt.Errorf("failed to read FlatGeobuf header: %v", err)
+	}
+	if string(prefix[0:3]) != "fgb" || string(prefix[4:7]) != "fgb" {
+		return nil, fmt.Errorf("not a FlatGeobuf file")
+	}
+
+	headerSize := int64(binary.LittleEndian.Uint32(prefix[8:12]))
+	buf := make([]byte, headerSize)
+	if _, err := file.ReadAt(buf, 12); err != nil {
+		return nil, fmt.Errorf("failed to read FlatGeobuf header: %v", err)
+	}
+
+	root := newFBTable(buf, flatbuffers.GetUOffsetT(buf))
+	header := &fgbHeader{
+		Name:          root.str(0),
+		Envelope:      root.float64s(1),
+		GeometryType:  root.byteField(2, fgbUnknown),
+		HasZ:          root.byteField(3, 0) != 0,
+		FeaturesCount: root.uint64Field(8, 0),
+		IndexNodeSize: root.uint16Field(9, 16),
+	}
+
+	for _, col := range root.tables(7) {
+		header.Columns = append(header.Columns, fgbColumn{
+			Name:     col.str(0),
+			Type:     col.byteField(1, fgbColString),
+			Nullable: col.byteField(7, 1) != 0,
+		})
+	}
+
+	if crs, ok := root.table(10); ok {
+		org, code := crs.str(0), crs.int32Field(1, 0)
+		switch {
+		case code != 0:
+			if org == "" {
+				org = "EPSG"
+			}
+			header.CRS = fmt.Sprintf("%s:%d", strings.ToUpper(org), code)
+		case crs.str(5) != "":
+			header.CRS = crs.str(5)
+		case crs.str(4) != "":
+			header.CRS = crs.str(4)
+		}
+	}
+
+	header.indexOffset = 12 + headerSize
+	header.featuresOffset = header.indexOffset
+	if header.IndexNodeSize > 0 && header.FeaturesCount > 0 {
+		_, numNodes := fgbLevelBounds(header.FeaturesCount, header.IndexNodeSize)
+		header.featuresOffset += int64(numNodes) * fgbNodeItemSize
+	}
+
+	return header, nil
+}
+
+// fgbLevelBounds returns the [start, end) node ranges of each packed R-tree level, leaves first,
+// and the total node count
+func fgbLevelBounds(numItems uint64, nodeSize uint16) ([][2]uint64, uint64) {
+	size := uint64(nodeSize)
+	if size < 2 {
+		size = 2
+	}
+
+	// The root level always exists, even for a single feature
+	n := numItems
+	numNodes := n
+	levelNumNodes := []uint64{n}
+	for {
+		n = (n + size - 1) / size
+		numNodes += n
+		levelNumNodes = append(levelNumNodes, n)
+		if n == 1 {
+			break
+		}
+	}
+
+	bounds := make([][2]uint64, len(levelNumNodes))
+	offset := numNodes
+	for i, count := range levelNumNodes {
+		bounds[i] = [2]uint64{offset - count, offset}
+		offset -= count
+	}
+	return bounds, numNodes
+}
+
+// searchFlatGeobufIndex walks the packed Hilbert R-tree and returns the byte offsets (relative to
+// the features section) of every feature whose envelope intersects the bbox
+func searchFlatGeobufIndex(file *os.File, header *fgbHeader, bbox []float64) ([]uint64, error) {
+	levels, numNodes := fgbLevelBounds(header.FeaturesCount, header.IndexNodeSize)
+	nodeSize := uint64(header.IndexNodeSize)
+	leafStart := numNodes - header.FeaturesCount
+
+	type queued struct {
+		node  uint64
+		level int
+	}
+	queue := []queued{{0, len(levels) - 1}}
+	var offsets []uint64
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		end := current.node + nodeSize
+		if levelEnd := levels[current.level][1]; end > levelEnd {
+			end = levelEnd
+		}
+
+		// Read the whole node (all its children) in one go
+		buf := make([]byte, (end-current.node)*fgbNodeItemSize)
+		if _, err := file.ReadAt(buf, header.indexOffset+int64(current.node*fgbNodeItemSize)); err != nil {
+			return nil, fmt.Errorf("failed to read spatial index: %v", err)
+		}
+
+		for i := uint64(0); i < end-current.node; i++ {
+			item := buf[i*fgbNodeItemSize:]
+			minX, minY := readFloat64LE(item[0:]), readFloat64LE(item[8:])
+			maxX, maxY := readFloat64LE(item[16:]), readFloat64LE(item[24:])
+			if maxX < bbox[0] || minX > bbox[2] || maxY < bbox[1] || minY > bbox[3] {
+				continue
+			}
+
+			offset := binary.LittleEndian.Uint64(item[32:])
+			if current.node+i >= leafStart {
+				offsets = append(offsets, offset)
+			} else {
+				queue = append(queue, queued{offset, current.level - 1})
+			}
+		}
+	}
+
+	return offsets, nil
+}
+
+// readFlatGeobufFeature reads the size-prefixed feature at an absolute file position
+func readFlatGeobufFeature(file *os.File, header *fgbHeader, pos int64) (map[string]interface{}, int64, error) {
+	sizeBuf := make([]byte, 4)
+	if _, err := file.ReadAt(sizeBuf, pos); err != nil {
+		return nil, 0, err
+	}
+	size := int64(binary.LittleEndian.Uint32(sizeBuf))
+
+	buf := make([]byte, size)
+	if _, err := file.ReadAt(buf, pos+4); err != nil {
+		return nil, 0, fmt.Errorf("truncated feature at offset %d: %v", pos, err)
+	}
+
+	root := newFBTable(buf, flatbuffers.GetUOffsetT(buf))
+
+	columns := header.Columns
+	if featureColumns := root.tables(2); len(featureColumns) > 0 {
+		columns = nil
+		for _, col := range featureColumns {
+			columns = append(columns, fgbColumn{Name: col.str(0), Type: col.byteField(1, fgbColString)})
+		}
+	}
+
+	feature := map[string]interface{}{
+		"type":       "Feature",
+		"properties": decodeFlatGeobufProperties(root.bytesField(1), columns),
+		"geometry":   nil,
+	}
+	if geom, ok := root.table(0); ok {
+		feature["geometry"] = decodeFlatGeobufGeometry(geom, header.GeometryType)
+	}
+
+	return feature, 4 + size, nil
+}
+
+// decodeFlatGeobufGeometry converts a FlatGeobuf Geometry table into a GeoJSON geometry map
+func decodeFlatGeobufGeometry(g fbTable, geometryType byte) map[string]interface{} {
+	if t := g.byteField(6, fgbUnknown); t != fgbUnknown {
+		geometryType = t
+	}
+
+	xy := g.float64s(1)
+	z := g.float64s(2)
+	points := make([][]float64, len(xy)/2)
+	for i := range points {
+		points[i] = []float64{xy[2*i], xy[2*i+1]}
+		if i < len(z) {
+			points[i] = append(points[i], z[i])
+		}
+	}
+
+	// ends marks where each ring/line stops; a missing ends vector means one part
+	splitParts := func() [][][]float64 {
+		ends := g.uint32s(0)
+		if len(ends) == 0 {
+			return [][][]float64{points}
+		}
+		parts := make([][][]float64, 0, len(ends))
+		start := uint32(0)
+		for _, end := range ends {
+			if int(end) > len(points) || end < start {
+				break
+			}
+			parts = append(parts, points[start:end])
+			start = end
+		}
+		return parts
+	}
+
+	switch geometryType {
+	case fgbPoint:
+		if len(points) == 0 {
+			return nil
+		}
+		return map[string]interface{}{"type": "Point", "coordinates": points[0]}
+	case fgbMultiPoint:
+		return map[string]interface{}{"type": "MultiPoint", "coordinates": points}
+	case fgbLineString:
+		return map[string]interface{}{"type": "LineString", "coordinates": points}
+	case fgbMultiLineString:
+		return map[string]interface{}{"type": "MultiLineString", "coordinates": splitParts()}
+	case fgbPolygon:
+		return map[string]interface{}{"type": "Polygon", "coordinates": splitParts()}
+	case fgbMultiPolygon:
+		var polygons []interface{}
+		for _, part := range g.tables(7) {
+			if polygon := decodeFlatGeobufGeometry(part, fgbPolygon); polygon != nil {
+				polygons = append(polygons, polygon["coordinates"])
+			}
+		}
+		return map[string]interface{}{"type": "MultiPolygon", "coordinates": polygons}
+	case fgbGeometryCollection:
+		geometries := []interface{}{}
+		for _, part := range g.tables(7) {
+			if geometry := decodeFlatGeobufGeometry(part, fgbUnknown); geometry != nil {
+				geometries = append(geometries, geometry)
+			}
+		}
+		return map[string]interface{}{"type": "GeometryCollection", "geometries": geometries}
+	}
+
+	return nil
+}
+
+// decodeFlatGeobufProperties decodes the packed (column index, value) property buffer
+func decodeFlatGeobufProperties(buf []byte, columns []fgbColumn) map[string]interface{} {
+	properties := map[string]interface{}{}
+	pos := 0
+	for pos+2 <= len(buf) {
+		index := int(binary.LittleEndian.Uint16(buf[pos:]))
+		pos += 2
+		if index >= len(columns) {
+			break
+		}
+		column := columns[index]
+
+		var value interface{}
+		var size int
+		switch column.Type {
+		case fgbColByte:
+			size = 1
+			if pos+size <= len(buf) {
+				value = int8(buf[pos])
+			}
+		case fgbColUByte:
+			size = 1
+			if pos+size <= len(buf) {
+				value = buf[pos]
+			}
+		case fgbColBool:
+			size = 1
+			if pos+size <= len(buf) {
+				value = buf[pos] != 0
+			}
+		case fgbColShort:
+			size = 2
+			if pos+size <= len(buf) {
+				value = int16(binary.LittleEndian.Uint16(buf[pos:]))
+			}
+		case fgbColUShort:
+			size = 2
+			if pos+size <= len(buf) {
+				value = binary.LittleEndian.Uint16(buf[pos:])
+			}
+		case fgbColInt:
+			size = 4
+			if pos+size <= len(buf) {
+				value = int32(binary.LittleEndian.Uint32(buf[pos:]))
+			}
+		case fgbColUInt:
+			size = 4
+			if pos+size <= len(buf) {
+				value = binary.LittleEndian.Uint32(buf[pos:])
+			}
+		case fgbColLong:
+			size = 8
+			if pos+size <= len(buf) {
+				value = int64(binary.LittleEndian.Uint64(buf[pos:]))
+			}
+		case fgbColULong:
+			size = 8
+			if pos+size <= len(buf) {
+				value = binary.LittleEndian.Uint64(buf[pos:])
+			}
+		case fgbColFloat:
+			size = 4
+			if pos+size <= len(buf) {
+				value = math.Float32frombits(binary.LittleEndian.Uint32(buf[pos:]))
+			}
+		case fgbColDouble:
+			size = 8
+			if pos+size <= len(buf) {
+				value = readFloat64LE(buf[pos:])
+			}
+		default:
+			// String, Json, DateTime and Binary are length-prefixed
+			if pos+4 > len(buf) {
+				return properties
+			}
+			length := int(binary.LittleEndian.Uint32(buf[pos:]))
+			pos += 4
+			size = length
+			if pos+size <= len(buf) {
+				value = string(buf[pos : pos+size])
+			}
+		}
+
+		if pos+size > len(buf) {
+			break
+		}
+		properties[column.Name] = value
+		pos += size
+	}
+	return properties
+}
+
+// readFlatGeobuf loads every feature of a .fgb file as a GeoJSON FeatureCollection
+func readFlatGeobuf(filePath string) (map[string]interface{}, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open FlatGeobuf file: %v", err)
+	}
+	defer file.Close()
+
+	header, err := readFlatGeobufHeader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	features := []interface{}{}
+	pos := header.featuresOffset
+	for header.FeaturesCount == 0 || uint64(len(features)) < header.FeaturesCount {
+		feature, size, err := readFlatGeobufFeature(file, header, pos)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		features = append(features, feature)
+		pos += size
+	}
+
+	return flatGeobufCollection(filePath, header, features), nil
+}
+
+// FindFeaturesInBBox returns the features of a FlatGeobuf file intersecting bbox ([west, south, east, north]).
+// When the file has a spatial index only the matching features are read from disk.
+func (a *App) FindFeaturesInBBox(filePath string, bbox []float64) (map[string]interface{}, error) {
+	if len(bbox) != 4 {
+		return nil, fmt.Errorf("bbox must have 4 values [west, south, east, north]")
+	}
+	if strings.ToLower(filepath.Ext(filePath)) != ".fgb" {
+		return nil, fmt.Errorf("bbox search requires a FlatGeobuf (.fgb) file")
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open FlatGeobuf file: %v", err)
+	}
+	defer file.Close()
+
+	header, err := readFlatGeobufHeader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	features := []interface{}{}
+
+	// Without an index fall back to a sequential scan filtered by feature envelope
+	if header.IndexNodeSize == 0 || header.FeaturesCount == 0 {
+		collection, err := readFlatGeobuf(filePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range collection["features"].([]interface{}) {
+			feature := f.(map[string]interface{})
+			if g, err := orbGeometry(feature["geometry"]); err == nil && g != nil {
+				b := g.Bound()
+				if b.Max[0] >= bbox[0] && b.Min[0] <= bbox[2] && b.Max[1] >= bbox[1] && b.Min[1] <= bbox[3] {
+					features = append(features, feature)
+				}
+			}
+		}
+		return flatGeobufCollection(filePath, header, features), nil
+	}
+
+	offsets, err := searchFlatGeobufIndex(file, header, bbox)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, offset := range offsets {
+		feature, _, err := readFlatGeobufFeature(file, header, header.featuresOffset+int64(offset))
+		if err != nil {
+			return nil, err
+		}
+		features = append(features, feature)
+	}
+
+	return flatGeobufCollection(filePath, header, features), nil
+}
+
+// extractFlatGeobufMetadata reads feature count, extent, CRS and schema from the header only
+func (a *App) extractFlatGeobufMetadata(filePath string, metadata *FileMetadata) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	header, err := readFlatGeobufHeader(file)
+	if err != nil {
+		return err
+	}
+
+	metadata.NumFeatures = int(header.FeaturesCount)
+	metadata.Metadata["format"] = "FlatGeobuf"
+	metadata.Metadata["geometry_type"] = fgbGeometryTypeNames[header.GeometryType]
+	// Unknown means mixed types, which only a full read could list
+	if header.FeaturesCount == 0 {
+		metadata.Metadata["geometry_types"] = []string{}
+	} else if header.GeometryType != fgbUnknown {
+		metadata.Metadata["geometry_types"] = []string{fgbGeometryTypeNames[header.GeometryType]}
+	}
+	metadata.Metadata["has_spatial_index"] = header.IndexNodeSize > 0
+	if len(header.Envelope) >= 4 {
+		metadata.BBox = []float64{header.Envelope[0], header.Envelope[1], header.Envelope[2], header.Envelope[3]}
+	}
+	if header.CRS != "" {
+		metadata.CRS = header.CRS
+	}
+
+	columns := make([]string, 0, len(header.Columns))
+	for _, col := range header.Columns {
+		columns = append(columns, col.Name)
+	}
+	metadata.Metadata["columns"] = columns
+	metadata.Metadata["fields"] = flatGeobufFields(header.Columns)
+
+	return nil
+}
+
+func flatGeobufCollection(filePath string, header *fgbHeader, features []interface{}) map[string]interface{} {
+	name := header.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+	result := map[string]interface{}{
+		"type":     "FeatureCollection",
+		"name":     name,
+		"features": features,
+	}
+	if header.CRS != "" {
+		result["crs"] = map[string]interface{}{
+			"type":       "name",
+			"properties": map[string]interface{}{"name": header.CRS},
+		}
+	}
+	return result
+}
@@ -731,7 +731,8 @@ function App() {
                     // @ts-ignore - Wails runtime
                     const result = await window.go.main.App.SaveEditedOSMData(
                       layer.data,
-                      layer.file_name.replace("Overpass Query - ", "edited_")
+                      layer.file_name.replace("Overpass Query - ", "edited_"),
+                      {}
                     );
                     console.log("Saved edited OSM data successfully");
                   } catch (error) {
@@ -40,6 +40,7 @@ import {
 } from "../hooks/useMapLayers";
 import { GeoFileIndex, IVectorLayer } from "../types/interfaces";
 import { FeatureCollection } from "geojson";
+import { main } from "../../wailsjs/go/models";
 import IndexingDialog from "./IndexingDialog";
 import proj4 from "proj4";
 import { ShapefileLoader } from "@loaders.gl/shapefile";
@@ -80,6 +81,11 @@ const FilePanel: React.FC<FilePanelProps> = ({
     open: boolean;
     file: GeoFileIndex | null;
   }>({ open: false, file: null });
+  const [qualityReport, setQualityReport] = useState<main.QualityReport | null>(
+    null
+  );
+  const [qualityLoading, setQualityLoading] = useState(false);
+  const [qualityError, setQualityError] = useState<string | null>(null);
   const [isDragOver, setIsDragOver] = useState(false);
 
   const { addLayer, layers } = useMapLayers();
@@ -124,9 +130,24 @@ const FilePanel: React.FC<FilePanelProps> = ({
 
   const handleShowProperties = (file: GeoFileIndex) => {
     setPropertiesDialog({ open: true, file });
+    setQualityReport(null);
+    setQualityError(null);
     setContextMenu(null);
   };
 
+  const handleCheckQuality = async (file: GeoFileIndex) => {
+    setQualityLoading(true);
+    setQualityError(null);
+    try {
+      const { ComputeLayerQuality } = await import("../../wailsjs/go/main/App");
+      setQualityReport(await ComputeLayerQuality(file.file_path, ""));
+    } catch (error) {
+      setQualityError(String(error));
+    } finally {
+      setQualityLoading(false);
+    }
+  };
+
   const handleDragOver = (event: React.DragEvent) => {
     event.preventDefault();
     setIsDragOver(true);
@@ -1347,6 +1368,68 @@ const FilePanel: React.FC<FilePanelProps> = ({
                       </Typography>
                     </Box>
                   )}
+                  {propertiesDialog.file.file_type === "vector" && (
+                    <Box sx={{ mt: 2 }}>
+                      <Box
+                        sx={{
+                          display: "flex",
+                          alignItems: "center",
+                          justifyContent: "space-between",
+                        }}
+                      >
+                        <Typography variant="caption" color="text.secondary">
+                          Data Quality
+                        </Typography>
+                        <Button
+                          size="small"
+                          disabled={qualityLoading}
+                          onClick={() =>
+                            handleCheckQuality(propertiesDialog.file!)
+                          }
+                        >
+                          {qualityLoading ? "Checking..." : "Check Quality"}
+                        </Button>
+                      </Box>
+                      {qualityError && (
+                        <Typography variant="body2" color="error">
+                          {qualityError}
+                        </Typography>
+                      )}
+                      {qualityReport && (
+                        <Box>
+                          <Typography variant="body2">
+                            Score: {qualityReport.score} / 100
+                          </Typography>
+                          <Typography variant="body2">
+                            {qualityReport.null_geometries} null geometries •{" "}
+                            {qualityReport.invalid_geometries} invalid •{" "}
+                            {qualityReport.duplicate_features} duplicates of{" "}
+                            {qualityReport.feature_count} features
+                          </Typography>
+                          {qualityReport.empty_attributes
+                            .filter((field) => field.empty_count > 0)
+                            .map((field) => (
+                              <Typography
+                                key={field.field}
+                                variant="body2"
+                                sx={{ fontFamily: "monospace" }}
+                              >
+                                {field.field}: {field.empty_percent}% empty
+                              </Typography>
+                            ))}
+                          <Typography variant="caption" color="text.secondary">
+                            Decimal places:{" "}
+                            {qualityReport.precision_distribution
+                              .map((count, places) =>
+                                count > 0 ? `${places}: ${count}` : null
+                              )
+                              .filter(Boolean)
+                              .join(", ")}
+                          </Typography>
+                        </Box>
+                      )}
+                    </Box>
+                  )}
                 </Paper>
               )}
             </DialogContent>
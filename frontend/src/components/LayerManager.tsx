@@ -239,7 +239,7 @@ const LayerManager: React.FC<LayerManagerProps> = ({
       // @ts-ignore - Wails runtime
       if (window.go?.main?.App?.SaveEditedOSMData) {
         // @ts-ignore
-        await window.go.main.App.SaveEditedOSMData(layer.data, layer.file_name);
+        await window.go.main.App.SaveEditedOSMData(layer.data, layer.file_name, {});
         console.log(`✅ Saved layer: ${layer.file_name}`);
         alert(`✅ Saved ${layer.file_name}\n\nChanges have been written to disk.`);
       } else {
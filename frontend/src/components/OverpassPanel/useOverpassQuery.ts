@@ -67,7 +67,9 @@ export const useOverpassQuery = ({
         // Check if features exist
         if (!response.data?.features || response.data.features.length === 0) {
           setError(
-            'Query returned no features. Try:\n1. Use [out:json] instead of [out:xml]\n2. Check if the coordinates are correct\n3. Try broader search terms\n4. Include relations: rel["leisure"="park"]'
+            response.metadata?.empty
+              ? response.metadata.message
+              : 'Query returned no features. Try:\n1. Use [out:json] instead of [out:xml]\n2. Check if the coordinates are correct\n3. Try broader search terms\n4. Include relations: rel["leisure"="park"]'
           );
           return;
         }
@@ -2,54 +2,356 @@
 // This file is automatically generated. DO NOT EDIT
 import {main} from '../models';
 
+export function AddBookmark(arg1:string,arg2:number,arg3:number,arg4:number):Promise<number>;
+
+export function AddBookmarkBBox(arg1:string,arg2:Array<number>):Promise<number>;
+
+export function AddCustomCategory(arg1:string,arg2:Array<main.TagQuery>):Promise<void>;
+
+export function AddLayerToWorkspace(arg1:string,arg2:number):Promise<void>;
+
+export function AddQueryToWorkspace(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function AddSupportedExtension(arg1:string,arg2:string):Promise<void>;
+
+export function BackupDatabase(arg1:string):Promise<void>;
+
+export function BuildRasterMosaic(arg1:Array<string>,arg2:string):Promise<void>;
+
+export function CacheBasemapTiles(arg1:Array<number>,arg2:number,arg3:number,arg4:string):Promise<void>;
+
+export function CancelJob(arg1:string):Promise<void>;
+
+export function CancelLayerLoad(arg1:string):Promise<void>;
+
+export function CancelOverpassQuery(arg1:string):Promise<void>;
+
+export function CheckCRSConsistency(arg1:string):Promise<main.CRSCheckResult>;
+
+export function CheckDatabaseIntegrity():Promise<boolean>;
+
+export function CheckGDALAvailable():Promise<main.GDALInfo>;
+
+export function ClearQueryHistory():Promise<void>;
+
+export function ClipRaster(arg1:string,arg2:Record<string, any>,arg3:string):Promise<void>;
+
+export function ClipRasterWithOptions(arg1:string,arg2:Record<string, any>,arg3:string,arg4:main.ClipRasterOptions):Promise<void>;
+
+export function CombinedBounds(arg1:Array<string>):Promise<Array<number>>;
+
+export function CombinedBoundsDetailed(arg1:Array<string>):Promise<main.CombinedExtent>;
+
+export function ComputeBandMath(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function ComputeBandMathDetailed(arg1:string,arg2:string,arg3:string):Promise<main.BandMathResult>;
+
+export function ComputeLayerQuality(arg1:string,arg2:string):Promise<main.QualityReport>;
+
 export function ConvertDuckDBResultToGeoJSON(arg1:string):Promise<Record<string, any>>;
 
+export function CopyDataset(arg1:string,arg2:string,arg3:boolean):Promise<string>;
+
 export function CreateIndex(arg1:string,arg2:boolean,arg3:boolean):Promise<void>;
 
 export function CreateIndexProgress():Promise<number>;
 
+export function CreateWorkspace(arg1:string):Promise<number>;
+
+export function DecimatePoints(arg1:Record<string, any>,arg2:number):Promise<Record<string, any>>;
+
+export function DeleteBasemapCache(arg1:string):Promise<void>;
+
+export function DeleteBookmark(arg1:number):Promise<void>;
+
+export function DeleteDataset(arg1:string):Promise<void>;
+
+export function DeleteWorkspace(arg1:string):Promise<void>;
+
+export function DissolveByAttribute(arg1:Record<string, any>,arg2:string):Promise<Record<string, any>>;
+
+export function DissolveByAttributeWithOptions(arg1:Record<string, any>,arg2:string,arg3:main.DissolveOptions):Promise<Record<string, any>>;
+
 export function DropDuckDBTable(arg1:string):Promise<void>;
 
+export function ElevationProfile(arg1:string,arg2:Record<string, any>,arg3:number):Promise<Array<main.ProfilePoint>>;
+
+export function EnqueueJob(arg1:string,arg2:Record<string, any>):Promise<string>;
+
 export function ExecuteDuckDBQuery(arg1:string):Promise<Record<string, any>>;
 
+export function ExportAsOSM(arg1:Record<string, any>,arg2:string):Promise<Array<number>>;
+
+export function ExportCatalogDB(arg1:string):Promise<void>;
+
+export function ExportDiagnostics():Promise<string>;
+
+export function ExportOSMArea(arg1:Array<number>,arg2:Array<string>,arg3:string):Promise<void>;
+
+export function ExportTopoJSON(arg1:Record<string, any>):Promise<Array<number>>;
+
+export function ExportTopoJSONQuantized(arg1:Record<string, any>,arg2:number):Promise<Array<number>>;
+
+export function ExtractMetadata(arg1:string):Promise<main.FileMetadata>;
+
+export function FindFeaturesByAttribute(arg1:string,arg2:string,arg3:string,arg4:string):Promise<Record<string, any>>;
+
+export function FindFeaturesByAttributeWithOptions(arg1:string,arg2:string,arg3:string,arg4:string,arg5:main.AttributeSearchOptions):Promise<Record<string, any>>;
+
+export function FindFeaturesInBBox(arg1:string,arg2:Array<number>):Promise<Record<string, any>>;
+
+export function FindFilesByTag(arg1:string):Promise<Array<main.GeoFileIndex>>;
+
+export function FindFilesInBBox(arg1:Array<number>):Promise<Array<main.GeoFileIndex>>;
+
+export function FindNearestDatasets(arg1:number,arg2:number,arg3:number):Promise<Array<main.GeoFileIndex>>;
+
+export function FindRasterTileGroups():Promise<Array<main.RasterTileGroup>>;
+
+export function GenerateContours(arg1:string,arg2:number):Promise<Record<string, any>>;
+
+export function GenerateContoursWithBase(arg1:string,arg2:number,arg3:number):Promise<Record<string, any>>;
+
+export function GenerateDensityGrid(arg1:Record<string, any>,arg2:number):Promise<Record<string, any>>;
+
+export function GenerateHillshade(arg1:string,arg2:number,arg3:number):Promise<string>;
+
+export function GenerateHillshadeOverlay(arg1:string,arg2:main.HillshadeOptions):Promise<main.HillshadeResult>;
+
 export function GenerateOverpassQuery(arg1:string,arg2:Array<number>):Promise<string>;
 
+export function GenerateOverpassQueryAdvanced(arg1:string,arg2:Array<number>):Promise<string>;
+
+export function GenerateOverpassQueryDryRun(arg1:string,arg2:Array<number>):Promise<main.GeneratedQuery>;
+
+export function GenerateSmoothedDensityGrid(arg1:Record<string, any>,arg2:number,arg3:number):Promise<Record<string, any>>;
+
+export function GeoJSONToWKB(arg1:Record<string, any>):Promise<Array<string>>;
+
+export function GeoJSONToWKT(arg1:Record<string, any>):Promise<Array<string>>;
+
+export function GetActiveIndexRun():Promise<main.IndexProgress>;
+
+export function GetBasemapCaches():Promise<Array<main.BasemapCache>>;
+
+export function GetCRSInfo(arg1:string):Promise<main.CRSInfo>;
+
+export function GetContactEmail():Promise<string>;
+
+export function GetExtractionTimeout():Promise<number>;
+
 export function GetFileInfo(arg1:string):Promise<Record<string, any>>;
 
+export function GetGDALConcurrency():Promise<number>;
+
+export function GetGDALTimeout():Promise<number>;
+
 export function GetHomeDirectory():Promise<string>;
 
 export function GetIndexProgress(arg1:number):Promise<main.IndexProgress>;
 
+export function GetIndexQualityChecks():Promise<string>;
+
+export function GetJobStatus(arg1:string):Promise<main.Job>;
+
+export function GetLayerAttributes(arg1:string,arg2:string,arg3:number,arg4:number):Promise<Array<Record<string, any>>>;
+
+export function GetLayerBounds(arg1:string,arg2:string):Promise<Array<number>>;
+
+export function GetLayerFields(arg1:string,arg2:string):Promise<Array<main.FieldInfo>>;
+
+export function GetLayerStyle(arg1:string,arg2:string):Promise<Record<string, any>>;
+
+export function GetLogLevel():Promise<string>;
+
+export function GetOpenAIStatus():Promise<main.OpenAIStatus>;
+
+export function GetOutputDirectory():Promise<string>;
+
 export function GetOverpassQueryTemplates():Promise<Array<Record<string, any>>>;
 
+export function GetProxySettings():Promise<main.ProxySettings>;
+
+export function GetQueryHistory(arg1:number):Promise<Array<main.QueryHistoryEntry>>;
+
+export function GetRasterBandStats(arg1:string):Promise<Array<main.BandStats>>;
+
+export function GetRecentDirectories(arg1:number):Promise<Array<main.RecentItem>>;
+
+export function GetRecentFiles(arg1:number):Promise<Array<main.RecentItem>>;
+
+export function GetRecentLogs(arg1:number):Promise<Array<string>>;
+
+export function GetStorageInfo():Promise<main.StorageInfo>;
+
+export function GetSupportedExtensions():Promise<Array<main.SupportedExtension>>;
+
+export function GetWorkspaceQueries(arg1:string):Promise<Array<main.WorkspaceQuery>>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function ImportGeometryText(arg1:string):Promise<Record<string, any>>;
+
+export function ListBookmarks():Promise<Array<main.Bookmark>>;
+
+export function ListCategories():Promise<Array<main.OverpassCategory>>;
+
 export function ListDirectory(arg1:string):Promise<Array<Record<string, any>>>;
 
 export function ListDuckDBTables():Promise<Array<main.DuckDBTableInfo>>;
 
 export function ListIndexedFiles():Promise<Array<main.GeoFileIndex>>;
 
+export function ListJobs():Promise<Array<main.Job>>;
+
+export function ListTags():Promise<Array<main.TagCount>>;
+
+export function ListWorkspaces():Promise<Array<main.Workspace>>;
+
 export function LoadDataFileToDuckDB(arg1:string):Promise<string>;
 
 export function LoadGeoJSONToDuckDB(arg1:Record<string, any>,arg2:string,arg3:string):Promise<string>;
 
 export function LoadGeospatialFile(arg1:string):Promise<Record<string, any>>;
 
+export function LoadGeospatialFileDecimated(arg1:string,arg2:number,arg3:Array<number>):Promise<Record<string, any>>;
+
+export function LoadGeospatialFileStreaming(arg1:string,arg2:number):Promise<string>;
+
+export function LoadGeospatialFileWithProgress(arg1:string,arg2:string):Promise<Record<string, any>>;
+
+export function LoadParquetPage(arg1:string,arg2:number,arg3:number):Promise<Record<string, any>>;
+
+export function LoadPointCloudSample(arg1:string,arg2:number):Promise<Record<string, any>>;
+
+export function LoadPointCloudSampleFiltered(arg1:string,arg2:number,arg3:Array<number>):Promise<Record<string, any>>;
+
+export function LoadSession():Promise<main.Session>;
+
+export function LoadWorkspace(arg1:string):Promise<Array<main.GeoFileIndex>>;
+
+export function MoveDataset(arg1:string,arg2:string,arg3:boolean):Promise<string>;
+
+export function NormalizeGeoJSONWinding(arg1:Record<string, any>):Promise<Record<string, any>>;
+
+export function OpenInFileManager(arg1:string):Promise<void>;
+
+export function OpenWithDefaultApp(arg1:string):Promise<void>;
+
 export function QueryOverpassAPI(arg1:string):Promise<main.OverpassResponse>;
 
+export function QueryOverpassAPIDecimated(arg1:string,arg2:number):Promise<main.OverpassResponse>;
+
+export function QueryOverpassAround(arg1:number,arg2:number,arg3:number,arg4:Array<string>):Promise<main.OverpassResponse>;
+
+export function QueryOverpassTiled(arg1:string,arg2:Array<number>,arg3:number):Promise<main.OverpassResponse>;
+
 export function ReadFile(arg1:string):Promise<string>;
 
 export function ReadFileAsBase64(arg1:string):Promise<string>;
 
-export function SaveEditedOSMData(arg1:Record<string, any>,arg2:string):Promise<void>;
+export function RebuildIndex():Promise<void>;
+
+export function RefreshFileMetadata(arg1:string):Promise<main.GeoFileIndex>;
+
+export function RemoveFromIndex(arg1:string):Promise<void>;
+
+export function RemoveLayerFromWorkspace(arg1:string,arg2:string):Promise<void>;
+
+export function RemoveSupportedExtension(arg1:string):Promise<void>;
+
+export function RepairGeometry(arg1:Record<string, any>):Promise<Record<string, any>>;
+
+export function ReplayQuery(arg1:number):Promise<main.OverpassResponse>;
+
+export function RestoreDatabase(arg1:string):Promise<void>;
+
+export function RoundCoordinates(arg1:Record<string, any>,arg2:number,arg3:boolean):Promise<Record<string, any>>;
+
+export function RunGeneratedOverpassQuery(arg1:string,arg2:Array<number>):Promise<main.OverpassResponse>;
+
+export function SampleRasterValue(arg1:string,arg2:number,arg3:number):Promise<Array<any>>;
+
+export function SaveEditedOSMData(arg1:Record<string, any>,arg2:string,arg3:main.SaveOptions):Promise<main.SaveResult>;
 
 export function SaveFile(arg1:string,arg2:string):Promise<string>;
 
+export function SaveLayerStyle(arg1:string,arg2:string,arg3:Record<string, any>):Promise<void>;
+
+export function SaveSession(arg1:main.Session):Promise<void>;
+
+export function SearchCRS(arg1:string):Promise<Array<main.CRSInfo>>;
+
 export function SearchFiles(arg1:string,arg2:string):Promise<Array<string>>;
 
+export function SearchIndex(arg1:string):Promise<Array<main.GeoFileIndex>>;
+
 export function SelectDataFile():Promise<string>;
 
 export function SelectDirectory():Promise<string>;
 
+export function SelfTest():Promise<main.HealthReport>;
+
+export function SetContactEmail(arg1:string):Promise<void>;
+
+export function SetExtractionTimeout(arg1:number):Promise<void>;
+
+export function SetFavorite(arg1:string,arg2:string,arg3:boolean):Promise<void>;
+
+export function SetGDALConcurrency(arg1:number):Promise<void>;
+
+export function SetGDALTimeout(arg1:number):Promise<void>;
+
+export function SetHTTPTimeout(arg1:number):Promise<void>;
+
+export function SetIndexQualityChecks(arg1:string):Promise<void>;
+
+export function SetLogLevel(arg1:string):Promise<void>;
+
+export function SetOpenAILimits(arg1:number,arg2:number):Promise<void>;
+
+export function SetOpenAIModel(arg1:string):Promise<void>;
+
+export function SetOutputDirectory(arg1:string):Promise<void>;
+
+export function SetProxy(arg1:string):Promise<void>;
+
+export function SetProxyOverrides(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function SimplifyTopology(arg1:Record<string, any>,arg2:number):Promise<Record<string, any>>;
+
+export function SpatialJoin(arg1:Record<string, any>,arg2:Record<string, any>,arg3:string):Promise<Record<string, any>>;
+
+export function StartOverpassQuery(arg1:string):Promise<string>;
+
+export function SuggestUTMZone(arg1:Array<number>):Promise<string>;
+
+export function SuggestUTMZoneDetailed(arg1:Array<number>):Promise<main.UTMZoneSuggestion>;
+
+export function TagFile(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function TransformCoordinates(arg1:Array<any>,arg2:string,arg3:string):Promise<Array<any>>;
+
+export function UntagFile(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function UseBookmark(arg1:number):Promise<void>;
+
+export function VacuumDatabase():Promise<number>;
+
+export function ValidateGeoJSON(arg1:Record<string, any>):Promise<Array<main.ValidationError>>;
+
+export function ValidateGeometry(arg1:Record<string, any>):Promise<Array<main.GeometryIssue>>;
+
+export function ValidateOSMEdits(arg1:Record<string, any>):Promise<Array<main.OSMValidationIssue>>;
+
+export function ValidateOverpassQuery(arg1:string):Promise<main.OverpassValidation>;
+
+export function WKBToGeoJSON(arg1:string):Promise<Record<string, any>>;
+
+export function WKTToGeoJSON(arg1:string):Promise<Record<string, any>>;
+
+export function WarpRaster(arg1:string,arg2:string,arg3:string,arg4:number,arg5:string):Promise<void>;
+
+export function WarpRasterDetailed(arg1:string,arg2:string,arg3:string,arg4:number,arg5:string):Promise<main.WarpResult>;
+
 export function WriteFile(arg1:string,arg2:string):Promise<void>;
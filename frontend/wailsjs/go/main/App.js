@@ -2,10 +2,106 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AddBookmark(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['AddBookmark'](arg1, arg2, arg3, arg4);
+}
+
+export function AddBookmarkBBox(arg1, arg2) {
+  return window['go']['main']['App']['AddBookmarkBBox'](arg1, arg2);
+}
+
+export function AddCustomCategory(arg1, arg2) {
+  return window['go']['main']['App']['AddCustomCategory'](arg1, arg2);
+}
+
+export function AddLayerToWorkspace(arg1, arg2) {
+  return window['go']['main']['App']['AddLayerToWorkspace'](arg1, arg2);
+}
+
+export function AddQueryToWorkspace(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddQueryToWorkspace'](arg1, arg2, arg3);
+}
+
+export function AddSupportedExtension(arg1, arg2) {
+  return window['go']['main']['App']['AddSupportedExtension'](arg1, arg2);
+}
+
+export function BackupDatabase(arg1) {
+  return window['go']['main']['App']['BackupDatabase'](arg1);
+}
+
+export function BuildRasterMosaic(arg1, arg2) {
+  return window['go']['main']['App']['BuildRasterMosaic'](arg1, arg2);
+}
+
+export function CacheBasemapTiles(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['CacheBasemapTiles'](arg1, arg2, arg3, arg4);
+}
+
+export function CancelJob(arg1) {
+  return window['go']['main']['App']['CancelJob'](arg1);
+}
+
+export function CancelLayerLoad(arg1) {
+  return window['go']['main']['App']['CancelLayerLoad'](arg1);
+}
+
+export function CancelOverpassQuery(arg1) {
+  return window['go']['main']['App']['CancelOverpassQuery'](arg1);
+}
+
+export function CheckCRSConsistency(arg1) {
+  return window['go']['main']['App']['CheckCRSConsistency'](arg1);
+}
+
+export function CheckDatabaseIntegrity() {
+  return window['go']['main']['App']['CheckDatabaseIntegrity']();
+}
+
+export function CheckGDALAvailable() {
+  return window['go']['main']['App']['CheckGDALAvailable']();
+}
+
+export function ClearQueryHistory() {
+  return window['go']['main']['App']['ClearQueryHistory']();
+}
+
+export function ClipRaster(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ClipRaster'](arg1, arg2, arg3);
+}
+
+export function ClipRasterWithOptions(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['ClipRasterWithOptions'](arg1, arg2, arg3, arg4);
+}
+
+export function CombinedBounds(arg1) {
+  return window['go']['main']['App']['CombinedBounds'](arg1);
+}
+
+export function CombinedBoundsDetailed(arg1) {
+  return window['go']['main']['App']['CombinedBoundsDetailed'](arg1);
+}
+
+export function ComputeBandMath(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ComputeBandMath'](arg1, arg2, arg3);
+}
+
+export function ComputeBandMathDetailed(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ComputeBandMathDetailed'](arg1, arg2, arg3);
+}
+
+export function ComputeLayerQuality(arg1, arg2) {
+  return window['go']['main']['App']['ComputeLayerQuality'](arg1, arg2);
+}
+
 export function ConvertDuckDBResultToGeoJSON(arg1) {
   return window['go']['main']['App']['ConvertDuckDBResultToGeoJSON'](arg1);
 }
 
+export function CopyDataset(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CopyDataset'](arg1, arg2, arg3);
+}
+
 export function CreateIndex(arg1, arg2, arg3) {
   return window['go']['main']['App']['CreateIndex'](arg1, arg2, arg3);
 }
@@ -14,22 +110,186 @@ export function CreateIndexProgress() {
   return window['go']['main']['App']['CreateIndexProgress']();
 }
 
+export function CreateWorkspace(arg1) {
+  return window['go']['main']['App']['CreateWorkspace'](arg1);
+}
+
+export function DecimatePoints(arg1, arg2) {
+  return window['go']['main']['App']['DecimatePoints'](arg1, arg2);
+}
+
+export function DeleteBasemapCache(arg1) {
+  return window['go']['main']['App']['DeleteBasemapCache'](arg1);
+}
+
+export function DeleteBookmark(arg1) {
+  return window['go']['main']['App']['DeleteBookmark'](arg1);
+}
+
+export function DeleteDataset(arg1) {
+  return window['go']['main']['App']['DeleteDataset'](arg1);
+}
+
+export function DeleteWorkspace(arg1) {
+  return window['go']['main']['App']['DeleteWorkspace'](arg1);
+}
+
+export function DissolveByAttribute(arg1, arg2) {
+  return window['go']['main']['App']['DissolveByAttribute'](arg1, arg2);
+}
+
+export function DissolveByAttributeWithOptions(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DissolveByAttributeWithOptions'](arg1, arg2, arg3);
+}
+
 export function DropDuckDBTable(arg1) {
   return window['go']['main']['App']['DropDuckDBTable'](arg1);
 }
 
+export function ElevationProfile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ElevationProfile'](arg1, arg2, arg3);
+}
+
+export function EnqueueJob(arg1, arg2) {
+  return window['go']['main']['App']['EnqueueJob'](arg1, arg2);
+}
+
 export function ExecuteDuckDBQuery(arg1) {
   return window['go']['main']['App']['ExecuteDuckDBQuery'](arg1);
 }
 
+export function ExportAsOSM(arg1, arg2) {
+  return window['go']['main']['App']['ExportAsOSM'](arg1, arg2);
+}
+
+export function ExportCatalogDB(arg1) {
+  return window['go']['main']['App']['ExportCatalogDB'](arg1);
+}
+
+export function ExportDiagnostics() {
+  return window['go']['main']['App']['ExportDiagnostics']();
+}
+
+export function ExportOSMArea(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportOSMArea'](arg1, arg2, arg3);
+}
+
+export function ExportTopoJSON(arg1) {
+  return window['go']['main']['App']['ExportTopoJSON'](arg1);
+}
+
+export function ExportTopoJSONQuantized(arg1, arg2) {
+  return window['go']['main']['App']['ExportTopoJSONQuantized'](arg1, arg2);
+}
+
+export function ExtractMetadata(arg1) {
+  return window['go']['main']['App']['ExtractMetadata'](arg1);
+}
+
+export function FindFeaturesByAttribute(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['FindFeaturesByAttribute'](arg1, arg2, arg3, arg4);
+}
+
+export function FindFeaturesByAttributeWithOptions(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['FindFeaturesByAttributeWithOptions'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function FindFeaturesInBBox(arg1, arg2) {
+  return window['go']['main']['App']['FindFeaturesInBBox'](arg1, arg2);
+}
+
+export function FindFilesByTag(arg1) {
+  return window['go']['main']['App']['FindFilesByTag'](arg1);
+}
+
+export function FindFilesInBBox(arg1) {
+  return window['go']['main']['App']['FindFilesInBBox'](arg1);
+}
+
+export function FindNearestDatasets(arg1, arg2, arg3) {
+  return window['go']['main']['App']['FindNearestDatasets'](arg1, arg2, arg3);
+}
+
+export function FindRasterTileGroups() {
+  return window['go']['main']['App']['FindRasterTileGroups']();
+}
+
+export function GenerateContours(arg1, arg2) {
+  return window['go']['main']['App']['GenerateContours'](arg1, arg2);
+}
+
+export function GenerateContoursWithBase(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GenerateContoursWithBase'](arg1, arg2, arg3);
+}
+
+export function GenerateDensityGrid(arg1, arg2) {
+  return window['go']['main']['App']['GenerateDensityGrid'](arg1, arg2);
+}
+
+export function GenerateHillshade(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GenerateHillshade'](arg1, arg2, arg3);
+}
+
+export function GenerateHillshadeOverlay(arg1, arg2) {
+  return window['go']['main']['App']['GenerateHillshadeOverlay'](arg1, arg2);
+}
+
 export function GenerateOverpassQuery(arg1, arg2) {
   return window['go']['main']['App']['GenerateOverpassQuery'](arg1, arg2);
 }
 
+export function GenerateOverpassQueryAdvanced(arg1, arg2) {
+  return window['go']['main']['App']['GenerateOverpassQueryAdvanced'](arg1, arg2);
+}
+
+export function GenerateOverpassQueryDryRun(arg1, arg2) {
+  return window['go']['main']['App']['GenerateOverpassQueryDryRun'](arg1, arg2);
+}
+
+export function GenerateSmoothedDensityGrid(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GenerateSmoothedDensityGrid'](arg1, arg2, arg3);
+}
+
+export function GeoJSONToWKB(arg1) {
+  return window['go']['main']['App']['GeoJSONToWKB'](arg1);
+}
+
+export function GeoJSONToWKT(arg1) {
+  return window['go']['main']['App']['GeoJSONToWKT'](arg1);
+}
+
+export function GetActiveIndexRun() {
+  return window['go']['main']['App']['GetActiveIndexRun']();
+}
+
+export function GetBasemapCaches() {
+  return window['go']['main']['App']['GetBasemapCaches']();
+}
+
+export function GetCRSInfo(arg1) {
+  return window['go']['main']['App']['GetCRSInfo'](arg1);
+}
+
+export function GetContactEmail() {
+  return window['go']['main']['App']['GetContactEmail']();
+}
+
+export function GetExtractionTimeout() {
+  return window['go']['main']['App']['GetExtractionTimeout']();
+}
+
 export function GetFileInfo(arg1) {
   return window['go']['main']['App']['GetFileInfo'](arg1);
 }
 
+export function GetGDALConcurrency() {
+  return window['go']['main']['App']['GetGDALConcurrency']();
+}
+
+export function GetGDALTimeout() {
+  return window['go']['main']['App']['GetGDALTimeout']();
+}
+
 export function GetHomeDirectory() {
   return window['go']['main']['App']['GetHomeDirectory']();
 }
@@ -38,14 +298,98 @@ export function GetIndexProgress(arg1) {
   return window['go']['main']['App']['GetIndexProgress'](arg1);
 }
 
+export function GetIndexQualityChecks() {
+  return window['go']['main']['App']['GetIndexQualityChecks']();
+}
+
+export function GetJobStatus(arg1) {
+  return window['go']['main']['App']['GetJobStatus'](arg1);
+}
+
+export function GetLayerAttributes(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GetLayerAttributes'](arg1, arg2, arg3, arg4);
+}
+
+export function GetLayerBounds(arg1, arg2) {
+  return window['go']['main']['App']['GetLayerBounds'](arg1, arg2);
+}
+
+export function GetLayerFields(arg1, arg2) {
+  return window['go']['main']['App']['GetLayerFields'](arg1, arg2);
+}
+
+export function GetLayerStyle(arg1, arg2) {
+  return window['go']['main']['App']['GetLayerStyle'](arg1, arg2);
+}
+
+export function GetLogLevel() {
+  return window['go']['main']['App']['GetLogLevel']();
+}
+
+export function GetOpenAIStatus() {
+  return window['go']['main']['App']['GetOpenAIStatus']();
+}
+
+export function GetOutputDirectory() {
+  return window['go']['main']['App']['GetOutputDirectory']();
+}
+
 export function GetOverpassQueryTemplates() {
   return window['go']['main']['App']['GetOverpassQueryTemplates']();
 }
 
+export function GetProxySettings() {
+  return window['go']['main']['App']['GetProxySettings']();
+}
+
+export function GetQueryHistory(arg1) {
+  return window['go']['main']['App']['GetQueryHistory'](arg1);
+}
+
+export function GetRasterBandStats(arg1) {
+  return window['go']['main']['App']['GetRasterBandStats'](arg1);
+}
+
+export function GetRecentDirectories(arg1) {
+  return window['go']['main']['App']['GetRecentDirectories'](arg1);
+}
+
+export function GetRecentFiles(arg1) {
+  return window['go']['main']['App']['GetRecentFiles'](arg1);
+}
+
+export function GetRecentLogs(arg1) {
+  return window['go']['main']['App']['GetRecentLogs'](arg1);
+}
+
+export function GetStorageInfo() {
+  return window['go']['main']['App']['GetStorageInfo']();
+}
+
+export function GetSupportedExtensions() {
+  return window['go']['main']['App']['GetSupportedExtensions']();
+}
+
+export function GetWorkspaceQueries(arg1) {
+  return window['go']['main']['App']['GetWorkspaceQueries'](arg1);
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
+export function ImportGeometryText(arg1) {
+  return window['go']['main']['App']['ImportGeometryText'](arg1);
+}
+
+export function ListBookmarks() {
+  return window['go']['main']['App']['ListBookmarks']();
+}
+
+export function ListCategories() {
+  return window['go']['main']['App']['ListCategories']();
+}
+
 export function ListDirectory(arg1) {
   return window['go']['main']['App']['ListDirectory'](arg1);
 }
@@ -58,6 +402,18 @@ export function ListIndexedFiles() {
   return window['go']['main']['App']['ListIndexedFiles']();
 }
 
+export function ListJobs() {
+  return window['go']['main']['App']['ListJobs']();
+}
+
+export function ListTags() {
+  return window['go']['main']['App']['ListTags']();
+}
+
+export function ListWorkspaces() {
+  return window['go']['main']['App']['ListWorkspaces']();
+}
+
 export function LoadDataFileToDuckDB(arg1) {
   return window['go']['main']['App']['LoadDataFileToDuckDB'](arg1);
 }
@@ -70,10 +426,70 @@ export function LoadGeospatialFile(arg1) {
   return window['go']['main']['App']['LoadGeospatialFile'](arg1);
 }
 
+export function LoadGeospatialFileDecimated(arg1, arg2, arg3) {
+  return window['go']['main']['App']['LoadGeospatialFileDecimated'](arg1, arg2, arg3);
+}
+
+export function LoadGeospatialFileStreaming(arg1, arg2) {
+  return window['go']['main']['App']['LoadGeospatialFileStreaming'](arg1, arg2);
+}
+
+export function LoadGeospatialFileWithProgress(arg1, arg2) {
+  return window['go']['main']['App']['LoadGeospatialFileWithProgress'](arg1, arg2);
+}
+
+export function LoadParquetPage(arg1, arg2, arg3) {
+  return window['go']['main']['App']['LoadParquetPage'](arg1, arg2, arg3);
+}
+
+export function LoadPointCloudSample(arg1, arg2) {
+  return window['go']['main']['App']['LoadPointCloudSample'](arg1, arg2);
+}
+
+export function LoadPointCloudSampleFiltered(arg1, arg2, arg3) {
+  return window['go']['main']['App']['LoadPointCloudSampleFiltered'](arg1, arg2, arg3);
+}
+
+export function LoadSession() {
+  return window['go']['main']['App']['LoadSession']();
+}
+
+export function LoadWorkspace(arg1) {
+  return window['go']['main']['App']['LoadWorkspace'](arg1);
+}
+
+export function MoveDataset(arg1, arg2, arg3) {
+  return window['go']['main']['App']['MoveDataset'](arg1, arg2, arg3);
+}
+
+export function NormalizeGeoJSONWinding(arg1) {
+  return window['go']['main']['App']['NormalizeGeoJSONWinding'](arg1);
+}
+
+export function OpenInFileManager(arg1) {
+  return window['go']['main']['App']['OpenInFileManager'](arg1);
+}
+
+export function OpenWithDefaultApp(arg1) {
+  return window['go']['main']['App']['OpenWithDefaultApp'](arg1);
+}
+
 export function QueryOverpassAPI(arg1) {
   return window['go']['main']['App']['QueryOverpassAPI'](arg1);
 }
 
+export function QueryOverpassAPIDecimated(arg1, arg2) {
+  return window['go']['main']['App']['QueryOverpassAPIDecimated'](arg1, arg2);
+}
+
+export function QueryOverpassAround(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['QueryOverpassAround'](arg1, arg2, arg3, arg4);
+}
+
+export function QueryOverpassTiled(arg1, arg2, arg3) {
+  return window['go']['main']['App']['QueryOverpassTiled'](arg1, arg2, arg3);
+}
+
 export function ReadFile(arg1) {
   return window['go']['main']['App']['ReadFile'](arg1);
 }
@@ -82,18 +498,78 @@ export function ReadFileAsBase64(arg1) {
   return window['go']['main']['App']['ReadFileAsBase64'](arg1);
 }
 
-export function SaveEditedOSMData(arg1, arg2) {
-  return window['go']['main']['App']['SaveEditedOSMData'](arg1, arg2);
+export function RebuildIndex() {
+  return window['go']['main']['App']['RebuildIndex']();
+}
+
+export function RefreshFileMetadata(arg1) {
+  return window['go']['main']['App']['RefreshFileMetadata'](arg1);
+}
+
+export function RemoveFromIndex(arg1) {
+  return window['go']['main']['App']['RemoveFromIndex'](arg1);
+}
+
+export function RemoveLayerFromWorkspace(arg1, arg2) {
+  return window['go']['main']['App']['RemoveLayerFromWorkspace'](arg1, arg2);
+}
+
+export function RemoveSupportedExtension(arg1) {
+  return window['go']['main']['App']['RemoveSupportedExtension'](arg1);
+}
+
+export function RepairGeometry(arg1) {
+  return window['go']['main']['App']['RepairGeometry'](arg1);
+}
+
+export function ReplayQuery(arg1) {
+  return window['go']['main']['App']['ReplayQuery'](arg1);
+}
+
+export function RestoreDatabase(arg1) {
+  return window['go']['main']['App']['RestoreDatabase'](arg1);
+}
+
+export function RoundCoordinates(arg1, arg2, arg3) {
+  return window['go']['main']['App']['RoundCoordinates'](arg1, arg2, arg3);
+}
+
+export function RunGeneratedOverpassQuery(arg1, arg2) {
+  return window['go']['main']['App']['RunGeneratedOverpassQuery'](arg1, arg2);
+}
+
+export function SampleRasterValue(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SampleRasterValue'](arg1, arg2, arg3);
+}
+
+export function SaveEditedOSMData(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SaveEditedOSMData'](arg1, arg2, arg3);
 }
 
 export function SaveFile(arg1, arg2) {
   return window['go']['main']['App']['SaveFile'](arg1, arg2);
 }
 
+export function SaveLayerStyle(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SaveLayerStyle'](arg1, arg2, arg3);
+}
+
+export function SaveSession(arg1) {
+  return window['go']['main']['App']['SaveSession'](arg1);
+}
+
+export function SearchCRS(arg1) {
+  return window['go']['main']['App']['SearchCRS'](arg1);
+}
+
 export function SearchFiles(arg1, arg2) {
   return window['go']['main']['App']['SearchFiles'](arg1, arg2);
 }
 
+export function SearchIndex(arg1) {
+  return window['go']['main']['App']['SearchIndex'](arg1);
+}
+
 export function SelectDataFile() {
   return window['go']['main']['App']['SelectDataFile']();
 }
@@ -102,6 +578,134 @@ export function SelectDirectory() {
   return window['go']['main']['App']['SelectDirectory']();
 }
 
+export function SelfTest() {
+  return window['go']['main']['App']['SelfTest']();
+}
+
+export function SetContactEmail(arg1) {
+  return window['go']['main']['App']['SetContactEmail'](arg1);
+}
+
+export function SetExtractionTimeout(arg1) {
+  return window['go']['main']['App']['SetExtractionTimeout'](arg1);
+}
+
+export function SetFavorite(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetFavorite'](arg1, arg2, arg3);
+}
+
+export function SetGDALConcurrency(arg1) {
+  return window['go']['main']['App']['SetGDALConcurrency'](arg1);
+}
+
+export function SetGDALTimeout(arg1) {
+  return window['go']['main']['App']['SetGDALTimeout'](arg1);
+}
+
+export function SetHTTPTimeout(arg1) {
+  return window['go']['main']['App']['SetHTTPTimeout'](arg1);
+}
+
+export function SetIndexQualityChecks(arg1) {
+  return window['go']['main']['App']['SetIndexQualityChecks'](arg1);
+}
+
+export function SetLogLevel(arg1) {
+  return window['go']['main']['App']['SetLogLevel'](arg1);
+}
+
+export function SetOpenAILimits(arg1, arg2) {
+  return window['go']['main']['App']['SetOpenAILimits'](arg1, arg2);
+}
+
+export function SetOpenAIModel(arg1) {
+  return window['go']['main']['App']['SetOpenAIModel'](arg1);
+}
+
+export function SetOutputDirectory(arg1) {
+  return window['go']['main']['App']['SetOutputDirectory'](arg1);
+}
+
+export function SetProxy(arg1) {
+  return window['go']['main']['App']['SetProxy'](arg1);
+}
+
+export function SetProxyOverrides(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetProxyOverrides'](arg1, arg2, arg3);
+}
+
+export function SimplifyTopology(arg1, arg2) {
+  return window['go']['main']['App']['SimplifyTopology'](arg1, arg2);
+}
+
+export function SpatialJoin(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SpatialJoin'](arg1, arg2, arg3);
+}
+
+export function StartOverpassQuery(arg1) {
+  return window['go']['main']['App']['StartOverpassQuery'](arg1);
+}
+
+export function SuggestUTMZone(arg1) {
+  return window['go']['main']['App']['SuggestUTMZone'](arg1);
+}
+
+export function SuggestUTMZoneDetailed(arg1) {
+  return window['go']['main']['App']['SuggestUTMZoneDetailed'](arg1);
+}
+
+export function TagFile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['TagFile'](arg1, arg2, arg3);
+}
+
+export function TransformCoordinates(arg1, arg2, arg3) {
+  return window['go']['main']['App']['TransformCoordinates'](arg1, arg2, arg3);
+}
+
+export function UntagFile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UntagFile'](arg1, arg2, arg3);
+}
+
+export function UseBookmark(arg1) {
+  return window['go']['main']['App']['UseBookmark'](arg1);
+}
+
+export function VacuumDatabase() {
+  return window['go']['main']['App']['VacuumDatabase']();
+}
+
+export function ValidateGeoJSON(arg1) {
+  return window['go']['main']['App']['ValidateGeoJSON'](arg1);
+}
+
+export function ValidateGeometry(arg1) {
+  return window['go']['main']['App']['ValidateGeometry'](arg1);
+}
+
+export function ValidateOSMEdits(arg1) {
+  return window['go']['main']['App']['ValidateOSMEdits'](arg1);
+}
+
+export function ValidateOverpassQuery(arg1) {
+  return window['go']['main']['App']['ValidateOverpassQuery'](arg1);
+}
+
+export function WKBToGeoJSON(arg1) {
+  return window['go']['main']['App']['WKBToGeoJSON'](arg1);
+}
+
+export function WKTToGeoJSON(arg1) {
+  return window['go']['main']['App']['WKTToGeoJSON'](arg1);
+}
+
+export function WarpRaster(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['WarpRaster'](arg1, arg2, arg3, arg4, arg5);
+}
+
+export function WarpRasterDetailed(arg1, arg2, arg3, arg4, arg5) {
+  return window['go']['main']['App']['WarpRasterDetailed'](arg1, arg2, arg3, arg4, arg5);
+}
+
 export function WriteFile(arg1, arg2) {
   return window['go']['main']['App']['WriteFile'](arg1, arg2);
 }
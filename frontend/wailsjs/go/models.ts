@@ -1,5 +1,257 @@
 export namespace main {
 	
+	export class AttributeSearchOptions {
+	    match: string;
+	    case_insensitive: boolean;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new AttributeSearchOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.match = source["match"];
+	        this.case_insensitive = source["case_insensitive"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class BandMathResult {
+	    path: string;
+	    min: number;
+	    max: number;
+	    valid_pixels: number;
+	    nodata_pixels: number;
+	    engine: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BandMathResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	        this.valid_pixels = source["valid_pixels"];
+	        this.nodata_pixels = source["nodata_pixels"];
+	        this.engine = source["engine"];
+	    }
+	}
+	export class BandStats {
+	    band: number;
+	    data_type: string;
+	    color_interpretation?: string;
+	    min: number;
+	    max: number;
+	    mean: number;
+	    std_dev: number;
+	    nodata?: number;
+	    valid_percent?: number;
+	    histogram?: number[];
+	    histogram_min?: number;
+	    histogram_max?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BandStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.band = source["band"];
+	        this.data_type = source["data_type"];
+	        this.color_interpretation = source["color_interpretation"];
+	        this.min = source["min"];
+	        this.max = source["max"];
+	        this.mean = source["mean"];
+	        this.std_dev = source["std_dev"];
+	        this.nodata = source["nodata"];
+	        this.valid_percent = source["valid_percent"];
+	        this.histogram = source["histogram"];
+	        this.histogram_min = source["histogram_min"];
+	        this.histogram_max = source["histogram_max"];
+	    }
+	}
+	export class BasemapCache {
+	    id: string;
+	    tile_url: string;
+	    local_url: string;
+	    bbox: number[];
+	    min_zoom: number;
+	    max_zoom: number;
+	    tiles: number;
+	    bytes: number;
+	    updated_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BasemapCache(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.tile_url = source["tile_url"];
+	        this.local_url = source["local_url"];
+	        this.bbox = source["bbox"];
+	        this.min_zoom = source["min_zoom"];
+	        this.max_zoom = source["max_zoom"];
+	        this.tiles = source["tiles"];
+	        this.bytes = source["bytes"];
+	        this.updated_at = source["updated_at"];
+	    }
+	}
+	export class Bookmark {
+	    id: number;
+	    name: string;
+	    lat: number;
+	    lon: number;
+	    zoom: number;
+	    bbox?: number[];
+	    created_at: string;
+	    last_used_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Bookmark(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.lat = source["lat"];
+	        this.lon = source["lon"];
+	        this.zoom = source["zoom"];
+	        this.bbox = source["bbox"];
+	        this.created_at = source["created_at"];
+	        this.last_used_at = source["last_used_at"];
+	    }
+	}
+	export class CRSCheckResult {
+	    file_path: string;
+	    crs: string;
+	    bbox: number[];
+	    consistent: boolean;
+	    issues: string[];
+	    suggested_crs?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CRSCheckResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file_path = source["file_path"];
+	        this.crs = source["crs"];
+	        this.bbox = source["bbox"];
+	        this.consistent = source["consistent"];
+	        this.issues = source["issues"];
+	        this.suggested_crs = source["suggested_crs"];
+	    }
+	}
+	export class CRSInfo {
+	    code: string;
+	    name: string;
+	    type: string;
+	    area_of_use?: string;
+	    bbox?: number[];
+	    unit?: string;
+	    deprecated?: boolean;
+	    source: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new CRSInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.area_of_use = source["area_of_use"];
+	        this.bbox = source["bbox"];
+	        this.unit = source["unit"];
+	        this.deprecated = source["deprecated"];
+	        this.source = source["source"];
+	    }
+	}
+	export class ClipRasterOptions {
+	    crop_to_cutline: boolean;
+	    nodata?: number;
+	    progress_id?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ClipRasterOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.crop_to_cutline = source["crop_to_cutline"];
+	        this.nodata = source["nodata"];
+	        this.progress_id = source["progress_id"];
+	    }
+	}
+	export class ExcludedExtent {
+	    file_path: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExcludedExtent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file_path = source["file_path"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class CombinedExtent {
+	    bbox: number[];
+	    included: number;
+	    excluded: ExcludedExtent[];
+	
+	    static createFrom(source: any = {}) {
+	        return new CombinedExtent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.bbox = source["bbox"];
+	        this.included = source["included"];
+	        this.excluded = this.convertValues(source["excluded"], ExcludedExtent);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DissolveOptions {
+	    aggregate: string;
+	    tolerance: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DissolveOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.aggregate = source["aggregate"];
+	        this.tolerance = source["tolerance"];
+	    }
+	}
 	export class DuckDBTableInfo {
 	    table_name: string;
 	    file_name: string;
@@ -26,6 +278,113 @@ export namespace main {
 	        this.srid = source["srid"];
 	    }
 	}
+	
+	export class FieldCompleteness {
+	    field: string;
+	    empty_count: number;
+	    empty_percent: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FieldCompleteness(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.field = source["field"];
+	        this.empty_count = source["empty_count"];
+	        this.empty_percent = source["empty_percent"];
+	    }
+	}
+	export class FieldInfo {
+	    name: string;
+	    type: string;
+	    width?: number;
+	    precision?: number;
+	    nullable?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new FieldInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.width = source["width"];
+	        this.precision = source["precision"];
+	        this.nullable = source["nullable"];
+	    }
+	}
+	export class FileMetadata {
+	    file_size: number;
+	    created_at: number;
+	    modified_at: number;
+	    file_type: string;
+	    crs: string;
+	    bbox: number[];
+	    num_features: number;
+	    num_bands: number;
+	    resolution: number;
+	    metadata: Record<string, any>;
+	
+	    static createFrom(source: any = {}) {
+	        return new FileMetadata(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file_size = source["file_size"];
+	        this.created_at = source["created_at"];
+	        this.modified_at = source["modified_at"];
+	        this.file_type = source["file_type"];
+	        this.crs = source["crs"];
+	        this.bbox = source["bbox"];
+	        this.num_features = source["num_features"];
+	        this.num_bands = source["num_bands"];
+	        this.resolution = source["resolution"];
+	        this.metadata = source["metadata"];
+	    }
+	}
+	export class GDALInfo {
+	    available: boolean;
+	    version: string;
+	    ogr2ogr_path: string;
+	    ogrinfo_path: string;
+	    message?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GDALInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.available = source["available"];
+	        this.version = source["version"];
+	        this.ogr2ogr_path = source["ogr2ogr_path"];
+	        this.ogrinfo_path = source["ogrinfo_path"];
+	        this.message = source["message"];
+	    }
+	}
+	export class GeneratedQuery {
+	    query: string;
+	    bbox: number[];
+	    categories: string[];
+	    source: string;
+	    fallback_reason?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GeneratedQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.query = source["query"];
+	        this.bbox = source["bbox"];
+	        this.categories = source["categories"];
+	        this.source = source["source"];
+	        this.fallback_reason = source["fallback_reason"];
+	    }
+	}
 	export class GeoFileIndex {
 	    id: number;
 	    file_name: string;
@@ -44,6 +403,14 @@ export namespace main {
 	    resolution: number;
 	    bbox_geom: string;
 	    centroid_geom: string;
+	    geometry_types: string[];
+	    missing?: boolean;
+	    scan_error?: string;
+	    resolution_unit?: string;
+	    resolution_meters?: number;
+	    tags: string[];
+	    favorite: boolean;
+	    distance_meters?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new GeoFileIndex(source);
@@ -68,6 +435,132 @@ export namespace main {
 	        this.resolution = source["resolution"];
 	        this.bbox_geom = source["bbox_geom"];
 	        this.centroid_geom = source["centroid_geom"];
+	        this.geometry_types = source["geometry_types"];
+	        this.missing = source["missing"];
+	        this.scan_error = source["scan_error"];
+	        this.resolution_unit = source["resolution_unit"];
+	        this.resolution_meters = source["resolution_meters"];
+	        this.tags = source["tags"];
+	        this.favorite = source["favorite"];
+	        this.distance_meters = source["distance_meters"];
+	    }
+	}
+	export class GeometryIssue {
+	    feature_index: number;
+	    feature_id?: any;
+	    geometry_type: string;
+	    part: number;
+	    ring: number;
+	    issue: string;
+	    message: string;
+	    fixable: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new GeometryIssue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.feature_index = source["feature_index"];
+	        this.feature_id = source["feature_id"];
+	        this.geometry_type = source["geometry_type"];
+	        this.part = source["part"];
+	        this.ring = source["ring"];
+	        this.issue = source["issue"];
+	        this.message = source["message"];
+	        this.fixable = source["fixable"];
+	    }
+	}
+	export class HealthCheck {
+	    name: string;
+	    status: string;
+	    message: string;
+	    duration_ms: number;
+	    details?: Record<string, any>;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.status = source["status"];
+	        this.message = source["message"];
+	        this.duration_ms = source["duration_ms"];
+	        this.details = source["details"];
+	    }
+	}
+	export class HealthReport {
+	    ok: boolean;
+	    checks: HealthCheck[];
+	    generated_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HealthReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.ok = source["ok"];
+	        this.checks = this.convertValues(source["checks"], HealthCheck);
+	        this.generated_at = source["generated_at"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HillshadeOptions {
+	    azimuth?: number;
+	    altitude: number;
+	    z_factor: number;
+	    multidirectional: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new HillshadeOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.azimuth = source["azimuth"];
+	        this.altitude = source["altitude"];
+	        this.z_factor = source["z_factor"];
+	        this.multidirectional = source["multidirectional"];
+	    }
+	}
+	export class HillshadeResult {
+	    image: string;
+	    bbox: number[];
+	    width: number;
+	    height: number;
+	    cached: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new HillshadeResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.image = source["image"];
+	        this.bbox = source["bbox"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.cached = source["cached"];
 	    }
 	}
 	export class IndexProgress {
@@ -77,6 +570,7 @@ export namespace main {
 	    total_files: number;
 	    processed_files: number;
 	    status: string;
+	    timed_out_files?: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new IndexProgress(source);
@@ -90,8 +584,175 @@ export namespace main {
 	        this.total_files = source["total_files"];
 	        this.processed_files = source["processed_files"];
 	        this.status = source["status"];
+	        this.timed_out_files = source["timed_out_files"];
+	    }
+	}
+	export class Job {
+	    id: string;
+	    kind: string;
+	    params: Record<string, any>;
+	    status: string;
+	    progress: number;
+	    result?: Record<string, any>;
+	    error?: string;
+	    created_at: string;
+	    started_at?: string;
+	    finished_at?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.params = source["params"];
+	        this.status = source["status"];
+	        this.progress = source["progress"];
+	        this.result = source["result"];
+	        this.error = source["error"];
+	        this.created_at = source["created_at"];
+	        this.started_at = source["started_at"];
+	        this.finished_at = source["finished_at"];
+	    }
+	}
+	export class OSMValidationIssue {
+	    feature_index: number;
+	    feature_id?: string;
+	    geometry_type: string;
+	    part: number;
+	    ring: number;
+	    issue: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OSMValidationIssue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.feature_index = source["feature_index"];
+	        this.feature_id = source["feature_id"];
+	        this.geometry_type = source["geometry_type"];
+	        this.part = source["part"];
+	        this.ring = source["ring"];
+	        this.issue = source["issue"];
+	        this.message = source["message"];
 	    }
 	}
+	export class OpenAIStatus {
+	    configured: boolean;
+	    model: string;
+	    json_mode: boolean;
+	    rate_per_minute: number;
+	    remaining: number;
+	    cache_ttl_seconds: number;
+	    cached_answers: number;
+	    last_request_at?: string;
+	    last_error?: string;
+	    last_error_at?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new OpenAIStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.configured = source["configured"];
+	        this.model = source["model"];
+	        this.json_mode = source["json_mode"];
+	        this.rate_per_minute = source["rate_per_minute"];
+	        this.remaining = source["remaining"];
+	        this.cache_ttl_seconds = source["cache_ttl_seconds"];
+	        this.cached_answers = source["cached_answers"];
+	        this.last_request_at = source["last_request_at"];
+	        this.last_error = source["last_error"];
+	        this.last_error_at = source["last_error_at"];
+	    }
+	}
+	export class TagFilter {
+	    key: string;
+	    value?: string;
+	    regex?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.value = source["value"];
+	        this.regex = source["regex"];
+	    }
+	}
+	export class TagQuery {
+	    elements: string[];
+	    tags: TagFilter[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TagQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.elements = source["elements"];
+	        this.tags = this.convertValues(source["tags"], TagFilter);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class OverpassCategory {
+	    name: string;
+	    tags: TagQuery[];
+	    custom: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new OverpassCategory(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.tags = this.convertValues(source["tags"], TagQuery);
+	        this.custom = source["custom"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class OverpassResponse {
 	    success: boolean;
 	    data?: Record<string, any>;
@@ -110,6 +771,437 @@ export namespace main {
 	        this.metadata = source["metadata"];
 	    }
 	}
+	export class OverpassValidation {
+	    valid: boolean;
+	    errors: string[];
+	    warnings: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new OverpassValidation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.valid = source["valid"];
+	        this.errors = source["errors"];
+	        this.warnings = source["warnings"];
+	    }
+	}
+	export class ProfilePoint {
+	    distance_m: number;
+	    lon: number;
+	    lat: number;
+	    elevation?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProfilePoint(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.distance_m = source["distance_m"];
+	        this.lon = source["lon"];
+	        this.lat = source["lat"];
+	        this.elevation = source["elevation"];
+	    }
+	}
+	export class ProxySettings {
+	    http_proxy: string;
+	    https_proxy: string;
+	    no_proxy: string;
+	    source: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProxySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.http_proxy = source["http_proxy"];
+	        this.https_proxy = source["https_proxy"];
+	        this.no_proxy = source["no_proxy"];
+	        this.source = source["source"];
+	    }
+	}
+	export class QualityReport {
+	    file_path: string;
+	    layer_name: string;
+	    feature_count: number;
+	    null_geometries: number;
+	    invalid_geometries: number;
+	    duplicate_features: number;
+	    empty_attributes: FieldCompleteness[];
+	    precision_distribution: number[];
+	    full_checks: boolean;
+	    score: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QualityReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file_path = source["file_path"];
+	        this.layer_name = source["layer_name"];
+	        this.feature_count = source["feature_count"];
+	        this.null_geometries = source["null_geometries"];
+	        this.invalid_geometries = source["invalid_geometries"];
+	        this.duplicate_features = source["duplicate_features"];
+	        this.empty_attributes = this.convertValues(source["empty_attributes"], FieldCompleteness);
+	        this.precision_distribution = source["precision_distribution"];
+	        this.full_checks = source["full_checks"];
+	        this.score = source["score"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueryHistoryEntry {
+	    id: number;
+	    query: string;
+	    source: string;
+	    ai_generated: boolean;
+	    description?: string;
+	    bbox?: number[];
+	    tiles?: number;
+	    executed_at: string;
+	    duration_ms: number;
+	    success: boolean;
+	    feature_count: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueryHistoryEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.query = source["query"];
+	        this.source = source["source"];
+	        this.ai_generated = source["ai_generated"];
+	        this.description = source["description"];
+	        this.bbox = source["bbox"];
+	        this.tiles = source["tiles"];
+	        this.executed_at = source["executed_at"];
+	        this.duration_ms = source["duration_ms"];
+	        this.success = source["success"];
+	        this.feature_count = source["feature_count"];
+	        this.error = source["error"];
+	    }
+	}
+	export class RasterTileGroup {
+	    files: string[];
+	    crs: string;
+	    resolution: number[];
+	    num_bands: number;
+	    bbox: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new RasterTileGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.files = source["files"];
+	        this.crs = source["crs"];
+	        this.resolution = source["resolution"];
+	        this.num_bands = source["num_bands"];
+	        this.bbox = source["bbox"];
+	    }
+	}
+	export class RecentItem {
+	    path: string;
+	    name: string;
+	    used_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecentItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.name = source["name"];
+	        this.used_at = source["used_at"];
+	    }
+	}
+	export class SaveOptions {
+	    precision: number;
+	    whole_units: boolean;
+	    full_precision: boolean;
+	    pretty: boolean;
+	    append: boolean;
+	    directory: string;
+	    validate_osm: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SaveOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.precision = source["precision"];
+	        this.whole_units = source["whole_units"];
+	        this.full_precision = source["full_precision"];
+	        this.pretty = source["pretty"];
+	        this.append = source["append"];
+	        this.directory = source["directory"];
+	        this.validate_osm = source["validate_osm"];
+	    }
+	}
+	export class SaveResult {
+	    file_path: string;
+	    feature_count: number;
+	    replaced: number;
+	    duplicates_removed: number;
+	    original_size: number;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SaveResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file_path = source["file_path"];
+	        this.feature_count = source["feature_count"];
+	        this.replaced = source["replaced"];
+	        this.duplicates_removed = source["duplicates_removed"];
+	        this.original_size = source["original_size"];
+	        this.size = source["size"];
+	    }
+	}
+	export class SessionLayer {
+	    index_id: number;
+	    file_path: string;
+	    visible: boolean;
+	    order: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionLayer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index_id = source["index_id"];
+	        this.file_path = source["file_path"];
+	        this.visible = source["visible"];
+	        this.order = source["order"];
+	    }
+	}
+	export class Session {
+	    version: number;
+	    center: number[];
+	    zoom: number;
+	    layers: SessionLayer[];
+	    overpass_query: string;
+	    saved_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Session(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.center = source["center"];
+	        this.zoom = source["zoom"];
+	        this.layers = this.convertValues(source["layers"], SessionLayer);
+	        this.overpass_query = source["overpass_query"];
+	        this.saved_at = source["saved_at"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class StorageInfo {
+	    data_dir: string;
+	    database_bytes: number;
+	    duckdb_bytes: number;
+	    cache_bytes: number;
+	    basemap_bytes: number;
+	    log_bytes: number;
+	    output_dir: string;
+	    output_bytes: number;
+	    free_bytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new StorageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.data_dir = source["data_dir"];
+	        this.database_bytes = source["database_bytes"];
+	        this.duckdb_bytes = source["duckdb_bytes"];
+	        this.cache_bytes = source["cache_bytes"];
+	        this.basemap_bytes = source["basemap_bytes"];
+	        this.log_bytes = source["log_bytes"];
+	        this.output_dir = source["output_dir"];
+	        this.output_bytes = source["output_bytes"];
+	        this.free_bytes = source["free_bytes"];
+	    }
+	}
+	export class SupportedExtension {
+	    extension: string;
+	    file_type: string;
+	    group?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SupportedExtension(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.extension = source["extension"];
+	        this.file_type = source["file_type"];
+	        this.group = source["group"];
+	    }
+	}
+	export class TagCount {
+	    tag: string;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagCount(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tag = source["tag"];
+	        this.count = source["count"];
+	    }
+	}
+	
+	
+	export class UTMZoneSuggestion {
+	    code: string;
+	    zone: number;
+	    hemisphere: string;
+	    warning?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new UTMZoneSuggestion(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.zone = source["zone"];
+	        this.hemisphere = source["hemisphere"];
+	        this.warning = source["warning"];
+	    }
+	}
+	export class ValidationError {
+	    path: string;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ValidationError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.message = source["message"];
+	    }
+	}
+	export class WarpResult {
+	    path: string;
+	    crs: string;
+	    width: number;
+	    height: number;
+	    num_bands: number;
+	    resolution: number[];
+	    bbox: number[];
+	
+	    static createFrom(source: any = {}) {
+	        return new WarpResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.crs = source["crs"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.num_bands = source["num_bands"];
+	        this.resolution = source["resolution"];
+	        this.bbox = source["bbox"];
+	    }
+	}
+	export class Workspace {
+	    id: number;
+	    name: string;
+	    created_at: string;
+	    updated_at: string;
+	    layer_count: number;
+	    query_count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Workspace(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.created_at = source["created_at"];
+	        this.updated_at = source["updated_at"];
+	        this.layer_count = source["layer_count"];
+	        this.query_count = source["query_count"];
+	    }
+	}
+	export class WorkspaceQuery {
+	    id: number;
+	    name: string;
+	    query: string;
+	    created_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WorkspaceQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.query = source["query"];
+	        this.created_at = source["created_at"];
+	    }
+	}
 
 }
 
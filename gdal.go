@@ -0,0 +1,456 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GDALInfo describes the GDAL command-line tools available on this machine
+type GDALInfo struct {
+	Available   bool   `json:"available"`
+	Version     string `json:"version"`
+	Ogr2ogrPath string `json:"ogr2ogr_path"`
+	OgrinfoPath string `json:"ogrinfo_path"`
+	Message     string `json:"message,omitempty"`
+}
+
+// gdalInstallHint is shown to the user whenever a GDAL binary is missing
+const gdalInstallHint = "GDAL is not installed or not on PATH. Install GDAL (macOS: brew install gdal, Debian/Ubuntu: apt install gdal-bin, Windows: OSGeo4W) and restart Terrabox"
+
+// defaultGDALProcesses is how many GDAL subprocesses may run at once unless configured otherwise
+const defaultGDALProcesses = 3
+
+// maxGDALProcesses is the highest configurable GDAL concurrency
+const maxGDALProcesses = 16
+
+// gdalProcessesSettingKey stores the configured GDAL concurrency in the settings table
+const gdalProcessesSettingKey = "gdal_max_processes"
+
+// defaultGDALTimeout stops a GDAL process that hangs, e.g. on a malformed file
+const defaultGDALTimeout = 60 * time.Second
+
+// gdalTimeoutSettingKey stores the configured GDAL timeout, in seconds, in the settings table
+const gdalTimeoutSettingKey = "gdal_timeout_seconds"
+
+// gdalVersionTimeout bounds the ogrinfo --version probe run while detecting GDAL
+const gdalVersionTimeout = 10 * time.Second
+
+// errGDALTimeout is wrapped by the error returned when a GDAL process is killed for running too long
+var errGDALTimeout = errors.New("timed out")
+
+// gdalSearchDirs are checked in addition to PATH, since apps launched from the
+// Finder or a desktop launcher don't inherit the user's shell PATH
+var gdalSearchDirs = []string{
+	"/opt/homebrew/bin",
+	"/usr/local/bin",
+	"/usr/bin",
+	"/Applications/QGIS.app/Contents/MacOS/bin",
+	`C:\OSGeo4W\bin`,
+	`C:\OSGeo4W64\bin`,
+}
+
+// CheckGDALAvailable reports whether ogr2ogr/ogrinfo are installed and which GDAL version they belong to.
+// A missing installation is reported through Available/Message rather than an error so the UI
+// can render a setup banner. The result is cached for the lifetime of the app.
+func (a *App) CheckGDALAvailable() (GDALInfo, error) {
+	a.gdalOnce.Do(func() {
+		a.gdalInfo = detectGDAL()
+	})
+
+	return a.gdalInfo, nil
+}
+
+// requireGDAL returns a user-facing error when the GDAL tools are missing
+func (a *App) requireGDAL() (GDALInfo, error) {
+	info, _ := a.CheckGDALAvailable()
+	if !info.Available {
+		return info, fmt.Errorf("cannot load this file: %s", info.Message)
+	}
+	return info, nil
+}
+
+// detectGDAL locates the GDAL binaries and parses the version from ogrinfo
+func detectGDAL() GDALInfo {
+	info, found := locateGDAL()
+	if !found {
+		return info
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gdalVersionTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, info.OgrinfoPath, "--version")
+	killProcessGroupOnCancel(cmd)
+	output, err := cmd.Output()
+	return withGDALVersion(info, output, err)
+}
+
+// locateGDAL finds ogr2ogr and ogrinfo, reporting whether both are installed
+func locateGDAL() (GDALInfo, bool) {
+	info := GDALInfo{
+		Ogr2ogrPath: findGDALBinary("ogr2ogr"),
+		OgrinfoPath: findGDALBinary("ogrinfo"),
+	}
+	if info.Ogr2ogrPath == "" || info.OgrinfoPath == "" {
+		info.Message = gdalInstallHint
+		return info, false
+	}
+	return info, true
+}
+
+// withGDALVersion completes a located installation with the result of `ogrinfo --version`,
+// which prints e.g. "GDAL 3.8.4, released 2024/02/08"
+func withGDALVersion(info GDALInfo, output []byte, err error) GDALInfo {
+	if err != nil {
+		info.Message = fmt.Sprintf("ogrinfo found at %s but failed to run: %v", info.OgrinfoPath, err)
+		return info
+	}
+	info.Version = parseGDALVersion(string(output))
+	info.Available = true
+	return info
+}
+
+// findGDALBinary resolves a GDAL executable from PATH or the well-known install locations
+func findGDALBinary(name string) string {
+	if path, err := exec.LookPath(name); err == nil {
+		return path
+	}
+
+	for _, dir := range gdalSearchDirs {
+		candidate := filepath.Join(dir, name)
+		if filepath.Separator == '\\' {
+			candidate += ".exe"
+		}
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+
+	return ""
+}
+
+// parseGDALVersion extracts the version number from `ogrinfo --version` output
+func parseGDALVersion(output string) string {
+	fields := strings.Fields(strings.TrimSpace(output))
+	if len(fields) >= 2 && fields[0] == "GDAL" {
+		return strings.TrimSuffix(fields[1], ",")
+	}
+	return strings.TrimSpace(output)
+}
+
+// gdalTool locates one of the other GDAL utilities (gdalinfo, gdaldem, ...) once GDAL itself is
+// known to be installed
+func (a *App) gdalTool(name string) (string, error) {
+	if _, err := a.requireGDAL(); err != nil {
+		return "", err
+	}
+	path := findGDALBinary(name)
+	if path == "" {
+		return "", fmt.Errorf("%s not found; it ships with GDAL, so reinstall GDAL or add its bin directory to PATH", name)
+	}
+	return path, nil
+}
+
+// gdalTransformPoints reprojects x/y/z positions between two CRS definitions (EPSG codes, WKT or
+// PROJ strings) with gdaltransform. Output is in traditional x/y (lon/lat) order.
+func (a *App) gdalTransformPoints(srcCRS, dstCRS string, points [][]float64) ([][]float64, error) {
+	if len(points) == 0 {
+		return points, nil
+	}
+	var input strings.Builder
+	for _, p := range points {
+		z := 0.0
+		if len(p) > 2 {
+			z = p[2]
+		}
+		fmt.Fprintf(&input, "%.10f %.10f %.10f\n", p[0], p[1], z)
+	}
+
+	output, err := a.runGDALToolInput("gdaltransform", input.String(), "-s_srs", srcCRS, "-t_srs", dstCRS)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != len(points) {
+		return nil, fmt.Errorf("gdaltransform returned %d positions for %d inputs", len(lines), len(points))
+	}
+	transformed := make([][]float64, len(points))
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("unexpected gdaltransform output: %q", line)
+		}
+		p := make([]float64, 0, 3)
+		for _, field := range fields[:min(len(fields), 3)] {
+			v, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected gdaltransform output: %q", line)
+			}
+			p = append(p, v)
+		}
+		transformed[i] = p
+	}
+	return transformed, nil
+}
+
+// runGDALTool runs a GDAL utility and returns its stdout, including stderr in any error
+func (a *App) runGDALTool(name string, args ...string) ([]byte, error) {
+	return a.runGDALToolContext(context.Background(), name, "", args...)
+}
+
+// runGDALToolInput is runGDALTool for utilities that read coordinates from stdin
+func (a *App) runGDALToolInput(name string, input string, args ...string) ([]byte, error) {
+	return a.runGDALToolContext(context.Background(), name, input, args...)
+}
+
+// runGDALToolContext is runGDALToolInput with the process killed once ctx is cancelled
+func (a *App) runGDALToolContext(ctx context.Context, name string, input string, args ...string) ([]byte, error) {
+	tool, err := a.gdalTool(name)
+	if err != nil {
+		return nil, err
+	}
+
+	output, stderr, err := a.execGDAL(ctx, tool, input, args...)
+	if err != nil {
+		// Some tools exit non-zero for partial failures, so stdout is returned as well
+		return output, fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(stderr)))
+	}
+	return output, nil
+}
+
+// execGDAL runs a GDAL binary once a process slot is free, killing its process group if it
+// outlives the configured timeout or ctx is cancelled. stdout and stderr are returned separately.
+func (a *App) execGDAL(ctx context.Context, path string, input string, args ...string) ([]byte, []byte, error) {
+	var stdout bytes.Buffer
+	stderr, err := a.execGDALTo(ctx, &stdout, path, input, args...)
+	return stdout.Bytes(), stderr, err
+}
+
+// execGDALTo is execGDAL with stdout streamed to the given writer as the process writes it
+func (a *App) execGDALTo(ctx context.Context, stdout io.Writer, path string, input string, args ...string) ([]byte, error) {
+	name := filepath.Base(path)
+	release, err := a.acquireGDALSlot(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	timeout := a.gdalTimeout()
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(runCtx, path, args...)
+	killProcessGroupOnCancel(cmd)
+	// Don't wait forever for pipes held open by orphaned children
+	cmd.WaitDelay = 5 * time.Second
+	a.logDebug("Running %s", strings.Join(cmd.Args, " "))
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+	var stderr bytes.Buffer
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	if ctx.Err() != nil {
+		a.logDebug("Killed %s: %v", strings.Join(cmd.Args, " "), ctx.Err())
+		err = fmt.Errorf("%s cancelled: %w", name, ctx.Err())
+	} else if runCtx.Err() == context.DeadlineExceeded {
+		a.logWarn("Killed %s after %s", strings.Join(cmd.Args, " "), timeout)
+		err = fmt.Errorf("%s %w after %s", name, errGDALTimeout, timeout)
+	}
+	return stderr.Bytes(), err
+}
+
+// gdalTimeout returns how long a GDAL process may run before it is killed. The setting is cached
+// after the first read.
+func (a *App) gdalTimeout() time.Duration {
+	a.gdalSlotsMu.Lock()
+	timeout := a.gdalTimeoutValue
+	a.gdalSlotsMu.Unlock()
+	if timeout > 0 {
+		return timeout
+	}
+
+	timeout = defaultGDALTimeout
+	if value, found, err := a.getSetting(gdalTimeoutSettingKey); err == nil && found {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			timeout = time.Duration(seconds) * time.Second
+		}
+	}
+	a.gdalSlotsMu.Lock()
+	a.gdalTimeoutValue = timeout
+	a.gdalSlotsMu.Unlock()
+	return timeout
+}
+
+// GetGDALTimeout returns how many seconds a GDAL process may run before it is killed
+func (a *App) GetGDALTimeout() int {
+	return int(a.gdalTimeout().Seconds())
+}
+
+// SetGDALTimeout changes how many seconds a GDAL process may run before it is killed
+func (a *App) SetGDALTimeout(seconds int) error {
+	if seconds < 1 || seconds > 24*60*60 {
+		return fmt.Errorf("GDAL timeout must be between 1 second and 24 hours")
+	}
+	if err := a.setSetting(gdalTimeoutSettingKey, strconv.Itoa(seconds)); err != nil {
+		return err
+	}
+	a.gdalSlotsMu.Lock()
+	a.gdalTimeoutValue = time.Duration(seconds) * time.Second
+	a.gdalSlotsMu.Unlock()
+	return nil
+}
+
+// gdalSlots returns the semaphore limiting concurrent GDAL processes, sized from settings
+func (a *App) gdalSlots() chan struct{} {
+	a.gdalSlotsMu.Lock()
+	defer a.gdalSlotsMu.Unlock()
+
+	if a.gdalSlotsChan == nil {
+		limit := defaultGDALProcesses
+		if value, found, err := a.getSetting(gdalProcessesSettingKey); err == nil && found {
+			if n, err := strconv.Atoi(value); err == nil && n >= 1 && n <= maxGDALProcesses {
+				limit = n
+			}
+		}
+		a.gdalSlotsChan = make(chan struct{}, limit)
+	}
+	return a.gdalSlotsChan
+}
+
+// acquireGDALSlot waits for a free GDAL process slot, or until ctx is cancelled, and returns the
+// function that frees it
+func (a *App) acquireGDALSlot(ctx context.Context, name string) (func(), error) {
+	slots := a.gdalSlots()
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		start := time.Now()
+		a.logDebug("%s queued: all %d GDAL process slots are busy", name, cap(slots))
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%s cancelled: %w", name, ctx.Err())
+		}
+		a.logInfo("%s waited %s for a GDAL process slot", name, time.Since(start).Round(time.Millisecond))
+	}
+	if err := ctx.Err(); err != nil {
+		<-slots
+		return nil, fmt.Errorf("%s cancelled: %w", name, err)
+	}
+
+	// Release into the same channel even if the limit changes meanwhile
+	return func() { <-slots }, nil
+}
+
+// SetGDALConcurrency changes how many GDAL processes may run at once and remembers it.
+// Processes already running finish under the old limit.
+func (a *App) SetGDALConcurrency(limit int) error {
+	if limit < 1 || limit > maxGDALProcesses {
+		return fmt.Errorf("GDAL concurrency must be between 1 and %d", maxGDALProcesses)
+	}
+	if err := a.setSetting(gdalProcessesSettingKey, strconv.Itoa(limit)); err != nil {
+		return err
+	}
+
+	a.gdalSlotsMu.Lock()
+	a.gdalSlotsChan = make(chan struct{}, limit)
+	a.gdalSlotsMu.Unlock()
+	return nil
+}
+
+// GetGDALConcurrency returns how many GDAL processes may run at once
+func (a *App) GetGDALConcurrency() int {
+	return cap(a.gdalSlots())
+}
+
+// gdalConnectionPattern matches GDAL connection strings and URLs such as PG:..., WFS:... or
+// https://..., but not Windows drive letters
+var gdalConnectionPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+.-]+:`)
+
+// gdalInputPath checks a user-supplied path before it is handed to a GDAL tool and returns it in
+// absolute form, which can't be mistaken for a command-line option. GDAL virtual file systems
+// (/vsicurl/, /vsizip/, ...), connection strings and URLs are rejected, and the file must exist.
+func gdalInputPath(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("file path is empty")
+	}
+	if strings.ContainsRune(path, 0) {
+		return "", fmt.Errorf("file path contains a NUL character")
+	}
+
+	if strings.HasPrefix(strings.ToLower(filepath.ToSlash(path)), "/vsi") {
+		return "", fmt.Errorf("GDAL virtual file system paths are not allowed: %s", path)
+	}
+	if gdalConnectionPattern.MatchString(path) {
+		return "", fmt.Errorf("only local files can be opened, not %s", path)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid file path %s: %v", path, err)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file does not exist: %s", path)
+		}
+		return "", fmt.Errorf("cannot access %s: %v", path, err)
+	}
+	return abs, nil
+}
+
+// xmlEscape escapes text placed in a generated VRT file, so paths and column names can't break out
+// of their element or attribute
+func xmlEscape(text string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// writeViaTempFile runs write against a hidden temporary path beside dstPath and renames the
+// result into place, so a failed run never leaves a partial output behind
+func writeViaTempFile(dstPath string, write func(tmpPath string) error) error {
+	tmpPath := filepath.Join(filepath.Dir(dstPath), "."+filepath.Base(dstPath)+".tmp")
+	os.Remove(tmpPath)
+	if err := write(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save %s: %v", filepath.Base(dstPath), err)
+	}
+	return nil
+}
+
+// geotiffOutputPath checks that a raster output goes to a .tif file other than the source and
+// returns it in absolute form
+func geotiffOutputPath(srcPath, dstPath string) (string, error) {
+	switch strings.ToLower(filepath.Ext(dstPath)) {
+	case ".tif", ".tiff":
+	default:
+		return "", fmt.Errorf("the output raster must be saved as a .tif file")
+	}
+	abs, err := filepath.Abs(dstPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid output path: %v", err)
+	}
+	if sameFile(srcPath, abs) {
+		return "", fmt.Errorf("the output raster cannot replace its source")
+	}
+	return abs, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// gdalProgressEvent is emitted while a GDAL conversion started with a progress id runs
+const gdalProgressEvent = "gdal:progress"
+
+// GDALProgress is the payload of gdal:progress events. Indeterminate is set until the tool
+// reports a percentage, and stays set for GDAL builds that don't support -progress.
+type GDALProgress struct {
+	ProgressID    string  `json:"progress_id"`
+	Percent       float64 `json:"percent"`
+	Indeterminate bool    `json:"indeterminate"`
+	Done          bool    `json:"done"`
+	Error         string  `json:"error,omitempty"`
+}
+
+// emitEvent sends a Wails event; like logging it is skipped before startup
+func (a *App) emitEvent(name string, data interface{}) {
+	if a.ctx != nil {
+		runtime.EventsEmit(a.ctx, name, data)
+	}
+}
+
+// gdalProgressWriter parses the "0...10...20...30" line GDAL tools print with -progress.
+// Each of the three dots after a number is another 2.5%.
+type gdalProgressWriter struct {
+	mu       sync.Mutex
+	emit     func(percent float64)
+	digits   []byte
+	last     float64
+	dots     int
+	reported float64
+	started  bool
+}
+
+func (w *gdalProgressWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, c := range p {
+		switch {
+		case c >= '0' && c <= '9':
+			w.digits = append(w.digits, c)
+		case c == '.':
+			if len(w.digits) > 0 {
+				var value float64
+				fmt.Sscan(string(w.digits), &value)
+				w.digits = w.digits[:0]
+				w.last, w.dots = value, 0
+				w.report(value)
+			}
+			if w.started {
+				w.dots++
+				w.report(w.last + 2.5*float64(w.dots))
+			}
+		default:
+			// "100 - done." ends the line
+			if len(w.digits) > 0 {
+				var value float64
+				fmt.Sscan(string(w.digits), &value)
+				w.digits = w.digits[:0]
+				w.report(value)
+			}
+		}
+	}
+	return len(p), nil
+}
+
+// report forwards a new percentage, never going backwards
+func (w *gdalProgressWriter) report(percent float64) {
+	if percent > 100 {
+		percent = 100
+	}
+	if w.started && percent <= w.reported {
+		return
+	}
+	w.started = true
+	w.reported = percent
+	w.emit(percent)
+}
+
+// ogr2ogrGeoJSONWithProgress converts filePath to GeoJSON with -progress, emitting gdal:progress
+// events. GDAL prints progress on stdout, so the GeoJSON goes to a temporary file instead.
+func (a *App) ogr2ogrGeoJSONWithProgress(ogr2ogr string, progressID string, filePath string) ([]byte, error) {
+	tmp, err := os.CreateTemp("", "terrabox-*.geojson")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	// ogr2ogr refuses to overwrite an existing GeoJSON file
+	os.Remove(tmpPath)
+	defer os.Remove(tmpPath)
+
+	if err := a.runOgr2ogrWithProgress(ogr2ogr, progressID, "-f", "GeoJSON", tmpPath, filePath); err != nil {
+		return nil, err
+	}
+	output, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ogr2ogr output: %v", err)
+	}
+	return output, nil
+}
+
+// runOgr2ogrWithProgress runs ogr2ogr with -progress and reports it under progressID, starting
+// and ending with an event so the UI always sees the conversion begin and finish
+func (a *App) runOgr2ogrWithProgress(ogr2ogr string, progressID string, args ...string) error {
+	a.emitEvent(gdalProgressEvent, GDALProgress{ProgressID: progressID, Indeterminate: true})
+	writer := &gdalProgressWriter{emit: func(percent float64) {
+		a.emitEvent(gdalProgressEvent, GDALProgress{ProgressID: progressID, Percent: percent})
+	}}
+
+	stderr, err := a.execGDALTo(context.Background(), writer, ogr2ogr, "", append([]string{"-progress"}, args...)...)
+	if err != nil && !writer.started && bytes.Contains(stderr, []byte("-progress")) {
+		// Builds without -progress reject the option; the UI keeps its indeterminate bar
+		a.logDebug("ogr2ogr does not support -progress, converting without it")
+		stderr, err = a.execGDALTo(context.Background(), io.Discard, ogr2ogr, "", args...)
+	}
+
+	final := GDALProgress{ProgressID: progressID, Percent: 100, Done: true}
+	if err != nil {
+		err = fmt.Errorf("ogr2ogr failed: %w: %s", err, strings.TrimSpace(string(stderr)))
+		final.Percent = writer.reported
+		final.Error = err.Error()
+	}
+	a.emitEvent(gdalProgressEvent, final)
+	return err
+}
+
+// runGDALToolWithProgress runs a raster utility such as gdalwarp, which prints the same progress
+// line as ogr2ogr by default, and reports it under progressID
+func (a *App) runGDALToolWithProgress(name string, progressID string, args ...string) error {
+	tool, err := a.gdalTool(name)
+	if err != nil {
+		return err
+	}
+
+	a.emitEvent(gdalProgressEvent, GDALProgress{ProgressID: progressID, Indeterminate: true})
+	writer := &gdalProgressWriter{emit: func(percent float64) {
+		a.emitEvent(gdalProgressEvent, GDALProgress{ProgressID: progressID, Percent: percent})
+	}}
+	stderr, err := a.execGDALTo(context.Background(), writer, tool, "", args...)
+
+	final := GDALProgress{ProgressID: progressID, Percent: 100, Done: true}
+	if err != nil {
+		err = fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(stderr)))
+		final.Percent = writer.reported
+		final.Error = err.Error()
+	}
+	a.emitEvent(gdalProgressEvent, final)
+	return err
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxReportedValidationErrors caps the errors quoted when validation blocks an import or save
+const maxReportedValidationErrors = 3
+
+// ValidationError is one RFC 7946 violation; Path locates it, e.g. $.features[2].geometry.type
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// geometryTypes are the RFC 7946 geometry types
+var geometryTypes = map[string]bool{
+	"Point": true, "MultiPoint": true, "LineString": true, "MultiLineString": true,
+	"Polygon": true, "MultiPolygon": true, "GeometryCollection": true,
+}
+
+// ValidateGeoJSON checks a FeatureCollection, Feature or geometry against the structure RFC 7946
+// requires: type members, coordinate nesting per geometry type, closed polygon rings of at least
+// four positions, 4 or 6 element bboxes and longitude/latitude ranges. An empty list means valid.
+func (a *App) ValidateGeoJSON(geojson map[string]interface{}) ([]ValidationError, error) {
+	if geojson == nil {
+		return nil, fmt.Errorf("no GeoJSON given")
+	}
+	// Round-trip through JSON so values built in Go look the same as decoded ones
+	data, err := json.Marshal(geojson)
+	if err != nil {
+		return nil, fmt.Errorf("GeoJSON cannot be encoded: %v", err)
+	}
+	var object interface{}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return nil, fmt.Errorf("GeoJSON cannot be encoded: %v", err)
+	}
+
+	v := &geojsonValidator{errors: []ValidationError{}}
+	v.object(object, "$")
+	return v.errors, nil
+}
+
+// validationFailure turns validation errors into a single error quoting the first few
+func validationFailure(errs []ValidationError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	var messages []string
+	for i, e := range errs {
+		if i == maxReportedValidationErrors {
+			messages = append(messages, fmt.Sprintf("and %d more", len(errs)-i))
+			break
+		}
+		messages = append(messages, e.Path+": "+e.Message)
+	}
+	return fmt.Errorf("invalid GeoJSON: %s", strings.Join(messages, "; "))
+}
+
+type geojsonValidator struct {
+	errors []ValidationError
+}
+
+func (v *geojsonValidator) fail(path string, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// object validates any top-level GeoJSON object by its type
+func (v *geojsonValidator) object(value interface{}, path string) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail(path, "must be an object")
+		return
+	}
+	typ, ok := object["type"].(string)
+	if !ok {
+		v.fail(path+".type", "is required and must be a string")
+		return
+	}
+	switch {
+	case typ == "FeatureCollection":
+		v.bbox(object, path)
+		features, ok := object["features"].([]interface{})
+		if !ok {
+			v.fail(path+".features", "is required and must be an array")
+			return
+		}
+		for i, feature := range features {
+			v.feature(feature, fmt.Sprintf("%s.features[%d]", path, i))
+		}
+	case typ == "Feature":
+		v.feature(object, path)
+	case geometryTypes[typ]:
+		v.geometry(object, path)
+	default:
+		v.fail(path+".type", "%q is not a GeoJSON type", typ)
+	}
+}
+
+func (v *geojsonValidator) feature(value interface{}, path string) {
+	feature, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail(path, "must be an object")
+		return
+	}
+	if typ, _ := feature["type"].(string); typ != "Feature" {
+		v.fail(path+".type", "must be \"Feature\"")
+	}
+	if id, ok := feature["id"]; ok {
+		switch id.(type) {
+		case string, float64:
+		default:
+			v.fail(path+".id", "must be a string or number")
+		}
+	}
+	if properties, ok := feature["properties"]; !ok {
+		v.fail(path+".properties", "is required (use null for none)")
+	} else if _, isObject := properties.(map[string]interface{}); !isObject && properties != nil {
+		v.fail(path+".properties", "must be an object or null")
+	}
+	v.bbox(feature, path)
+
+	geometry, ok := feature["geometry"]
+	if !ok {
+		v.fail(path+".geometry", "is required (use null for none)")
+	} else if geometry != nil {
+		v.geometry(geometry, path+".geometry")
+	}
+}
+
+func (v *geojsonValidator) geometry(value interface{}, path string) {
+	geometry, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail(path, "must be an object or null")
+		return
+	}
+	typ, _ := geometry["type"].(string)
+	if !geometryTypes[typ] {
+		v.fail(path+".type", "must be a geometry type, got %v", geometry["type"])
+		return
+	}
+	v.bbox(geometry, path)
+
+	if typ == "GeometryCollection" {
+		geometries, ok := geometry["geometries"].([]interface{})
+		if !ok {
+			v.fail(path+".geometries", "is required and must be an array")
+			return
+		}
+		for i, member := range geometries {
+			v.geometry(member, fmt.Sprintf("%s.geometries[%d]", path, i))
+		}
+		return
+	}
+
+	coordinates, ok := geometry["coordinates"]
+	if !ok {
+		v.fail(path+".coordinates", "is required")
+		return
+	}
+	path += ".coordinates"
+	switch typ {
+	case "Point":
+		v.position(coordinates, path)
+	case "MultiPoint":
+		v.positions(coordinates, path, 0)
+	case "LineString":
+		v.positions(coordinates, path, 2)
+	case "MultiLineString":
+		v.each(coordinates, path, func(line interface{}, linePath string) {
+			v.positions(line, linePath, 2)
+		})
+	case "Polygon":
+		v.polygon(coordinates, path)
+	case "MultiPolygon":
+		v.each(coordinates, path, v.polygon)
+	}
+}
+
+func (v *geojsonValidator) polygon(value interface{}, path string) {
+	v.each(value, path, func(ring interface{}, ringPath string) {
+		if !v.positions(ring, ringPath, 4) {
+			return
+		}
+		positions := ring.([]interface{})
+		first, _ := positions[0].([]interface{})
+		last, _ := positions[len(positions)-1].([]interface{})
+		if len(first) < 2 || len(last) < 2 || first[0] != last[0] || first[1] != last[1] {
+			v.fail(ringPath, "ring is not closed: the first and last positions must be equal")
+		}
+	})
+}
+
+// each validates every element of an array with fn
+func (v *geojsonValidator) each(value interface{}, path string, fn func(interface{}, string)) {
+	items, ok := value.([]interface{})
+	if !ok {
+		v.fail(path, "must be an array")
+		return
+	}
+	for i, item := range items {
+		fn(item, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+// positions validates an array of at least min positions and reports whether its shape is usable
+func (v *geojsonValidator) positions(value interface{}, path string, min int) bool {
+	items, ok := value.([]interface{})
+	if !ok {
+		v.fail(path, "must be an array of positions")
+		return false
+	}
+	if len(items) < min {
+		v.fail(path, "needs at least %d positions, got %d", min, len(items))
+		return false
+	}
+	valid := true
+	for i, item := range items {
+		if !v.position(item, fmt.Sprintf("%s[%d]", path, i)) {
+			valid = false
+		}
+	}
+	return valid
+}
+
+// position validates [longitude, latitude(, elevation)] and reports whether it is well formed
+func (v *geojsonValidator) position(value interface{}, path string) bool {
+	items, ok := value.([]interface{})
+	if !ok || len(items) < 2 {
+		v.fail(path, "must be a position of at least two numbers")
+		return false
+	}
+	for i, item := range items {
+		if _, ok := item.(float64); !ok {
+			v.fail(fmt.Sprintf("%s[%d]", path, i), "must be a number")
+			return false
+		}
+	}
+	lon, lat := items[0].(float64), items[1].(float64)
+	if lon < -180 || lon > 180 {
+		v.fail(path+"[0]", "longitude %g is outside -180..180", lon)
+	}
+	if lat < -90 || lat > 90 {
+		v.fail(path+"[1]", "latitude %g is outside -90..90", lat)
+	}
+	return true
+}
+
+// bbox validates an optional bbox member: 4 or 6 numbers, south <= north. West may exceed east
+// for boxes crossing the antimeridian.
+func (v *geojsonValidator) bbox(object map[string]interface{}, path string) {
+	value, ok := object["bbox"]
+	if !ok {
+		return
+	}
+	path += ".bbox"
+	items, ok := value.([]interface{})
+	if !ok || (len(items) != 4 && len(items) != 6) {
+		v.fail(path, "must be an array of 4 or 6 numbers")
+		return
+	}
+	numbers := make([]float64, len(items))
+	for i, item := range items {
+		number, ok := item.(float64)
+		if !ok {
+			v.fail(fmt.Sprintf("%s[%d]", path, i), "must be a number")
+			return
+		}
+		numbers[i] = number
+	}
+	half := len(numbers) / 2
+	south, north := numbers[1], numbers[half+1]
+	if south > north {
+		v.fail(path, "south (%g) is greater than north (%g)", south, north)
+	}
+	if south < -90 || north > 90 {
+		v.fail(path, "latitudes must be within -90..90")
+	}
+}
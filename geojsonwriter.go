@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// geojsonWriter streams a FeatureCollection one feature at a time so large collections never have
+// to be marshaled as a single document in memory
+type geojsonWriter struct {
+	w       io.Writer
+	pretty  bool
+	buf     bytes.Buffer
+	enc     *json.Encoder
+	count   int
+	written int64
+}
+
+// newGeoJSONWriter writes the FeatureCollection envelope, including any foreign members such as
+// name or crs, up to the opening bracket of the features array
+func newGeoJSONWriter(w io.Writer, members map[string]interface{}, pretty bool) (*geojsonWriter, error) {
+	gw := &geojsonWriter{w: w, pretty: pretty}
+	gw.enc = json.NewEncoder(&gw.buf)
+
+	keys := make([]string, 0, len(members))
+	for key := range members {
+		if key != "type" && key != "features" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	if err := gw.writeString("{" + gw.newline(1) + `"type": "FeatureCollection"`); err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		name, _ := json.Marshal(key)
+		value, err := gw.encode(members[key], "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %v", key, err)
+		}
+		if err := gw.writeString("," + gw.newline(1) + string(name) + ": " + value); err != nil {
+			return nil, err
+		}
+	}
+	if err := gw.writeString("," + gw.newline(1) + `"features": [`); err != nil {
+		return nil, err
+	}
+
+	return gw, nil
+}
+
+// WriteFeature appends one feature to the features array and returns its encoded size
+func (gw *geojsonWriter) WriteFeature(feature map[string]interface{}) (int64, error) {
+	data, err := gw.encode(feature, "    ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode feature %d: %v", gw.count, err)
+	}
+
+	separator := ","
+	if gw.count == 0 {
+		separator = ""
+	}
+	if err := gw.writeString(separator + gw.newline(2) + data); err != nil {
+		return 0, err
+	}
+	gw.count++
+	return int64(len(data)), nil
+}
+
+// Close terminates the features array and the collection
+func (gw *geojsonWriter) Close() error {
+	closing := "]}"
+	if gw.pretty {
+		closing = "\n  ]\n}"
+		if gw.count == 0 {
+			closing = "]\n}"
+		}
+	}
+	return gw.writeString(closing + "\n")
+}
+
+// encodedSize returns how many bytes a feature takes in this writer's format
+func (gw *geojsonWriter) encodedSize(feature map[string]interface{}) (int64, error) {
+	data, err := gw.encode(feature, "    ")
+	return int64(len(data)), err
+}
+
+// Written returns the number of bytes written so far
+func (gw *geojsonWriter) Written() int64 {
+	return gw.written
+}
+
+func (gw *geojsonWriter) encode(value interface{}, prefix string) (string, error) {
+	gw.buf.Reset()
+	if gw.pretty {
+		gw.enc.SetIndent(prefix, "  ")
+	}
+	if err := gw.enc.Encode(value); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimRight(gw.buf.Bytes(), "\n")), nil
+}
+
+func (gw *geojsonWriter) newline(depth int) string {
+	if !gw.pretty {
+		return ""
+	}
+	return "\n" + string(bytes.Repeat([]byte("  "), depth))
+}
+
+func (gw *geojsonWriter) writeString(s string) error {
+	n, err := io.WriteString(gw.w, s)
+	gw.written += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write GeoJSON: %v", err)
+	}
+	return nil
+}
+
+// readGeoJSONFeatures streams the features of a FeatureCollection document to fn one at a time,
+// without holding the whole collection in memory
+func readGeoJSONFeatures(r io.Reader, fn func(feature map[string]interface{}) error) error {
+	dec := json.NewDecoder(r)
+	if token, err := dec.Token(); err != nil || token != json.Delim('{') {
+		return fmt.Errorf("not a GeoJSON object")
+	}
+
+	sawFeatures := false
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("failed to parse GeoJSON: %v", err)
+		}
+		key, _ := token.(string)
+		if key != "features" {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return fmt.Errorf("failed to parse GeoJSON: %v", err)
+			}
+			continue
+		}
+
+		if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+			return fmt.Errorf("features is not an array")
+		}
+		for dec.More() {
+			var feature map[string]interface{}
+			if err := dec.Decode(&feature); err != nil {
+				return fmt.Errorf("failed to parse feature: %v", err)
+			}
+			if err := fn(feature); err != nil {
+				return err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return fmt.Errorf("failed to parse GeoJSON: %v", err)
+		}
+		sawFeatures = true
+	}
+
+	if !sawFeatures {
+		return fmt.Errorf("not a GeoJSON FeatureCollection")
+	}
+	return nil
+}
+
+// featureKey returns the identity used to dedupe features: the feature id, or an "id" property
+func featureKey(feature map[string]interface{}) (string, bool) {
+	if id, ok := feature["id"]; ok && id != nil {
+		return fmt.Sprint(id), true
+	}
+	if props, ok := feature["properties"].(map[string]interface{}); ok {
+		if id, ok := props["id"]; ok && id != nil {
+			return fmt.Sprint(id), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// gpkgGeometryTypeNames maps GeoPackage geometry type names to GeoJSON spelling
+var gpkgGeometryTypeNames = map[string]string{
+	"GEOMETRY":           "Geometry",
+	"POINT":              "Point",
+	"LINESTRING":         "LineString",
+	"POLYGON":            "Polygon",
+	"MULTIPOINT":         "MultiPoint",
+	"MULTILINESTRING":    "MultiLineString",
+	"MULTIPOLYGON":       "MultiPolygon",
+	"GEOMETRYCOLLECTION": "GeometryCollection",
+}
+
+// geometryTypeSet collects the distinct geometry types of a layer
+type geometryTypeSet struct {
+	seen  map[string]bool
+	nulls int
+}
+
+// add records a feature's geometry; nil counts as a feature without geometry
+func (s *geometryTypeSet) add(geometry map[string]interface{}) {
+	if geometry == nil {
+		s.nulls++
+		return
+	}
+	if name, ok := geometry["type"].(string); ok && name != "" {
+		if s.seen == nil {
+			s.seen = map[string]bool{}
+		}
+		s.seen[name] = true
+	}
+}
+
+// list returns the sorted types; empty for an empty or geometry-less layer
+func (s *geometryTypeSet) list() []string {
+	types := make([]string, 0, len(s.seen))
+	for name := range s.seen {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// store writes geometry_types, and null_geometries when some features had none
+func (s *geometryTypeSet) store(metadata *FileMetadata) {
+	metadata.Metadata["geometry_types"] = s.list()
+	if s.nulls > 0 {
+		metadata.Metadata["null_geometries"] = s.nulls
+	}
+}
+
+// shapefileGeometryTypes reads the shape type from the .shp header. Shapefiles hold a single
+// type; a file with no records or the null shape type is reported as empty.
+func shapefileGeometryTypes(filePath string) ([]string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data := make([]byte, 100)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, fmt.Errorf("failed to read shapefile header: %v", err)
+	}
+	header, err := readShapefileHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	name := shapeTypeName(header.ShapeType)
+	if info.Size() <= 100 || name == "" {
+		return []string{}, nil
+	}
+	return []string{name}, nil
+}
+
+// extractGeoPackageMetadata reads layers, geometry types and CRS from gpkg_geometry_columns
+func (a *App) extractGeoPackageMetadata(filePath string, metadata *FileMetadata) error {
+	metadata.Metadata["format"] = "GeoPackage"
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(filePath)+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`
+		SELECT g.table_name, g.geometry_type_name, COALESCE(s.organization, ''), COALESCE(s.organization_coordsys_id, 0)
+		FROM gpkg_geometry_columns g
+		LEFT JOIN gpkg_spatial_ref_sys s ON s.srs_id = g.srs_id
+		ORDER BY g.table_name`)
+	if err != nil {
+		return fmt.Errorf("failed to read GeoPackage layers: %v", err)
+	}
+	defer rows.Close()
+
+	types := map[string]bool{}
+	layers := map[string]string{}
+	for rows.Next() {
+		var table, geometryType, organization string
+		var code int
+		if err := rows.Scan(&table, &geometryType, &organization, &code); err != nil {
+			return fmt.Errorf("failed to read GeoPackage layers: %v", err)
+		}
+		name, ok := gpkgGeometryTypeNames[strings.ToUpper(geometryType)]
+		if !ok {
+			name = geometryType
+		}
+		types[name] = true
+		layers[table] = name
+		if strings.EqualFold(organization, "EPSG") && code > 0 && len(layers) == 1 {
+			metadata.CRS = fmt.Sprintf("EPSG:%d", code)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read GeoPackage layers: %v", err)
+	}
+
+	list := make([]string, 0, len(types))
+	for name := range types {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	metadata.Metadata["geometry_types"] = list
+	metadata.Metadata["layer_geometry_types"] = layers
+
+	fields, err := geoPackageFields(filePath)
+	if err != nil {
+		return err
+	}
+	metadata.Metadata["layer_fields"] = fields
+	if len(fields) == 1 {
+		for _, layer := range fields {
+			metadata.Metadata["fields"] = layer
+		}
+	}
+	return nil
+}
+
+// indexedGeometryTypes reads geometry_types back out of a stored metadata document
+func indexedGeometryTypes(metadataJSON string) []string {
+	var metadata struct {
+		GeometryTypes []string `json:"geometry_types"`
+	}
+	if metadataJSON == "" || json.Unmarshal([]byte(metadataJSON), &metadata) != nil {
+		return nil
+	}
+	return metadata.GeometryTypes
+}
@@ -0,0 +1,504 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb/encoding/wkb"
+	"github.com/paulmach/orb/encoding/wkt"
+)
+
+// ewktSRIDPattern matches the "SRID=4326;" prefix PostGIS puts on EWKT
+var ewktSRIDPattern = regexp.MustCompile(`(?i)^\s*SRID=\d+\s*;`)
+
+// ewkbSRIDFlag marks PostGIS EWKB that carries an SRID after the type
+const ewkbSRIDFlag = 0x20000000
+
+// GeoJSONToWKT converts a geometry, Feature or FeatureCollection to one WKT string per feature,
+// in feature order. Features without a geometry give an empty string.
+func (a *App) GeoJSONToWKT(geojson map[string]interface{}) ([]string, error) {
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, len(features))
+	for i, feature := range features {
+		g, err := orbGeometry(feature["geometry"])
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+		if g != nil {
+			result[i] = wkt.MarshalString(g)
+		}
+	}
+	return result, nil
+}
+
+// GeoJSONToWKB is GeoJSONToWKT for WKB, as upper-case hex the way PostGIS prints it
+func (a *App) GeoJSONToWKB(geojson map[string]interface{}) ([]string, error) {
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, len(features))
+	for i, feature := range features {
+		g, err := orbGeometry(feature["geometry"])
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+		if g == nil {
+			continue
+		}
+		data, err := wkb.Marshal(g)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: failed to encode WKB: %v", i, err)
+		}
+		result[i] = strings.ToUpper(hex.EncodeToString(data))
+	}
+	return result, nil
+}
+
+// WKTToGeoJSON parses a WKT or PostGIS EWKT geometry, including Z coordinates and
+// GEOMETRYCOLLECTION, into a GeoJSON geometry. M values are dropped.
+func (a *App) WKTToGeoJSON(text string) (map[string]interface{}, error) {
+	return parseWKT(text)
+}
+
+// WKBToGeoJSON decodes a hex-encoded WKB or PostGIS EWKB geometry into a GeoJSON geometry
+func (a *App) WKBToGeoJSON(hexWKB string) (map[string]interface{}, error) {
+	text := strings.TrimSpace(hexWKB)
+	text = strings.TrimPrefix(strings.TrimPrefix(text, `\x`), "0x")
+	data, err := hex.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("WKB must be hex encoded: %v", err)
+	}
+	data = stripEWKBSRID(data)
+
+	g, err := wkb.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WKB: %v", err)
+	}
+	return geometryToMap(g), nil
+}
+
+// stripEWKBSRID removes the SRID from the outer geometry of PostGIS EWKB, leaving plain WKB
+func stripEWKBSRID(data []byte) []byte {
+	if len(data) < 9 || data[0] > 1 {
+		return data
+	}
+	var order binary.ByteOrder = binary.BigEndian
+	if data[0] == 1 {
+		order = binary.LittleEndian
+	}
+	typ := order.Uint32(data[1:5])
+	if typ&ewkbSRIDFlag == 0 {
+		return data
+	}
+	stripped := make([]byte, 0, len(data)-4)
+	stripped = append(stripped, data[0], 0, 0, 0, 0)
+	order.PutUint32(stripped[1:5], typ&^ewkbSRIDFlag)
+	return append(stripped, data[9:]...)
+}
+
+// parseWKT parses one WKT geometry that must make up the whole text
+func parseWKT(text string) (map[string]interface{}, error) {
+	text = ewktSRIDPattern.ReplaceAllString(text, "")
+	p := &wktParser{text: text}
+	geometry, err := p.geometry()
+	if err != nil {
+		return nil, fmt.Errorf("invalid WKT: %v", err)
+	}
+	p.skipSpace()
+	if p.pos < len(p.text) {
+		return nil, fmt.Errorf("invalid WKT: unexpected %q after the geometry", p.rest())
+	}
+	return geometry, nil
+}
+
+// wktParser is a recursive-descent parser producing GeoJSON geometry maps
+type wktParser struct {
+	text string
+	pos  int
+	// hasM means the fourth (or, without Z, third) ordinate is a measure to drop
+	hasZ, hasM bool
+}
+
+var wktGeometryNames = map[string]string{
+	"POINT":              "Point",
+	"LINESTRING":         "LineString",
+	"POLYGON":            "Polygon",
+	"MULTIPOINT":         "MultiPoint",
+	"MULTILINESTRING":    "MultiLineString",
+	"MULTIPOLYGON":       "MultiPolygon",
+	"GEOMETRYCOLLECTION": "GeometryCollection",
+}
+
+func (p *wktParser) skipSpace() {
+	for p.pos < len(p.text) && strings.ContainsRune(" \t\r\n", rune(p.text[p.pos])) {
+		p.pos++
+	}
+}
+
+// rest returns a short excerpt of the unparsed text for error messages
+func (p *wktParser) rest() string {
+	rest := p.text[p.pos:]
+	if len(rest) > 20 {
+		rest = rest[:20] + "..."
+	}
+	return rest
+}
+
+func (p *wktParser) word() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.text) {
+		c := p.text[p.pos]
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z') {
+			break
+		}
+		p.pos++
+	}
+	return strings.ToUpper(p.text[start:p.pos])
+}
+
+// peekWord returns the next word without consuming it
+func (p *wktParser) peekWord() string {
+	pos := p.pos
+	word := p.word()
+	p.pos = pos
+	return word
+}
+
+// accept consumes c if it comes next
+func (p *wktParser) accept(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.text) && p.text[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *wktParser) expect(c byte) error {
+	if !p.accept(c) {
+		if p.pos >= len(p.text) {
+			return fmt.Errorf("expected %q but the text ended", c)
+		}
+		return fmt.Errorf("expected %q at %q", c, p.rest())
+	}
+	return nil
+}
+
+func (p *wktParser) geometry() (map[string]interface{}, error) {
+	name := p.word()
+	if name == "" {
+		return nil, fmt.Errorf("expected a geometry type at %q", p.rest())
+	}
+
+	// Dimensions may be attached ("POINTZ") or separate ("POINT Z")
+	dims := ""
+	if _, ok := wktGeometryNames[name]; !ok {
+		for _, suffix := range []string{"ZM", "Z", "M"} {
+			if base := strings.TrimSuffix(name, suffix); base != name && wktGeometryNames[base] != "" {
+				name, dims = base, suffix
+				break
+			}
+		}
+	}
+	geoType, ok := wktGeometryNames[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown geometry type %s", name)
+	}
+	if dims == "" {
+		if next := p.peekWord(); next == "Z" || next == "M" || next == "ZM" {
+			dims = p.word()
+		}
+	}
+	if dims != "" {
+		p.hasZ, p.hasM = strings.Contains(dims, "Z"), strings.Contains(dims, "M")
+	}
+
+	if p.peekWord() == "EMPTY" {
+		p.word()
+		if geoType == "GeometryCollection" {
+			return map[string]interface{}{"type": geoType, "geometries": []interface{}{}}, nil
+		}
+		return map[string]interface{}{"type": geoType, "coordinates": []interface{}{}}, nil
+	}
+
+	var coordinates interface{}
+	var err error
+	switch geoType {
+	case "Point":
+		if err = p.expect('('); err == nil {
+			if coordinates, err = p.position(); err == nil {
+				err = p.expect(')')
+			}
+		}
+	case "LineString":
+		coordinates, err = p.positions()
+	case "Polygon":
+		coordinates, err = p.list(p.positions)
+	case "MultiPoint":
+		// Both MULTIPOINT(1 2, 3 4) and MULTIPOINT((1 2), (3 4)) are in use
+		coordinates, err = p.list(func() (interface{}, error) {
+			if p.accept('(') {
+				position, err := p.position()
+				if err != nil {
+					return nil, err
+				}
+				return position, p.expect(')')
+			}
+			return p.position()
+		})
+	case "MultiLineString":
+		coordinates, err = p.list(p.positions)
+	case "MultiPolygon":
+		coordinates, err = p.list(func() (interface{}, error) { return p.list(p.positions) })
+	case "GeometryCollection":
+		geometries, err := p.list(func() (interface{}, error) { return p.geometry() })
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": geoType, "geometries": geometries}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"type": geoType, "coordinates": coordinates}, nil
+}
+
+// list parses "(" item ("," item)* ")"
+func (p *wktParser) list(item func() (interface{}, error)) ([]interface{}, error) {
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	items := []interface{}{}
+	for {
+		value, err := item()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+		if !p.accept(',') {
+			break
+		}
+	}
+	return items, p.expect(')')
+}
+
+// positions parses a parenthesised coordinate sequence
+func (p *wktParser) positions() (interface{}, error) {
+	return p.list(func() (interface{}, error) { return p.position() })
+}
+
+// position parses "x y [z] [m]" into a GeoJSON position, dropping any measure
+func (p *wktParser) position() ([]interface{}, error) {
+	var values []float64
+	for len(values) < 4 {
+		p.skipSpace()
+		start := p.pos
+		for p.pos < len(p.text) && strings.ContainsRune("+-.0123456789eE", rune(p.text[p.pos])) {
+			p.pos++
+		}
+		if start == p.pos {
+			break
+		}
+		value, err := strconv.ParseFloat(p.text[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.text[start:p.pos])
+		}
+		values = append(values, value)
+	}
+	if len(values) < 2 {
+		return nil, fmt.Errorf("expected a coordinate at %q", p.rest())
+	}
+
+	keep := len(values)
+	switch {
+	case p.hasM && p.hasZ, keep == 4:
+		keep = 3
+	case p.hasM:
+		keep = 2
+	}
+	if keep > len(values) {
+		keep = len(values)
+	}
+	position := make([]interface{}, keep)
+	for i := range position {
+		position[i] = values[i]
+	}
+	return position, nil
+}
+
+var (
+	wktStartPattern  = regexp.MustCompile(`(?i)^\s*(SRID=\d+\s*;\s*)?(POINT|LINESTRING|POLYGON|MULTIPOINT|MULTILINESTRING|MULTIPOLYGON|GEOMETRYCOLLECTION)\b`)
+	hexWKBPattern    = regexp.MustCompile(`^(\\x|0x)?0[01][0-9A-Fa-f]{16,}$`)
+	coordSeparators  = regexp.MustCompile(`[\n;]+`)
+	coordPairPattern = regexp.MustCompile(`^\s*\(?\s*([-+]?[\d.]+(?:[eE][-+]?\d+)?)\s*[,\s]\s*([-+]?[\d.]+(?:[eE][-+]?\d+)?)\s*\)?\s*,?\s*$`)
+)
+
+// ImportGeometryText turns pasted text into a FeatureCollection for display. It accepts GeoJSON
+// (a geometry, Feature or FeatureCollection), one WKT/EWKT geometry per line, hex WKB, or a bare
+// list of "lon lat" / "lon,lat" pairs: one pair is a point, several a line, and a closed ring of
+// four or more a polygon.
+func (a *App) ImportGeometryText(text string) (map[string]interface{}, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("nothing to import")
+	}
+
+	var geometries []map[string]interface{}
+	switch {
+	case strings.HasPrefix(text, "{"):
+		return importGeoJSONText(text)
+	case strings.HasPrefix(text, "["):
+		return importCoordinateArray(text)
+	case wktStartPattern.MatchString(text):
+		for _, line := range strings.Split(text, "\n") {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			geometry, err := parseWKT(line)
+			if err != nil {
+				// Pretty-printed WKT spans lines, so try the text as a whole before giving up
+				if geometry, wholeErr := parseWKT(text); wholeErr == nil {
+					geometries = []map[string]interface{}{geometry}
+					break
+				}
+				return nil, err
+			}
+			geometries = append(geometries, geometry)
+		}
+	case hexWKBPattern.MatchString(text):
+		geometry, err := a.WKBToGeoJSON(text)
+		if err != nil {
+			return nil, err
+		}
+		geometries = append(geometries, geometry)
+	default:
+		geometry, err := parseCoordinateList(text)
+		if err != nil {
+			return nil, fmt.Errorf("could not recognise the text as GeoJSON, WKT, WKB or a coordinate list: %v", err)
+		}
+		geometries = append(geometries, geometry)
+	}
+
+	features := make([]interface{}, len(geometries))
+	for i, geometry := range geometries {
+		features[i] = map[string]interface{}{"type": "Feature", "properties": map[string]interface{}{}, "geometry": geometry}
+	}
+	return map[string]interface{}{"type": "FeatureCollection", "features": features}, nil
+}
+
+// importGeoJSONText parses and validates GeoJSON, normalising it to a FeatureCollection
+func importGeoJSONText(text string) (map[string]interface{}, error) {
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(text), &object); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+	validation := &geojsonValidator{}
+	validation.object(object, "$")
+	if err := validationFailure(validation.errors); err != nil {
+		return nil, err
+	}
+	features, err := geojsonFeatures(object)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, len(features))
+	for i, feature := range features {
+		if _, err := orbGeometry(feature["geometry"]); err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+		featureProperties(feature)
+		result[i] = feature
+	}
+	return map[string]interface{}{"type": "FeatureCollection", "features": result}, nil
+}
+
+// importCoordinateArray accepts [lon, lat] or [[lon, lat], ...] in JSON notation
+func importCoordinateArray(text string) (map[string]interface{}, error) {
+	var single []float64
+	if err := json.Unmarshal([]byte(text), &single); err == nil {
+		return coordinateListCollection([][]float64{single})
+	}
+	var list [][]float64
+	if err := json.Unmarshal([]byte(text), &list); err != nil {
+		return nil, fmt.Errorf("could not read the array as [lon, lat] positions: %v", err)
+	}
+	return coordinateListCollection(list)
+}
+
+func coordinateListCollection(positions [][]float64) (map[string]interface{}, error) {
+	geometry, err := coordinateGeometry(positions)
+	if err != nil {
+		return nil, err
+	}
+	feature := map[string]interface{}{"type": "Feature", "properties": map[string]interface{}{}, "geometry": geometry}
+	return map[string]interface{}{"type": "FeatureCollection", "features": []interface{}{feature}}, nil
+}
+
+// parseCoordinateList reads one "x y" or "x,y" pair per line (or separated by semicolons)
+func parseCoordinateList(text string) (map[string]interface{}, error) {
+	var positions [][]float64
+	for _, part := range coordSeparators.Split(text, -1) {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		match := coordPairPattern.FindStringSubmatch(part)
+		if match == nil {
+			return nil, fmt.Errorf("%q is not a coordinate pair", strings.TrimSpace(part))
+		}
+		x, err1 := strconv.ParseFloat(match[1], 64)
+		y, err2 := strconv.ParseFloat(match[2], 64)
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("%q is not a coordinate pair", strings.TrimSpace(part))
+		}
+		positions = append(positions, []float64{x, y})
+	}
+	return coordinateGeometry(positions)
+}
+
+// coordinateGeometry builds a Point, LineString or Polygon from lon/lat positions. Pairs that only
+// make sense as lat/lon (first within ±90, second beyond it) are swapped.
+func coordinateGeometry(positions [][]float64) (map[string]interface{}, error) {
+	if len(positions) == 0 {
+		return nil, fmt.Errorf("no coordinates found")
+	}
+	swap := true
+	for _, p := range positions {
+		if len(p) < 2 {
+			return nil, fmt.Errorf("every position needs at least two numbers")
+		}
+		if !(p[0] >= -90 && p[0] <= 90 && (p[1] < -90 || p[1] > 90)) {
+			swap = false
+		}
+	}
+
+	coordinates := make([]interface{}, len(positions))
+	for i, p := range positions {
+		lon, lat := p[0], p[1]
+		if swap {
+			lon, lat = lat, lon
+		}
+		if lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("position %d (%g, %g) is not a longitude/latitude", i, p[0], p[1])
+		}
+		coordinates[i] = []interface{}{lon, lat}
+	}
+
+	first, last := positions[0], positions[len(positions)-1]
+	switch {
+	case len(positions) == 1:
+		return map[string]interface{}{"type": "Point", "coordinates": coordinates[0]}, nil
+	case len(positions) >= 4 && first[0] == last[0] && first[1] == last[1]:
+		return map[string]interface{}{"type": "Polygon", "coordinates": []interface{}{coordinates}}, nil
+	}
+	return map[string]interface{}{"type": "LineString", "coordinates": coordinates}, nil
+}
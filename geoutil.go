@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+)
+
+// earthRadiusMeters is the mean Earth radius used for geodesic approximations
+const earthRadiusMeters = 6371008.8
+
+// haversineMeters returns the great-circle distance between two lon/lat positions in meters
+func haversineMeters(lon1, lat1, lon2, lat2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// geojsonFeatures returns the features of a FeatureCollection. A single Feature or a bare geometry
+// is wrapped so callers can treat every GeoJSON object the same way.
+func geojsonFeatures(geojson map[string]interface{}) ([]map[string]interface{}, error) {
+	switch geojson["type"] {
+	case "FeatureCollection":
+		raw, ok := geojson["features"].([]interface{})
+		if !ok {
+			// Collections built in Go (rather than decoded from JSON) may use a concrete slice type
+			if typed, ok := geojson["features"].([]map[string]interface{}); ok {
+				return typed, nil
+			}
+			return nil, fmt.Errorf("FeatureCollection has no features array")
+		}
+		features := make([]map[string]interface{}, 0, len(raw))
+		for _, f := range raw {
+			if feature, ok := f.(map[string]interface{}); ok {
+				features = append(features, feature)
+			}
+		}
+		return features, nil
+	case "Feature":
+		return []map[string]interface{}{geojson}, nil
+	case "Point", "MultiPoint", "LineString", "MultiLineString", "Polygon", "MultiPolygon", "GeometryCollection":
+		return []map[string]interface{}{{"type": "Feature", "properties": map[string]interface{}{}, "geometry": geojson}}, nil
+	}
+	return nil, fmt.Errorf("unsupported GeoJSON type: %v", geojson["type"])
+}
+
+// orbGeometry converts a GeoJSON geometry object into an orb geometry. A null geometry yields nil.
+func orbGeometry(geometry interface{}) (orb.Geometry, error) {
+	if geometry == nil {
+		return nil, nil
+	}
+	if m, ok := geometry.(map[string]interface{}); ok && m == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(geometry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal geometry: %v", err)
+	}
+	g, err := geojson.UnmarshalGeometry(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid geometry: %v", err)
+	}
+	return g.Geometry(), nil
+}
+
+// geometryToMap converts an orb geometry back into a generic GeoJSON geometry map
+func geometryToMap(g orb.Geometry) map[string]interface{} {
+	if g == nil {
+		return nil
+	}
+	data, err := json.Marshal(geojson.NewGeometry(g))
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// featureProperties returns a feature's properties, creating the map when absent
+func featureProperties(feature map[string]interface{}) map[string]interface{} {
+	props, ok := feature["properties"].(map[string]interface{})
+	if !ok || props == nil {
+		props = map[string]interface{}{}
+		feature["properties"] = props
+	}
+	return props
+}
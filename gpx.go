@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// gpxFile mirrors the GPX 1.0/1.1 elements we convert to GeoJSON
+type gpxFile struct {
+	Waypoints []gpxPoint `xml:"wpt"`
+	Routes    []gpxRoute `xml:"rte"`
+	Tracks    []gpxTrack `xml:"trk"`
+}
+
+type gpxPoint struct {
+	Lat         float64  `xml:"lat,attr"`
+	Lon         float64  `xml:"lon,attr"`
+	Ele         *float64 `xml:"ele"`
+	Time        string   `xml:"time"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"desc"`
+	Symbol      string   `xml:"sym"`
+	Type        string   `xml:"type"`
+}
+
+type gpxRoute struct {
+	Name        string     `xml:"name"`
+	Description string     `xml:"desc"`
+	Type        string     `xml:"type"`
+	Points      []gpxPoint `xml:"rtept"`
+}
+
+type gpxTrack struct {
+	Name        string `xml:"name"`
+	Description string `xml:"desc"`
+	Type        string `xml:"type"`
+	Segments    []struct {
+		Points []gpxPoint `xml:"trkpt"`
+	} `xml:"trkseg"`
+}
+
+// gpxStats summarizes a track or route
+type gpxStats struct {
+	DistanceMeters      float64
+	ElevationGainMeters float64
+	PointCount          int
+}
+
+// readGPXFile parses a .gpx file
+func readGPXFile(filePath string) (*gpxFile, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GPX file: %v", err)
+	}
+
+	var gpx gpxFile
+	if err := xml.Unmarshal(content, &gpx); err != nil {
+		return nil, fmt.Errorf("failed to parse GPX: %v", err)
+	}
+	return &gpx, nil
+}
+
+// readGPX converts a .gpx file into a GeoJSON FeatureCollection: waypoints become Points,
+// routes LineStrings, and tracks LineStrings (MultiLineStrings when they have several segments)
+func readGPX(filePath string) (map[string]interface{}, error) {
+	gpx, err := readGPXFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	features := []interface{}{}
+
+	for _, wpt := range gpx.Waypoints {
+		properties := gpxPointProperties(wpt)
+		properties["gpx_type"] = "waypoint"
+		features = append(features, map[string]interface{}{
+			"type":       "Feature",
+			"properties": properties,
+			"geometry":   map[string]interface{}{"type": "Point", "coordinates": gpxCoordinate(wpt)},
+		})
+	}
+
+	for _, rte := range gpx.Routes {
+		coords, times := gpxLine(rte.Points)
+		stats := computeGPXStats([][]gpxPoint{rte.Points})
+		properties := gpxLineProperties(rte.Name, rte.Description, rte.Type, stats)
+		properties["gpx_type"] = "route"
+		if len(times) > 0 {
+			properties["coord_times"] = times
+		}
+		features = append(features, map[string]interface{}{
+			"type":       "Feature",
+			"properties": properties,
+			"geometry":   map[string]interface{}{"type": "LineString", "coordinates": coords},
+		})
+	}
+
+	for _, trk := range gpx.Tracks {
+		var segments [][]gpxPoint
+		var lines [][][]float64
+		var segmentTimes [][]string
+		timed := true
+		for _, seg := range trk.Segments {
+			if len(seg.Points) == 0 {
+				continue
+			}
+			coords, times := gpxLine(seg.Points)
+			segments = append(segments, seg.Points)
+			lines = append(lines, coords)
+			segmentTimes = append(segmentTimes, times)
+			timed = timed && times != nil
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		stats := computeGPXStats(segments)
+		properties := gpxLineProperties(trk.Name, trk.Description, trk.Type, stats)
+		properties["gpx_type"] = "track"
+
+		geometry := map[string]interface{}{"type": "MultiLineString", "coordinates": lines}
+		var times interface{} = segmentTimes
+		if len(lines) == 1 {
+			geometry = map[string]interface{}{"type": "LineString", "coordinates": lines[0]}
+			times = segmentTimes[0]
+		}
+		// Times are only kept when every point of every segment has one, so they line up with
+		// the coordinates
+		if timed {
+			properties["coord_times"] = times
+		}
+
+		features = append(features, map[string]interface{}{
+			"type":       "Feature",
+			"properties": properties,
+			"geometry":   geometry,
+		})
+	}
+
+	return map[string]interface{}{
+		"type":     "FeatureCollection",
+		"name":     strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)),
+		"features": features,
+	}, nil
+}
+
+// extractGPXMetadata extracts counts, bbox and track statistics from GPX files
+func (a *App) extractGPXMetadata(filePath string, metadata *FileMetadata) error {
+	gpx, err := readGPXFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var segments [][]gpxPoint
+	for _, trk := range gpx.Tracks {
+		for _, seg := range trk.Segments {
+			segments = append(segments, seg.Points)
+		}
+	}
+	for _, rte := range gpx.Routes {
+		segments = append(segments, rte.Points)
+	}
+	stats := computeGPXStats(segments)
+
+	// Compute the bbox across every point in the file
+	minLon, minLat := math.Inf(1), math.Inf(1)
+	maxLon, maxLat := math.Inf(-1), math.Inf(-1)
+	extend := func(p gpxPoint) {
+		minLon, maxLon = math.Min(minLon, p.Lon), math.Max(maxLon, p.Lon)
+		minLat, maxLat = math.Min(minLat, p.Lat), math.Max(maxLat, p.Lat)
+	}
+	for _, wpt := range gpx.Waypoints {
+		extend(wpt)
+	}
+	for _, seg := range segments {
+		for _, p := range seg {
+			extend(p)
+		}
+	}
+	if !math.IsInf(minLon, 1) {
+		metadata.BBox = []float64{minLon, minLat, maxLon, maxLat}
+	}
+
+	metadata.CRS = "EPSG:4326"
+	metadata.NumFeatures = len(gpx.Waypoints) + len(gpx.Routes) + len(gpx.Tracks)
+	metadata.Metadata["format"] = "GPX"
+	metadata.Metadata["waypoint_count"] = len(gpx.Waypoints)
+	metadata.Metadata["route_count"] = len(gpx.Routes)
+	metadata.Metadata["track_count"] = len(gpx.Tracks)
+	metadata.Metadata["point_count"] = stats.PointCount + len(gpx.Waypoints)
+	metadata.Metadata["distance_m"] = math.Round(stats.DistanceMeters*10) / 10
+	metadata.Metadata["elevation_gain_m"] = math.Round(stats.ElevationGainMeters*10) / 10
+
+	return nil
+}
+
+// computeGPXStats sums distance and positive elevation change over a set of point sequences
+func computeGPXStats(segments [][]gpxPoint) gpxStats {
+	var stats gpxStats
+	for _, points := range segments {
+		stats.PointCount += len(points)
+		for i := 1; i < len(points); i++ {
+			prev, cur := points[i-1], points[i]
+			stats.DistanceMeters += haversineMeters(prev.Lon, prev.Lat, cur.Lon, cur.Lat)
+			if prev.Ele != nil && cur.Ele != nil && *cur.Ele > *prev.Ele {
+				stats.ElevationGainMeters += *cur.Ele - *prev.Ele
+			}
+		}
+	}
+	return stats
+}
+
+// gpxLine returns the coordinates of a point sequence and, when every point has one, its timestamps
+func gpxLine(points []gpxPoint) ([][]float64, []string) {
+	coords := make([][]float64, 0, len(points))
+	times := make([]string, 0, len(points))
+	for _, p := range points {
+		coords = append(coords, gpxCoordinate(p))
+		if p.Time != "" {
+			times = append(times, strings.TrimSpace(p.Time))
+		}
+	}
+	if len(times) != len(points) {
+		times = nil
+	}
+	return coords, times
+}
+
+// gpxCoordinate returns [lon, lat] or [lon, lat, ele] when elevation is recorded
+func gpxCoordinate(p gpxPoint) []float64 {
+	if p.Ele != nil {
+		return []float64{p.Lon, p.Lat, *p.Ele}
+	}
+	return []float64{p.Lon, p.Lat}
+}
+
+func gpxPointProperties(p gpxPoint) map[string]interface{} {
+	properties := map[string]interface{}{}
+	if p.Name != "" {
+		properties["name"] = p.Name
+	}
+	if p.Description != "" {
+		properties["description"] = p.Description
+	}
+	if p.Symbol != "" {
+		properties["sym"] = p.Symbol
+	}
+	if p.Type != "" {
+		properties["type"] = p.Type
+	}
+	if p.Time != "" {
+		properties["time"] = strings.TrimSpace(p.Time)
+	}
+	if p.Ele != nil {
+		properties["ele"] = *p.Ele
+	}
+	return properties
+}
+
+func gpxLineProperties(name, description, kind string, stats gpxStats) map[string]interface{} {
+	properties := map[string]interface{}{
+		"distance_m":       math.Round(stats.DistanceMeters*10) / 10,
+		"elevation_gain_m": math.Round(stats.ElevationGainMeters*10) / 10,
+		"point_count":      stats.PointCount,
+	}
+	if name != "" {
+		properties["name"] = name
+	}
+	if description != "" {
+		properties["description"] = description
+	}
+	if kind != "" {
+		properties["type"] = kind
+	}
+	return properties
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadGPXCoordTimes(t *testing.T) {
+	const timed = `<trkseg>
+<trkpt lat="1" lon="1"><time>2026-01-01T10:00:00Z</time></trkpt>
+<trkpt lat="1" lon="2"><time>2026-01-01T10:01:00Z</time></trkpt>
+</trkseg>`
+	const partlyTimed = `<trkseg>
+<trkpt lat="2" lon="1"><time>2026-01-01T11:00:00Z</time></trkpt>
+<trkpt lat="2" lon="2"></trkpt>
+</trkseg>`
+	const untimed = `<trkseg><trkpt lat="3" lon="1"/><trkpt lat="3" lon="2"/></trkseg>`
+
+	tests := []struct {
+		name     string
+		segments string
+		want     interface{}
+	}{
+		{
+			name:     "single timed segment",
+			segments: timed,
+			want:     []string{"2026-01-01T10:00:00Z", "2026-01-01T10:01:00Z"},
+		},
+		{
+			name:     "all segments timed",
+			segments: timed + timed,
+			want: [][]string{
+				{"2026-01-01T10:00:00Z", "2026-01-01T10:01:00Z"},
+				{"2026-01-01T10:00:00Z", "2026-01-01T10:01:00Z"},
+			},
+		},
+		{name: "later segment untimed", segments: timed + untimed},
+		{name: "first segment untimed", segments: untimed + timed},
+		{name: "segment partly timed", segments: timed + partlyTimed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "track.gpx")
+			body := `<?xml version="1.0"?><gpx version="1.1"><trk><name>Ride</name>` + tt.segments + `</trk></gpx>`
+			if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := readGPX(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			features, _ := geojsonFeatures(result)
+			if len(features) != 1 {
+				t.Fatalf("got %d features, want 1", len(features))
+			}
+			got, ok := features[0]["properties"].(map[string]interface{})["coord_times"]
+			if tt.want == nil {
+				if ok {
+					t.Errorf("coord_times = %v, want none", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("coord_times = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Health check statuses
+const (
+	healthOK      = "ok"
+	healthWarning = "warning"
+	healthError   = "error"
+)
+
+// minFreeDiskBytes is the free space below which the data directory check warns
+const minFreeDiskBytes = 500 * 1024 * 1024
+
+// overpassStatusEndpoint is polled to check that the Overpass API is reachable
+const overpassStatusEndpoint = "https://overpass-api.de/api/status"
+
+// HealthCheck is the result of one self-test check
+type HealthCheck struct {
+	Name       string                 `json:"name"`
+	Status     string                 `json:"status"`
+	Message    string                 `json:"message"`
+	DurationMs int64                  `json:"duration_ms"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// HealthReport collects every self-test check for the diagnostics page
+type HealthReport struct {
+	OK          bool          `json:"ok"`
+	Checks      []HealthCheck `json:"checks"`
+	GeneratedAt string        `json:"generated_at"`
+}
+
+// healthCheckFunc runs one check; it should give up when ctx is cancelled
+type healthCheckFunc func(ctx context.Context) HealthCheck
+
+// SelfTest checks the database, data directory, GDAL, Overpass reachability and the OpenAI key.
+// Checks run concurrently, each with its own timeout, so one slow check can't block the report.
+func (a *App) SelfTest() (HealthReport, error) {
+	checks := []struct {
+		name    string
+		timeout time.Duration
+		run     healthCheckFunc
+	}{
+		{"database", 5 * time.Second, a.checkDatabase},
+		{"duckdb", 5 * time.Second, a.checkDuckDB},
+		{"data_directory", 5 * time.Second, a.checkDataDirectory},
+		{"gdal", 10 * time.Second, a.checkGDAL},
+		{"overpass", 10 * time.Second, a.checkOverpass},
+		{"proxy", 10 * time.Second, a.checkProxy},
+		{"openai_key", time.Second, a.checkOpenAIKey},
+	}
+
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	report := HealthReport{
+		Checks:      make([]HealthCheck, len(checks)),
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, name string, timeout time.Duration, run healthCheckFunc) {
+			defer wg.Done()
+			report.Checks[i] = runHealthCheck(parent, name, timeout, run)
+		}(i, check.name, check.timeout, check.run)
+	}
+	wg.Wait()
+
+	report.OK = true
+	for _, check := range report.Checks {
+		if check.Status == healthError {
+			report.OK = false
+		}
+	}
+
+	return report, nil
+}
+
+// runHealthCheck times a check and reports it as failed if it outlives its timeout
+func runHealthCheck(parent context.Context, name string, timeout time.Duration, run healthCheckFunc) HealthCheck {
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan HealthCheck, 1)
+	go func() {
+		done <- run(ctx)
+	}()
+
+	var result HealthCheck
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		result = HealthCheck{Status: healthError, Message: fmt.Sprintf("check did not finish within %s", timeout)}
+	}
+
+	result.Name = name
+	result.DurationMs = time.Since(start).Milliseconds()
+	return result
+}
+
+// checkDatabase verifies the SQLite catalog is reachable and writable
+func (a *App) checkDatabase(ctx context.Context) HealthCheck {
+	if a.db == nil {
+		return HealthCheck{Status: healthError, Message: "database not initialized"}
+	}
+
+	if err := a.db.PingContext(ctx); err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("database unreachable: %v", err)}
+	}
+
+	// Indexing holds a.mu for the whole run, so don't wait for it past the check's timeout
+	if err := a.lockContext(ctx); err != nil {
+		return HealthCheck{Status: healthWarning, Message: "database is busy, most likely indexing; write check skipped"}
+	}
+	defer a.mu.Unlock()
+
+	// Write inside a transaction that is always rolled back
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("failed to begin transaction: %v", err)}
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "INSERT OR REPLACE INTO settings (key, value, updated_at) VALUES ('self_test', '1', ?)", time.Now().Format(time.RFC3339))
+	if err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("database is not writable: %v", err)}
+	}
+
+	var indexed int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM geo_file_index").Scan(&indexed); err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("failed to read file index: %v", err)}
+	}
+
+	return HealthCheck{
+		Status:  healthOK,
+		Message: "database is reachable and writable",
+		Details: map[string]interface{}{"indexed_files": indexed},
+	}
+}
+
+// lockContext takes a.mu for writing, giving up with ctx's error once ctx is done
+func (a *App) lockContext(ctx context.Context) error {
+	for !a.mu.TryLock() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+// checkDuckDB verifies the DuckDB analytics database is open
+func (a *App) checkDuckDB(ctx context.Context) HealthCheck {
+	if a.duckDB == nil {
+		return HealthCheck{Status: healthError, Message: "DuckDB not initialized"}
+	}
+	if err := a.duckDB.PingContext(ctx); err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("DuckDB unreachable: %v", err)}
+	}
+	return HealthCheck{Status: healthOK, Message: "DuckDB is reachable"}
+}
+
+// checkDataDirectory verifies ~/.terrabox exists and has free space
+func (a *App) checkDataDirectory(ctx context.Context) HealthCheck {
+	dir, err := terraboxDir()
+	if err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("data directory unavailable: %v", err)}
+	}
+
+	details := map[string]interface{}{"path": dir}
+	free, err := diskFreeBytes(dir)
+	if err != nil {
+		return HealthCheck{Status: healthWarning, Message: fmt.Sprintf("could not determine free space: %v", err), Details: details}
+	}
+	details["free_bytes"] = free
+
+	if free < minFreeDiskBytes {
+		return HealthCheck{
+			Status:  healthWarning,
+			Message: fmt.Sprintf("only %d MB free in %s", free/(1024*1024), dir),
+			Details: details,
+		}
+	}
+	return HealthCheck{Status: healthOK, Message: fmt.Sprintf("%d MB free", free/(1024*1024)), Details: details}
+}
+
+// checkGDAL reports whether the GDAL command-line tools are installed. It probes them afresh
+// rather than through the cached detection, so ogrinfo is killed if the check times out.
+func (a *App) checkGDAL(ctx context.Context) HealthCheck {
+	info, found := locateGDAL()
+	if found {
+		versionCtx, cancel := context.WithTimeout(ctx, gdalVersionTimeout)
+		output, _, err := a.execGDAL(versionCtx, info.OgrinfoPath, "", "--version")
+		cancel()
+		if ctx.Err() != nil {
+			return HealthCheck{Status: healthWarning, Message: "GDAL detection did not finish in time"}
+		}
+		info = withGDALVersion(info, output, err)
+	}
+
+	details := map[string]interface{}{
+		"version":      info.Version,
+		"ogr2ogr_path": info.Ogr2ogrPath,
+		"ogrinfo_path": info.OgrinfoPath,
+	}
+	if !info.Available {
+		// Native readers cover the common formats, so a missing GDAL isn't fatal
+		return HealthCheck{Status: healthWarning, Message: info.Message, Details: details}
+	}
+	return HealthCheck{Status: healthOK, Message: "GDAL " + info.Version, Details: details}
+}
+
+// checkOverpass verifies the Overpass API answers its status endpoint
+func (a *App) checkOverpass(ctx context.Context) HealthCheck {
+	req, err := http.NewRequestWithContext(ctx, "GET", overpassStatusEndpoint, nil)
+	if err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("failed to create request: %v", err)}
+	}
+	req.Header.Set("User-Agent", a.userAgent())
+
+	resp, err := a.httpClient().Do(req)
+	if err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("Overpass API unreachable: %v", err)}
+	}
+	defer resp.Body.Close()
+
+	details := map[string]interface{}{"endpoint": overpassEndpoint, "http_status": resp.StatusCode}
+	if resp.StatusCode != http.StatusOK {
+		return HealthCheck{Status: healthWarning, Message: fmt.Sprintf("Overpass API returned HTTP %d", resp.StatusCode), Details: details}
+	}
+	return HealthCheck{Status: healthOK, Message: "Overpass API is reachable", Details: details}
+}
+
+// checkProxy verifies the configured proxy, if any, accepts our connection and credentials
+func (a *App) checkProxy(ctx context.Context) HealthCheck {
+	settings := a.GetProxySettings()
+	details := map[string]interface{}{
+		"source":      settings.Source,
+		"http_proxy":  settings.HTTPProxy,
+		"https_proxy": settings.HTTPSProxy,
+		"no_proxy":    settings.NoProxy,
+	}
+	if settings.Source == "none" {
+		return HealthCheck{Status: healthOK, Message: "No proxy configured, connecting directly", Details: details}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", overpassStatusEndpoint, nil)
+	if err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("failed to create request: %v", err)}
+	}
+	req.Header.Set("User-Agent", a.userAgent())
+
+	resp, err := a.httpClient().Do(req)
+	if err != nil {
+		return HealthCheck{Status: healthError, Message: fmt.Sprintf("could not connect through the proxy: %v", redactSecrets(err.Error())), Details: details}
+	}
+	defer resp.Body.Close()
+
+	details["http_status"] = resp.StatusCode
+	if resp.StatusCode == http.StatusProxyAuthRequired {
+		return HealthCheck{Status: healthError, Message: "the proxy rejected the credentials (HTTP 407)", Details: details}
+	}
+	return HealthCheck{Status: healthOK, Message: fmt.Sprintf("Connected through the proxy from %s", settings.Source), Details: details}
+}
+
+// checkOpenAIKey reports whether natural-language query generation can use OpenAI
+func (a *App) checkOpenAIKey(ctx context.Context) HealthCheck {
+	if strings.TrimSpace(os.Getenv("OPENAI_API_KEY")) == "" {
+		return HealthCheck{
+			Status:  healthWarning,
+			Message: "OPENAI_API_KEY is not set; query generation falls back to keyword matching",
+		}
+	}
+	return HealthCheck{Status: healthOK, Message: "OpenAI API key is configured"}
+}
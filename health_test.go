@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCheckDatabase(t *testing.T) {
+	a := newTestApp(t)
+
+	if check := a.checkDatabase(context.Background()); check.Status != healthOK {
+		t.Errorf("idle database: status %s (%s), want %s", check.Status, check.Message, healthOK)
+	}
+
+	// While indexing holds the lock the check gives up at its deadline instead of blocking
+	a.mu.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	check := a.checkDatabase(ctx)
+	elapsed := time.Since(start)
+	a.mu.Unlock()
+
+	if check.Status != healthWarning {
+		t.Errorf("locked database: status %s (%s), want %s", check.Status, check.Message, healthWarning)
+	}
+	if elapsed > time.Second {
+		t.Errorf("locked database: check took %s, want it to stop at the 100ms deadline", elapsed)
+	}
+}
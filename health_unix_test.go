@@ -0,0 +1,49 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCheckGDALKillsProbeOnTimeout(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "ogrinfo.pid")
+	tools := map[string]string{
+		"ogrinfo": "#!/bin/sh\necho $$ > " + pidFile + "\nexec sleep 60\n",
+		"ogr2ogr": "#!/bin/sh\nexit 0\n",
+	}
+	for name, script := range tools {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	a := NewApp()
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	check := a.checkGDAL(ctx)
+	if check.Status != healthWarning {
+		t.Errorf("status %s (%s), want %s", check.Status, check.Message, healthWarning)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("check took %s, want it to stop at the 300ms deadline", elapsed)
+	}
+
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	defer syscall.Kill(pid, syscall.SIGKILL)
+	waitFor(t, "ogrinfo to be killed", func() bool { return !processAlive(pid) })
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxHillshadeSize is the longest side, in pixels, of a rendered hillshade overlay
+const maxHillshadeSize = 2048
+
+// maxHillshadeCacheEntries is how many rendered overlays are kept; the least recently used go first
+const maxHillshadeCacheEntries = 200
+
+// defaultHillshadeAzimuth lights the terrain from the north-west, the cartographic convention
+const defaultHillshadeAzimuth = 315.0
+
+// metersPerDegree converts degrees to meters for DEMs in geographic coordinates
+const metersPerDegree = 111120
+
+// HillshadeOptions controls how GenerateHillshadeOverlay lights the terrain. Azimuth is nil for
+// the default, since 0 lights from due north.
+type HillshadeOptions struct {
+	Azimuth          *float64 `json:"azimuth,omitempty"`
+	Altitude         float64  `json:"altitude"`
+	ZFactor          float64  `json:"z_factor"`
+	Multidirectional bool     `json:"multidirectional"`
+}
+
+// HillshadeResult is a rendered hillshade and where to place it on the map
+type HillshadeResult struct {
+	Image  string    `json:"image"`
+	BBox   []float64 `json:"bbox"`
+	Width  int       `json:"width"`
+	Height int       `json:"height"`
+	Cached bool      `json:"cached"`
+}
+
+// GenerateHillshade renders a hillshade of a DEM lit from azimuth/altitude (degrees) and returns
+// it as a base64 PNG
+func (a *App) GenerateHillshade(demPath string, azimuth, altitude float64) (string, error) {
+	result, err := a.GenerateHillshadeOverlay(demPath, HillshadeOptions{Azimuth: &azimuth, Altitude: altitude})
+	if err != nil {
+		return "", err
+	}
+	return result.Image, nil
+}
+
+// GenerateHillshadeOverlay renders a hillshade with gdaldem, warps it to WGS84 with transparent
+// NoData and returns the PNG with its bounding box. Renders are cached by file and options.
+func (a *App) GenerateHillshadeOverlay(demPath string, options HillshadeOptions) (*HillshadeResult, error) {
+	options, err := resolveHillshadeOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	demPath, err = gdalInputPath(demPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(demPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DEM: %v", err)
+	}
+
+	cacheDir, err := hillshadeCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	key := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d|%g|%g|%g|%t", demPath, info.ModTime().UnixNano(), info.Size(),
+		*options.Azimuth, options.Altitude, options.ZFactor, options.Multidirectional)))
+	cachePath := filepath.Join(cacheDir, hex.EncodeToString(key[:]))
+
+	if result, err := readCachedHillshade(cachePath); err == nil {
+		// Reading counts as a use, so pruning keeps the overlays still being looked at
+		now := time.Now()
+		os.Chtimes(cachePath+".json", now, now)
+		result.Cached = true
+		return result, nil
+	}
+
+	demInfo, err := a.readRasterInfo(demPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "terrabox-hillshade-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	shadedPath := filepath.Join(tmpDir, "hillshade.tif")
+	args := hillshadeArgs(options, isGeographicWKT(demInfo.CoordinateSystem.WKT))
+	args = append(args, demPath, shadedPath)
+	if _, err := a.runGDALTool("gdaldem", args...); err != nil {
+		return nil, err
+	}
+
+	// gdaldem marks NoData as 0; warp to WGS84 with an alpha band so it renders transparent
+	warpedPath := filepath.Join(tmpDir, "warped.tif")
+	warpArgs := []string{"-t_srs", "EPSG:4326", "-srcnodata", "0", "-dstalpha", "-r", "bilinear"}
+	if len(demInfo.Size) == 2 && max(demInfo.Size[0], demInfo.Size[1]) > maxHillshadeSize {
+		if demInfo.Size[0] >= demInfo.Size[1] {
+			warpArgs = append(warpArgs, "-ts", strconv.Itoa(maxHillshadeSize), "0")
+		} else {
+			warpArgs = append(warpArgs, "-ts", "0", strconv.Itoa(maxHillshadeSize))
+		}
+	}
+	warpArgs = append(warpArgs, shadedPath, warpedPath)
+	if _, err := a.runGDALTool("gdalwarp", warpArgs...); err != nil {
+		return nil, err
+	}
+
+	warpedInfo, err := a.readRasterInfo(warpedPath)
+	if err != nil {
+		return nil, err
+	}
+	bbox, ok := warpedInfo.bounds()
+	if !ok {
+		return nil, fmt.Errorf("could not determine the hillshade extent")
+	}
+
+	pngPath := filepath.Join(tmpDir, "hillshade.png")
+	if _, err := a.runGDALTool("gdal_translate", "-of", "PNG", warpedPath, pngPath); err != nil {
+		return nil, err
+	}
+	png, err := os.ReadFile(pngPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hillshade: %v", err)
+	}
+
+	result := &HillshadeResult{
+		Image: base64.StdEncoding.EncodeToString(png),
+		BBox:  bbox,
+	}
+	if len(warpedInfo.Size) == 2 {
+		result.Width, result.Height = warpedInfo.Size[0], warpedInfo.Size[1]
+	}
+
+	if err := writeCachedHillshade(cachePath, png, result); err != nil {
+		a.logWarn("Could not cache hillshade for %s: %v", demPath, err)
+	}
+	if err := pruneHillshadeCache(cacheDir, maxHillshadeCacheEntries); err != nil {
+		a.logWarn("Could not prune the hillshade cache: %v", err)
+	}
+	return result, nil
+}
+
+// resolveHillshadeOptions fills in the defaults for unset options and validates the altitude
+func resolveHillshadeOptions(options HillshadeOptions) (HillshadeOptions, error) {
+	if options.Altitude == 0 {
+		options.Altitude = 45
+	}
+	if options.Azimuth == nil {
+		azimuth := defaultHillshadeAzimuth
+		options.Azimuth = &azimuth
+	}
+	if options.ZFactor == 0 {
+		options.ZFactor = 1
+	}
+	if options.Altitude < 0 || options.Altitude > 90 {
+		return options, fmt.Errorf("altitude must be between 0 and 90 degrees")
+	}
+	return options, nil
+}
+
+// hillshadeArgs returns the gdaldem hillshade options for resolved options
+func hillshadeArgs(options HillshadeOptions, geographic bool) []string {
+	args := []string{"hillshade", "-z", strconv.FormatFloat(options.ZFactor, 'f', -1, 64),
+		"-alt", strconv.FormatFloat(options.Altitude, 'f', -1, 64)}
+	if options.Multidirectional {
+		args = append(args, "-multidirectional")
+	} else {
+		args = append(args, "-az", strconv.FormatFloat(*options.Azimuth, 'f', -1, 64))
+	}
+	if geographic {
+		// Horizontal units are degrees but elevations are meters
+		args = append(args, "-s", strconv.Itoa(metersPerDegree))
+	}
+	return args
+}
+
+// isGeographicWKT reports whether a WKT CRS uses degrees rather than projected units
+func isGeographicWKT(wkt string) bool {
+	upper := strings.ToUpper(strings.TrimSpace(wkt))
+	return strings.HasPrefix(upper, "GEOGCS") || strings.HasPrefix(upper, "GEOGCRS")
+}
+
+// hillshadeCacheDir returns ~/.terrabox/cache/hillshade, creating it if needed
+func hillshadeCacheDir() (string, error) {
+	dir, err := terraboxDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get data directory: %v", err)
+	}
+	cacheDir := filepath.Join(dir, "cache", "hillshade")
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create hillshade cache: %v", err)
+	}
+	return cacheDir, nil
+}
+
+// readCachedHillshade loads a cached PNG and its placement, stored as <key>.png and <key>.json
+func readCachedHillshade(cachePath string) (*HillshadeResult, error) {
+	meta, err := os.ReadFile(cachePath + ".json")
+	if err != nil {
+		return nil, err
+	}
+	png, err := os.ReadFile(cachePath + ".png")
+	if err != nil {
+		return nil, err
+	}
+	var result HillshadeResult
+	if err := json.Unmarshal(meta, &result); err != nil {
+		return nil, err
+	}
+	result.Image = base64.StdEncoding.EncodeToString(png)
+	return &result, nil
+}
+
+// writeCachedHillshade stores a rendered hillshade next to its placement
+func writeCachedHillshade(cachePath string, png []byte, result *HillshadeResult) error {
+	meta, err := json.Marshal(HillshadeResult{BBox: result.BBox, Width: result.Width, Height: result.Height})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(cachePath+".png", png, 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath+".json", meta, 0644)
+}
+
+// pruneHillshadeCache removes the least recently used overlays until at most keep remain. An
+// overlay's .json file is touched on every read, so its mtime is when it was last used.
+func pruneHillshadeCache(cacheDir string, keep int) error {
+	entries, err := os.ReadDir(cacheDir)
+	if err != nil {
+		return err
+	}
+
+	type cached struct {
+		key  string
+		used time.Time
+	}
+	var overlays []cached
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		overlays = append(overlays, cached{strings.TrimSuffix(entry.Name(), ".json"), info.ModTime()})
+	}
+	if len(overlays) <= keep {
+		return nil
+	}
+
+	sort.Slice(overlays, func(i, j int) bool { return overlays[i].used.After(overlays[j].used) })
+	for _, overlay := range overlays[keep:] {
+		os.Remove(filepath.Join(cacheDir, overlay.key+".json"))
+		os.Remove(filepath.Join(cacheDir, overlay.key+".png"))
+	}
+	return nil
+}
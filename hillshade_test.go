@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestHillshadeArgsAzimuth(t *testing.T) {
+	north := 0.0
+	tests := []struct {
+		name    string
+		options HillshadeOptions
+		want    string
+	}{
+		{name: "due north", options: HillshadeOptions{Azimuth: &north}, want: "0"},
+		{name: "unset", options: HillshadeOptions{}, want: "315"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := resolveHillshadeOptions(tt.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args := hillshadeArgs(options, false)
+			i := slices.Index(args, "-az")
+			if i < 0 || args[i+1] != tt.want {
+				t.Errorf("args = %v, want -az %s", args, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneHillshadeCache(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i < 5; i++ {
+		for _, ext := range []string{".png", ".json"} {
+			path := filepath.Join(dir, fmt.Sprintf("overlay%d%s", i, ext))
+			if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			used := start.Add(time.Duration(i) * time.Minute)
+			os.Chtimes(path, used, used)
+		}
+	}
+	// overlay0 was rendered first but read most recently
+	now := time.Now()
+	os.Chtimes(filepath.Join(dir, "overlay0.json"), now, now)
+
+	if err := pruneHillshadeCache(dir, 2); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		_, err := os.Stat(filepath.Join(dir, fmt.Sprintf("overlay%d.png", i)))
+		kept := i == 0 || i == 4
+		if kept != (err == nil) {
+			t.Errorf("overlay%d: kept = %v, want %v", i, err == nil, kept)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// indexBatchSize is how many files are inserted per transaction while indexing
+const indexBatchSize = 500
+
+// insertIndexQuery adds one file to the catalog
+const insertIndexQuery = `
+	INSERT INTO geo_file_index
+	(file_path, file_name, file_extension, file_size, created_at, modified_at,
+	 file_type, layer_name, crs, bbox, num_features, num_bands, resolution, metadata)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`
+
+// indexBatch groups geo_file_index inserts into transactions using one prepared statement, since
+// an autocommit per file makes SQLite sync to disk for every row. The run's processed_files
+// counter is updated in the same transaction, so it always matches what has been committed.
+type indexBatch struct {
+	db         *sql.DB
+	progressID int
+	tx         *sql.Tx
+	stmt       *sql.Stmt
+	pending    int
+	committed  int
+}
+
+// insert queues one row, committing the batch once it reaches indexBatchSize
+func (b *indexBatch) insert(args ...interface{}) error {
+	if b.tx == nil {
+		tx, err := b.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin index batch: %v", err)
+		}
+		stmt, err := tx.Prepare(insertIndexQuery)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to prepare index insert: %v", err)
+		}
+		b.tx, b.stmt = tx, stmt
+	}
+
+	if _, err := b.stmt.Exec(args...); err != nil {
+		b.rollback()
+		return fmt.Errorf("failed to index %v: %v", args[0], err)
+	}
+	b.pending++
+
+	if b.pending >= indexBatchSize {
+		return b.commit()
+	}
+	return nil
+}
+
+// commit writes the pending rows and the updated progress counter
+func (b *indexBatch) commit() error {
+	if b.tx == nil {
+		return nil
+	}
+
+	total := b.committed + b.pending
+	if _, err := b.tx.Exec(
+		"UPDATE index_progress SET processed_files = ? WHERE id = ?", total, b.progressID,
+	); err != nil {
+		b.rollback()
+		return fmt.Errorf("failed to update index progress: %v", err)
+	}
+
+	b.stmt.Close()
+	if err := b.tx.Commit(); err != nil {
+		b.tx, b.stmt = nil, nil
+		b.pending = 0
+		return fmt.Errorf("failed to commit index batch: %v", err)
+	}
+
+	b.tx, b.stmt = nil, nil
+	b.committed = total
+	b.pending = 0
+	return nil
+}
+
+// rollback discards the pending rows; earlier batches stay committed
+func (b *indexBatch) rollback() {
+	if b.tx == nil {
+		return
+	}
+	b.stmt.Close()
+	b.tx.Rollback()
+	b.tx, b.stmt = nil, nil
+	b.pending = 0
+}
+
+// count returns the rows inserted so far, including the uncommitted batch
+func (b *indexBatch) count() int {
+	return b.committed + b.pending
+}
+
+// encodeIndexMetadata converts a file's bbox and metadata map to the JSON stored in the index
+func (a *App) encodeIndexMetadata(filePath string, metadata *FileMetadata) (string, string) {
+	bboxJSON := fmt.Sprintf("[%f,%f,%f,%f]", metadata.BBox[0], metadata.BBox[1], metadata.BBox[2], metadata.BBox[3])
+
+	metadataJSON := "{}"
+	if len(metadata.Metadata) > 0 {
+		if data, err := json.Marshal(metadata.Metadata); err == nil {
+			metadataJSON = string(data)
+		} else {
+			a.logWarn("Could not encode metadata for %s: %v", filePath, err)
+		}
+	}
+	return bboxJSON, metadataJSON
+}
+
+// indexFile extracts one file's metadata and adds it to the catalog, replacing any rows it
+// already has. Used for files Terrabox creates after the directory was indexed. Cancelling ctx
+// stops the extraction.
+func (a *App) indexFile(ctx context.Context, filePath string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	extractionTimeout := a.extractionTimeout()
+	metadata, err := a.extractFileMetadataWithin(ctx, filePath, extractionTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata of %s: %v", filePath, err)
+	}
+	a.flagSuspectCRS(metadata)
+	a.scoreIndexedLayer(ctx, filePath, metadata, a.indexQualityChecks(), extractionTimeout)
+	bboxJSON, metadataJSON := a.encodeIndexMetadata(filePath, metadata)
+	fileName := filepath.Base(filePath)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	tx, err := a.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to index %s: %v", filePath, err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec("DELETE FROM geo_file_index WHERE file_path = ?", filePath); err != nil {
+		return fmt.Errorf("failed to index %s: %v", filePath, err)
+	}
+	if _, err := tx.Exec(insertIndexQuery,
+		filePath, fileName, strings.ToLower(filepath.Ext(filePath)), metadata.FileSize, metadata.CreatedAt,
+		metadata.ModifiedAt, metadata.FileType, fileName, metadata.CRS, bboxJSON, metadata.NumFeatures,
+		metadata.NumBands, metadata.Resolution, metadataJSON,
+	); err != nil {
+		return fmt.Errorf("failed to index %s: %v", filePath, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to index %s: %v", filePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// benchmarkInsertRows is how many files each benchmark iteration indexes
+const benchmarkInsertRows = 1000
+
+func TestIndexBatchCommitsProgress(t *testing.T) {
+	a := newTestApp(t)
+	result, err := a.db.Exec("INSERT INTO index_progress (start_time, status) VALUES ('2026-01-01T00:00:00Z', 'running')")
+	if err != nil {
+		t.Fatal(err)
+	}
+	progressID, _ := result.LastInsertId()
+
+	rng := rand.New(rand.NewSource(1))
+	batch := &indexBatch{db: a.db, progressID: int(progressID)}
+	rows := indexBatchSize + 3
+	for i := 0; i < rows; i++ {
+		if err := batch.insert(testIndexRow(i, randomBBox(rng))...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if batch.count() != rows {
+		t.Errorf("count = %d, want %d", batch.count(), rows)
+	}
+
+	// The first full batch is committed with its progress; the rest is still pending
+	var indexed, processed int
+	a.db.QueryRow("SELECT COUNT(*) FROM geo_file_index").Scan(&indexed)
+	a.db.QueryRow("SELECT processed_files FROM index_progress WHERE id = ?", progressID).Scan(&processed)
+	if indexed != indexBatchSize || processed != indexBatchSize {
+		t.Errorf("after one full batch: %d rows indexed, %d processed, want %d", indexed, processed, indexBatchSize)
+	}
+
+	if err := batch.commit(); err != nil {
+		t.Fatal(err)
+	}
+	a.db.QueryRow("SELECT COUNT(*) FROM geo_file_index").Scan(&indexed)
+	a.db.QueryRow("SELECT processed_files FROM index_progress WHERE id = ?", progressID).Scan(&processed)
+	if indexed != rows || processed != rows {
+		t.Errorf("after commit: %d rows indexed, %d processed, want %d", indexed, processed, rows)
+	}
+}
+
+func TestIndexBatchFailureKeepsCommittedBatches(t *testing.T) {
+	a := newTestApp(t)
+	result, err := a.db.Exec("INSERT INTO index_progress (start_time, status, total_files) VALUES ('2026-01-01T00:00:00Z', 'running', 2000)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	progressID, _ := result.LastInsertId()
+
+	rng := rand.New(rand.NewSource(1))
+	batch := &indexBatch{db: a.db, progressID: int(progressID)}
+	for i := 0; i < indexBatchSize+10; i++ {
+		if err := batch.insert(testIndexRow(i, randomBBox(rng))...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A duplicate of the first file violates UNIQUE(file_path, layer_name) midway through the second batch
+	if err := batch.insert(testIndexRow(0, randomBBox(rng))...); err == nil {
+		t.Fatal("inserting a duplicate row succeeded")
+	}
+	if batch.committed != indexBatchSize || batch.count() != indexBatchSize {
+		t.Errorf("after the failure: committed %d, count %d, want %d", batch.committed, batch.count(), indexBatchSize)
+	}
+
+	var indexed, rtree, processed, total int
+	a.db.QueryRow("SELECT COUNT(*) FROM geo_file_index").Scan(&indexed)
+	a.db.QueryRow("SELECT COUNT(*) FROM geo_file_rtree").Scan(&rtree)
+	a.db.QueryRow("SELECT processed_files, total_files FROM index_progress WHERE id = ?", progressID).Scan(&processed, &total)
+	if indexed != indexBatchSize || rtree != indexBatchSize {
+		t.Errorf("%d rows indexed and %d in the R*Tree, want only the first batch of %d", indexed, rtree, indexBatchSize)
+	}
+	if processed != indexBatchSize {
+		t.Errorf("processed_files = %d, want %d", processed, indexBatchSize)
+	}
+	// The counted total isn't replaced by the processed count
+	if total != 2000 {
+		t.Errorf("total_files = %d, want the counted 2000", total)
+	}
+
+	// Nothing of the failed batch survives a later commit
+	if err := batch.commit(); err != nil {
+		t.Fatal(err)
+	}
+	a.db.QueryRow("SELECT COUNT(*) FROM geo_file_index").Scan(&indexed)
+	if indexed != indexBatchSize {
+		t.Errorf("after commit: %d rows indexed, want %d", indexed, indexBatchSize)
+	}
+}
+
+// BenchmarkIndexInsert compares indexBatch with an autocommit INSERT per file, indexing
+// benchmarkInsertRows files per iteration
+func BenchmarkIndexInsert(b *testing.B) {
+	b.Run("batched", func(b *testing.B) {
+		a := newTestApp(b)
+		rng := rand.New(rand.NewSource(1))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			batch := &indexBatch{db: a.db}
+			for j := 0; j < benchmarkInsertRows; j++ {
+				if err := batch.insert(testIndexRow(i*benchmarkInsertRows+j, randomBBox(rng))...); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := batch.commit(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("per_row", func(b *testing.B) {
+		a := newTestApp(b)
+		rng := rand.New(rand.NewSource(1))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for j := 0; j < benchmarkInsertRows; j++ {
+				if _, err := a.db.Exec(insertIndexQuery, testIndexRow(i*benchmarkInsertRows+j, randomBBox(rng))...); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CreateIndex reports each run through these events, all carrying the run's index_progress ID:
+// index:start once the files to index have been counted, index:file after each file,
+// index:error for each file whose metadata could not be read and when the run fails, and
+// index:complete at the end whatever the outcome.
+const (
+	indexStartEvent    = "index:start"
+	indexFileEvent     = "index:file"
+	indexErrorEvent    = "index:error"
+	indexCompleteEvent = "index:complete"
+)
+
+// Per-file results in index:file events. Files are indexed with basic metadata whatever the result.
+const (
+	indexFileOK      = "ok"
+	indexFileError   = "error"
+	indexFileTimeout = "timeout"
+)
+
+// IndexStartEvent is the payload of index:start events
+type IndexStartEvent struct {
+	RunID      int    `json:"run_id"`
+	Path       string `json:"path"`
+	TotalFiles int    `json:"total_files"`
+}
+
+// IndexFileEvent is the payload of index:file events. Result is "ok", "error" or "timeout".
+type IndexFileEvent struct {
+	RunID          int    `json:"run_id"`
+	FilePath       string `json:"file_path"`
+	FileType       string `json:"file_type"`
+	Result         string `json:"result"`
+	Error          string `json:"error,omitempty"`
+	ProcessedFiles int    `json:"processed_files"`
+	TotalFiles     int    `json:"total_files"`
+}
+
+// IndexErrorEvent is the payload of index:error events. FilePath is empty when the run itself
+// failed.
+type IndexErrorEvent struct {
+	RunID    int    `json:"run_id"`
+	FilePath string `json:"file_path,omitempty"`
+	Error    string `json:"error"`
+}
+
+// IndexCompleteEvent is the payload of index:complete events. Status is "completed", "failed"
+// or "interrupted".
+type IndexCompleteEvent struct {
+	RunID            int      `json:"run_id"`
+	Path             string   `json:"path"`
+	Status           string   `json:"status"`
+	IndexedFiles     int      `json:"indexed_files"`
+	ExtractionErrors int      `json:"extraction_errors"`
+	TimedOutFiles    []string `json:"timed_out_files"`
+	DurationMs       int64    `json:"duration_ms"`
+	Error            string   `json:"error,omitempty"`
+}
+
+// countIndexableFiles counts the files under path with an indexed extension, so index:start can
+// carry a total
+func countIndexableFiles(ctx context.Context, path string, extensions map[string]bool) (int, error) {
+	total := 0
+	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+		if ctx.Err() != nil {
+			return fmt.Errorf("indexing cancelled")
+		}
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if extensions[strings.ToLower(filepath.Ext(filePath))] {
+			total++
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Single files are re-read or dropped from the catalog without a full re-index. index:refreshed
+// carries the updated GeoFileIndex so the UI can replace its row in place, and index:removed
+// an IndexRemovedEvent.
+const (
+	indexRefreshedEvent = "index:refreshed"
+	indexRemovedEvent   = "index:removed"
+)
+
+// IndexRemovedEvent is the payload of index:removed events
+type IndexRemovedEvent struct {
+	FilePath string `json:"file_path"`
+}
+
+// RefreshFileMetadata re-reads one file's metadata and replaces its index entry, adding it if
+// it wasn't indexed, and returns the updated entry. Tags and favorites are kept. A file that no
+// longer exists is an error; RemoveFromIndex drops its entry.
+func (a *App) RefreshFileMetadata(filePath string) (*GeoFileIndex, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file no longer exists: %s; remove it from the index instead", filePath)
+		}
+		return nil, fmt.Errorf("failed to access %s: %v", filePath, err)
+	}
+
+	if err := a.indexFile(context.Background(), filePath); err != nil {
+		return nil, err
+	}
+	files, err := a.queryIndex("SELECT "+geoFileIndexColumns+" FROM geo_file_index g WHERE file_path = ?", filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("failed to read refreshed index entry for %s", filePath)
+	}
+
+	file := &files[0]
+	a.logInfo("Refreshed metadata of %s", filePath)
+	a.emitEvent(indexRefreshedEvent, file)
+	return file, nil
+}
+
+// RemoveFromIndex drops a file's index entries, e.g. after it was deleted outside Terrabox,
+// without touching the file itself
+func (a *App) RemoveFromIndex(filePath string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	err := a.deleteIndexRows(filePath)
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	a.logInfo("Removed %s from the index", filePath)
+	a.emitEvent(indexRemovedEvent, IndexRemovedEvent{FilePath: filePath})
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+)
+
+// lastIndexSettingKey holds the directory and options of the last CreateIndex run, for RebuildIndex
+const lastIndexSettingKey = "last_index"
+
+// indexedDirectory is the value stored under lastIndexSettingKey
+type indexedDirectory struct {
+	Path          string `json:"path"`
+	IncludeImages bool   `json:"include_images"`
+	IncludeCSV    bool   `json:"include_csv"`
+}
+
+// rememberIndexedDirectory records the directory being indexed. Failures are only logged.
+func (a *App) rememberIndexedDirectory(path string, includeImages bool, includeCSV bool) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	data, err := json.Marshal(indexedDirectory{Path: path, IncludeImages: includeImages, IncludeCSV: includeCSV})
+	if err == nil {
+		err = a.setSetting(lastIndexSettingKey, string(data))
+	}
+	if err != nil {
+		a.logWarn("Failed to remember indexed directory %s: %v", path, err)
+	}
+}
+
+// CheckDatabaseIntegrity runs SQLite's integrity and foreign key checks on the catalog and
+// returns whether it is healthy along with any problems found
+func (a *App) CheckDatabaseIntegrity() (bool, []string, error) {
+	if a.db == nil {
+		return false, nil, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	problems := []string{}
+	rows, err := a.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to run integrity check: %v", err)
+	}
+	for rows.Next() {
+		var message string
+		if err := rows.Scan(&message); err != nil {
+			rows.Close()
+			return false, nil, fmt.Errorf("failed to read integrity check: %v", err)
+		}
+		if message != "ok" {
+			problems = append(problems, message)
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to run integrity check: %v", err)
+	}
+
+	rows, err = a.db.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to run foreign key check: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, parent string
+		var rowID, foreignKey interface{}
+		if err := rows.Scan(&table, &rowID, &parent, &foreignKey); err != nil {
+			return false, nil, fmt.Errorf("failed to read foreign key check: %v", err)
+		}
+		problems = append(problems, fmt.Sprintf("row %v of %s references a missing %s", rowID, table, parent))
+	}
+	if err := rows.Err(); err != nil {
+		return false, nil, fmt.Errorf("failed to run foreign key check: %v", err)
+	}
+
+	if len(problems) > 0 {
+		a.logWarn("Catalog integrity check found %d problems", len(problems))
+	}
+	return len(problems) == 0, problems, nil
+}
+
+// RebuildIndex drops the file catalog and indexes the last indexed directory again with the same
+// options. Tags, favorites, bookmarks, styles and workspaces are kept: they are stored by path in
+// their own tables.
+func (a *App) RebuildIndex() error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	last, err := a.lastIndexedDirectory()
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.stmtMu.Lock()
+	if a.listFilesStmt != nil {
+		a.listFilesStmt.Close()
+		a.listFilesStmt = nil
+	}
+	a.stmtMu.Unlock()
+	_, err = a.db.Exec("DROP TABLE IF EXISTS geo_file_index; DROP TABLE IF EXISTS geo_file_rtree; DROP TABLE IF EXISTS index_progress")
+	if err == nil {
+		_, err = a.db.Exec(catalogSchema)
+	}
+	a.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to recreate catalog: %v", err)
+	}
+
+	a.logInfo("Rebuilding catalog from %s", last.Path)
+	return a.CreateIndex(last.Path, last.IncludeImages, last.IncludeCSV)
+}
+
+// lastIndexedDirectory returns the last CreateIndex directory, falling back to the most recent
+// directory in the recent items list
+func (a *App) lastIndexedDirectory() (indexedDirectory, error) {
+	var last indexedDirectory
+	value, found, err := a.getSetting(lastIndexSettingKey)
+	if err == nil && found && json.Unmarshal([]byte(value), &last) == nil && last.Path != "" {
+		return last, nil
+	}
+
+	directories, err := a.GetRecentDirectories(1)
+	if err != nil {
+		return last, fmt.Errorf("failed to find the last indexed directory: %v", err)
+	}
+	if len(directories) == 0 {
+		return last, fmt.Errorf("no indexed directory to rebuild from")
+	}
+	return indexedDirectory{Path: directories[0].Path}, nil
+}
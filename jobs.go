@@ -0,0 +1,580 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job statuses. Jobs still running when the app closes are marked interrupted; queued jobs
+// start again on the next launch.
+const (
+	jobQueued      = "queued"
+	jobRunning     = "running"
+	jobCompleted   = "completed"
+	jobFailed      = "failed"
+	jobCancelled   = "cancelled"
+	jobInterrupted = "interrupted"
+)
+
+// jobWorkers is how many jobs run at once; the rest wait in the queue
+const jobWorkers = 2
+
+// maxListedJobs caps how many of the most recent jobs ListJobs returns
+const maxListedJobs = 200
+
+// jobUpdateEvent carries a Job whenever one is queued, starts, reports progress or finishes
+const jobUpdateEvent = "job:update"
+
+// Job is a background operation started with EnqueueJob. Progress runs from 0 to 100 and
+// Result holds what the job produced, e.g. the file it wrote.
+type Job struct {
+	ID         string                 `json:"id"`
+	Kind       string                 `json:"kind"`
+	Params     map[string]interface{} `json:"params"`
+	Status     string                 `json:"status"`
+	Progress   float64                `json:"progress"`
+	Result     map[string]interface{} `json:"result,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	CreatedAt  string                 `json:"created_at"`
+	StartedAt  string                 `json:"started_at,omitempty"`
+	FinishedAt string                 `json:"finished_at,omitempty"`
+}
+
+// jobParams are the decoded parameters of one kind of job, which know how to run it. run
+// reports progress as a percentage and should return soon after ctx is cancelled.
+type jobParams interface {
+	validate() error
+	run(ctx context.Context, a *App, report func(percent float64)) (map[string]interface{}, error)
+}
+
+// jobKinds maps each job kind to its parameters
+var jobKinds = map[string]func() jobParams{
+	"raster_mosaic": func() jobParams { return &mosaicJob{} },
+	"contours":      func() jobParams { return &contoursJob{} },
+	"osm_export":    func() jobParams { return &osmExportJob{} },
+}
+
+// jobQueueState limits running jobs and tracks them so they can be cancelled
+type jobQueueState struct {
+	mu      sync.Mutex
+	slots   chan struct{}
+	cancels map[string]context.CancelFunc
+	// closing is set at shutdown, which records the final statuses itself
+	closing bool
+
+	// jobs mirrors the jobs table, so job status is answered without waiting on a.mu, which
+	// CreateIndex holds for a whole walk. Changes are queued in pending and saved by flushJobs.
+	jobs     map[string]*jobEntry
+	loaded   bool
+	nextSeq  int64
+	pending  []jobWrite
+	flushing bool
+}
+
+// jobEntry is a mirrored job; seq orders jobs by when they were queued
+type jobEntry struct {
+	job Job
+	seq int64
+}
+
+// jobWrite is a queued change to the jobs table
+type jobWrite struct {
+	query string
+	args  []interface{}
+}
+
+// EnqueueJob queues a background job and returns its ID. Kinds and their params:
+//   - raster_mosaic: file_paths, dst_path (a .vrt file), as BuildRasterMosaic
+//   - contours: dem_path, interval, base (optional) and dst_path, where the GeoJSON is saved
+//   - osm_export: bbox, categories and dst_path, as ExportOSMArea
+//
+// Progress and the outcome arrive as job:update events and through GetJobStatus.
+func (a *App) EnqueueJob(kind string, params map[string]interface{}) (string, error) {
+	if a.db == nil {
+		return "", fmt.Errorf("database not initialized")
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode job parameters: %v", err)
+	}
+	job, err := decodeJobParams(kind, paramsJSON)
+	if err != nil {
+		return "", err
+	}
+
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("failed to create job ID: %v", err)
+	}
+	id := "job-" + hex.EncodeToString(idBytes)
+
+	if err := a.loadJobs(); err != nil {
+		return "", err
+	}
+	var decoded map[string]interface{}
+	json.Unmarshal(paramsJSON, &decoded)
+	createdAt := time.Now().Format(time.RFC3339)
+
+	a.jobs.mu.Lock()
+	if a.jobs.closing {
+		a.jobs.mu.Unlock()
+		return "", fmt.Errorf("failed to queue job: Terrabox is closing")
+	}
+	a.jobs.nextSeq++
+	a.jobs.jobs[id] = &jobEntry{
+		job: Job{ID: id, Kind: kind, Params: decoded, Status: jobQueued, CreatedAt: createdAt},
+		seq: a.jobs.nextSeq,
+	}
+	a.queueJobWrite("INSERT INTO jobs (id, kind, params, status, created_at) VALUES (?, ?, ?, ?, ?)",
+		id, kind, string(paramsJSON), jobQueued, createdAt)
+	a.jobs.mu.Unlock()
+
+	a.logInfo("Queued %s job %s", kind, id)
+	a.startJob(id, job)
+	return id, nil
+}
+
+// GetJobStatus returns a job's current state
+func (a *App) GetJobStatus(jobID string) (*Job, error) {
+	if err := a.loadJobs(); err != nil {
+		return nil, err
+	}
+
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+	entry, ok := a.jobs.jobs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("job %q not found", jobID)
+	}
+	job := entry.job
+	return &job, nil
+}
+
+// ListJobs returns the most recent jobs, newest first
+func (a *App) ListJobs() ([]Job, error) {
+	if err := a.loadJobs(); err != nil {
+		return nil, err
+	}
+
+	a.jobs.mu.Lock()
+	entries := make([]*jobEntry, 0, len(a.jobs.jobs))
+	for _, entry := range a.jobs.jobs {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seq > entries[j].seq })
+	jobs := []Job{}
+	for _, entry := range entries[:min(len(entries), maxListedJobs)] {
+		jobs = append(jobs, entry.job)
+	}
+	a.jobs.mu.Unlock()
+	return jobs, nil
+}
+
+// CancelJob cancels a queued or running job. A running mosaic's GDAL process is killed and OSM
+// exports stop between tiles; contours finish tracing and their output is discarded.
+func (a *App) CancelJob(jobID string) error {
+	a.jobs.mu.Lock()
+	cancel, ok := a.jobs.cancels[jobID]
+	a.jobs.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("job %q is not queued or running", jobID)
+	}
+	cancel()
+	a.logInfo("Cancelling job %s", jobID)
+	return nil
+}
+
+// resumeJobs runs at startup: jobs left running by a crash are marked interrupted and queued jobs
+// are started again
+func (a *App) resumeJobs() error {
+	if err := a.loadJobs(); err != nil {
+		return err
+	}
+
+	a.jobs.mu.Lock()
+	var running, queued []*jobEntry
+	for _, entry := range a.jobs.jobs {
+		switch entry.job.Status {
+		case jobRunning:
+			running = append(running, entry)
+		case jobQueued:
+			queued = append(queued, entry)
+		}
+	}
+	a.jobs.mu.Unlock()
+	sort.Slice(queued, func(i, j int) bool { return queued[i].seq < queued[j].seq })
+
+	finishedAt := time.Now().Format(time.RFC3339)
+	for _, entry := range running {
+		a.updateJob(entry.job.ID, func(job *Job) {
+			job.Status, job.FinishedAt = jobInterrupted, finishedAt
+		})
+	}
+	for _, entry := range queued {
+		job, err := decodeJobParams(entry.job.Kind, mustJSON(entry.job.Params))
+		if err != nil {
+			a.finishJob(entry.job.ID, jobFailed, nil, err)
+			continue
+		}
+		a.startJob(entry.job.ID, job)
+	}
+	if len(queued) > 0 {
+		a.logInfo("Resumed %d queued jobs", len(queued))
+	}
+	return nil
+}
+
+// loadJobs fills the job mirror from the jobs table the first time it is needed
+func (a *App) loadJobs() error {
+	a.jobs.mu.Lock()
+	loaded := a.jobs.loaded
+	a.jobs.mu.Unlock()
+	if loaded {
+		return nil
+	}
+
+	a.mu.RLock()
+	if a.db == nil {
+		a.mu.RUnlock()
+		return fmt.Errorf("database not initialized")
+	}
+	rows, err := a.db.Query("SELECT " + jobColumns + " FROM jobs ORDER BY created_at, rowid")
+	if err != nil {
+		a.mu.RUnlock()
+		return fmt.Errorf("failed to read jobs: %v", err)
+	}
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			rows.Close()
+			a.mu.RUnlock()
+			return fmt.Errorf("failed to read jobs: %v", err)
+		}
+		jobs = append(jobs, job)
+	}
+	rows.Close()
+	a.mu.RUnlock()
+
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+	if a.jobs.loaded {
+		return nil
+	}
+	a.jobs.jobs = map[string]*jobEntry{}
+	for _, job := range jobs {
+		a.jobs.nextSeq++
+		a.jobs.jobs[job.ID] = &jobEntry{job: *job, seq: a.jobs.nextSeq}
+	}
+	a.jobs.loaded = true
+	return nil
+}
+
+// decodeJobParams decodes and checks the parameters of a job kind
+func decodeJobParams(kind string, paramsJSON []byte) (jobParams, error) {
+	newParams, ok := jobKinds[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown job kind %q", kind)
+	}
+	job := newParams()
+	if err := json.Unmarshal(paramsJSON, job); err != nil {
+		return nil, fmt.Errorf("invalid %s job parameters: %v", kind, err)
+	}
+	if err := job.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s job parameters: %v", kind, err)
+	}
+	return job, nil
+}
+
+// startJob runs a queued job in the background once a worker is free
+func (a *App) startJob(id string, job jobParams) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.jobs.mu.Lock()
+	if a.jobs.slots == nil {
+		a.jobs.slots = make(chan struct{}, jobWorkers)
+		a.jobs.cancels = map[string]context.CancelFunc{}
+	}
+	slots := a.jobs.slots
+	a.jobs.cancels[id] = cancel
+	a.jobs.mu.Unlock()
+
+	a.emitJobUpdate(id)
+	go func() {
+		defer func() {
+			a.jobs.mu.Lock()
+			delete(a.jobs.cancels, id)
+			a.jobs.mu.Unlock()
+			cancel()
+		}()
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			a.finishJob(id, jobCancelled, nil, nil)
+			return
+		}
+		defer func() { <-slots }()
+		if ctx.Err() != nil {
+			a.finishJob(id, jobCancelled, nil, nil)
+			return
+		}
+
+		startedAt := time.Now().Format(time.RFC3339)
+		if !a.updateJob(id, func(job *Job) { job.Status, job.StartedAt = jobRunning, startedAt }) {
+			return
+		}
+		a.emitJobUpdate(id)
+
+		result, err := a.runJob(ctx, id, job)
+		switch {
+		case ctx.Err() != nil:
+			a.finishJob(id, jobCancelled, nil, nil)
+		case err != nil:
+			a.finishJob(id, jobFailed, nil, err)
+		default:
+			a.finishJob(id, jobCompleted, result, nil)
+		}
+	}()
+}
+
+// runJob runs a job, turning a panic into an error so one bad job can't take down the app
+func (a *App) runJob(ctx context.Context, id string, job jobParams) (result map[string]interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job crashed: %v", r)
+		}
+	}()
+	return job.run(ctx, a, func(percent float64) {
+		if a.updateJob(id, func(job *Job) { job.Progress = percent }) {
+			a.emitJobUpdate(id)
+		}
+	})
+}
+
+// finishJob records a job's outcome
+func (a *App) finishJob(id string, status string, result map[string]interface{}, jobErr error) {
+	errorText := ""
+	if jobErr != nil {
+		errorText = jobErr.Error()
+		a.logWarn("Job %s failed: %v", id, jobErr)
+	} else {
+		a.logInfo("Job %s %s", id, status)
+	}
+
+	finishedAt := time.Now().Format(time.RFC3339)
+	updated := a.updateJob(id, func(job *Job) {
+		job.Status, job.Result, job.Error, job.FinishedAt = status, result, errorText, finishedAt
+		if status == jobCompleted {
+			job.Progress = 100
+		}
+	})
+	if updated {
+		a.emitJobUpdate(id)
+	}
+}
+
+// updateJob applies change to a job and queues the changed row to be saved, reporting whether it
+// was. Nothing is changed once shutdown has started.
+func (a *App) updateJob(id string, change func(job *Job)) bool {
+	a.jobs.mu.Lock()
+	defer a.jobs.mu.Unlock()
+	entry, ok := a.jobs.jobs[id]
+	if a.jobs.closing || !ok {
+		return false
+	}
+
+	change(&entry.job)
+	job := entry.job
+	var resultJSON interface{}
+	if job.Result != nil {
+		resultJSON = string(mustJSON(job.Result))
+	}
+	a.queueJobWrite(
+		"UPDATE jobs SET status = ?, progress = ?, result = ?, error = ?, started_at = ?, finished_at = ? WHERE id = ?",
+		job.Status, job.Progress, resultJSON, nullIfEmpty(job.Error), nullIfEmpty(job.StartedAt),
+		nullIfEmpty(job.FinishedAt), job.ID,
+	)
+	return true
+}
+
+// queueJobWrite queues a jobs table change for flushJobs, starting it if it isn't running.
+// Callers hold a.jobs.mu.
+func (a *App) queueJobWrite(query string, args ...interface{}) {
+	a.jobs.pending = append(a.jobs.pending, jobWrite{query: query, args: args})
+	if !a.jobs.flushing {
+		a.jobs.flushing = true
+		go a.flushJobs()
+	}
+}
+
+// flushJobs saves queued job changes in order. While CreateIndex holds a.mu the changes wait
+// here, so neither the job nor status calls are held up.
+func (a *App) flushJobs() {
+	for {
+		a.mu.Lock()
+		a.jobs.mu.Lock()
+		writes := a.jobs.pending
+		a.jobs.pending = nil
+		if len(writes) == 0 {
+			a.jobs.flushing = false
+			a.jobs.mu.Unlock()
+			a.mu.Unlock()
+			return
+		}
+		a.jobs.mu.Unlock()
+		a.writeJobs(writes)
+		a.mu.Unlock()
+	}
+}
+
+// writeJobs executes queued job changes. Callers must hold a.mu.
+func (a *App) writeJobs(writes []jobWrite) {
+	if a.db == nil {
+		return
+	}
+	for _, write := range writes {
+		if _, err := a.db.Exec(write.query, write.args...); err != nil {
+			a.logWarn("Could not update job: %v", err)
+		}
+	}
+}
+
+// emitJobUpdate sends a job's current state as a job:update event
+func (a *App) emitJobUpdate(id string) {
+	if a.ctx == nil {
+		return
+	}
+	if job, err := a.GetJobStatus(id); err == nil {
+		a.emitEvent(jobUpdateEvent, job)
+	}
+}
+
+// jobColumns is the column list scanned by scanJob
+const jobColumns = "id, kind, params, status, progress, result, error, created_at, started_at, finished_at"
+
+// scanJob scans one jobs row selected with jobColumns
+func scanJob(row rowScanner) (*Job, error) {
+	var job Job
+	var params string
+	var result, errorText, startedAt, finishedAt sql.NullString
+	if err := row.Scan(&job.ID, &job.Kind, &params, &job.Status, &job.Progress, &result, &errorText,
+		&job.CreatedAt, &startedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+	json.Unmarshal([]byte(params), &job.Params)
+	if result.Valid {
+		json.Unmarshal([]byte(result.String), &job.Result)
+	}
+	job.Error = errorText.String
+	job.StartedAt = startedAt.String
+	job.FinishedAt = finishedAt.String
+	return &job, nil
+}
+
+// mosaicJob builds a raster mosaic with BuildRasterMosaic
+type mosaicJob struct {
+	FilePaths []string `json:"file_paths"`
+	DstPath   string   `json:"dst_path"`
+}
+
+func (j *mosaicJob) validate() error {
+	if len(j.FilePaths) < 2 {
+		return fmt.Errorf("a mosaic needs at least two rasters")
+	}
+	if j.DstPath == "" {
+		return fmt.Errorf("dst_path is required")
+	}
+	return nil
+}
+
+func (j *mosaicJob) run(ctx context.Context, a *App, report func(percent float64)) (map[string]interface{}, error) {
+	if err := a.buildRasterMosaicContext(ctx, j.FilePaths, j.DstPath); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"file_path": j.DstPath}, nil
+}
+
+// contoursJob traces contours with GenerateContoursWithBase and saves them as GeoJSON
+type contoursJob struct {
+	DEMPath  string  `json:"dem_path"`
+	Interval float64 `json:"interval"`
+	Base     float64 `json:"base"`
+	DstPath  string  `json:"dst_path"`
+}
+
+func (j *contoursJob) validate() error {
+	if j.DEMPath == "" || j.DstPath == "" {
+		return fmt.Errorf("dem_path and dst_path are required")
+	}
+	if j.Interval <= 0 {
+		return fmt.Errorf("contour interval must be positive")
+	}
+	return nil
+}
+
+func (j *contoursJob) run(ctx context.Context, a *App, report func(percent float64)) (map[string]interface{}, error) {
+	contours, err := a.GenerateContoursWithBase(j.DEMPath, j.Interval, j.Base)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	report(90)
+
+	data, err := json.Marshal(contours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode contours: %v", err)
+	}
+	err = writeViaTempFile(j.DstPath, func(tmpPath string) error {
+		return os.WriteFile(tmpPath, data, 0644)
+	})
+	if err != nil {
+		return nil, err
+	}
+	features, _ := contours["features"].([]interface{})
+	return map[string]interface{}{"file_path": j.DstPath, "feature_count": len(features)}, nil
+}
+
+// osmExportJob downloads an area tile by tile like ExportOSMArea
+type osmExportJob struct {
+	BBox       []float64 `json:"bbox"`
+	Categories []string  `json:"categories"`
+	DstPath    string    `json:"dst_path"`
+}
+
+func (j *osmExportJob) validate() error {
+	if len(j.BBox) != 4 {
+		return fmt.Errorf("bbox must be [west, south, east, north]")
+	}
+	if len(j.Categories) == 0 || j.DstPath == "" {
+		return fmt.Errorf("categories and dst_path are required")
+	}
+	return nil
+}
+
+func (j *osmExportJob) run(ctx context.Context, a *App, report func(percent float64)) (map[string]interface{}, error) {
+	var features int
+	err := a.exportOSMArea(ctx, j.BBox, j.Categories, j.DstPath, func(progress OSMExportProgress) {
+		a.emitEvent(osmExportProgressEvent, progress)
+		features = progress.Features
+		if progress.Tiles > 0 && !progress.Done {
+			report(float64(progress.Completed) / float64(progress.Tiles) * 100)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"file_path": j.DstPath, "feature_count": features}, nil
+}
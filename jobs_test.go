@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// gateJob runs until its gate is closed or it is cancelled
+type gateJob struct {
+	gate chan struct{}
+}
+
+func (j *gateJob) validate() error { return nil }
+
+func (j *gateJob) run(ctx context.Context, a *App, report func(percent float64)) (map[string]interface{}, error) {
+	report(50)
+	select {
+	case <-j.gate:
+		return map[string]interface{}{"done": true}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// waitFor polls cond until it holds or a few seconds pass
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestJobsDontWaitForIndexLock(t *testing.T) {
+	gate := make(chan struct{})
+	jobKinds["test_gate"] = func() jobParams { return &gateJob{gate: gate} }
+	defer delete(jobKinds, "test_gate")
+
+	a := newTestApp(t)
+	if err := a.resumeJobs(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the catalog lock the way CreateIndex does for a whole walk
+	a.mu.Lock()
+	locked := true
+	defer func() {
+		if locked {
+			a.mu.Unlock()
+		}
+	}()
+
+	done := make(chan string, 1)
+	go func() {
+		id, err := a.EnqueueJob("test_gate", nil)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- id
+	}()
+	var id string
+	select {
+	case id = <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("EnqueueJob waited for the catalog lock")
+	}
+
+	waitFor(t, "the job to report progress", func() bool {
+		job, err := a.GetJobStatus(id)
+		return err == nil && job.Status == jobRunning && job.Progress == 50
+	})
+	close(gate)
+	waitFor(t, "the job to complete", func() bool {
+		job, err := a.GetJobStatus(id)
+		return err == nil && job.Status == jobCompleted && job.Progress == 100
+	})
+	if jobs, err := a.ListJobs(); err != nil || len(jobs) != 1 || jobs[0].ID != id {
+		t.Errorf("ListJobs = %v, %v, want the one job", jobs, err)
+	}
+
+	// The rows are saved once the lock is released
+	a.mu.Unlock()
+	locked = false
+	waitFor(t, "the job row to be saved", func() bool {
+		a.mu.RLock()
+		defer a.mu.RUnlock()
+		job, err := scanJob(a.db.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = ?", id))
+		return err == nil && job.Status == jobCompleted && job.Result["done"] == true
+	})
+}
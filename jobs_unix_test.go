@@ -0,0 +1,77 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCancelMosaicJobKillsGDAL(t *testing.T) {
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "gdalbuildvrt.pid")
+	info := `{"size":[10,10],"geoTransform":[0,1,0,10,0,-1],"coordinateSystem":{"wkt":"GEOGCRS[\"WGS 84\"]"},"bands":[{"band":1}]}`
+	tools := map[string]string{
+		"ogrinfo":      "#!/bin/sh\necho 'GDAL 3.8.4, released 2024/02/08'\n",
+		"ogr2ogr":      "#!/bin/sh\nexit 0\n",
+		"gdalinfo":     "#!/bin/sh\necho '" + info + "'\n",
+		"gdalbuildvrt": "#!/bin/sh\necho $$ > " + pidFile + "\nexec sleep 60\n",
+	}
+	for name, script := range tools {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	var tiles []interface{}
+	for _, name := range []string{"a.tif", "b.tif"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("tiff"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		tiles = append(tiles, path)
+	}
+
+	a := newTestApp(t)
+	a.gdalTimeoutValue = time.Minute
+	id, err := a.EnqueueJob("raster_mosaic", map[string]interface{}{
+		"file_paths": tiles,
+		"dst_path":   filepath.Join(dir, "mosaic.vrt"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pid int
+	waitFor(t, "gdalbuildvrt to start", func() bool {
+		data, err := os.ReadFile(pidFile)
+		if err != nil {
+			return false
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err == nil
+	})
+	defer syscall.Kill(pid, syscall.SIGKILL)
+
+	if err := a.CancelJob(id); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "gdalbuildvrt to be killed", func() bool { return !processAlive(pid) })
+	waitFor(t, "the job to be cancelled", func() bool {
+		job, err := a.GetJobStatus(id)
+		return err == nil && job.Status == jobCancelled
+	})
+	// Let the cancelled status reach the database before it is closed
+	waitFor(t, "the job row to be saved", func() bool {
+		a.mu.RLock()
+		defer a.mu.RUnlock()
+		job, err := scanJob(a.db.QueryRow("SELECT "+jobColumns+" FROM jobs WHERE id = ?", id))
+		return err == nil && job.Status == jobCancelled
+	})
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// kmlPlacemark mirrors the parts of a KML <Placemark> we convert to GeoJSON
+type kmlPlacemark struct {
+	ID            string            `xml:"id,attr"`
+	Name          string            `xml:"name"`
+	Description   string            `xml:"description"`
+	ExtendedData  kmlExtendedData   `xml:"ExtendedData"`
+	Point         *kmlPoint         `xml:"Point"`
+	LineString    *kmlLineString    `xml:"LineString"`
+	LinearRing    *kmlLineString    `xml:"LinearRing"`
+	Polygon       *kmlPolygon       `xml:"Polygon"`
+	MultiGeometry *kmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+type kmlExtendedData struct {
+	Data []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:"value"`
+	} `xml:"Data"`
+	SimpleData []struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"SchemaData>SimpleData"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlLineString struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Outer string   `xml:"outerBoundaryIs>LinearRing>coordinates"`
+	Inner []string `xml:"innerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlMultiGeometry struct {
+	Points        []kmlPoint         `xml:"Point"`
+	LineStrings   []kmlLineString    `xml:"LineString"`
+	Polygons      []kmlPolygon       `xml:"Polygon"`
+	MultiGeometry []kmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+// readKML parses a .kml file into a GeoJSON FeatureCollection without GDAL
+func readKML(filePath string) (map[string]interface{}, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open KML file: %v", err)
+	}
+	defer file.Close()
+
+	return parseKML(file, strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)))
+}
+
+// readKMZ unzips a .kmz archive and parses its main KML document (doc.kml, or the first .kml entry)
+func readKMZ(filePath string) (map[string]interface{}, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open KMZ archive: %v", err)
+	}
+	defer archive.Close()
+
+	var docFile *zip.File
+	for _, f := range archive.File {
+		if strings.EqualFold(f.Name, "doc.kml") {
+			docFile = f
+			break
+		}
+		if docFile == nil && strings.EqualFold(filepath.Ext(f.Name), ".kml") {
+			docFile = f
+		}
+	}
+	if docFile == nil {
+		return nil, fmt.Errorf("no KML document found in KMZ archive")
+	}
+
+	reader, err := docFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from KMZ: %v", docFile.Name, err)
+	}
+	defer reader.Close()
+
+	return parseKML(reader, strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)))
+}
+
+// parseKML streams a KML document and converts every Placemark, however deeply nested in
+// Documents/Folders, into a GeoJSON feature
+func parseKML(r io.Reader, name string) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(r)
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// KML is UTF-8 by spec; accept mislabelled files rather than failing
+		return input, nil
+	}
+
+	features := []interface{}{}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse KML: %v", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "Placemark" {
+			continue
+		}
+
+		var placemark kmlPlacemark
+		if err := decoder.DecodeElement(&placemark, &start); err != nil {
+			return nil, fmt.Errorf("failed to parse Placemark: %v", err)
+		}
+		features = append(features, placemark.toFeature())
+	}
+
+	return map[string]interface{}{
+		"type":     "FeatureCollection",
+		"name":     name,
+		"features": features,
+	}, nil
+}
+
+// toFeature converts a Placemark into a GeoJSON feature
+func (p *kmlPlacemark) toFeature() map[string]interface{} {
+	properties := map[string]interface{}{}
+	if p.Name != "" {
+		properties["name"] = strings.TrimSpace(p.Name)
+	}
+	if p.Description != "" {
+		properties["description"] = strings.TrimSpace(p.Description)
+	}
+	for _, d := range p.ExtendedData.Data {
+		properties[d.Name] = strings.TrimSpace(d.Value)
+	}
+	for _, d := range p.ExtendedData.SimpleData {
+		properties[d.Name] = strings.TrimSpace(d.Value)
+	}
+
+	feature := map[string]interface{}{
+		"type":       "Feature",
+		"properties": properties,
+		"geometry":   nil,
+	}
+	if p.ID != "" {
+		feature["id"] = p.ID
+	}
+
+	switch {
+	case p.Point != nil:
+		feature["geometry"] = kmlPointGeometry(*p.Point)
+	case p.LineString != nil:
+		feature["geometry"] = kmlLineGeometry(*p.LineString)
+	case p.LinearRing != nil:
+		feature["geometry"] = kmlLineGeometry(*p.LinearRing)
+	case p.Polygon != nil:
+		feature["geometry"] = kmlPolygonGeometry(*p.Polygon)
+	case p.MultiGeometry != nil:
+		feature["geometry"] = kmlMultiGeometryToGeoJSON(*p.MultiGeometry)
+	}
+
+	return feature
+}
+
+func kmlPointGeometry(p kmlPoint) map[string]interface{} {
+	coords := parseKMLCoordinates(p.Coordinates)
+	if len(coords) == 0 {
+		return nil
+	}
+	return map[string]interface{}{"type": "Point", "coordinates": coords[0]}
+}
+
+func kmlLineGeometry(l kmlLineString) map[string]interface{} {
+	return map[string]interface{}{"type": "LineString", "coordinates": parseKMLCoordinates(l.Coordinates)}
+}
+
+func kmlPolygonGeometry(p kmlPolygon) map[string]interface{} {
+	return map[string]interface{}{"type": "Polygon", "coordinates": kmlPolygonRings(p)}
+}
+
+func kmlPolygonRings(p kmlPolygon) [][][]float64 {
+	rings := [][][]float64{parseKMLCoordinates(p.Outer)}
+	for _, inner := range p.Inner {
+		rings = append(rings, parseKMLCoordinates(inner))
+	}
+	return rings
+}
+
+// kmlMultiGeometryToGeoJSON collapses a homogeneous MultiGeometry into the matching Multi* type and
+// falls back to a GeometryCollection for mixed content
+func kmlMultiGeometryToGeoJSON(m kmlMultiGeometry) map[string]interface{} {
+	kinds := 0
+	for _, n := range []int{len(m.Points), len(m.LineStrings), len(m.Polygons), len(m.MultiGeometry)} {
+		if n > 0 {
+			kinds++
+		}
+	}
+
+	if kinds == 1 {
+		switch {
+		case len(m.Points) > 0:
+			var coords [][]float64
+			for _, p := range m.Points {
+				coords = append(coords, parseKMLCoordinates(p.Coordinates)...)
+			}
+			return map[string]interface{}{"type": "MultiPoint", "coordinates": coords}
+		case len(m.LineStrings) > 0:
+			var coords [][][]float64
+			for _, l := range m.LineStrings {
+				coords = append(coords, parseKMLCoordinates(l.Coordinates))
+			}
+			return map[string]interface{}{"type": "MultiLineString", "coordinates": coords}
+		case len(m.Polygons) > 0:
+			var coords [][][][]float64
+			for _, p := range m.Polygons {
+				coords = append(coords, kmlPolygonRings(p))
+			}
+			return map[string]interface{}{"type": "MultiPolygon", "coordinates": coords}
+		}
+	}
+
+	geometries := []interface{}{}
+	for _, p := range m.Points {
+		if g := kmlPointGeometry(p); g != nil {
+			geometries = append(geometries, g)
+		}
+	}
+	for _, l := range m.LineStrings {
+		geometries = append(geometries, kmlLineGeometry(l))
+	}
+	for _, p := range m.Polygons {
+		geometries = append(geometries, kmlPolygonGeometry(p))
+	}
+	for _, nested := range m.MultiGeometry {
+		geometries = append(geometries, kmlMultiGeometryToGeoJSON(nested))
+	}
+
+	return map[string]interface{}{"type": "GeometryCollection", "geometries": geometries}
+}
+
+// kmlCommaPattern matches a comma inside a coordinate tuple with the whitespace around it, which
+// some writers add ("lon, lat") or wrap onto the next line
+var kmlCommaPattern = regexp.MustCompile(`\s*,\s*`)
+
+// parseKMLCoordinates parses a KML <coordinates> value: whitespace-separated "lon,lat[,alt]" tuples
+func parseKMLCoordinates(text string) [][]float64 {
+	coords := [][]float64{}
+	text = kmlCommaPattern.ReplaceAllString(text, ",")
+	for _, tuple := range strings.Fields(text) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			continue
+		}
+
+		coord := make([]float64, 0, 3)
+		valid := true
+		for i, part := range parts {
+			if i > 2 {
+				break
+			}
+			value, err := strconv.ParseFloat(part, 64)
+			if err != nil {
+				valid = false
+				break
+			}
+			coord = append(coord, value)
+		}
+		if valid {
+			coords = append(coords, coord)
+		}
+	}
+	return coords
+}
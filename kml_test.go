@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKMLCoordinates(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want [][]float64
+	}{
+		{"compact", "1.5,2.5 3,4", [][]float64{{1.5, 2.5}, {3, 4}}},
+		{"space after comma", "1.5, 2.5 3, 4", [][]float64{{1.5, 2.5}, {3, 4}}},
+		{"spaces around commas", "1.5 , 2.5 , 10   3 ,4", [][]float64{{1.5, 2.5, 10}, {3, 4}}},
+		{"altitude", "1,2,100 3,4,200", [][]float64{{1, 2, 100}, {3, 4, 200}}},
+		{"extra values ignored", "1,2,3,4", [][]float64{{1, 2, 3}}},
+		{"one tuple per line", "\n\t1,2,0\n\t3,4,0\n", [][]float64{{1, 2, 0}, {3, 4, 0}}},
+		{"tuple across lines", "1,\n  2,\n  0\n3,4", [][]float64{{1, 2, 0}, {3, 4}}},
+		{"tab-separated tuples", "1,2\t3,4", [][]float64{{1, 2}, {3, 4}}},
+		{"invalid tuple skipped", "1,2 x,y 3,4", [][]float64{{1, 2}, {3, 4}}},
+		{"lone number skipped", "1,2 5 3,4", [][]float64{{1, 2}, {3, 4}}},
+		{"empty", "  ", [][]float64{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseKMLCoordinates(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseKMLCoordinates(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	// layerFeaturesEvent carries each batch of features of a streamed layer
+	layerFeaturesEvent = "layer:features"
+	// layerCompleteEvent ends a streamed layer, whether it finished, failed or was cancelled
+	layerCompleteEvent = "layer:complete"
+
+	defaultLayerBatchSize = 1000
+	maxLayerBatchSize     = 50000
+)
+
+var errLayerLoadCancelled = errors.New("layer load cancelled")
+
+// LayerFeaturesBatch is the payload of layer:features events. Sequence starts at 0 and has no gaps.
+type LayerFeaturesBatch struct {
+	Handle   string                   `json:"handle"`
+	Sequence int                      `json:"sequence"`
+	Features []map[string]interface{} `json:"features"`
+}
+
+// LayerLoadComplete is the payload of layer:complete events. BBox is nil when no feature had
+// coordinates.
+type LayerLoadComplete struct {
+	Handle       string    `json:"handle"`
+	FilePath     string    `json:"file_path"`
+	Batches      int       `json:"batches"`
+	FeatureCount int       `json:"feature_count"`
+	BBox         []float64 `json:"bbox"`
+	Cancelled    bool      `json:"cancelled"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// layerStreamState tracks streamed loads so they can be cancelled
+type layerStreamState struct {
+	mu      sync.Mutex
+	next    int
+	cancels map[string]context.CancelFunc
+}
+
+// LoadGeospatialFileStreaming starts loading a file in the background and returns a handle at
+// once. Features arrive in layer:features events of batchSize features (0 for the default) as
+// the file is parsed, followed by a layer:complete event with the count and bbox.
+func (a *App) LoadGeospatialFileStreaming(filePath string, batchSize int) (string, error) {
+	filePath, err := gdalInputPath(filePath)
+	if err != nil {
+		return "", err
+	}
+	if batchSize <= 0 {
+		batchSize = defaultLayerBatchSize
+	}
+	if batchSize > maxLayerBatchSize {
+		batchSize = maxLayerBatchSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.streams.mu.Lock()
+	if a.streams.cancels == nil {
+		a.streams.cancels = map[string]context.CancelFunc{}
+	}
+	a.streams.next++
+	handle := fmt.Sprintf("layer-%d", a.streams.next)
+	a.streams.cancels[handle] = cancel
+	a.streams.mu.Unlock()
+
+	go func() {
+		defer func() {
+			a.streams.mu.Lock()
+			delete(a.streams.cancels, handle)
+			a.streams.mu.Unlock()
+			cancel()
+		}()
+		a.streamLayer(ctx, handle, filePath, batchSize)
+	}()
+	return handle, nil
+}
+
+// CancelLayerLoad stops a streamed load; a layer:complete event with cancelled set follows
+func (a *App) CancelLayerLoad(handle string) error {
+	a.streams.mu.Lock()
+	cancel, ok := a.streams.cancels[handle]
+	a.streams.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("layer load %q not found", handle)
+	}
+	cancel()
+	return nil
+}
+
+// streamLayer parses the file and emits its features in batches
+func (a *App) streamLayer(ctx context.Context, handle string, filePath string, batchSize int) {
+	complete := LayerLoadComplete{Handle: handle, FilePath: filePath}
+	bbox := []float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	batch := make([]map[string]interface{}, 0, batchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		a.emitEvent(layerFeaturesEvent, LayerFeaturesBatch{Handle: handle, Sequence: complete.Batches, Features: batch})
+		complete.Batches++
+		batch = make([]map[string]interface{}, 0, batchSize)
+	}
+	add := func(feature map[string]interface{}) error {
+		if ctx.Err() != nil {
+			return errLayerLoadCancelled
+		}
+		if geometry, ok := feature["geometry"].(map[string]interface{}); ok {
+			extendBBox(bbox, geometry)
+		}
+		batch = append(batch, feature)
+		complete.FeatureCount++
+		if len(batch) >= batchSize {
+			flush()
+		}
+		return nil
+	}
+
+	err := a.readLayerFeatures(ctx, filePath, "", add)
+	if err == nil {
+		flush()
+	}
+	if validBBox(bbox) {
+		complete.BBox = bbox
+	}
+
+	switch {
+	case ctx.Err() != nil:
+		complete.Cancelled = true
+		a.logInfo("Cancelled loading %s after %d features", filePath, complete.FeatureCount)
+	case err != nil:
+		complete.Error = err.Error()
+		a.logWarn("Streaming %s failed after %d features: %v", filePath, complete.FeatureCount, err)
+	default:
+		a.recordRecentItem(filePath, recentFile)
+		a.logInfo("Streamed %d features from %s in %d batches", complete.FeatureCount, filePath, complete.Batches)
+	}
+	a.emitEvent(layerCompleteEvent, complete)
+}
+
+// readLayerFeatures calls fn for each feature of a file. GeoJSON is decoded straight from disk
+// and other GDAL formats from the ogr2ogr stream; formats with a native reader are loaded whole
+// and then handed out feature by feature. layerName selects a layer of a multi-layer GDAL format
+// and may be empty for the first one.
+func (a *App) readLayerFeatures(ctx context.Context, filePath string, layerName string, fn func(map[string]interface{}) error) error {
+	ext := strings.ToLower(filepath.Ext(filePath))
+	if ext == ".geojson" || ext == ".json" {
+		file, err := os.Open(filePath)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %v", filepath.Base(filePath), err)
+		}
+		defer file.Close()
+		return readGeoJSONFeatures(bufio.NewReader(file), fn)
+	}
+
+	loader, hasNative := nativeLoaders[ext]
+	gdal, gdalErr := a.requireGDAL()
+	if ext == ".csv" || (hasNative && (loader.preferred || gdalErr != nil)) {
+		geojson, err := a.loadGeospatialFile(filePath, "")
+		if err != nil {
+			return err
+		}
+		features, err := geojsonFeatures(geojson)
+		if err != nil {
+			return err
+		}
+		for _, feature := range features {
+			if err := fn(feature); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if gdalErr != nil {
+		return gdalErr
+	}
+
+	// Closing the read side on cancel makes ogr2ogr's next write fail, which ends it
+	args := []string{"-f", "GeoJSON", "/dev/stdout", filePath}
+	if layerName != "" {
+		args = append(args, layerName)
+	}
+	reader, writer := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		stderr, err := a.execGDALTo(ctx, writer, gdal.Ogr2ogrPath, "", args...)
+		if err != nil {
+			err = fmt.Errorf("ogr2ogr failed: %w: %s", err, strings.TrimSpace(string(stderr)))
+		}
+		writer.CloseWithError(err)
+		done <- err
+	}()
+	err := readGeoJSONFeatures(bufio.NewReader(reader), fn)
+	reader.CloseWithError(errLayerLoadCancelled)
+	if execErr := <-done; execErr != nil && err == nil {
+		err = execErr
+	}
+	return err
+}
+
+// extendBBox grows a [minX, minY, maxX, maxY] bbox by every position in a GeoJSON geometry
+func extendBBox(bbox []float64, geometry map[string]interface{}) {
+	if members, ok := geometry["geometries"].([]interface{}); ok {
+		for _, member := range members {
+			if m, ok := member.(map[string]interface{}); ok {
+				extendBBox(bbox, m)
+			}
+		}
+		return
+	}
+	var walk func(interface{})
+	walk = func(value interface{}) {
+		items, ok := value.([]interface{})
+		if !ok || len(items) == 0 {
+			return
+		}
+		if x, ok := items[0].(float64); ok {
+			if len(items) >= 2 {
+				if y, ok := items[1].(float64); ok {
+					bbox[0], bbox[1] = math.Min(bbox[0], x), math.Min(bbox[1], y)
+					bbox[2], bbox[3] = math.Max(bbox[2], x), math.Max(bbox[3], y)
+				}
+			}
+			return
+		}
+		for _, item := range items {
+			walk(item)
+		}
+	}
+	walk(geometry["coordinates"])
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SaveLayerStyle stores the frontend's rendering config for a layer, replacing any earlier one.
+// Styles are keyed by path and layer name so they survive re-indexing.
+func (a *App) SaveLayerStyle(filePath string, layerName string, style map[string]interface{}) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	if style == nil {
+		return fmt.Errorf("style is empty")
+	}
+
+	data, err := json.Marshal(style)
+	if err != nil {
+		return fmt.Errorf("failed to encode style: %v", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, err = a.db.Exec(`
+		INSERT INTO layer_styles (file_path, layer_name, style, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(file_path, layer_name) DO UPDATE SET style = excluded.style, updated_at = excluded.updated_at`,
+		filePath, layerName, string(data), time.Now().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to save layer style: %v", err)
+	}
+	return nil
+}
+
+// GetLayerStyle returns the stored style for a layer, or a default suited to its geometry type
+// when none has been saved
+func (a *App) GetLayerStyle(filePath string, layerName string) (map[string]interface{}, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	var raw string
+	err := a.db.QueryRow("SELECT style FROM layer_styles WHERE file_path = ? AND layer_name = ?", filePath, layerName).Scan(&raw)
+	a.mu.RUnlock()
+	if err == sql.ErrNoRows {
+		return a.defaultLayerStyle(filePath), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layer style: %v", err)
+	}
+
+	var style map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &style); err != nil {
+		return nil, fmt.Errorf("failed to decode layer style: %v", err)
+	}
+	return style, nil
+}
+
+// defaultLayerStyle mirrors the frontend's LayerStyleConfig, tuned by the indexed geometry type:
+// lines get a thicker unfilled stroke and points a smaller radius than the frontend's default
+func (a *App) defaultLayerStyle(filePath string) map[string]interface{} {
+	style := map[string]interface{}{
+		"fillColor":     "#34a853",
+		"strokeColor":   "#006428",
+		"fillOpacity":   0.6,
+		"strokeOpacity": 1.0,
+		"strokeWidth":   2,
+		"pointRadius":   18,
+	}
+
+	geometryType := ""
+	if metadata, ok := a.indexedMetadata(filePath); ok {
+		if types, ok := metadata["geometry_types"].([]interface{}); ok && len(types) == 1 {
+			geometryType, _ = types[0].(string)
+		}
+	}
+	switch geometryType {
+	case "LineString", "MultiLineString":
+		style["strokeColor"] = "#1a73e8"
+		style["fillOpacity"] = 0.0
+		style["strokeWidth"] = 3
+	case "Point", "MultiPoint":
+		style["fillColor"] = "#ea4335"
+		style["strokeColor"] = "#a50e0e"
+		style["fillOpacity"] = 0.9
+		style["strokeWidth"] = 1
+		style["pointRadius"] = 6
+	}
+
+	return map[string]interface{}{
+		"defaultStyle":      style,
+		"categorizedStyles": []interface{}{},
+		"stylingMode":       "default",
+		"geometryType":      geometryType,
+	}
+}
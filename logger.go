@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// logLevel orders log messages by severity
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+// logLevelSettingKey stores the configured level in the settings table
+const logLevelSettingKey = "log_level"
+
+// maxLogFileSize is the size at which the log file is rotated
+const maxLogFileSize = 5 * 1024 * 1024
+
+// maxLogBackups is how many rotated log files (terrabox.log.1 ... terrabox.log.N) are kept
+const maxLogBackups = 5
+
+var logLevelNames = map[logLevel]string{
+	levelDebug: "DEBUG",
+	levelInfo:  "INFO",
+	levelWarn:  "WARN",
+	levelError: "ERROR",
+}
+
+// parseLogLevel accepts debug, info, warn/warning or error in any case
+func parseLogLevel(level string) (logLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return levelDebug, nil
+	case "info":
+		return levelInfo, nil
+	case "warn", "warning":
+		return levelWarn, nil
+	case "error":
+		return levelError, nil
+	}
+	return levelInfo, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", level)
+}
+
+// appLogger filters messages by level and appends them to a log file under the data directory
+type appLogger struct {
+	mu    sync.Mutex
+	level logLevel
+	path  string
+	file  *os.File
+	size  int64
+}
+
+// storedLogLevel returns the level saved in settings, or info if there is none
+func (a *App) storedLogLevel() logLevel {
+	if value, found, err := a.getSetting(logLevelSettingKey); err == nil && found {
+		if parsed, err := parseLogLevel(value); err == nil {
+			return parsed
+		}
+	}
+	return levelInfo
+}
+
+// initLogger opens the log file and applies the level saved in settings
+func (a *App) initLogger() error {
+	level := a.storedLogLevel()
+
+	dir, err := terraboxDir()
+	if err != nil {
+		return err
+	}
+	logDir := filepath.Join(dir, "logs")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return err
+	}
+
+	a.logger.mu.Lock()
+	defer a.logger.mu.Unlock()
+
+	a.logger.level = level
+	a.logger.path = filepath.Join(logDir, "terrabox.log")
+	return a.logger.open()
+}
+
+// SetLogLevel changes the minimum level that is logged and remembers it across restarts
+func (a *App) SetLogLevel(level string) error {
+	parsed, err := parseLogLevel(level)
+	if err != nil {
+		return err
+	}
+
+	a.logger.mu.Lock()
+	a.logger.level = parsed
+	a.logger.mu.Unlock()
+
+	a.logInfo("Log level set to %s", logLevelNames[parsed])
+	return a.setSetting(logLevelSettingKey, strings.ToLower(logLevelNames[parsed]))
+}
+
+// GetLogLevel returns the current log level
+func (a *App) GetLogLevel() string {
+	a.logger.mu.Lock()
+	defer a.logger.mu.Unlock()
+	return strings.ToLower(logLevelNames[a.logger.level])
+}
+
+func (a *App) logDebug(format string, args ...interface{}) { a.logf(levelDebug, format, args...) }
+func (a *App) logInfo(format string, args ...interface{})  { a.logf(levelInfo, format, args...) }
+func (a *App) logWarn(format string, args ...interface{})  { a.logf(levelWarn, format, args...) }
+func (a *App) logError(format string, args ...interface{}) { a.logf(levelError, format, args...) }
+
+// logf sends a message to the Wails log and the log file if it meets the configured level
+func (a *App) logf(level logLevel, format string, args ...interface{}) {
+	a.logger.mu.Lock()
+	enabled := level >= a.logger.level
+	a.logger.mu.Unlock()
+	if !enabled {
+		return
+	}
+
+	message := fmt.Sprintf(format, args...)
+
+	// The Wails runtime aborts when called without its context, e.g. before startup
+	if a.ctx != nil {
+		switch level {
+		case levelDebug:
+			runtime.LogDebug(a.ctx, message)
+		case levelInfo:
+			runtime.LogInfo(a.ctx, message)
+		case levelWarn:
+			runtime.LogWarning(a.ctx, message)
+		case levelError:
+			runtime.LogError(a.ctx, message)
+		}
+	}
+
+	a.logger.write(level, message)
+}
+
+// write appends one line to the log file, rotating it when it grows too large
+func (l *appLogger) write(level logLevel, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return
+	}
+
+	line := fmt.Sprintf("%s [%s] %s\n", time.Now().Format(time.RFC3339), logLevelNames[level], message)
+	if l.size+int64(len(line)) > maxLogFileSize {
+		l.rotate()
+		if l.file == nil {
+			return
+		}
+	}
+
+	n, _ := l.file.WriteString(line)
+	l.size += int64(n)
+}
+
+// rotate shifts terrabox.log.N-1 to .N (dropping the oldest), moves the current log to .1
+// and starts a new one. Callers must hold l.mu.
+func (l *appLogger) rotate() {
+	l.file.Close()
+	l.file = nil
+
+	os.Remove(fmt.Sprintf("%s.%d", l.path, maxLogBackups))
+	for i := maxLogBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+	}
+	os.Rename(l.path, l.path+".1")
+	l.open()
+}
+
+// close flushes and closes the log file; later messages only go to the Wails log
+func (l *appLogger) close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
+// files returns the current log followed by its rotated backups, newest first
+func (l *appLogger) files() []string {
+	l.mu.Lock()
+	path := l.path
+	l.mu.Unlock()
+
+	if path == "" {
+		return nil
+	}
+	files := []string{path}
+	for i := 1; i <= maxLogBackups; i++ {
+		backup := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(backup); err == nil {
+			files = append(files, backup)
+		}
+	}
+	return files
+}
+
+// open opens the log file for appending. Callers must hold l.mu.
+func (l *appLogger) open() error {
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to open log file: %v", err)
+	}
+	l.file = file
+	l.size = info.Size()
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ogrGeometryPattern   = regexp.MustCompile(`(?m)^Geometry: (.+?)\r?$`)
+	gmlSchemaLocation    = regexp.MustCompile(`schemaLocation\s*=\s*"([^"]*)"`)
+	dxfLayerValuePattern = regexp.MustCompile(`(?m)^\s*Layer \(String\) = (.*?)\r?$`)
+)
+
+// ogrLayerSummary is one layer of `ogrinfo -so -al` output
+type ogrLayerSummary struct {
+	name         string
+	geometryType string
+	featureCount int
+	bbox         []float64
+	// wktHead is the first line of the layer's WKT, enough to tell geographic from projected
+	wktHead string
+	summary string
+}
+
+// parseOgrinfoLayers splits `ogrinfo -so -al` output into its layers
+func parseOgrinfoLayers(summary string) []ogrLayerSummary {
+	var layers []ogrLayerSummary
+	starts := ogrLayerNamePattern.FindAllStringSubmatchIndex(summary, -1)
+	for i, start := range starts {
+		end := len(summary)
+		if i+1 < len(starts) {
+			end = starts[i+1][0]
+		}
+		block := summary[start[0]:end]
+		layer := ogrLayerSummary{name: summary[start[2]:start[3]], featureCount: -1, summary: block}
+		if match := ogrGeometryPattern.FindStringSubmatch(block); match != nil {
+			layer.geometryType = ogrGeometryTypeName(match[1])
+		}
+		if match := ogrFeatureCountPattern.FindStringSubmatch(block); match != nil {
+			layer.featureCount, _ = strconv.Atoi(match[1])
+		}
+		if bbox, _, err := parseOgrinfoExtent(block); err == nil {
+			layer.bbox = bbox
+		}
+		if i := strings.Index(block, "Layer SRS WKT:\n"); i >= 0 {
+			rest := block[i+len("Layer SRS WKT:\n"):]
+			if j := strings.Index(rest, "\n"); j >= 0 {
+				layer.wktHead = strings.TrimSpace(rest[:j])
+			}
+		}
+		layers = append(layers, layer)
+	}
+	return layers
+}
+
+// ogrGeometryTypeName turns an ogrinfo geometry type such as "3D Multi Line String" into the
+// GeoJSON name used elsewhere in metadata; layers without geometry give ""
+func ogrGeometryTypeName(name string) string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "3D ")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, " Z"), " M")
+	switch {
+	case name == "None":
+		return ""
+	case strings.HasPrefix(name, "Unknown"):
+		return "Geometry"
+	}
+	return strings.ReplaceAll(name, " ", "")
+}
+
+// storeOgrLayers fills in the feature count, geometry types, fields, CRS and extent of a file
+// from its layers. The extent is in the first layer's CRS, so a projected one is kept as
+// native_bbox like LAS and VRT extents.
+func storeOgrLayers(layers []ogrLayerSummary, metadata *FileMetadata) {
+	names := []string{}
+	types := map[string]bool{}
+	var bbox []float64
+	metadata.NumFeatures = 0
+	for _, layer := range layers {
+		names = append(names, layer.name)
+		if layer.geometryType != "" {
+			types[layer.geometryType] = true
+		}
+		if layer.featureCount > 0 {
+			metadata.NumFeatures += layer.featureCount
+		}
+		if layer.bbox == nil {
+			continue
+		}
+		if bbox == nil {
+			bbox = append([]float64(nil), layer.bbox...)
+		} else {
+			bbox[0], bbox[1] = math.Min(bbox[0], layer.bbox[0]), math.Min(bbox[1], layer.bbox[1])
+			bbox[2], bbox[3] = math.Max(bbox[2], layer.bbox[2]), math.Max(bbox[3], layer.bbox[3])
+		}
+	}
+
+	list := make([]string, 0, len(types))
+	for name := range types {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	metadata.Metadata["geometry_types"] = list
+	metadata.Metadata["layers"] = names
+	if len(layers) == 0 {
+		return
+	}
+	metadata.Metadata["fields"] = parseOgrinfoFields(layers[0].summary)
+
+	_, crs, _ := parseOgrinfoExtent(layers[0].summary)
+	metadata.CRS = crs
+	if bbox != nil && validBBox(bbox) {
+		if isGeographicWKT(layers[0].wktHead) {
+			metadata.BBox = bbox
+		} else {
+			metadata.Metadata["native_bbox"] = bbox
+		}
+	}
+}
+
+// extractGMLMetadata reads the feature types of a GML file with ogrinfo. GDAL builds them from
+// the application schema, either the .xsd next to the file or the one named in
+// xsi:schemaLocation, which is recorded as application_schema.
+func (a *App) extractGMLMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	metadata.Metadata["format"] = "GML"
+	if schema := gmlApplicationSchema(filePath); schema != "" {
+		metadata.Metadata["application_schema"] = schema
+	}
+
+	summary, err := a.ogrinfoSummaryContext(ctx, filePath, "")
+	if err != nil {
+		return err
+	}
+	storeOgrLayers(parseOgrinfoLayers(summary), metadata)
+	return nil
+}
+
+// gmlApplicationSchema returns the .xsd GDAL will read a GML file's schema from, or ""
+func gmlApplicationSchema(filePath string) string {
+	xsd := strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".xsd"
+	if _, err := os.Stat(xsd); err == nil {
+		return filepath.Base(xsd)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	header := make([]byte, 4096)
+	n, _ := io.ReadFull(file, header)
+	match := gmlSchemaLocation.FindSubmatch(header[:n])
+	if match == nil {
+		return ""
+	}
+	// schemaLocation holds namespace and location pairs; skip the standard GML and OGC schemas
+	parts := strings.Fields(string(match[1]))
+	for i := 1; i < len(parts); i += 2 {
+		if !strings.Contains(parts[i], "opengis.net") {
+			return parts[i]
+		}
+	}
+	return ""
+}
+
+// extractDXFMetadata reads a DXF drawing with ogrinfo. GDAL exposes the drawing as one
+// "entities" layer with block references expanded into their entities; the CAD layer of each
+// entity is its Layer field, and the distinct CAD layer names are recorded as cad_layers so the
+// drawing can be filtered by them.
+func (a *App) extractDXFMetadata(ctx context.Context, filePath string, metadata *FileMetadata) error {
+	metadata.Metadata["format"] = "DXF"
+
+	summary, err := a.ogrinfoSummaryContext(ctx, filePath, "")
+	if err != nil {
+		return err
+	}
+	layers := parseOgrinfoLayers(summary)
+	storeOgrLayers(layers, metadata)
+
+	cadLayers, err := a.dxfCADLayers(ctx, filePath)
+	if err != nil {
+		a.logDebug("Could not list CAD layers of %s: %v", filePath, err)
+		return nil
+	}
+	metadata.Metadata["cad_layers"] = cadLayers
+	return nil
+}
+
+// dxfCADLayers returns the sorted CAD layer names used by a DXF drawing's entities
+func (a *App) dxfCADLayers(ctx context.Context, filePath string) ([]string, error) {
+	input, err := gdalInputPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	output, err := a.runGDALToolContext(ctx, "ogrinfo", "", "-ro", "-q", "-sql", "SELECT DISTINCT Layer FROM entities", input)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, match := range dxfLayerValuePattern.FindAllStringSubmatch(string(output), -1) {
+		names = append(names, match[1])
+	}
+	sort.Strings(names)
+	return names, nil
+}
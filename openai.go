@@ -0,0 +1,616 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/sashabaranov/go-openai/jsonschema"
+)
+
+// defaultOpenAIRate is how many OpenAI requests are allowed per minute, also the burst size
+const defaultOpenAIRate = 10
+
+// defaultOpenAICacheTTL is how long an answer is reused for the same description and bbox
+const defaultOpenAICacheTTL = 5 * time.Minute
+
+// maxOpenAIWait is how long a request waits for the rate limiter before giving up
+const maxOpenAIWait = 10 * time.Second
+
+const (
+	openAIRateSettingKey     = "openai_rate_per_minute"
+	openAICacheTTLSettingKey = "openai_cache_ttl_seconds"
+	openAIModelSettingKey    = "openai_model"
+)
+
+// structuredOutputModels are model prefixes that accept a JSON schema response format; other
+// models get plain JSON mode
+var structuredOutputModels = []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4"}
+
+// reasoningModels are model prefixes that count their hidden reasoning against the reply limit.
+// They reject max_tokens and take max_completion_tokens instead.
+var reasoningModels = []string{"gpt-5", "o1", "o3", "o4"}
+
+// maxReplyTokens limits a reply; reasoning models get maxReasoningTokens on top to think in
+const (
+	maxReplyTokens     = 200
+	maxReasoningTokens = 4000
+)
+
+// locationDataSchema describes the LocationData reply. It is sent to models that support
+// structured output and used to validate every reply, however it was requested.
+var locationDataSchema = jsonschema.Definition{
+	Type: jsonschema.Object,
+	Properties: map[string]jsonschema.Definition{
+		"bounding_box": {
+			Type:        jsonschema.Array,
+			Items:       &jsonschema.Definition{Type: jsonschema.Number},
+			Description: "[west, south, east, north] in degrees of the place named in the search, or [0, 0, 0, 0] if none is named",
+		},
+		"categories": {
+			Type:        jsonschema.Array,
+			Items:       &jsonschema.Definition{Type: jsonschema.String},
+			Description: "Known categories matching the search",
+		},
+		"location": {
+			Type:        jsonschema.String,
+			Description: "The place named in the search, or an empty string",
+		},
+	},
+	Required:             []string{"bounding_box", "categories", "location"},
+	AdditionalProperties: false,
+}
+
+// openAIStrictSystemMessage is sent on the retry after a response that wasn't valid JSON
+const openAIStrictSystemMessage = "You are a JSON API. Reply with a single JSON object and nothing else: " +
+	"no markdown, no code fences, no explanations."
+
+// openAIState is a token bucket limiting OpenAI requests plus a cache of recent answers
+type openAIState struct {
+	mu          sync.Mutex
+	tokens      float64
+	refilled    time.Time
+	cache       map[string]openAICacheEntry
+	lastRequest time.Time
+	lastError   string
+	lastErrorAt time.Time
+	// noJSONMode remembers models that rejected a response format
+	noJSONMode map[string]bool
+}
+
+type openAICacheEntry struct {
+	data    LocationData
+	expires time.Time
+}
+
+// OpenAIStatus reports the OpenAI budget and the most recent failure
+type OpenAIStatus struct {
+	Configured      bool   `json:"configured"`
+	Model           string `json:"model"`
+	JSONMode        bool   `json:"json_mode"`
+	RatePerMinute   int    `json:"rate_per_minute"`
+	Remaining       int    `json:"remaining"`
+	CacheTTLSeconds int    `json:"cache_ttl_seconds"`
+	CachedAnswers   int    `json:"cached_answers"`
+	LastRequestAt   string `json:"last_request_at,omitempty"`
+	LastError       string `json:"last_error,omitempty"`
+	LastErrorAt     string `json:"last_error_at,omitempty"`
+}
+
+// getLocationFromOpenAI answers from the cache when the same description and bbox were asked
+// recently, and otherwise waits for the rate limiter before calling OpenAI
+func (a *App) getLocationFromOpenAI(description string, fallbackBbox []float64) (*LocationData, error) {
+	// Get OpenAI API key from environment
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return nil, fmt.Errorf("OPENAI_API_KEY environment variable not set")
+	}
+
+	key := openAICacheKey(description, fallbackBbox)
+	if cached, ok := a.cachedLocation(key); ok {
+		a.logDebug("Reusing OpenAI answer for %q", description)
+		return cached, nil
+	}
+
+	if err := a.waitForOpenAIToken(); err != nil {
+		a.recordOpenAIError(err)
+		return nil, err
+	}
+
+	locationData, err := a.requestLocationFromOpenAI(apiKey, description, fallbackBbox)
+
+	a.openAI.mu.Lock()
+	defer a.openAI.mu.Unlock()
+	a.openAI.lastRequest = time.Now()
+	if err != nil {
+		a.openAI.lastError = err.Error()
+		a.openAI.lastErrorAt = time.Now()
+		return nil, err
+	}
+	if a.openAI.cache == nil {
+		a.openAI.cache = map[string]openAICacheEntry{}
+	}
+	a.openAI.cache[key] = openAICacheEntry{data: *locationData, expires: time.Now().Add(a.openAICacheTTL())}
+	return locationData, nil
+}
+
+// openAICacheKey normalizes the description so trivially different wording shares an entry
+func openAICacheKey(description string, bbox []float64) string {
+	return fmt.Sprintf("%s|%.6f,%.6f,%.6f,%.6f", strings.ToLower(strings.Join(strings.Fields(description), " ")),
+		bbox[0], bbox[1], bbox[2], bbox[3])
+}
+
+// cachedLocation returns a copy of an unexpired cache entry, pruning expired ones
+func (a *App) cachedLocation(key string) (*LocationData, bool) {
+	a.openAI.mu.Lock()
+	defer a.openAI.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range a.openAI.cache {
+		if now.After(entry.expires) {
+			delete(a.openAI.cache, k)
+		}
+	}
+	entry, ok := a.openAI.cache[key]
+	if !ok {
+		return nil, false
+	}
+	data := entry.data
+	return &data, true
+}
+
+// waitForOpenAIToken takes a token from the bucket, waiting up to maxOpenAIWait for one
+func (a *App) waitForOpenAIToken() error {
+	rate := a.openAIRate()
+	deadline := time.Now().Add(maxOpenAIWait)
+	for {
+		a.openAI.mu.Lock()
+		a.refillOpenAITokens(rate)
+		if a.openAI.tokens >= 1 {
+			a.openAI.tokens--
+			a.openAI.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - a.openAI.tokens) * float64(time.Minute) / float64(rate))
+		a.openAI.mu.Unlock()
+
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("OpenAI rate limit of %d requests per minute reached; try again in %s",
+				rate, wait.Round(time.Second))
+		}
+		a.logDebug("OpenAI request queued for %s by the rate limiter", wait.Round(time.Millisecond))
+		time.Sleep(wait)
+	}
+}
+
+// refillOpenAITokens adds the tokens earned since the last refill. Callers must hold openAI.mu.
+func (a *App) refillOpenAITokens(rate int) {
+	now := time.Now()
+	if a.openAI.refilled.IsZero() {
+		a.openAI.tokens = float64(rate)
+	} else {
+		a.openAI.tokens += now.Sub(a.openAI.refilled).Minutes() * float64(rate)
+	}
+	a.openAI.tokens = math.Min(a.openAI.tokens, float64(rate))
+	a.openAI.refilled = now
+}
+
+func (a *App) openAIRate() int {
+	if value, found, err := a.getSetting(openAIRateSettingKey); err == nil && found {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultOpenAIRate
+}
+
+func (a *App) openAICacheTTL() time.Duration {
+	if value, found, err := a.getSetting(openAICacheTTLSettingKey); err == nil && found {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return defaultOpenAICacheTTL
+}
+
+// GetOpenAIStatus returns the remaining OpenAI budget, cache size and last error
+func (a *App) GetOpenAIStatus() OpenAIStatus {
+	rate := a.openAIRate()
+	ttl := a.openAICacheTTL()
+	model := a.openAIModel()
+
+	a.openAI.mu.Lock()
+	defer a.openAI.mu.Unlock()
+	a.refillOpenAITokens(rate)
+
+	status := OpenAIStatus{
+		Configured:      os.Getenv("OPENAI_API_KEY") != "",
+		Model:           model,
+		JSONMode:        !a.openAI.noJSONMode[model],
+		RatePerMinute:   rate,
+		Remaining:       int(a.openAI.tokens),
+		CacheTTLSeconds: int(ttl.Seconds()),
+		CachedAnswers:   len(a.openAI.cache),
+		LastError:       a.openAI.lastError,
+	}
+	if !a.openAI.lastRequest.IsZero() {
+		status.LastRequestAt = a.openAI.lastRequest.Format(time.RFC3339)
+	}
+	if !a.openAI.lastErrorAt.IsZero() {
+		status.LastErrorAt = a.openAI.lastErrorAt.Format(time.RFC3339)
+	}
+	return status
+}
+
+// SetOpenAILimits changes the allowed requests per minute and how many seconds answers are
+// cached; a TTL of 0 disables the cache
+func (a *App) SetOpenAILimits(ratePerMinute int, cacheTTLSeconds int) error {
+	if ratePerMinute < 1 || ratePerMinute > 600 {
+		return fmt.Errorf("OpenAI rate must be between 1 and 600 requests per minute")
+	}
+	if cacheTTLSeconds < 0 || cacheTTLSeconds > 24*60*60 {
+		return fmt.Errorf("OpenAI cache TTL must be between 0 seconds and 24 hours")
+	}
+	if err := a.setSetting(openAIRateSettingKey, strconv.Itoa(ratePerMinute)); err != nil {
+		return err
+	}
+	if err := a.setSetting(openAICacheTTLSettingKey, strconv.Itoa(cacheTTLSeconds)); err != nil {
+		return err
+	}
+
+	if cacheTTLSeconds == 0 {
+		a.openAI.mu.Lock()
+		a.openAI.cache = nil
+		a.openAI.mu.Unlock()
+	}
+	return nil
+}
+
+// requestLocationFromOpenAI asks OpenAI for the area and OSM categories a description refers to.
+// A reply that isn't valid JSON is retried once with a stricter system message.
+func (a *App) requestLocationFromOpenAI(apiKey string, description string, fallbackBbox []float64) (*LocationData, error) {
+	client := a.openAIClient(apiKey)
+
+	var names []string
+	for name := range a.overpassCategories() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	prompt := fmt.Sprintf(`Find the area and OpenStreetMap categories for this map search: "%s"
+
+The map currently shows the bounding box [%.6f, %.6f, %.6f, %.6f] (west, south, east, north).
+If the search names a place, use that place's bounding box instead.
+
+Known categories: %s
+
+Reply with a JSON object:
+{"bounding_box": [west, south, east, north], "categories": ["category", ...], "location": "place name"}
+
+Use only known categories. Use an empty list if none fit; the search text is then matched against names.`,
+		description, fallbackBbox[0], fallbackBbox[1], fallbackBbox[2], fallbackBbox[3], strings.Join(names, ", "))
+
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleUser, Content: prompt},
+	}
+	model := a.openAIModel()
+	format := a.locationResponseFormat(model)
+	content, err := a.openAIChat(client, model, messages, format)
+	if err != nil && format != nil && isResponseFormatError(err) {
+		// Older models don't support JSON mode; fall back to asking for JSON in the prompt
+		a.logWarn("%s does not support JSON mode, using the prompt instead: %v", model, err)
+		a.openAI.mu.Lock()
+		if a.openAI.noJSONMode == nil {
+			a.openAI.noJSONMode = map[string]bool{}
+		}
+		a.openAI.noJSONMode[model] = true
+		a.openAI.mu.Unlock()
+		format = nil
+		content, err = a.openAIChat(client, model, messages, format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	locationData, err := parseLocationData(content)
+	if err != nil {
+		a.logWarn("OpenAI reply was not usable (%v), retrying with a JSON-only instruction", err)
+		messages = append([]openai.ChatCompletionMessage{
+			{Role: openai.ChatMessageRoleSystem, Content: openAIStrictSystemMessage},
+		}, messages...)
+		if content, err = a.openAIChat(client, model, messages, format); err != nil {
+			return nil, err
+		}
+		if locationData, err = parseLocationData(content); err != nil {
+			return nil, fmt.Errorf("could not parse OpenAI reply: %v", err)
+		}
+	}
+
+	// No place named in the search: keep the current view
+	if locationData.BoundingBox == [4]float64{} {
+		locationData.BoundingBox = [4]float64{fallbackBbox[0], fallbackBbox[1], fallbackBbox[2], fallbackBbox[3]}
+	}
+	return locationData, nil
+}
+
+// openAIChat sends one chat completion request and returns the reply text
+func (a *App) openAIChat(client *openai.Client, model string, messages []openai.ChatCompletionMessage, format *openai.ChatCompletionResponseFormat) (string, error) {
+	resp, err := client.CreateChatCompletion(context.Background(), chatCompletionRequest(model, messages, format))
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from OpenAI")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// chatCompletionRequest builds the request for a model, limiting the reply with the token field
+// the model accepts
+func chatCompletionRequest(model string, messages []openai.ChatCompletionMessage, format *openai.ChatCompletionResponseFormat) openai.ChatCompletionRequest {
+	request := openai.ChatCompletionRequest{
+		Model:          model,
+		Messages:       messages,
+		MaxTokens:      maxReplyTokens,
+		ResponseFormat: format,
+	}
+	for _, prefix := range reasoningModels {
+		if strings.HasPrefix(model, prefix) {
+			request.MaxTokens = 0
+			request.MaxCompletionTokens = maxReplyTokens + maxReasoningTokens
+			break
+		}
+	}
+	return request
+}
+
+// locationResponseFormat constrains the reply to locationDataSchema where the model supports it,
+// or to any JSON object otherwise. It is nil for models known not to support either.
+func (a *App) locationResponseFormat(model string) *openai.ChatCompletionResponseFormat {
+	a.openAI.mu.Lock()
+	unsupported := a.openAI.noJSONMode[model]
+	a.openAI.mu.Unlock()
+	if unsupported {
+		return nil
+	}
+
+	for _, prefix := range structuredOutputModels {
+		if strings.HasPrefix(model, prefix) {
+			return &openai.ChatCompletionResponseFormat{
+				Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+				JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+					Name:   "location_data",
+					Schema: &locationDataSchema,
+					Strict: true,
+				},
+			}
+		}
+	}
+	return &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+}
+
+// isResponseFormatError reports whether OpenAI rejected the request's response_format
+func isResponseFormatError(err error) bool {
+	var apiErr *openai.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != 400 {
+		return false
+	}
+	param := ""
+	if apiErr.Param != nil {
+		param = *apiErr.Param
+	}
+	return strings.Contains(param, "response_format") || strings.Contains(apiErr.Message, "response_format")
+}
+
+// openAIClient creates an OpenAI client that sends its requests through the shared HTTP client
+func (a *App) openAIClient(apiKey string) *openai.Client {
+	config := openai.DefaultConfig(apiKey)
+	config.HTTPClient = a.httpClient()
+	return openai.NewClientWithConfig(config)
+}
+
+func (a *App) openAIModel() string {
+	if value, found, err := a.getSetting(openAIModelSettingKey); err == nil && found && value != "" {
+		return value
+	}
+	return openai.GPT3Dot5Turbo
+}
+
+// SetOpenAIModel chooses the OpenAI chat model used for query generation, e.g. "gpt-4o-mini"
+func (a *App) SetOpenAIModel(model string) error {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return fmt.Errorf("model name is required")
+	}
+	return a.setSetting(openAIModelSettingKey, model)
+}
+
+// parseLocationData decodes and validates the JSON object in an OpenAI reply
+func parseLocationData(content string) (*LocationData, error) {
+	object, err := extractJSONObject(content)
+	if err != nil {
+		return nil, err
+	}
+
+	var locationData LocationData
+	if err := locationDataSchema.Unmarshal(object, &locationData); err != nil {
+		return nil, fmt.Errorf("reply does not match the expected JSON: %v", err)
+	}
+	var raw struct {
+		BoundingBox []float64 `json:"bounding_box"`
+	}
+	if json.Unmarshal([]byte(object), &raw) == nil && len(raw.BoundingBox) != 4 {
+		return nil, fmt.Errorf("bounding box must have 4 numbers, got %d", len(raw.BoundingBox))
+	}
+
+	bbox := locationData.BoundingBox
+	if bbox != [4]float64{} {
+		west, south, east, north := bbox[0], bbox[1], bbox[2], bbox[3]
+		if west < -180 || west > 180 || east < -180 || east > 180 {
+			return nil, fmt.Errorf("bounding box longitudes %g, %g are out of range", west, east)
+		}
+		if south < -90 || south > 90 || north < -90 || north > 90 {
+			return nil, fmt.Errorf("bounding box latitudes %g, %g are out of range", south, north)
+		}
+		if south >= north || west == east {
+			return nil, fmt.Errorf("bounding box %v is empty or not in west, south, east, north order", bbox)
+		}
+	}
+
+	for i, category := range locationData.Categories {
+		locationData.Categories[i] = strings.ToLower(strings.TrimSpace(category))
+	}
+	return &locationData, nil
+}
+
+// extractJSONObject returns the first balanced {...} in text, skipping markdown code fences
+// and any prose around the object
+func extractJSONObject(text string) (string, error) {
+	// Prefer the contents of a fenced block such as ```json ... ```
+	if start := strings.Index(text, "```"); start >= 0 {
+		body := text[start+3:]
+		if newline := strings.IndexByte(body, '\n'); newline >= 0 {
+			body = body[newline+1:]
+		}
+		if end := strings.Index(body, "```"); end >= 0 {
+			body = body[:end]
+		}
+		if strings.Contains(body, "{") {
+			text = body
+		}
+	}
+
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return "", fmt.Errorf("no JSON object in reply")
+	}
+
+	depth := 0
+	inString, escaped := false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("unterminated JSON object in reply")
+}
+
+// advancedQueryAttempts is how often GenerateOverpassQueryAdvanced asks the model to fix an
+// invalid query before giving up
+const advancedQueryAttempts = 3
+
+// GenerateOverpassQueryAdvanced asks the model to write a complete Overpass QL query, for requests
+// the category table can't express ("buildings taller than 5 floors near parks"). The query is
+// checked with ValidateOverpassQuery and sent back to the model for repair when it fails.
+// GenerateOverpassQuery remains the safer default.
+func (a *App) GenerateOverpassQueryAdvanced(description string, bbox []float64) (string, error) {
+	if len(bbox) != 4 {
+		return "", fmt.Errorf("bbox must be [west, south, east, north]")
+	}
+	apiKey := os.Getenv("OPENAI_API_KEY")
+	if apiKey == "" {
+		return "", fmt.Errorf("OPENAI_API_KEY environment variable not set")
+	}
+
+	west, south, east, north := bbox[0], bbox[1], bbox[2], bbox[3]
+	prompt := fmt.Sprintf(`Write an Overpass QL query for this request: "%s"
+
+Rules:
+1. Start with [out:json][timeout:25];
+2. Restrict every statement to the bounding box (%.6f,%.6f,%.6f,%.6f) (south, west, north, east),
+   or to an around: filter or area derived from it
+3. Use node, way and relation statements where appropriate
+4. End with: out geom;
+
+Reply with the query only, no explanations.`, description, south, west, north, east)
+
+	client := a.openAIClient(apiKey)
+	model := a.openAIModel()
+	messages := []openai.ChatCompletionMessage{
+		{Role: openai.ChatMessageRoleUser, Content: prompt},
+	}
+
+	var problems []string
+	for attempt := 1; attempt <= advancedQueryAttempts; attempt++ {
+		if err := a.waitForOpenAIToken(); err != nil {
+			a.recordOpenAIError(err)
+			return "", err
+		}
+		content, err := a.openAIChat(client, model, messages, nil)
+		if err != nil {
+			a.recordOpenAIError(err)
+			return "", err
+		}
+
+		query := extractCodeBlock(content)
+		validation, _ := a.ValidateOverpassQuery(query)
+		if validation.Valid {
+			if attempt > 1 {
+				a.logInfo("Overpass query was valid after %d attempts", attempt)
+			}
+			a.rememberGeneratedQuery(query, querySourceOpenAI, description)
+			return query, nil
+		}
+
+		problems = validation.Errors
+		a.logWarn("Generated Overpass query is invalid (attempt %d): %s", attempt, strings.Join(problems, "; "))
+		messages = append(messages,
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+			openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "That query has these problems:\n- " +
+				strings.Join(problems, "\n- ") + "\nReply with the corrected query only."},
+		)
+	}
+
+	err := fmt.Errorf("could not generate a valid Overpass query: %s", strings.Join(problems, "; "))
+	a.recordOpenAIError(err)
+	return "", err
+}
+
+// recordOpenAIError keeps err for GetOpenAIStatus
+func (a *App) recordOpenAIError(err error) {
+	a.openAI.mu.Lock()
+	defer a.openAI.mu.Unlock()
+	a.openAI.lastError = err.Error()
+	a.openAI.lastErrorAt = time.Now()
+}
+
+// extractCodeBlock returns the contents of the first markdown code fence in text, or the trimmed
+// text if there is none
+func extractCodeBlock(text string) string {
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return strings.TrimSpace(text)
+	}
+	body := text[start+3:]
+	// Drop a language tag such as ```overpassql
+	if newline := strings.IndexByte(body, '\n'); newline >= 0 && !strings.ContainsAny(body[:newline], "[(;") {
+		body = body[newline+1:]
+	}
+	if end := strings.Index(body, "```"); end >= 0 {
+		body = body[:end]
+	}
+	return strings.TrimSpace(body)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestChatCompletionRequest(t *testing.T) {
+	messages := []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "cafes in Lisbon"}}
+	validator := openai.NewReasoningValidator()
+
+	tests := []struct {
+		model     string
+		reasoning bool
+	}{
+		{model: openai.GPT3Dot5Turbo},
+		{model: "gpt-4o-mini"},
+		{model: "o1", reasoning: true},
+		{model: "o3-mini", reasoning: true},
+		{model: "o4-mini", reasoning: true},
+		{model: "gpt-5-mini", reasoning: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			a := NewApp()
+			request := chatCompletionRequest(tt.model, messages, a.locationResponseFormat(tt.model))
+			if err := validator.Validate(request); err != nil {
+				t.Fatalf("request rejected: %v", err)
+			}
+			if tt.reasoning && (request.MaxTokens != 0 || request.MaxCompletionTokens <= maxReplyTokens) {
+				t.Errorf("max_tokens %d, max_completion_tokens %d, want only max_completion_tokens with room to reason",
+					request.MaxTokens, request.MaxCompletionTokens)
+			}
+			if !tt.reasoning && (request.MaxTokens != maxReplyTokens || request.MaxCompletionTokens != 0) {
+				t.Errorf("max_tokens %d, max_completion_tokens %d, want max_tokens %d",
+					request.MaxTokens, request.MaxCompletionTokens, maxReplyTokens)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// executableExtensions are never handed to the default application, which would run them
+var executableExtensions = map[string]bool{
+	".exe": true, ".bat": true, ".cmd": true, ".com": true, ".msi": true, ".scr": true, ".ps1": true,
+	".vbs": true, ".js": true, ".jar": true, ".app": true, ".command": true, ".sh": true, ".desktop": true,
+}
+
+// OpenInFileManager shows a file or directory in the platform file manager, selecting it where
+// the file manager supports that
+func (a *App) OpenInFileManager(path string) error {
+	if err := revealInFileManager(path); err != nil {
+		return err
+	}
+	a.logDebug("Revealed %s in file manager", path)
+	return nil
+}
+
+// OpenWithDefaultApp opens a file or directory in the application the OS associates with it,
+// e.g. QGIS for .qgz projects. Executables and scripts are refused.
+func (a *App) OpenWithDefaultApp(path string) error {
+	path, info, err := openerPath(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() && executableExtensions[strings.ToLower(filepath.Ext(path))] {
+		return fmt.Errorf("refusing to open executable file %s", path)
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		// start would need cmd.exe to parse the path; the URL handler takes it as one argument
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	if err := startOpener(cmd); err != nil {
+		return fmt.Errorf("failed to open %s: %v", path, err)
+	}
+	a.logDebug("Opened %s with default application", path)
+	return nil
+}
+
+// revealInFileManager shows path in the platform file manager, selecting it where the file
+// manager supports that
+func revealInFileManager(path string) error {
+	path, info, err := openerPath(path)
+	if err != nil {
+		return err
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", "-R", path)
+	case "windows":
+		cmd = exec.Command("explorer", "/select,"+path)
+	default:
+		// xdg-open can only open the folder, not select a file in it
+		if !info.IsDir() {
+			path = filepath.Dir(path)
+		}
+		cmd = exec.Command("xdg-open", path)
+	}
+	if err := startOpener(cmd); err != nil {
+		return fmt.Errorf("failed to open file manager: %v", err)
+	}
+	return nil
+}
+
+// openerPath makes path absolute and checks that it exists. Openers get it as a single
+// argument, never through a shell, and an absolute path can't be mistaken for an option.
+func openerPath(path string) (string, os.FileInfo, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", nil, fmt.Errorf("no path given")
+	}
+	if strings.ContainsAny(path, "\x00\r\n\"") {
+		return "", nil, fmt.Errorf("invalid path: %q", path)
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid path: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to access %s: %v", path, err)
+	}
+	return path, info, nil
+}
+
+// startOpener starts an opener without waiting for it. explorer exits with status 1 even when
+// it succeeds, so the exit status is ignored and the process only reaped.
+func startOpener(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/osm"
+)
+
+// osmGenerator is written to the generator attribute of exported OSM files
+const osmGenerator = "Terrabox"
+
+// osmReservedProperties are the feature properties that describe the OSM element rather than
+// being tags. Tags with these names are exported from their tag: prefixed properties.
+var osmReservedProperties = map[string]bool{
+	"id": true, "type": true, "version": true, "timestamp": true, "changeset": true, "user": true,
+	"uid": true, "tainted": true, "relations": true, "action": true,
+}
+
+// osmExport collects the elements rebuilt from edited features
+type osmExport struct {
+	create, modify, delete *osm.OSM
+	// vertices gives shared positions of new ways the same new node
+	vertices map[orb.Point]osm.NodeID
+	nextID   int64
+}
+
+// ExportAsOSM rebuilds OSM elements from GeoJSON features converted from Overpass, using their
+// id, type and version properties, and encodes them as OSM XML (format "osm") or as an osmChange
+// diff ("osc"). A feature's "action" property, as in JOSM files, marks it "modify" or "delete";
+// features without an OSM id are new. The diff holds only new, modified and deleted elements,
+// while OSM XML holds every element except deleted ones.
+//
+// Points become nodes and lines and single-ring polygons become ways. GeoJSON keeps no node ids
+// for way vertices, so way vertices are written as new nodes, shared where ways meet. Other
+// geometries are skipped unless they were edited, which is an error.
+func (a *App) ExportAsOSM(geojson map[string]interface{}, format string) ([]byte, error) {
+	format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+	if format != "osm" && format != "osc" {
+		return nil, fmt.Errorf("unsupported format %q; use osm or osc", format)
+	}
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+
+	export := &osmExport{create: &osm.OSM{}, modify: &osm.OSM{}, delete: &osm.OSM{}, vertices: map[orb.Point]osm.NodeID{}}
+	unchanged := &osm.OSM{}
+	for i, feature := range features {
+		if err := export.add(feature, format == "osc", unchanged); err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+	}
+
+	var doc interface{}
+	if format == "osc" {
+		change := &osm.Change{Version: "0.6", Generator: osmGenerator}
+		if len(export.create.Nodes)+len(export.create.Ways) > 0 {
+			change.Create = export.create
+		}
+		if len(export.modify.Nodes)+len(export.modify.Ways) > 0 {
+			change.Modify = export.modify
+		}
+		if len(export.delete.Nodes)+len(export.delete.Ways) > 0 {
+			change.Delete = export.delete
+		}
+		doc = change
+	} else {
+		all := &osm.OSM{Version: "0.6", Generator: osmGenerator}
+		for _, part := range []*osm.OSM{unchanged, export.modify, export.create} {
+			all.Nodes = append(all.Nodes, part.Nodes...)
+			all.Ways = append(all.Ways, part.Ways...)
+		}
+		sort.SliceStable(all.Nodes, func(i, j int) bool { return all.Nodes[i].ID < all.Nodes[j].ID })
+		sort.SliceStable(all.Ways, func(i, j int) bool { return all.Ways[i].ID < all.Ways[j].ID })
+		doc = all
+	}
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OSM %s: %v", format, err)
+	}
+	a.logInfo("Exported %d created, %d modified and %d deleted OSM elements as %s",
+		len(export.create.Nodes)+len(export.create.Ways), len(export.modify.Nodes)+len(export.modify.Ways),
+		len(export.delete.Nodes)+len(export.delete.Ways), format)
+	return append([]byte(xml.Header), data...), nil
+}
+
+// add rebuilds one feature. Unedited features only go into OSM XML, so building them is
+// skipped for diffs.
+func (e *osmExport) add(feature map[string]interface{}, diff bool, unchanged *osm.OSM) error {
+	properties, _ := feature["properties"].(map[string]interface{})
+	elementType, id, hasID := osmElementID(feature, properties)
+	action, _ := properties["action"].(string)
+	switch {
+	case !hasID:
+		action = "create"
+	case action != "modify" && action != "delete":
+		action = ""
+	}
+	if action == "" && diff {
+		return nil
+	}
+
+	geometry, err := orbGeometry(feature["geometry"])
+	if err != nil {
+		return err
+	}
+	version, _ := osmNumber(properties["version"])
+	if hasID && action != "" && version < 1 {
+		return fmt.Errorf("%s/%d has no version; query Overpass with out meta to edit it", elementType, id)
+	}
+	tags := osmTagsFromProperties(properties)
+
+	var object osm.Object
+	switch g := geometry.(type) {
+	case orb.Point:
+		if hasID && elementType != "node" {
+			return e.skip(action, elementType, id, "a point")
+		}
+		node := &osm.Node{ID: osm.NodeID(id), Version: int(version), Lat: g[1], Lon: g[0], Tags: tags, Visible: true}
+		if !hasID {
+			node.ID = osm.NodeID(e.newID())
+		}
+		object = node
+	case orb.LineString, orb.Polygon:
+		if hasID && elementType != "way" {
+			return e.skip(action, elementType, id, "a line or polygon")
+		}
+		line, ok := g.(orb.LineString)
+		if polygon, isPolygon := g.(orb.Polygon); isPolygon {
+			if len(polygon) != 1 {
+				return e.skip(action, elementType, id, "a polygon with holes")
+			}
+			line, ok = orb.LineString(polygon[0]), true
+		}
+		if !ok || len(line) < 2 {
+			return fmt.Errorf("a way needs at least two positions")
+		}
+		way := &osm.Way{ID: osm.WayID(id), Version: int(version), Tags: tags, Visible: true}
+		if !hasID {
+			way.ID = osm.WayID(e.newID())
+		}
+		if action != "delete" {
+			target := unchanged
+			if action != "" {
+				target = e.create
+			}
+			for _, position := range line {
+				way.Nodes = append(way.Nodes, osm.WayNode{ID: e.vertex(position, target)})
+			}
+		}
+		object = way
+	default:
+		return e.skip(action, elementType, id, fmt.Sprintf("a %T", geometry))
+	}
+
+	target := unchanged
+	switch action {
+	case "create":
+		target = e.create
+	case "modify":
+		target = e.modify
+	case "delete":
+		target = e.delete
+	}
+	// osm.OSM.Append can't take the negative ids of new elements
+	switch object := object.(type) {
+	case *osm.Node:
+		target.Nodes = append(target.Nodes, object)
+	case *osm.Way:
+		target.Ways = append(target.Ways, object)
+	}
+	return nil
+}
+
+// skip ignores an unedited feature that can't be rebuilt as a node or way
+func (e *osmExport) skip(action string, elementType string, id int64, what string) error {
+	if action == "" {
+		return nil
+	}
+	return fmt.Errorf("%s/%d is %s; only edits to nodes and simple ways can be exported", elementType, id, what)
+}
+
+// vertex returns the node at a way vertex, adding a new node the first time a position is used
+func (e *osmExport) vertex(position orb.Point, target *osm.OSM) osm.NodeID {
+	if id, ok := e.vertices[position]; ok {
+		return id
+	}
+	id := osm.NodeID(e.newID())
+	e.vertices[position] = id
+	target.Nodes = append(target.Nodes, &osm.Node{ID: id, Lat: position[1], Lon: position[0], Visible: true})
+	return id
+}
+
+// newID returns the next placeholder id; OSM gives new elements negative ids until uploaded
+func (e *osmExport) newID() int64 {
+	e.nextID--
+	return e.nextID
+}
+
+// osmElementID reads the element type and id from the properties, or from a feature id such as
+// "way/123"
+func osmElementID(feature map[string]interface{}, properties map[string]interface{}) (string, int64, bool) {
+	elementType, _ := properties["type"].(string)
+	if id, ok := osmNumber(properties["id"]); ok && id > 0 && elementType != "" {
+		return elementType, int64(id), true
+	}
+	if featureID, ok := feature["id"].(string); ok {
+		if kind, number, found := strings.Cut(featureID, "/"); found {
+			if id, err := strconv.ParseInt(number, 10, 64); err == nil && id > 0 {
+				return kind, id, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// osmTagsFromProperties turns the properties that aren't element metadata back into tags,
+// restoring tag: prefixed names
+func osmTagsFromProperties(properties map[string]interface{}) osm.Tags {
+	tags := osm.Tags{}
+	for key, value := range properties {
+		if osmReservedProperties[key] || value == nil {
+			continue
+		}
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			continue
+		}
+		tags = append(tags, osm.Tag{Key: strings.TrimPrefix(key, "tag:"), Value: fmt.Sprint(value)})
+	}
+	tags.SortByKeyValue()
+	return tags
+}
+
+// osmNumber reads a numeric property, which arrives from the frontend as a JSON number but
+// may have been typed into an attribute table as a string
+func osmNumber(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		v, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return v, err == nil
+	}
+	return 0, false
+}
+
+// OSM edit issue codes reported by ValidateOSMEdits
+const (
+	osmIssueTooFewNodes       = "too_few_nodes"
+	osmIssueUnclosedArea      = "unclosed_area"
+	osmIssueZeroLengthSegment = "zero_length_segment"
+	osmIssueDuplicateNode     = "duplicate_node"
+)
+
+// osmCoordinateFactor scales degrees to the 7 decimal places OSM stores, so positions that
+// OSM would merge count as the same node
+const osmCoordinateFactor = 1e7
+
+// OSMValidationIssue is one way that OSM would reject or render wrongly. Part is the index
+// within a Multi* geometry and Ring the ring index within a polygon (0 = outer).
+type OSMValidationIssue struct {
+	FeatureIndex int    `json:"feature_index"`
+	FeatureID    string `json:"feature_id,omitempty"`
+	GeometryType string `json:"geometry_type"`
+	Part         int    `json:"part"`
+	Ring         int    `json:"ring"`
+	Issue        string `json:"issue"`
+	Message      string `json:"message"`
+}
+
+// ValidateOSMEdits checks lines and polygons against what OSM accepts as ways: at least two
+// nodes, closed rings for areas, no zero-length segments and no node visited twice (besides a
+// closed way's first and last). Points are always valid nodes. An empty list means valid.
+func (a *App) ValidateOSMEdits(geojson map[string]interface{}) ([]OSMValidationIssue, error) {
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %v", err)
+	}
+
+	issues := []OSMValidationIssue{}
+	for i, feature := range features {
+		if feature["geometry"] == nil {
+			continue
+		}
+		geometry, err := orbGeometry(feature["geometry"])
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+		properties, _ := feature["properties"].(map[string]interface{})
+		featureID := ""
+		if elementType, id, ok := osmElementID(feature, properties); ok {
+			featureID = fmt.Sprintf("%s/%d", elementType, id)
+		}
+
+		add := func(part, ring int, issue, message string) {
+			issues = append(issues, OSMValidationIssue{FeatureIndex: i, FeatureID: featureID,
+				GeometryType: geometry.GeoJSONType(), Part: part, Ring: ring, Issue: issue, Message: message})
+		}
+		switch g := geometry.(type) {
+		case orb.LineString:
+			checkOSMWay(g, false, 0, 0, add)
+		case orb.MultiLineString:
+			for part, line := range g {
+				checkOSMWay(line, false, part, 0, add)
+			}
+		case orb.Polygon:
+			for ring, r := range g {
+				checkOSMWay(orb.LineString(r), true, 0, ring, add)
+			}
+		case orb.MultiPolygon:
+			for part, polygon := range g {
+				for ring, r := range polygon {
+					checkOSMWay(orb.LineString(r), true, part, ring, add)
+				}
+			}
+		}
+	}
+	return issues, nil
+}
+
+// checkOSMWay reports the problems of one way; area ways must be closed
+func checkOSMWay(line orb.LineString, area bool, part, ring int, add func(part, ring int, issue, message string)) {
+	nodes := make([][2]int64, len(line))
+	for i, p := range line {
+		nodes[i] = [2]int64{int64(math.Round(p[0] * osmCoordinateFactor)), int64(math.Round(p[1] * osmCoordinateFactor))}
+	}
+
+	distinct := map[[2]int64]int{}
+	for i, node := range nodes {
+		if i > 0 && node == nodes[i-1] {
+			add(part, ring, osmIssueZeroLengthSegment, fmt.Sprintf("positions %d and %d are the same node", i-1, i))
+			continue
+		}
+		if first, seen := distinct[node]; seen && !(i == len(nodes)-1 && first == 0) {
+			add(part, ring, osmIssueDuplicateNode, fmt.Sprintf("position %d repeats the node at position %d", i, first))
+			continue
+		}
+		if _, seen := distinct[node]; !seen {
+			distinct[node] = i
+		}
+	}
+
+	closed := len(nodes) > 1 && nodes[0] == nodes[len(nodes)-1]
+	switch {
+	case area && !closed:
+		add(part, ring, osmIssueUnclosedArea, "an area's first and last nodes must be the same")
+	case area && len(distinct) < 3:
+		add(part, ring, osmIssueTooFewNodes, fmt.Sprintf("an area needs at least 3 distinct nodes, found %d", len(distinct)))
+	case len(distinct) < 2:
+		add(part, ring, osmIssueTooFewNodes, fmt.Sprintf("a way needs at least 2 distinct nodes, found %d", len(distinct)))
+	}
+}
+
+// osmValidationFailure turns OSM validation issues into a single error quoting the first few
+func osmValidationFailure(issues []OSMValidationIssue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+	var messages []string
+	for i, issue := range issues {
+		if i == maxReportedValidationErrors {
+			messages = append(messages, fmt.Sprintf("and %d more", len(issues)-i))
+			break
+		}
+		messages = append(messages, fmt.Sprintf("feature %d: %s", issue.FeatureIndex, issue.Message))
+	}
+	return fmt.Errorf("edits break OSM rules: %s", strings.Join(messages, "; "))
+}
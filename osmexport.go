@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// osmExportTileDegrees is the side of each ExportOSMArea tile, about 5 km at mid latitudes
+const osmExportTileDegrees = 0.05
+
+// maxOSMExportTiles caps ExportOSMArea at roughly a 1.6 x 1.6 degree region
+const maxOSMExportTiles = 1024
+
+// osmExportProgressEvent is emitted after every ExportOSMArea tile and once at the end
+const osmExportProgressEvent = "osm:export-progress"
+
+// OSMExportProgress is the payload of osm:export-progress events. Skipped counts tiles finished
+// by an earlier, interrupted run.
+type OSMExportProgress struct {
+	DstPath   string `json:"dst_path"`
+	Tiles     int    `json:"tiles"`
+	Completed int    `json:"completed"`
+	Skipped   int    `json:"skipped"`
+	Failed    int    `json:"failed"`
+	Features  int    `json:"features,omitempty"`
+	Done      bool   `json:"done"`
+	Error     string `json:"error,omitempty"`
+}
+
+// osmExportState identifies the export a checkpoint directory belongs to
+type osmExportState struct {
+	BBox       []float64 `json:"bbox"`
+	Categories []string  `json:"categories"`
+	Rows       int       `json:"rows"`
+	Cols       int       `json:"cols"`
+}
+
+// ExportOSMArea downloads the given categories for bbox ([west, south, east, north]) into a
+// GeoJSON file, tile by tile. Each finished tile is saved in a <dstPath>.parts directory, so a
+// re-run after an interruption or failed tiles only fetches the missing ones. The tiles are then
+// streamed into dstPath with features crossing tile edges written once.
+func (a *App) ExportOSMArea(bbox []float64, categories []string, dstPath string) error {
+	return a.exportOSMArea(context.Background(), bbox, categories, dstPath, func(progress OSMExportProgress) {
+		a.emitEvent(osmExportProgressEvent, progress)
+	})
+}
+
+// exportOSMArea is ExportOSMArea reporting progress through report. Once ctx is cancelled no
+// more tiles are started and the export stops with its finished tiles kept for a re-run.
+func (a *App) exportOSMArea(ctx context.Context, bbox []float64, categories []string, dstPath string, report func(OSMExportProgress)) error {
+	if len(bbox) != 4 {
+		return fmt.Errorf("bbox must be [west, south, east, north]")
+	}
+	west, south, east, north := bbox[0], bbox[1], bbox[2], bbox[3]
+	if west >= east || south >= north {
+		return fmt.Errorf("bbox must have west < east and south < north")
+	}
+	selectors := a.overpassCategorySelectors(categories)
+	if len(selectors) == 0 {
+		return fmt.Errorf("no known categories in %v", categories)
+	}
+	dstPath, err := filepath.Abs(dstPath)
+	if err != nil {
+		return fmt.Errorf("invalid export path: %v", err)
+	}
+
+	state := osmExportState{
+		BBox:       bbox,
+		Categories: categories,
+		Rows:       int(math.Ceil((north - south) / osmExportTileDegrees)),
+		Cols:       int(math.Ceil((east - west) / osmExportTileDegrees)),
+	}
+	tiles := state.Rows * state.Cols
+	if tiles > maxOSMExportTiles {
+		return fmt.Errorf("area needs %d tiles, more than the %d allowed; export a smaller area", tiles, maxOSMExportTiles)
+	}
+
+	partsDir := dstPath + ".parts"
+	if err := prepareOSMExportParts(partsDir, state); err != nil {
+		return err
+	}
+
+	query := overpassUnionQuery(selectors, "{{bbox}}")
+	lonStep := (east - west) / float64(state.Cols)
+	latStep := (north - south) / float64(state.Rows)
+	progress := OSMExportProgress{DstPath: dstPath, Tiles: tiles}
+	var progressMu sync.Mutex
+	var lastError string
+
+	a.logInfo("Exporting OSM area to %s over %d tiles", dstPath, tiles)
+	start := time.Now()
+
+	slots := make(chan struct{}, overpassTileConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < tiles; i++ {
+		tilePath := filepath.Join(partsDir, fmt.Sprintf("tile_%04d.json", i))
+		if _, err := os.Stat(tilePath); err == nil {
+			progress.Completed++
+			progress.Skipped++
+			continue
+		}
+		row, col := i/state.Cols, i%state.Cols
+		tileBBox := fmt.Sprintf("%.6f,%.6f,%.6f,%.6f",
+			south+float64(row)*latStep, west+float64(col)*lonStep,
+			south+float64(row+1)*latStep, west+float64(col+1)*lonStep)
+
+		wg.Add(1)
+		go func(i int, tileQuery string, tilePath string) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-slots }()
+			if ctx.Err() != nil {
+				return
+			}
+
+			err := a.fetchOSMExportTile(tileQuery, i, tilePath)
+
+			progressMu.Lock()
+			defer progressMu.Unlock()
+			if err != nil {
+				progress.Failed++
+				lastError = err.Error()
+			} else {
+				progress.Completed++
+			}
+			report(progress)
+		}(i, substituteOverpassBBox(query, tileBBox), tilePath)
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		err := fmt.Errorf("export cancelled after %d of %d tiles, run it again to resume", progress.Completed, tiles)
+		progress.Done, progress.Error = true, err.Error()
+		report(progress)
+		a.logInfo("OSM export to %s cancelled", dstPath)
+		return err
+	}
+
+	if progress.Failed > 0 {
+		err := fmt.Errorf("%d of %d tiles failed, run the export again to retry them: %s", progress.Failed, tiles, lastError)
+		progress.Done, progress.Error = true, err.Error()
+		report(progress)
+		a.logWarn("OSM export to %s incomplete: %v", dstPath, err)
+		return err
+	}
+
+	count, err := mergeOSMExportTiles(partsDir, tiles, dstPath)
+	progress.Done, progress.Features = true, count
+	if err != nil {
+		progress.Error = err.Error()
+		report(progress)
+		return err
+	}
+	os.RemoveAll(partsDir)
+	report(progress)
+	a.logInfo("Exported %d OSM features to %s in %s (%d tiles resumed)", count, dstPath, time.Since(start).Round(time.Millisecond), progress.Skipped)
+	return nil
+}
+
+// prepareOSMExportParts creates the checkpoint directory, discarding one left by an export of a
+// different area or category list
+func prepareOSMExportParts(partsDir string, state osmExportState) error {
+	statePath := filepath.Join(partsDir, "export.json")
+	if data, err := os.ReadFile(statePath); err == nil {
+		var previous osmExportState
+		if json.Unmarshal(data, &previous) == nil && reflect.DeepEqual(previous, state) {
+			return nil
+		}
+		if err := os.RemoveAll(partsDir); err != nil {
+			return fmt.Errorf("failed to clear old export checkpoint: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(partsDir, 0755); err != nil {
+		return fmt.Errorf("failed to create export checkpoint: %v", err)
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to encode export checkpoint: %v", err)
+	}
+	if err := os.WriteFile(statePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write export checkpoint: %v", err)
+	}
+	return nil
+}
+
+// fetchOSMExportTile queries one tile and saves its features, renaming into place only once the
+// file is complete so a partly written tile never counts as done
+func (a *App) fetchOSMExportTile(query string, tile int, tilePath string) error {
+	resp, err := a.queryOverpassTile(query, tile)
+	if err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s", resp.Error)
+	}
+	features, _ := resp.Data["features"].([]interface{})
+	if features == nil {
+		features = []interface{}{}
+	}
+
+	data, err := json.Marshal(features)
+	if err != nil {
+		return fmt.Errorf("failed to encode tile %d: %v", tile, err)
+	}
+	tmpPath := tilePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to save tile %d: %v", tile, err)
+	}
+	return os.Rename(tmpPath, tilePath)
+}
+
+// mergeOSMExportTiles streams the saved tiles into dstPath, one tile in memory at a time, and
+// returns the number of features written
+func mergeOSMExportTiles(partsDir string, tiles int, dstPath string) (int, error) {
+	tmpPath := dstPath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %v", dstPath, err)
+	}
+	defer os.Remove(tmpPath)
+
+	buffered := bufio.NewWriter(file)
+	writer, err := newGeoJSONWriter(buffered, nil, false)
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < tiles; i++ {
+		data, err := os.ReadFile(filepath.Join(partsDir, fmt.Sprintf("tile_%04d.json", i)))
+		if err != nil {
+			file.Close()
+			return 0, fmt.Errorf("failed to read tile %d: %v", i, err)
+		}
+		var features []map[string]interface{}
+		if err := json.Unmarshal(data, &features); err != nil {
+			file.Close()
+			return 0, fmt.Errorf("failed to read tile %d: %v", i, err)
+		}
+		for _, feature := range features {
+			if key, ok := osmFeatureKey(feature); ok {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+			if _, err := writer.WriteFeature(feature); err != nil {
+				file.Close()
+				return 0, err
+			}
+		}
+	}
+
+	err = writer.Close()
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to write %s: %v", dstPath, err)
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %v", dstPath, err)
+	}
+	return writer.count, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// outputDirSettingKey stores the directory SaveEditedOSMData writes to when the caller gives none
+const outputDirSettingKey = "osm_output_dir"
+
+// defaultOutputDir is where edited OSM data goes until another directory is configured
+func defaultOutputDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %v", err)
+	}
+	return filepath.Join(homeDir, "TerraboxOSM"), nil
+}
+
+// outputDir returns the configured output directory, or the default one
+func (a *App) outputDir() (string, error) {
+	if value, found, err := a.getSetting(outputDirSettingKey); err == nil && found && value != "" {
+		return value, nil
+	}
+	return defaultOutputDir()
+}
+
+// GetOutputDirectory returns the directory edited OSM data is saved to by default
+func (a *App) GetOutputDirectory() (string, error) {
+	return a.outputDir()
+}
+
+// SetOutputDirectory changes the directory edited OSM data is saved to by default, creating it
+// if needed. An empty directory restores ~/TerraboxOSM.
+func (a *App) SetOutputDirectory(dir string) error {
+	if strings.TrimSpace(dir) == "" {
+		return a.setSetting(outputDirSettingKey, "")
+	}
+	dir, err := prepareOutputDir(dir)
+	if err != nil {
+		return err
+	}
+	return a.setSetting(outputDirSettingKey, dir)
+}
+
+// prepareOutputDir resolves a leading ~ in dir, requires the result to be absolute, creates it
+// and checks that files can be written there. It returns the cleaned directory.
+func prepareOutputDir(dir string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "~" || strings.HasPrefix(dir, "~/") || strings.HasPrefix(dir, `~\`) {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %v", err)
+		}
+		dir = filepath.Join(homeDir, dir[1:])
+	}
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("output directory must be an absolute path: %s", dir)
+	}
+	dir = filepath.Clean(dir)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %v", err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to access output directory: %v", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("output directory is not a directory: %s", dir)
+	}
+	probe, err := os.CreateTemp(dir, ".terrabox-write-*")
+	if err != nil {
+		return "", fmt.Errorf("output directory is not writable: %v", err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return dir, nil
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxOverpassTilesPerSide caps QueryOverpassTiled at a tiles x tiles grid
+const maxOverpassTilesPerSide = 8
+
+// overpassTileConcurrency matches the two query slots the public Overpass instance gives each client
+const overpassTileConcurrency = 2
+
+// overpassTileAttempts is how often a tile is tried when Overpass is rate limiting or overloaded
+const overpassTileAttempts = 3
+
+// overpassRetryDelay is the wait before the first retry; later retries wait longer
+const overpassRetryDelay = 5 * time.Second
+
+// maxOverpassAroundRadius caps QueryOverpassAround so a single request can't scan a whole region
+const maxOverpassAroundRadius = 50000
+
+var (
+	// overpassBBoxFilter matches a literal (south,west,north,east) filter
+	overpassBBoxFilter = regexp.MustCompile(`\(\s*-?\d+(?:\.\d+)?\s*,\s*-?\d+(?:\.\d+)?\s*,\s*-?\d+(?:\.\d+)?\s*,\s*-?\d+(?:\.\d+)?\s*\)`)
+	// overpassGlobalBBox matches the [bbox:south,west,north,east] setting
+	overpassGlobalBBox = regexp.MustCompile(`\[bbox:[^\]]*\]`)
+)
+
+// QueryOverpassTiled runs query over a tiles x tiles grid covering bbox ([west, south, east, north])
+// and merges the results, so regions too large for a single query can still be fetched. The
+// query's {{bbox}} placeholders, literal bbox filters and [bbox:...] setting are replaced with
+// each tile's bounds. Features crossing tile edges are returned once. The query is added to the
+// query history.
+func (a *App) QueryOverpassTiled(query string, bbox []float64, tiles int) (*OverpassResponse, error) {
+	start := time.Now()
+	resp, err := a.queryOverpassTiled(query, bbox, tiles)
+	entry := a.queryHistoryEntry(query)
+	entry.BBox, entry.Tiles = bbox, tiles
+	a.recordQuery(entry, start, resp, err)
+	return resp, err
+}
+
+// queryOverpassTiled runs a tiled query without adding it to the query history
+func (a *App) queryOverpassTiled(query string, bbox []float64, tiles int) (*OverpassResponse, error) {
+	if len(bbox) != 4 {
+		return nil, fmt.Errorf("bbox must be [west, south, east, north]")
+	}
+	west, south, east, north := bbox[0], bbox[1], bbox[2], bbox[3]
+	if west >= east || south >= north {
+		return nil, fmt.Errorf("bbox must have west < east and south < north")
+	}
+	if tiles < 1 || tiles > maxOverpassTilesPerSide {
+		return nil, fmt.Errorf("tiles must be between 1 and %d, got %d", maxOverpassTilesPerSide, tiles)
+	}
+	if !strings.Contains(query, "{{bbox}}") && !overpassBBoxFilter.MatchString(query) && !overpassGlobalBBox.MatchString(query) {
+		return nil, fmt.Errorf("query has no bounding box to tile; use {{bbox}} in its filters")
+	}
+
+	type tileResult struct {
+		resp *OverpassResponse
+		err  error
+	}
+	lonStep := (east - west) / float64(tiles)
+	latStep := (north - south) / float64(tiles)
+	results := make([]tileResult, tiles*tiles)
+
+	a.logInfo("Running Overpass query over %d tiles", len(results))
+	start := time.Now()
+
+	slots := make(chan struct{}, overpassTileConcurrency)
+	var wg sync.WaitGroup
+	for i := range results {
+		row, col := i/tiles, i%tiles
+		tileBBox := fmt.Sprintf("%.6f,%.6f,%.6f,%.6f",
+			south+float64(row)*latStep, west+float64(col)*lonStep,
+			south+float64(row+1)*latStep, west+float64(col+1)*lonStep)
+
+		wg.Add(1)
+		go func(i int, tileQuery string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			resp, err := a.queryOverpassTile(tileQuery, i)
+			results[i] = tileResult{resp, err}
+		}(i, substituteOverpassBBox(query, tileBBox))
+	}
+	wg.Wait()
+
+	features := []interface{}{}
+	failed := []int{}
+	var lastError string
+	for i, result := range results {
+		if result.err != nil || !result.resp.Success {
+			failed = append(failed, i)
+			if result.err != nil {
+				lastError = result.err.Error()
+			} else {
+				lastError = result.resp.Error
+			}
+			continue
+		}
+		tileFeatures, _ := result.resp.Data["features"].([]interface{})
+		features = append(features, tileFeatures...)
+	}
+	merged := len(features)
+	features = dedupeOSMFeatures(features)
+
+	if len(failed) == len(results) {
+		return &OverpassResponse{
+			Success: false,
+			Error:   fmt.Sprintf("All %d tiles failed: %s", len(results), lastError),
+		}, nil
+	}
+	if len(failed) > 0 {
+		a.logWarn("%d of %d Overpass tiles failed, results are incomplete: %s", len(failed), len(results), lastError)
+	}
+	a.logInfo("Tiled Overpass query returned %d features in %s", len(features), time.Since(start).Round(time.Millisecond))
+
+	metadata := map[string]interface{}{
+		"query_time":         time.Now().Format(time.RFC3339),
+		"feature_count":      len(features),
+		"duplicates_removed": merged - len(features),
+		"query":              query,
+		"api_endpoint":       overpassEndpoint,
+		"format":             "json",
+		"tiles":              len(results),
+		"failed_tiles":       failed,
+		"complete":           len(failed) == 0,
+	}
+	markEmptyOverpassResult(metadata, len(features), bbox)
+	return &OverpassResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"type":     "FeatureCollection",
+			"features": features,
+		},
+		Metadata: metadata,
+	}, nil
+}
+
+// QueryOverpassAround finds the given categories (as used by GenerateOverpassQuery, e.g. "cafe"
+// or "park") within radiusMeters of a point
+func (a *App) QueryOverpassAround(lat, lon, radiusMeters float64, categories []string) (*OverpassResponse, error) {
+	if lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		return nil, fmt.Errorf("invalid position %.6f, %.6f", lat, lon)
+	}
+	if !(radiusMeters > 0) || radiusMeters > maxOverpassAroundRadius {
+		return nil, fmt.Errorf("radius must be between 0 and %d meters, got %g", maxOverpassAroundRadius, radiusMeters)
+	}
+
+	selectors := a.overpassCategorySelectors(categories)
+	if len(selectors) == 0 {
+		return nil, fmt.Errorf("no known categories in %v", categories)
+	}
+
+	query := overpassUnionQuery(selectors, fmt.Sprintf("around:%.0f,%.6f,%.6f", radiusMeters, lat, lon))
+	start := time.Now()
+	resp, err := a.runOverpassQuery(context.Background(), query)
+	a.recordQuery(QueryHistoryEntry{Query: query, Source: querySourceCategory, Description: strings.Join(categories, ", ")},
+		start, resp, err)
+	if err != nil || !resp.Success {
+		return resp, err
+	}
+	resp.Metadata["center"] = []float64{lon, lat}
+	resp.Metadata["radius_meters"] = radiusMeters
+	if empty, _ := resp.Metadata["empty"].(bool); empty {
+		// The circle's bounds, so an empty result can be widened like a bbox query
+		dLat := radiusMeters / metersPerDegreeLat
+		dLon := dLat / math.Max(math.Cos(lat*math.Pi/180), 0.01)
+		resp.Metadata["bbox"] = []float64{lon - dLon, lat - dLat, lon + dLon, lat + dLat}
+	}
+	return resp, nil
+}
+
+// queryOverpassTile runs one tile's query, backing off and retrying while Overpass answers
+// 429 Too Many Requests or 504 Gateway Timeout
+func (a *App) queryOverpassTile(query string, tile int) (*OverpassResponse, error) {
+	var resp *OverpassResponse
+	var err error
+	for attempt := 1; attempt <= overpassTileAttempts; attempt++ {
+		resp, err = a.runOverpassQuery(context.Background(), query)
+		if err != nil || resp.Success {
+			return resp, err
+		}
+		status := overpassHTTPStatus(resp)
+		if status != 429 && status != 504 {
+			return resp, nil
+		}
+		if attempt < overpassTileAttempts {
+			delay := overpassRetryDelay * time.Duration(attempt)
+			a.logWarn("Overpass tile %d got HTTP %d, retrying in %s", tile, status, delay)
+			time.Sleep(delay)
+		}
+	}
+	return resp, nil
+}
+
+// overpassHTTPStatus recovers the status code from a failed runOverpassQuery response, or 0
+func overpassHTTPStatus(resp *OverpassResponse) int {
+	var status int
+	if _, err := fmt.Sscanf(resp.Error, "HTTP error %d", &status); err != nil {
+		return 0
+	}
+	return status
+}
+
+// substituteOverpassBBox points every bbox in query at bbox ("south,west,north,east")
+func substituteOverpassBBox(query, bbox string) string {
+	query = strings.ReplaceAll(query, "{{bbox}}", bbox)
+	query = overpassBBoxFilter.ReplaceAllLiteralString(query, "("+bbox+")")
+	return overpassGlobalBBox.ReplaceAllLiteralString(query, "[bbox:"+bbox+"]")
+}
+
+// dedupeOSMFeatures drops repeats of the same OSM element, keeping the first occurrence. Merged
+// Overpass results repeat ways and relations that cross tile or query boundaries.
+func dedupeOSMFeatures(features []interface{}) []interface{} {
+	seen := make(map[string]bool, len(features))
+	unique := make([]interface{}, 0, len(features))
+	for _, f := range features {
+		if feature, ok := f.(map[string]interface{}); ok {
+			if key, ok := osmFeatureKey(feature); ok {
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+			}
+		}
+		unique = append(unique, f)
+	}
+	return unique
+}
+
+// osmFeatureKey identifies an Overpass feature by its OSM type and id
+func osmFeatureKey(feature map[string]interface{}) (string, bool) {
+	if props, ok := feature["properties"].(map[string]interface{}); ok {
+		if id, ok := props["id"]; ok && id != nil {
+			return fmt.Sprintf("%v/%v", props["type"], id), true
+		}
+	}
+	// Features converted from OSM XML carry ids like "way/123"
+	if id, ok := feature["id"].(string); ok && id != "" {
+		return id, true
+	}
+	return "", false
+}
+
+// maxOverpassTimeout is the longest [timeout:...] accepted without a warning, in seconds
+const maxOverpassTimeout = 180
+
+var (
+	overpassTimeoutSetting = regexp.MustCompile(`\[timeout:(\d+)\]`)
+	// overpassAreaFilter matches the filters that keep a query from scanning the whole planet
+	overpassAreaFilter   = regexp.MustCompile(`\(\s*around\s*:|\(\s*area|\(\s*poly\s*:|\[bbox:|\(\s*-?\d+(?:\.\d+)?\s*,`)
+	overpassOutStatement = regexp.MustCompile(`(?m)(^|[;\s])out\b`)
+)
+
+// OverpassValidation lists problems found in an Overpass QL query. Errors make the query fail or
+// misbehave; warnings are worth a look but the query will run.
+type OverpassValidation struct {
+	Valid    bool     `json:"valid"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+// ValidateOverpassQuery checks a query for syntax problems and unsafe patterns before it is sent
+func (a *App) ValidateOverpassQuery(query string) (*OverpassValidation, error) {
+	result := &OverpassValidation{Errors: []string{}, Warnings: []string{}}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		result.Errors = append(result.Errors, "query is empty")
+		return result, nil
+	}
+
+	// Brackets must balance outside string literals
+	var stack []rune
+	closers := map[rune]rune{')': '(', ']': '[', '}': '{'}
+	var quote rune
+	escaped := false
+	for _, c := range query {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0 && c == '\\':
+			escaped = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[' || c == '{':
+			stack = append(stack, c)
+		case c == ')' || c == ']' || c == '}':
+			if len(stack) == 0 || stack[len(stack)-1] != closers[c] {
+				result.Errors = append(result.Errors, fmt.Sprintf("unbalanced %q", c))
+				stack = nil
+				continue
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if quote != 0 {
+		result.Errors = append(result.Errors, "unterminated string literal")
+	}
+	for _, open := range stack {
+		result.Errors = append(result.Errors, fmt.Sprintf("unclosed %q", open))
+	}
+
+	if strings.Contains(query, "{{") {
+		result.Errors = append(result.Errors, "Overpass Turbo shortcuts such as {{bbox}} must be replaced before the query is sent")
+	}
+	if !strings.HasSuffix(query, ";") {
+		result.Errors = append(result.Errors, "the last statement must end with ';'")
+	}
+	if !overpassOutStatement.MatchString(query) {
+		result.Errors = append(result.Errors, "query has no out statement, so it returns nothing")
+	}
+	if !overpassAreaFilter.MatchString(query) {
+		result.Errors = append(result.Errors, "query has no bbox, around, area or poly filter and would search the whole planet")
+	}
+
+	if !strings.Contains(query, "[out:json]") {
+		result.Warnings = append(result.Warnings, "add [out:json] for faster parsing")
+	}
+	if match := overpassTimeoutSetting.FindStringSubmatch(query); match != nil {
+		if seconds, err := strconv.Atoi(match[1]); err == nil && seconds > maxOverpassTimeout {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("timeout of %ds is likely to be rejected by the public server (max %ds)", seconds, maxOverpassTimeout))
+		}
+	}
+	if !strings.Contains(query, "out geom") && !strings.Contains(query, ">") && !strings.Contains(query, "out center") {
+		result.Warnings = append(result.Warnings, "ways and relations need 'out geom' (or recursion with '>') to have geometry")
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result, nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// customCategoriesSettingKey stores user-defined categories as JSON in the settings table
+const customCategoriesSettingKey = "overpass_custom_categories"
+
+//go:embed overpass_categories.json
+var builtinCategoriesJSON []byte
+
+// TagFilter is one tag condition: key=value, key~regex, or just the key when Value is empty
+type TagFilter struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	Regex bool   `json:"regex,omitempty"`
+}
+
+// TagQuery selects the given OSM element types that match every tag filter
+type TagQuery struct {
+	Elements []string    `json:"elements"`
+	Tags     []TagFilter `json:"tags"`
+}
+
+// OverpassCategory is a named set of tag queries, e.g. "cafe" or a user's "playgrounds"
+type OverpassCategory struct {
+	Name   string     `json:"name"`
+	Tags   []TagQuery `json:"tags"`
+	Custom bool       `json:"custom"`
+}
+
+// islandCategories all map to the "island" category, which takes priority over any others
+var islandCategories = map[string]bool{"island": true, "islands": true, "archipelago": true, "isle": true}
+
+// fallbackKeywords map words in a description to categories with different names
+var fallbackKeywords = map[string][]string{
+	"food":       {"restaurant", "cafe", "fast_food"},
+	"education":  {"school", "university"},
+	"recreation": {"park"},
+}
+
+var categoryNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+var overpassElements = map[string]bool{"node": true, "way": true, "relation": true, "nwr": true}
+
+// builtinCategories parses the embedded category table
+func builtinCategories() map[string][]TagQuery {
+	var categories map[string][]TagQuery
+	if err := json.Unmarshal(builtinCategoriesJSON, &categories); err != nil {
+		panic(fmt.Sprintf("invalid overpass_categories.json: %v", err))
+	}
+	return categories
+}
+
+// customCategories reads the user's categories from settings
+func (a *App) customCategories() (map[string][]TagQuery, error) {
+	categories := map[string][]TagQuery{}
+	value, found, err := a.getSetting(customCategoriesSettingKey)
+	if err != nil || !found {
+		return categories, err
+	}
+	if err := json.Unmarshal([]byte(value), &categories); err != nil {
+		return categories, fmt.Errorf("failed to decode custom categories: %v", err)
+	}
+	return categories, nil
+}
+
+// overpassCategories is the built-in table with the user's categories added; a custom category
+// replaces a built-in one of the same name
+func (a *App) overpassCategories() map[string][]TagQuery {
+	categories := builtinCategories()
+	custom, err := a.customCategories()
+	if err != nil {
+		a.logWarn("Ignoring custom Overpass categories: %v", err)
+	}
+	for name, tags := range custom {
+		categories[name] = tags
+	}
+	return categories
+}
+
+// ListCategories returns every category the query builders understand, sorted by name
+func (a *App) ListCategories() ([]OverpassCategory, error) {
+	custom, err := a.customCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	list := []OverpassCategory{}
+	for name, tags := range a.overpassCategories() {
+		_, isCustom := custom[name]
+		list = append(list, OverpassCategory{Name: name, Tags: tags, Custom: isCustom})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// AddCustomCategory adds or replaces a user-defined category, e.g. "playgrounds" with
+// leisure=playground. Elements default to node, way and relation.
+func (a *App) AddCustomCategory(name string, tags []TagQuery) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !categoryNamePattern.MatchString(name) {
+		return fmt.Errorf("category names may only contain letters, digits and underscores")
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("category %s needs at least one tag query", name)
+	}
+	for i := range tags {
+		if len(tags[i].Elements) == 0 {
+			tags[i].Elements = []string{"node", "way", "relation"}
+		}
+		for _, element := range tags[i].Elements {
+			if !overpassElements[element] {
+				return fmt.Errorf("unknown element type %q (expected node, way, relation or nwr)", element)
+			}
+		}
+		if len(tags[i].Tags) == 0 {
+			return fmt.Errorf("category %s has a tag query without tags", name)
+		}
+		for _, tag := range tags[i].Tags {
+			if strings.TrimSpace(tag.Key) == "" {
+				return fmt.Errorf("category %s has a tag without a key", name)
+			}
+			if tag.Regex {
+				if _, err := regexp.Compile(tag.Value); err != nil {
+					return fmt.Errorf("invalid regular expression %q: %v", tag.Value, err)
+				}
+			}
+		}
+	}
+
+	custom, err := a.customCategories()
+	if err != nil {
+		return err
+	}
+	custom[name] = tags
+	data, err := json.Marshal(custom)
+	if err != nil {
+		return fmt.Errorf("failed to encode custom categories: %v", err)
+	}
+	if err := a.setSetting(customCategoriesSettingKey, string(data)); err != nil {
+		return err
+	}
+	a.logInfo("Saved custom Overpass category %s", name)
+	return nil
+}
+
+// overpassCategorySelectors maps category names to Overpass selectors such as
+// node["amenity"="cafe"], without an area filter. Unknown categories are skipped.
+func (a *App) overpassCategorySelectors(categories []string) []string {
+	// Island requests are answered with coastline and place tags only
+	for _, category := range categories {
+		if islandCategories[category] {
+			categories = []string{"island"}
+			break
+		}
+	}
+
+	table := a.overpassCategories()
+	seen := map[string]bool{}
+	var selectors []string
+	for _, category := range categories {
+		for _, query := range table[category] {
+			for _, selector := range query.selectors() {
+				// Aliases such as "coast" and "coastline" share selectors
+				if !seen[selector] {
+					seen[selector] = true
+					selectors = append(selectors, selector)
+				}
+			}
+		}
+	}
+	return selectors
+}
+
+// selectors renders the query once per element type
+func (q TagQuery) selectors() []string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	var filters strings.Builder
+	for _, tag := range q.Tags {
+		switch {
+		case tag.Value == "":
+			fmt.Fprintf(&filters, `["%s"]`, escape.Replace(tag.Key))
+		case tag.Regex:
+			fmt.Fprintf(&filters, `["%s"~"%s"]`, escape.Replace(tag.Key), escape.Replace(tag.Value))
+		default:
+			fmt.Fprintf(&filters, `["%s"="%s"]`, escape.Replace(tag.Key), escape.Replace(tag.Value))
+		}
+	}
+
+	selectors := make([]string, len(q.Elements))
+	for i, element := range q.Elements {
+		selectors[i] = element + filters.String()
+	}
+	return selectors
+}
+
+// fallbackCategories picks categories whose names (or keywords) appear in a description
+func (a *App) fallbackCategories(description string) []string {
+	description = strings.ToLower(description)
+
+	// Sandy beaches get the narrower category instead of every beach
+	if strings.Contains(description, "beach") && strings.Contains(description, "sand") {
+		return []string{"beach_sandy"}
+	}
+
+	names := []string{}
+	for name := range a.overpassCategories() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seen := map[string]bool{}
+	var categories []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			categories = append(categories, name)
+		}
+	}
+	for _, name := range names {
+		if strings.Contains(description, strings.ReplaceAll(name, "_", " ")) {
+			add(name)
+		}
+	}
+	keywords := []string{}
+	for keyword := range fallbackKeywords {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		if strings.Contains(description, keyword) {
+			for _, name := range fallbackKeywords[keyword] {
+				add(name)
+			}
+		}
+	}
+	return categories
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/osm"
+	"github.com/paulmach/osm/osmgeojson"
+)
+
+// overpassElement is one element of an [out:json] response. Ways and relation members carry
+// their coordinates in geometry with `out geom`, and the edit metadata is set with `out meta`.
+type overpassElement struct {
+	Type      string               `json:"type"`
+	ID        int64                `json:"id"`
+	Version   int                  `json:"version"`
+	Timestamp time.Time            `json:"timestamp"`
+	Changeset int64                `json:"changeset"`
+	User      string               `json:"user"`
+	UID       int64                `json:"uid"`
+	Lat       float64              `json:"lat"`
+	Lon       float64              `json:"lon"`
+	Tags      map[string]string    `json:"tags"`
+	Nodes     []int64              `json:"nodes"`
+	Geometry  []*overpassLatLon    `json:"geometry"`
+	Members   []overpassJSONMember `json:"members"`
+}
+
+// overpassJSONMember is a relation member of an [out:json] response
+type overpassJSONMember struct {
+	Type     string            `json:"type"`
+	Ref      int64             `json:"ref"`
+	Role     string            `json:"role"`
+	Lat      float64           `json:"lat"`
+	Lon      float64           `json:"lon"`
+	Geometry []*overpassLatLon `json:"geometry"`
+}
+
+// overpassLatLon is a position in geometry lists; Overpass writes null for positions outside a
+// clipping bbox
+type overpassLatLon struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// overpassFormat tells an [out:json] response body from an [out:xml] one
+func overpassFormat(body []byte) string {
+	trimmed := strings.TrimSpace(string(body))
+	if strings.HasPrefix(trimmed, "{") {
+		return "json"
+	}
+	return "xml"
+}
+
+// overpassXMLRemark matches the remark element Overpass adds to XML responses
+var overpassXMLRemark = regexp.MustCompile(`(?s)<remark>\s*(.*?)\s*</remark>`)
+
+// overpassRemark returns the remark Overpass adds to a response, with HTTP status 200, when a
+// query stops part way, e.g. "runtime error: Query run out of memory using about 2048 MB of RAM."
+func overpassRemark(body []byte, format string) string {
+	switch format {
+	case "json":
+		if !bytes.Contains(body, []byte(`"remark"`)) {
+			return ""
+		}
+		var response struct {
+			Remark string `json:"remark"`
+		}
+		json.Unmarshal(body, &response)
+		return strings.TrimSpace(response.Remark)
+	case "xml":
+		if match := overpassXMLRemark.FindSubmatch(body); match != nil {
+			return html.UnescapeString(string(match[1]))
+		}
+	}
+	return ""
+}
+
+// overpassRemarkError turns a runtime error remark into an error suggesting how to make the query
+// fit; other remarks give nil
+func overpassRemarkError(remark string) error {
+	lower := strings.ToLower(remark)
+	if !strings.Contains(lower, "error") {
+		return nil
+	}
+	hint := "try a smaller bbox or a longer [timeout:...]"
+	if strings.Contains(lower, "memory") {
+		hint = "try a smaller bbox or a larger [maxsize:...]"
+	}
+	return fmt.Errorf("Overpass could not complete the query: %s; %s", strings.TrimSuffix(remark, "."), hint)
+}
+
+// markEmptyOverpassResult sets the empty flag of a response's metadata and, when nothing was
+// found, a message and the queried bbox ([west, south, east, north], if known) so the UI can
+// offer to widen the search
+func markEmptyOverpassResult(metadata map[string]interface{}, featureCount int, bbox []float64) {
+	metadata["empty"] = featureCount == 0
+	if featureCount > 0 {
+		return
+	}
+	metadata["message"] = "No features matched the query in this area; try a larger area or broader filters"
+	if len(bbox) == 4 {
+		metadata["bbox"] = bbox
+	}
+}
+
+// overpassToGeoJSON converts an Overpass response in either format ("json" or "xml") to GeoJSON.
+// Both go through osmgeojson, so a query returns the same features whichever format it asks
+// for: nodes as points, ways as lines or, when their tags describe an area, polygons, and
+// multipolygon, boundary and route relations. Untagged nodes of returned ways are only vertices
+// and aren't repeated as points. Relations of any other type, such as site or associatedStreet,
+// become a GeometryCollection of their members. Each feature's id is e.g. "way/123" and its
+// properties are the element's id and type, its edit metadata when the query asked for it
+// (version, timestamp, changeset, user and uid) and its tags. Tags named like one of the other
+// properties are kept with a tag: prefix, e.g. tag:type.
+func overpassToGeoJSON(body []byte, format string) (*geojson.FeatureCollection, error) {
+	var data *osm.OSM
+	var err error
+	switch format {
+	case "json":
+		data, err = decodeOverpassJSON(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSON response: %v", err)
+		}
+	case "xml":
+		data = &osm.OSM{}
+		if err := xml.Unmarshal(body, data); err != nil {
+			return nil, fmt.Errorf("failed to parse OSM XML data: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown Overpass response format %q", format)
+	}
+
+	fc, err := osmgeojson.Convert(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert to GeoJSON: %v", err)
+	}
+	fc.Features = append(fc.Features, otherRelationFeatures(data)...)
+	for _, feature := range fc.Features {
+		feature.Properties = flattenOSMProperties(feature.Properties)
+	}
+	return fc, nil
+}
+
+// osmgeojsonRelationTypes are the relation types osmgeojson builds a geometry for
+var osmgeojsonRelationTypes = map[string]bool{"multipolygon": true, "boundary": true, "route": true}
+
+// otherRelationFeatures returns a feature for every relation osmgeojson skips, with the members
+// that have a geometry in the response collected into a GeometryCollection. Member geometry
+// comes from `out geom` or from the member nodes and ways returned alongside the relation.
+// Properties follow osmgeojson's layout so flattenOSMProperties handles them the same way.
+func otherRelationFeatures(data *osm.OSM) []*geojson.Feature {
+	nodes := map[osm.NodeID]*osm.Node{}
+	for _, node := range data.Nodes {
+		nodes[node.ID] = node
+	}
+	ways := map[osm.WayID]*osm.Way{}
+	for _, way := range data.Ways {
+		ways[way.ID] = way
+	}
+	position := func(id osm.NodeID, lat, lon float64) (orb.Point, bool) {
+		if lat != 0 || lon != 0 {
+			return orb.Point{lon, lat}, true
+		}
+		if node, ok := nodes[id]; ok {
+			return orb.Point{node.Lon, node.Lat}, true
+		}
+		return orb.Point{}, false
+	}
+	line := func(wayNodes osm.WayNodes) orb.LineString {
+		var ls orb.LineString
+		for _, wn := range wayNodes {
+			if p, ok := position(wn.ID, wn.Lat, wn.Lon); ok {
+				ls = append(ls, p)
+			}
+		}
+		return ls
+	}
+
+	var features []*geojson.Feature
+	for _, relation := range data.Relations {
+		if osmgeojsonRelationTypes[relation.Tags.Find("type")] {
+			continue
+		}
+
+		var members orb.Collection
+		for _, member := range relation.Members {
+			switch member.Type {
+			case osm.TypeNode:
+				if p, ok := position(osm.NodeID(member.Ref), member.Lat, member.Lon); ok {
+					members = append(members, p)
+				}
+			case osm.TypeWay:
+				ls := line(member.Nodes)
+				if len(ls) == 0 {
+					if way, ok := ways[osm.WayID(member.Ref)]; ok {
+						ls = line(way.Nodes)
+					}
+				}
+				if len(ls) > 1 {
+					members = append(members, ls)
+				}
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+
+		feature := geojson.NewFeature(members)
+		feature.ID = fmt.Sprintf("relation/%d", relation.ID)
+		feature.Properties["id"] = int(relation.ID)
+		feature.Properties["type"] = "relation"
+		feature.Properties["tags"] = relation.Tags.Map()
+		meta := map[string]interface{}{}
+		if !relation.Timestamp.IsZero() {
+			meta["timestamp"] = relation.Timestamp
+		}
+		if relation.Version != 0 {
+			meta["version"] = relation.Version
+		}
+		if relation.ChangesetID != 0 {
+			meta["changeset"] = relation.ChangesetID
+		}
+		if relation.User != "" {
+			meta["user"] = relation.User
+		}
+		if relation.UserID != 0 {
+			meta["uid"] = relation.UserID
+		}
+		feature.Properties["meta"] = meta
+		features = append(features, feature)
+	}
+	return features
+}
+
+// decodeOverpassJSON reads an [out:json] response into the osm types osmgeojson works on
+func decodeOverpassJSON(body []byte) (*osm.OSM, error) {
+	var response struct {
+		Elements []overpassElement `json:"elements"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+
+	data := &osm.OSM{}
+	for _, element := range response.Elements {
+		tags := osmTags(element.Tags)
+		switch element.Type {
+		case "node":
+			data.Nodes = append(data.Nodes, &osm.Node{
+				ID: osm.NodeID(element.ID), Lat: element.Lat, Lon: element.Lon, Tags: tags, Visible: true,
+				Version: element.Version, Timestamp: element.Timestamp, ChangesetID: osm.ChangesetID(element.Changeset),
+				User: element.User, UserID: osm.UserID(element.UID),
+			})
+		case "way":
+			way := &osm.Way{ID: osm.WayID(element.ID), Tags: tags, Visible: true,
+				Version: element.Version, Timestamp: element.Timestamp, ChangesetID: osm.ChangesetID(element.Changeset),
+				User: element.User, UserID: osm.UserID(element.UID)}
+			for i, ref := range element.Nodes {
+				node := osm.WayNode{ID: osm.NodeID(ref)}
+				if i < len(element.Geometry) && element.Geometry[i] != nil {
+					node.Lat, node.Lon = element.Geometry[i].Lat, element.Geometry[i].Lon
+				}
+				way.Nodes = append(way.Nodes, node)
+			}
+			data.Ways = append(data.Ways, way)
+		case "relation":
+			relation := &osm.Relation{ID: osm.RelationID(element.ID), Tags: tags, Visible: true,
+				Version: element.Version, Timestamp: element.Timestamp, ChangesetID: osm.ChangesetID(element.Changeset),
+				User: element.User, UserID: osm.UserID(element.UID)}
+			for _, member := range element.Members {
+				m := osm.Member{Type: osm.Type(member.Type), Ref: member.Ref, Role: member.Role,
+					Lat: member.Lat, Lon: member.Lon}
+				for _, position := range member.Geometry {
+					if position != nil {
+						m.Nodes = append(m.Nodes, osm.WayNode{Lat: position.Lat, Lon: position.Lon})
+					}
+				}
+				relation.Members = append(relation.Members, m)
+			}
+			data.Relations = append(data.Relations, relation)
+		}
+	}
+	return data, nil
+}
+
+// osmTags converts a tag map to osm.Tags
+func osmTags(tags map[string]string) osm.Tags {
+	list := make(osm.Tags, 0, len(tags))
+	for key, value := range tags {
+		list = append(list, osm.Tag{Key: key, Value: value})
+	}
+	list.SortByKeyValue()
+	return list
+}
+
+// flattenOSMProperties moves osmgeojson's nested tags and edit metadata up into the
+// properties, where styling and attribute tables expect them
+func flattenOSMProperties(properties geojson.Properties) geojson.Properties {
+	flat := geojson.Properties{"id": properties["id"], "type": properties["type"]}
+	if meta, ok := properties["meta"].(map[string]interface{}); ok {
+		for key, value := range meta {
+			flat[key] = value
+		}
+	}
+	if tags, ok := properties["tags"].(map[string]string); ok {
+		for key, value := range tags {
+			if _, taken := flat[key]; taken || key == "tainted" || key == "relations" {
+				key = "tag:" + key
+			}
+			flat[key] = value
+		}
+	}
+	if value, ok := properties["tainted"]; ok {
+		flat["tainted"] = value
+	}
+	// Relation memberships are only kept for features that have some
+	if value, ok := properties["relations"]; ok && reflect.ValueOf(value).Len() > 0 {
+		flat["relations"] = value
+	}
+	return flat
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+func TestOverpassRemarkError(t *testing.T) {
+	const outOfMemory = "runtime error: Query run out of memory using about 2048 MB of RAM."
+
+	tests := []struct {
+		name   string
+		body   string
+		format string
+		remark string
+		hint   string
+	}{
+		{
+			name:   "json out of memory",
+			body:   `{"version":0.6,"elements":[],"remark":"` + outOfMemory + `"}`,
+			format: "json",
+			remark: outOfMemory,
+			hint:   "[maxsize:...]",
+		},
+		{
+			name: "xml out of memory",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<osm version="0.6">
+<remark> runtime error: Query run out of memory using about 2048 MB of RAM. </remark>
+</osm>`,
+			format: "xml",
+			remark: outOfMemory,
+			hint:   "[maxsize:...]",
+		},
+		{
+			name:   "json timeout",
+			body:   `{"elements":[],"remark":"runtime error: Query timed out in \"query\" at line 3 after 26 seconds."}`,
+			format: "json",
+			remark: `runtime error: Query timed out in "query" at line 3 after 26 seconds.`,
+			hint:   "[timeout:...]",
+		},
+		{
+			name:   "json informational remark",
+			body:   `{"elements":[],"remark":"The data included in this document is from www.openstreetmap.org."}`,
+			format: "json",
+			remark: "The data included in this document is from www.openstreetmap.org.",
+		},
+		{
+			name:   "xml without remark",
+			body:   `<osm version="0.6"><node id="1" lat="0" lon="0"/></osm>`,
+			format: "xml",
+		},
+		{
+			name:   "json without remark",
+			body:   `{"elements":[{"type":"node","id":1,"lat":0,"lon":0}]}`,
+			format: "json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remark := overpassRemark([]byte(tt.body), tt.format)
+			if remark != tt.remark {
+				t.Fatalf("overpassRemark = %q, want %q", remark, tt.remark)
+			}
+
+			err := overpassRemarkError(remark)
+			if tt.hint == "" {
+				if err != nil {
+					t.Fatalf("overpassRemarkError = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("overpassRemarkError = nil, want an error")
+			}
+			if !strings.Contains(err.Error(), strings.TrimSuffix(tt.remark, ".")) || !strings.Contains(err.Error(), tt.hint) {
+				t.Errorf("overpassRemarkError = %q, want the remark and a %s hint", err, tt.hint)
+			}
+		})
+	}
+}
+
+func TestOverpassToGeoJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		format string
+	}{
+		{
+			name: "json",
+			body: `{"elements":[
+{"type":"node","id":1,"lat":1,"lon":1},
+{"type":"node","id":2,"lat":1,"lon":2},
+{"type":"node","id":3,"lat":5,"lon":5},
+{"type":"node","id":4,"lat":6,"lon":6,"tags":{"amenity":"bench"}},
+{"type":"way","id":10,"nodes":[1,2],"geometry":[{"lat":1,"lon":1},{"lat":1,"lon":2}],"tags":{"highway":"path"}},
+{"type":"relation","id":20,"tags":{"type":"site","name":"Park"},"members":[
+  {"type":"way","ref":10,"role":"","geometry":[{"lat":1,"lon":1},{"lat":1,"lon":2}]},
+  {"type":"node","ref":4,"role":"entrance","lat":6,"lon":6}]}
+]}`,
+			format: "json",
+		},
+		{
+			name: "xml",
+			body: `<osm version="0.6">
+<node id="1" lat="1" lon="1"/>
+<node id="2" lat="1" lon="2"/>
+<node id="3" lat="5" lon="5"/>
+<node id="4" lat="6" lon="6"><tag k="amenity" v="bench"/></node>
+<way id="10"><nd ref="1"/><nd ref="2"/><tag k="highway" v="path"/></way>
+<relation id="20"><member type="way" ref="10" role=""/><member type="node" ref="4" role="entrance"/>
+<tag k="type" v="site"/><tag k="name" v="Park"/></relation>
+</osm>`,
+			format: "xml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc, err := overpassToGeoJSON([]byte(tt.body), tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, feature := range fc.Features {
+				got[feature.ID.(string)] = feature.Geometry.GeoJSONType()
+			}
+
+			// Untagged vertices of the returned way aren't repeated as points
+			want := map[string]string{
+				"node/3":      "Point",
+				"node/4":      "Point",
+				"way/10":      "LineString",
+				"relation/20": "GeometryCollection",
+			}
+			if len(got) != len(want) {
+				t.Errorf("features = %v, want %v", got, want)
+			}
+			for id, geometryType := range want {
+				if got[id] != geometryType {
+					t.Errorf("%s: geometry %q, want %q", id, got[id], geometryType)
+				}
+			}
+
+			for _, feature := range fc.Features {
+				if feature.ID == "relation/20" {
+					if name := feature.Properties["name"]; name != "Park" {
+						t.Errorf("relation/20: name = %v, want Park", name)
+					}
+					if members := len(feature.Geometry.(orb.Collection)); members != 2 {
+						t.Errorf("relation/20: %d member geometries, want 2", members)
+					}
+				}
+			}
+		})
+	}
+}
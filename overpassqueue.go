@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// maxOverpassRequests is how many Overpass requests run at once across all queries, tiles and
+// exports; the public instance gives each client two query slots
+const maxOverpassRequests = 2
+
+// overpassResultEvent carries the outcome of a StartOverpassQuery query, whether it finished,
+// failed or was cancelled
+const overpassResultEvent = "overpass:result"
+
+// OverpassQueryResult is the payload of overpass:result events. Response is set when the query
+// ran, even if Overpass reported an error.
+type OverpassQueryResult struct {
+	ID        string            `json:"id"`
+	Response  *OverpassResponse `json:"response,omitempty"`
+	Cancelled bool              `json:"cancelled"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// overpassQueryState limits concurrent Overpass requests and tracks the queries started with
+// StartOverpassQuery so they can be cancelled
+type overpassQueryState struct {
+	mu      sync.Mutex
+	slots   chan struct{}
+	next    int
+	cancels map[string]context.CancelFunc
+}
+
+// acquireOverpassSlot waits for a free Overpass request slot and returns the function that
+// frees it, or an error once ctx is cancelled
+func (a *App) acquireOverpassSlot(ctx context.Context) (func(), error) {
+	a.overpass.mu.Lock()
+	if a.overpass.slots == nil {
+		a.overpass.slots = make(chan struct{}, maxOverpassRequests)
+	}
+	slots := a.overpass.slots
+	a.overpass.mu.Unlock()
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		start := time.Now()
+		a.logDebug("Overpass query queued: all %d request slots are busy", cap(slots))
+		select {
+		case slots <- struct{}{}:
+			a.logDebug("Overpass query waited %s for a request slot", time.Since(start).Round(time.Millisecond))
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return func() { <-slots }, nil
+}
+
+// StartOverpassQuery runs a query in the background and returns its ID at once, so several can
+// run side by side. Queries beyond the request limit wait their turn. The result arrives in an
+// overpass:result event; CancelOverpassQuery stops the query. Like QueryOverpassAPI it is added
+// to the query history.
+func (a *App) StartOverpassQuery(query string) (string, error) {
+	if query == "" {
+		return "", fmt.Errorf("query is empty")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.overpass.mu.Lock()
+	if a.overpass.cancels == nil {
+		a.overpass.cancels = map[string]context.CancelFunc{}
+	}
+	a.overpass.next++
+	id := fmt.Sprintf("overpass-%d", a.overpass.next)
+	a.overpass.cancels[id] = cancel
+	a.overpass.mu.Unlock()
+
+	go func() {
+		defer func() {
+			a.overpass.mu.Lock()
+			delete(a.overpass.cancels, id)
+			a.overpass.mu.Unlock()
+			cancel()
+		}()
+
+		start := time.Now()
+		resp, err := a.runOverpassQuery(ctx, query)
+		a.recordQuery(a.queryHistoryEntry(query), start, resp, err)
+
+		result := OverpassQueryResult{ID: id, Response: resp, Cancelled: ctx.Err() != nil}
+		switch {
+		case result.Cancelled:
+			result.Response, result.Error = nil, "query cancelled"
+			a.logInfo("Overpass query %s cancelled", id)
+		case err != nil:
+			result.Error = err.Error()
+		case !resp.Success:
+			result.Error = resp.Error
+		}
+		a.emitEvent(overpassResultEvent, result)
+	}()
+	return id, nil
+}
+
+// CancelOverpassQuery stops a query started with StartOverpassQuery, whether it is still waiting
+// for a request slot or running; an overpass:result event with cancelled set follows
+func (a *App) CancelOverpassQuery(id string) error {
+	a.overpass.mu.Lock()
+	cancel, ok := a.overpass.cancels[id]
+	a.overpass.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("Overpass query %q not found", id)
+	}
+	cancel()
+	return nil
+}
@@ -0,0 +1,350 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	duckdb "github.com/marcboeker/go-duckdb"
+	"github.com/paulmach/orb/encoding/wkb"
+)
+
+// defaultParquetRowLimit caps how many rows are decoded when a Parquet file is opened for display
+const defaultParquetRowLimit = 10000
+
+// geoParquetMetadata is the GeoParquet "geo" key stored in the Parquet footer
+type geoParquetMetadata struct {
+	Version       string                      `json:"version"`
+	PrimaryColumn string                      `json:"primary_column"`
+	Columns       map[string]geoParquetColumn `json:"columns"`
+}
+
+type geoParquetColumn struct {
+	Encoding      string          `json:"encoding"`
+	GeometryTypes []string        `json:"geometry_types"`
+	CRS           json.RawMessage `json:"crs"`
+	BBox          []float64       `json:"bbox"`
+}
+
+// parquetInfo holds what we read from a Parquet footer without touching the row groups
+type parquetInfo struct {
+	NumRows        int64
+	Columns        []string
+	Geo            *geoParquetMetadata
+	GeometryColumn string
+}
+
+// openParquetDB opens a private in-memory DuckDB used to read Parquet files. It is kept
+// separate from the app's DuckDB so the spatial extension doesn't rewrite WKB columns.
+func openParquetDB() (*sql.DB, error) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open DuckDB: %v", err)
+	}
+	return db, nil
+}
+
+// readParquetInfo reads the row count, column names and GeoParquet metadata of a Parquet file
+func readParquetInfo(db *sql.DB, filePath string) (*parquetInfo, error) {
+	info := &parquetInfo{}
+
+	err := db.QueryRow("SELECT num_rows FROM parquet_file_metadata(?)", filePath).Scan(&info.NumRows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet metadata: %v", err)
+	}
+
+	// DESCRIBE lists top-level columns only, so nested struct fields stay out of the list
+	rows, err := db.Query("DESCRIBE SELECT * FROM read_parquet(?)", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet schema: %v", err)
+	}
+	blobColumns := map[string]bool{}
+	for rows.Next() {
+		var name, columnType string
+		var null, key, def, extra sql.NullString
+		if err := rows.Scan(&name, &columnType, &null, &key, &def, &extra); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read Parquet schema: %v", err)
+		}
+		info.Columns = append(info.Columns, name)
+		if columnType == "BLOB" {
+			blobColumns[name] = true
+		}
+	}
+	rows.Close()
+
+	kvRows, err := db.Query("SELECT key, value FROM parquet_kv_metadata(?)", filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet key/value metadata: %v", err)
+	}
+	defer kvRows.Close()
+	for kvRows.Next() {
+		var key, value []byte
+		if err := kvRows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to read Parquet key/value metadata: %v", err)
+		}
+		if string(key) != "geo" {
+			continue
+		}
+		var geo geoParquetMetadata
+		if err := json.Unmarshal(value, &geo); err != nil {
+			return nil, fmt.Errorf("failed to parse GeoParquet metadata: %v", err)
+		}
+		info.Geo = &geo
+	}
+
+	// Prefer the declared primary column, otherwise guess from common WKB column names
+	if info.Geo != nil && info.Geo.PrimaryColumn != "" {
+		info.GeometryColumn = info.Geo.PrimaryColumn
+	} else {
+		for _, name := range []string{"geometry", "geom", "wkb_geometry", "the_geom"} {
+			if blobColumns[name] {
+				info.GeometryColumn = name
+				break
+			}
+		}
+	}
+
+	return info, nil
+}
+
+// geometryColumn returns the GeoParquet description of the primary geometry column, if any
+func (info *parquetInfo) geometryColumn() *geoParquetColumn {
+	if info.Geo == nil {
+		return nil
+	}
+	column, ok := info.Geo.Columns[info.GeometryColumn]
+	if !ok {
+		return nil
+	}
+	return &column
+}
+
+// parquetCRS turns a GeoParquet crs member (PROJJSON) into an "AUTHORITY:CODE" string.
+// A missing crs means OGC:CRS84 per the spec; an explicit null means the CRS is unknown.
+func parquetCRS(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "EPSG:4326"
+	}
+
+	var projjson struct {
+		ID *struct {
+			Authority string      `json:"authority"`
+			Code      interface{} `json:"code"`
+		} `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &projjson); err == nil {
+		if projjson.ID != nil {
+			if projjson.ID.Authority == "OGC" && fmt.Sprint(projjson.ID.Code) == "CRS84" {
+				return "EPSG:4326"
+			}
+			return fmt.Sprintf("%s:%v", projjson.ID.Authority, projjson.ID.Code)
+		}
+		return projjson.Name
+	}
+
+	// Some writers store a plain string such as "EPSG:3857"
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text
+	}
+	return ""
+}
+
+// extractParquetMetadata extracts row count, columns and GeoParquet geometry info from Parquet files
+func (a *App) extractParquetMetadata(filePath string, metadata *FileMetadata) error {
+	db, err := openParquetDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	info, err := readParquetInfo(db, filePath)
+	if err != nil {
+		return err
+	}
+
+	metadata.NumFeatures = int(info.NumRows)
+	metadata.Metadata["format"] = "Parquet"
+	metadata.Metadata["columns"] = info.Columns
+
+	if info.Geo == nil {
+		if info.GeometryColumn != "" {
+			metadata.Metadata["geometry_column"] = info.GeometryColumn
+		}
+		return nil
+	}
+
+	metadata.Metadata["format"] = "GeoParquet"
+	metadata.Metadata["geoparquet_version"] = info.Geo.Version
+	metadata.Metadata["geometry_column"] = info.GeometryColumn
+	if column := info.geometryColumn(); column != nil {
+		metadata.CRS = parquetCRS(column.CRS)
+		metadata.Metadata["geometry_encoding"] = column.Encoding
+		if len(column.GeometryTypes) > 0 {
+			metadata.Metadata["geometry_types"] = column.GeometryTypes
+		}
+		if len(column.BBox) >= 4 {
+			// 3D bboxes are [minx, miny, minz, maxx, maxy, maxz]
+			half := len(column.BBox) / 2
+			metadata.BBox = []float64{column.BBox[0], column.BBox[1], column.BBox[half], column.BBox[half+1]}
+		}
+	}
+
+	return nil
+}
+
+// readParquet decodes the first rows of a Parquet file into a GeoJSON FeatureCollection
+func readParquet(filePath string) (map[string]interface{}, error) {
+	return readParquetPage(filePath, 0, defaultParquetRowLimit)
+}
+
+// LoadParquetPage decodes limit rows starting at offset from a (Geo)Parquet file into GeoJSON.
+// The collection carries total_rows and next_offset so the frontend can page through large files.
+func (a *App) LoadParquetPage(filePath string, offset int, limit int) (map[string]interface{}, error) {
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("file does not exist: %s", filePath)
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = defaultParquetRowLimit
+	}
+	return readParquetPage(filePath, offset, limit)
+}
+
+// readParquetPage reads one page of rows, decoding the geometry column from WKB
+func readParquetPage(filePath string, offset, limit int) (map[string]interface{}, error) {
+	db, err := openParquetDB()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	info, err := readParquetInfo(db, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if column := info.geometryColumn(); column != nil && !strings.EqualFold(column.Encoding, "WKB") {
+		return nil, fmt.Errorf("unsupported GeoParquet geometry encoding: %s", column.Encoding)
+	}
+
+	rows, err := db.Query("SELECT * FROM read_parquet(?) LIMIT ? OFFSET ?", filePath, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet rows: %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Parquet columns: %v", err)
+	}
+
+	features := []interface{}{}
+	skipped := 0
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan Parquet row: %v", err)
+		}
+
+		feature := map[string]interface{}{"type": "Feature", "geometry": nil}
+		properties := map[string]interface{}{}
+		for i, name := range columns {
+			if name == info.GeometryColumn {
+				data, ok := values[i].([]byte)
+				if !ok || len(data) == 0 {
+					continue
+				}
+				geometry, err := wkb.Unmarshal(data)
+				if err != nil {
+					skipped++
+					continue
+				}
+				feature["geometry"] = geometryToMap(geometry)
+				continue
+			}
+			properties[name] = parquetValue(values[i])
+		}
+		feature["properties"] = properties
+		features = append(features, feature)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Parquet rows: %v", err)
+	}
+
+	collection := map[string]interface{}{
+		"type":       "FeatureCollection",
+		"name":       strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)),
+		"features":   features,
+		"total_rows": info.NumRows,
+		"offset":     offset,
+	}
+	if next := int64(offset + len(features)); next < info.NumRows {
+		collection["next_offset"] = next
+	}
+	if skipped > 0 {
+		collection["invalid_geometries"] = skipped
+	}
+	if column := info.geometryColumn(); column != nil {
+		if crs := parquetCRS(column.CRS); crs != "" && crs != "EPSG:4326" {
+			collection["crs"] = map[string]interface{}{
+				"type":       "name",
+				"properties": map[string]interface{}{"name": crs},
+			}
+		}
+	}
+
+	return collection, nil
+}
+
+// parquetValue converts DuckDB driver values into something encoding/json can serialize
+func parquetValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, string, int8, int16, int32, int64, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case duckdb.Decimal:
+		return v.Float64()
+	case duckdb.UUID:
+		return fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:16])
+	case []byte:
+		// Non-geometry binary columns aren't displayable
+		return nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = parquetValue(item)
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = parquetValue(item)
+		}
+		return out
+	case duckdb.Map:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[fmt.Sprint(key)] = parquetValue(item)
+		}
+		return out
+	default:
+		if _, err := json.Marshal(v); err == nil {
+			return v
+		}
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultPointCloudSample is how many points LoadPointCloudSample returns when maxPoints is 0
+const defaultPointCloudSample = 50000
+
+// maxPointCloudSample caps the sample so the map stays responsive
+const maxPointCloudSample = 500000
+
+// lasClassNames are the ASPRS standard point classes
+var lasClassNames = map[int]string{
+	0:  "never_classified",
+	1:  "unclassified",
+	2:  "ground",
+	3:  "low_vegetation",
+	4:  "medium_vegetation",
+	5:  "high_vegetation",
+	6:  "building",
+	7:  "low_noise",
+	9:  "water",
+	10: "rail",
+	11: "road_surface",
+	13: "wire_guard",
+	14: "wire_conductor",
+	15: "transmission_tower",
+	17: "bridge_deck",
+	18: "high_noise",
+}
+
+// lasHeader holds the parts of the LAS public header block needed to read points
+type lasHeader struct {
+	VersionMajor  int
+	VersionMinor  int
+	PointFormat   int
+	RecordLength  int
+	PointOffset   int64
+	PointCount    uint64
+	Scale         [3]float64
+	Offset        [3]float64
+	Min           [3]float64
+	Max           [3]float64
+	CRS           string
+	Geographic    bool
+	SoftwareLabel string
+}
+
+// lasPoint is one decoded point record
+type lasPoint struct {
+	X, Y, Z        float64
+	Intensity      int
+	Classification int
+}
+
+// LoadPointCloudSample reads a LAS file and returns up to maxPoints of its points, evenly
+// subsampled, as a point FeatureCollection with z ranges for coloring
+func (a *App) LoadPointCloudSample(filePath string, maxPoints int) (map[string]interface{}, error) {
+	return a.LoadPointCloudSampleFiltered(filePath, maxPoints, nil)
+}
+
+// LoadPointCloudSampleFiltered is LoadPointCloudSample restricted to the given ASPRS classes,
+// e.g. 2 for ground, 3-5 for vegetation or 6 for buildings. An empty list keeps every class.
+func (a *App) LoadPointCloudSampleFiltered(filePath string, maxPoints int, classes []int) (map[string]interface{}, error) {
+	if maxPoints <= 0 {
+		maxPoints = defaultPointCloudSample
+	}
+	if maxPoints > maxPointCloudSample {
+		maxPoints = maxPointCloudSample
+	}
+	if strings.ToLower(filepath.Ext(filePath)) == ".laz" {
+		return nil, fmt.Errorf("LAZ files are compressed and can't be read directly; decompress to LAS first (e.g. with las2las or pdal translate)")
+	}
+
+	header, err := readLASHeader(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[int]bool{}
+	for _, class := range classes {
+		keep[class] = true
+	}
+	accept := func(p lasPoint) bool {
+		return len(keep) == 0 || keep[p.Classification]
+	}
+
+	// With a class filter the number of matching points is only known after a first pass
+	matching := header.PointCount
+	if len(keep) > 0 {
+		matching = 0
+		err := readLASPoints(filePath, header, func(p lasPoint) error {
+			if accept(p) {
+				matching++
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stride := uint64(1)
+	if matching > uint64(maxPoints) {
+		stride = (matching + uint64(maxPoints) - 1) / uint64(maxPoints)
+	}
+
+	var sample []lasPoint
+	var seen uint64
+	err = readLASPoints(filePath, header, func(p lasPoint) error {
+		if !accept(p) {
+			return nil
+		}
+		if seen%stride == 0 && len(sample) < maxPoints {
+			sample = append(sample, p)
+		}
+		seen++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make([][]float64, len(sample))
+	for i, p := range sample {
+		positions[i] = []float64{p.X, p.Y, p.Z}
+	}
+	if !header.Geographic {
+		if header.CRS == "" {
+			return nil, fmt.Errorf("point cloud has no coordinate reference system, so it can't be placed on the map")
+		}
+		positions, err = a.gdalTransformPoints(header.CRS, "EPSG:4326", positions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reproject point cloud: %v", err)
+		}
+	}
+
+	zMin, zMax := math.Inf(1), math.Inf(-1)
+	classCounts := map[string]int{}
+	features := make([]interface{}, 0, len(sample))
+	for i, p := range sample {
+		zMin, zMax = math.Min(zMin, p.Z), math.Max(zMax, p.Z)
+		className := lasClassName(p.Classification)
+		classCounts[className]++
+
+		features = append(features, map[string]interface{}{
+			"type": "Feature",
+			"geometry": map[string]interface{}{
+				"type":        "Point",
+				"coordinates": []float64{positions[i][0], positions[i][1], p.Z},
+			},
+			"properties": map[string]interface{}{
+				"z":              p.Z,
+				"intensity":      p.Intensity,
+				"classification": p.Classification,
+				"class_name":     className,
+			},
+		})
+	}
+
+	result := map[string]interface{}{
+		"type":           "FeatureCollection",
+		"features":       features,
+		"total_points":   header.PointCount,
+		"matched_points": matching,
+		"sampled_points": len(sample),
+		"stride":         stride,
+		"crs":            header.CRS,
+		"class_counts":   classCounts,
+	}
+	if len(sample) > 0 {
+		result["z_min"] = zMin
+		result["z_max"] = zMax
+	}
+	return result, nil
+}
+
+// lasClassName names an ASPRS class, falling back to its number
+func lasClassName(class int) string {
+	if name, ok := lasClassNames[class]; ok {
+		return name
+	}
+	return fmt.Sprintf("class_%d", class)
+}
+
+// readLASHeader parses the public header block and the CRS variable length records
+func readLASHeader(filePath string) (*lasHeader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open point cloud: %v", err)
+	}
+	defer file.Close()
+
+	buf := make([]byte, 375)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read LAS header: %v", err)
+	}
+	buf = buf[:n]
+	if n < 227 || string(buf[0:4]) != "LASF" {
+		return nil, fmt.Errorf("not a LAS file")
+	}
+
+	le := binary.LittleEndian
+	f64 := func(off int) float64 { return math.Float64frombits(le.Uint64(buf[off:])) }
+
+	h := &lasHeader{
+		VersionMajor:  int(buf[24]),
+		VersionMinor:  int(buf[25]),
+		SoftwareLabel: strings.TrimRight(string(buf[58:90]), "\x00 "),
+		PointOffset:   int64(le.Uint32(buf[96:])),
+		// The top two bits flag LAZ compression
+		PointFormat:  int(buf[104] & 0x3F),
+		RecordLength: int(le.Uint16(buf[105:])),
+		PointCount:   uint64(le.Uint32(buf[107:])),
+		Scale:        [3]float64{f64(131), f64(139), f64(147)},
+		Offset:       [3]float64{f64(155), f64(163), f64(171)},
+		Max:          [3]float64{f64(179), f64(195), f64(211)},
+		Min:          [3]float64{f64(187), f64(203), f64(219)},
+	}
+	headerSize := int64(le.Uint16(buf[94:]))
+	numVLRs := int(le.Uint32(buf[100:]))
+
+	// LAS 1.4 moved the point count to a 64-bit field
+	if h.VersionMajor == 1 && h.VersionMinor >= 4 && n >= 255 {
+		if count := le.Uint64(buf[247:]); count > 0 {
+			h.PointCount = count
+		}
+	}
+	if h.PointFormat > 10 {
+		return nil, fmt.Errorf("unsupported LAS point format %d", h.PointFormat)
+	}
+	if h.RecordLength < 20 {
+		return nil, fmt.Errorf("invalid LAS point record length %d", h.RecordLength)
+	}
+
+	if err := readLASCRS(file, headerSize, numVLRs, h); err != nil {
+		return nil, err
+	}
+	if h.CRS == "" && h.Min[0] >= -180 && h.Max[0] <= 180 && h.Min[1] >= -90 && h.Max[1] <= 90 {
+		// No CRS records but the extent only fits lon/lat
+		h.CRS = "EPSG:4326"
+		h.Geographic = true
+	}
+
+	return h, nil
+}
+
+// readLASCRS looks for a GeoTIFF key directory or an OGC WKT record among the VLRs
+func readLASCRS(file *os.File, offset int64, count int, h *lasHeader) error {
+	le := binary.LittleEndian
+	vlrHeader := make([]byte, 54)
+
+	for i := 0; i < count; i++ {
+		if _, err := file.ReadAt(vlrHeader, offset); err != nil {
+			return fmt.Errorf("failed to read LAS variable length record: %v", err)
+		}
+		userID := string(bytes.TrimRight(vlrHeader[2:18], "\x00"))
+		recordID := le.Uint16(vlrHeader[18:])
+		length := int64(le.Uint16(vlrHeader[20:]))
+		dataOffset := offset + 54
+		offset = dataOffset + length
+
+		if userID != "LASF_Projection" {
+			continue
+		}
+		data := make([]byte, length)
+		if _, err := file.ReadAt(data, dataOffset); err != nil {
+			return fmt.Errorf("failed to read LAS projection record: %v", err)
+		}
+
+		switch recordID {
+		case 34735:
+			// GeoKeyDirectoryTag: a header of four shorts, then four shorts per key
+			var projected, geographic int
+			for k := 4; k+3 < len(data)/2; k += 4 {
+				keyID := le.Uint16(data[k*2:])
+				location := le.Uint16(data[(k+1)*2:])
+				value := int(le.Uint16(data[(k+3)*2:]))
+				if location != 0 {
+					continue
+				}
+				switch keyID {
+				case 3072:
+					projected = value
+				case 2048:
+					geographic = value
+				}
+			}
+			if projected > 0 && projected != 32767 {
+				h.CRS = fmt.Sprintf("EPSG:%d", projected)
+			} else if geographic > 0 && geographic != 32767 && h.CRS == "" {
+				h.CRS = fmt.Sprintf("EPSG:%d", geographic)
+				h.Geographic = true
+			}
+		case 2112:
+			// OGC WKT takes precedence when both are present
+			if wkt := strings.TrimRight(string(data), "\x00 "); wkt != "" {
+				h.CRS = wkt
+				h.Geographic = isGeographicWKT(wkt)
+			}
+		}
+	}
+	return nil
+}
+
+// readLASPoints decodes every point record in order and passes it to fn
+func readLASPoints(filePath string, h *lasHeader, fn func(p lasPoint) error) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open point cloud: %v", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(h.PointOffset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to point data: %v", err)
+	}
+
+	le := binary.LittleEndian
+	reader := bufio.NewReaderSize(file, 1<<20)
+	record := make([]byte, h.RecordLength)
+	for i := uint64(0); i < h.PointCount; i++ {
+		if _, err := io.ReadFull(reader, record); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				// Truncated file; keep what was read
+				return nil
+			}
+			return fmt.Errorf("failed to read point %d: %v", i, err)
+		}
+
+		p := lasPoint{
+			X:         float64(int32(le.Uint32(record[0:])))*h.Scale[0] + h.Offset[0],
+			Y:         float64(int32(le.Uint32(record[4:])))*h.Scale[1] + h.Offset[1],
+			Z:         float64(int32(le.Uint32(record[8:])))*h.Scale[2] + h.Offset[2],
+			Intensity: int(le.Uint16(record[12:])),
+		}
+		if h.PointFormat >= 6 {
+			p.Classification = int(record[16])
+		} else {
+			p.Classification = int(record[15] & 0x1F)
+		}
+
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
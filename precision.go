@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// defaultCoordinatePrecision is the number of decimal places kept on save (~1cm at the equator)
+const defaultCoordinatePrecision = 7
+
+// resolvePrecision converts a requested precision to decimal places: wholeUnits rounds to whole
+// units whatever precision is, 0 is defaultCoordinatePrecision and 1 to 15 are taken as given
+func resolvePrecision(precision int, wholeUnits bool) (int, error) {
+	switch {
+	case wholeUnits:
+		return 0, nil
+	case precision == 0:
+		return defaultCoordinatePrecision, nil
+	case precision < 0 || precision > 15:
+		return 0, fmt.Errorf("precision must be 1 to 15 decimal places, or 0 for the default of %d, got %d",
+			defaultCoordinatePrecision, precision)
+	}
+	return precision, nil
+}
+
+// RoundCoordinates rounds every longitude/latitude to the given number of decimal places; 0 uses
+// the default of 7, and wholeUnits rounds to whole units instead. Elevations are left untouched,
+// and any line or ring that rounding would make degenerate keeps its original coordinates.
+func (a *App) RoundCoordinates(geojson map[string]interface{}, precision int, wholeUnits bool) (map[string]interface{}, error) {
+	decimals, err := resolvePrecision(precision, wholeUnits)
+	if err != nil {
+		return nil, err
+	}
+	if err := roundGeoJSON(geojson, decimals); err != nil {
+		return nil, err
+	}
+	return geojson, nil
+}
+
+// roundGeoJSON rounds the coordinates of every feature in place
+func roundGeoJSON(geojson map[string]interface{}, precision int) error {
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return err
+	}
+	for _, feature := range features {
+		if geometry, ok := feature["geometry"].(map[string]interface{}); ok && geometry != nil {
+			roundGeometry(geometry, precision)
+		}
+	}
+	return nil
+}
+
+// roundGeometry rounds a geometry's coordinates in place
+func roundGeometry(geometry map[string]interface{}, precision int) {
+	factor := math.Pow(10, float64(precision))
+
+	switch geometry["type"] {
+	case "Point":
+		var coord []float64
+		if decodeCoordinates(geometry["coordinates"], &coord) == nil && len(coord) >= 2 {
+			geometry["coordinates"] = roundPosition(coord, factor)
+		}
+	case "MultiPoint":
+		var coords [][]float64
+		if decodeCoordinates(geometry["coordinates"], &coords) == nil {
+			for i, coord := range coords {
+				coords[i] = roundPosition(coord, factor)
+			}
+			geometry["coordinates"] = coords
+		}
+	case "LineString":
+		var line [][]float64
+		if decodeCoordinates(geometry["coordinates"], &line) == nil {
+			geometry["coordinates"] = roundLine(line, factor, 2)
+		}
+	case "MultiLineString":
+		var lines [][][]float64
+		if decodeCoordinates(geometry["coordinates"], &lines) == nil {
+			for i, line := range lines {
+				lines[i] = roundLine(line, factor, 2)
+			}
+			geometry["coordinates"] = lines
+		}
+	case "Polygon":
+		var rings [][][]float64
+		if decodeCoordinates(geometry["coordinates"], &rings) == nil {
+			for i, ring := range rings {
+				rings[i] = roundLine(ring, factor, 4)
+			}
+			geometry["coordinates"] = rings
+		}
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if decodeCoordinates(geometry["coordinates"], &polygons) == nil {
+			for _, rings := range polygons {
+				for i, ring := range rings {
+					rings[i] = roundLine(ring, factor, 4)
+				}
+			}
+			geometry["coordinates"] = polygons
+		}
+	case "GeometryCollection":
+		geometries, _ := geometry["geometries"].([]interface{})
+		for _, g := range geometries {
+			if child, ok := g.(map[string]interface{}); ok {
+				roundGeometry(child, precision)
+			}
+		}
+	}
+}
+
+// roundLine rounds a line or ring and drops the consecutive duplicates rounding creates. If fewer
+// than minPoints positions would survive, the original positions are kept instead.
+func roundLine(line [][]float64, factor float64, minPoints int) [][]float64 {
+	rounded := make([][]float64, 0, len(line))
+	for _, p := range line {
+		if len(p) < 2 {
+			return line
+		}
+		rounded = append(rounded, roundPosition(p, factor))
+	}
+	rounded = dedupePositions(rounded)
+	if len(rounded) < minPoints && len(line) >= minPoints {
+		return line
+	}
+	return rounded
+}
+
+// roundPosition rounds x and y, keeping any further dimensions as they are
+func roundPosition(p []float64, factor float64) []float64 {
+	out := make([]float64, len(p))
+	copy(out, p)
+	out[0] = math.Round(p[0]*factor) / factor
+	out[1] = math.Round(p[1]*factor) / factor
+	return out
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoundCoordinatesPrecision(t *testing.T) {
+	tests := []struct {
+		precision  int
+		wholeUnits bool
+		want       []float64
+		wantErr    bool
+	}{
+		{precision: 0, want: []float64{12.3456789, -45.6789012, 123.456}},
+		{precision: 2, want: []float64{12.35, -45.68, 123.456}},
+		{wholeUnits: true, want: []float64{12, -46, 123.456}},
+		{precision: 3, wholeUnits: true, want: []float64{12, -46, 123.456}},
+		{precision: -1, wantErr: true},
+		{precision: 16, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		geojson := map[string]interface{}{
+			"type": "FeatureCollection",
+			"features": []interface{}{
+				map[string]interface{}{
+					"type":       "Feature",
+					"properties": map[string]interface{}{},
+					"geometry": map[string]interface{}{
+						"type":        "Point",
+						"coordinates": []interface{}{12.345678912, -45.678901234, 123.456},
+					},
+				},
+			},
+		}
+
+		result, err := NewApp().RoundCoordinates(geojson, tt.precision, tt.wholeUnits)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("precision %d: expected an error", tt.precision)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("precision %d: %v", tt.precision, err)
+		}
+		features, _ := geojsonFeatures(result)
+		got := features[0]["geometry"].(map[string]interface{})["coordinates"]
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("precision %d: coordinates = %v, want %v", tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestSaveFullPrecisionIgnoresPrecision(t *testing.T) {
+	a := newTestApp(t)
+	geojson := map[string]interface{}{
+		"type": "FeatureCollection",
+		"features": []interface{}{
+			map[string]interface{}{
+				"type":       "Feature",
+				"properties": map[string]interface{}{},
+				"geometry": map[string]interface{}{
+					"type":        "Point",
+					"coordinates": []interface{}{12.345678912, -45.678901234},
+				},
+			},
+		},
+	}
+
+	options := SaveOptions{Precision: 99, FullPrecision: true, Directory: t.TempDir()}
+	if _, err := a.SaveEditedOSMData(geojson, "points.geojson", options); err != nil {
+		t.Fatalf("full precision save rejected the unused precision: %v", err)
+	}
+
+	options.FullPrecision = false
+	if _, err := a.SaveEditedOSMData(geojson, "points.geojson", options); err == nil {
+		t.Error("rounded save accepted a precision of 99")
+	}
+}
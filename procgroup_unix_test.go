@@ -0,0 +1,73 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// processAlive reports whether pid is still running. Zombies count as dead, since nothing may
+// reap orphans inside a container.
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	stat, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return true
+	}
+	return !strings.Contains(string(stat), ") Z ")
+}
+
+func TestExecGDALKillsHungProcessGroup(t *testing.T) {
+	dir := t.TempDir()
+	childPIDFile := filepath.Join(dir, "child.pid")
+	// The stub leaves a background child behind, as some GDAL tools do, then hangs
+	stub := "#!/bin/sh\nsleep 60 &\necho $! > " + childPIDFile + "\nsleep 60\n"
+	if err := os.WriteFile(filepath.Join(dir, "ogrinfo"), []byte(stub), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tool := findGDALBinary("ogrinfo")
+	if tool != filepath.Join(dir, "ogrinfo") {
+		t.Fatalf("findGDALBinary(ogrinfo) = %q, want the stub", tool)
+	}
+
+	a := NewApp()
+	a.gdalTimeoutValue = 500 * time.Millisecond
+
+	start := time.Now()
+	_, _, err := a.execGDAL(context.Background(), tool, "", "--version")
+	if !errors.Is(err, errGDALTimeout) {
+		t.Fatalf("execGDAL error = %v, want errGDALTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("execGDAL returned after %s, want shortly after the timeout", elapsed)
+	}
+
+	data, err := os.ReadFile(childPIDFile)
+	if err != nil {
+		t.Fatalf("stub did not record its child: %v", err)
+	}
+	childPID, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for processAlive(childPID) {
+		if time.Now().After(deadline) {
+			syscall.Kill(childPID, syscall.SIGKILL)
+			t.Fatalf("child %d of the timed out process is still running", childPID)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
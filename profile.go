@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+)
+
+// defaultProfileSamples is used when ElevationProfile is called with samples <= 1
+const defaultProfileSamples = 200
+
+// maxProfileSamples caps the number of DEM samples along a profile
+const maxProfileSamples = 5000
+
+// ProfilePoint is one sample of an elevation profile. Elevation is null where the line leaves the
+// DEM or the pixel is NoData.
+type ProfilePoint struct {
+	Distance  float64  `json:"distance_m"`
+	Lon       float64  `json:"lon"`
+	Lat       float64  `json:"lat"`
+	Elevation *float64 `json:"elevation"`
+}
+
+// ElevationProfile samples a DEM at evenly spaced points along a LineString (a geometry or a
+// Feature) and returns cumulative geodesic distance / elevation pairs for charting
+func (a *App) ElevationProfile(demPath string, line map[string]interface{}, samples int) ([]ProfilePoint, error) {
+	if samples <= 1 {
+		samples = defaultProfileSamples
+	}
+	if samples > maxProfileSamples {
+		samples = maxProfileSamples
+	}
+
+	geometry := line
+	if line["type"] == "Feature" {
+		geometry, _ = line["geometry"].(map[string]interface{})
+	}
+	if geometry == nil || geometry["type"] != "LineString" {
+		return nil, fmt.Errorf("elevation profile needs a LineString")
+	}
+	var coords [][]float64
+	if err := decodeCoordinates(geometry["coordinates"], &coords); err != nil || len(coords) < 2 || !validPositions(coords) {
+		return nil, fmt.Errorf("LineString needs at least two valid positions")
+	}
+
+	// Cumulative distance at each vertex
+	cumulative := make([]float64, len(coords))
+	for i := 1; i < len(coords); i++ {
+		cumulative[i] = cumulative[i-1] + haversineMeters(coords[i-1][0], coords[i-1][1], coords[i][0], coords[i][1])
+	}
+	total := cumulative[len(coords)-1]
+
+	profile := make([]ProfilePoint, samples)
+	segment := 0
+	for i := range profile {
+		d := total * float64(i) / float64(samples-1)
+		for segment < len(coords)-2 && cumulative[segment+1] < d {
+			segment++
+		}
+		t := 0.0
+		if length := cumulative[segment+1] - cumulative[segment]; length > 0 {
+			t = (d - cumulative[segment]) / length
+		}
+		from, to := coords[segment], coords[segment+1]
+		profile[i] = ProfilePoint{
+			Distance: d,
+			Lon:      from[0] + (to[0]-from[0])*t,
+			Lat:      from[1] + (to[1]-from[1])*t,
+		}
+	}
+
+	demPath, err := gdalInputPath(demPath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := a.readRasterInfo(demPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only positions inside the DEM are sampled; the rest stay null
+	var inside []int
+	var positions [][]float64
+	for i, p := range profile {
+		if info.contains(p.Lon, p.Lat) {
+			inside = append(inside, i)
+			positions = append(positions, []float64{p.Lon, p.Lat})
+		}
+	}
+	if len(positions) == 0 {
+		return profile, nil
+	}
+
+	values, err := a.sampleRaster(demPath, info, positions)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range inside {
+		if values[j] != nil {
+			profile[i].Elevation = values[j][0]
+		}
+	}
+
+	return profile, nil
+}
@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Quality check levels for indexing. Basic counts null geometries, empty attributes and
+// coordinate precision in one cheap pass; full also validates every geometry and looks for
+// duplicate features.
+const (
+	qualityChecksOff   = "off"
+	qualityChecksBasic = "basic"
+	qualityChecksFull  = "full"
+
+	// qualityChecksSettingKey stores the level of quality checks CreateIndex runs on vector files
+	qualityChecksSettingKey = "index_quality_checks"
+
+	// maxPrecisionBucket is the last bucket of the precision distribution; coordinates with more
+	// decimal places are counted there
+	maxPrecisionBucket = 15
+)
+
+// Weights of each check in the quality score. Checks that didn't run are left out and the rest
+// rescaled, so basic and full scores are both out of 100.
+const (
+	nullGeometryWeight    = 0.35
+	invalidGeometryWeight = 0.25
+	duplicateWeight       = 0.15
+	emptyAttributeWeight  = 0.25
+)
+
+// FieldCompleteness is the share of features with no value for one attribute
+type FieldCompleteness struct {
+	Field        string  `json:"field"`
+	EmptyCount   int     `json:"empty_count"`
+	EmptyPercent float64 `json:"empty_percent"`
+}
+
+// QualityReport summarises the data quality of one layer. InvalidGeometries and
+// DuplicateFeatures are only counted when FullChecks is set. PrecisionDistribution[n] counts the
+// coordinates written with n decimal places. Score runs from 0 (unusable) to 100.
+type QualityReport struct {
+	FilePath              string              `json:"file_path"`
+	LayerName             string              `json:"layer_name"`
+	FeatureCount          int                 `json:"feature_count"`
+	NullGeometries        int                 `json:"null_geometries"`
+	InvalidGeometries     int                 `json:"invalid_geometries"`
+	DuplicateFeatures     int                 `json:"duplicate_features"`
+	EmptyAttributes       []FieldCompleteness `json:"empty_attributes"`
+	PrecisionDistribution []int               `json:"precision_distribution"`
+	FullChecks            bool                `json:"full_checks"`
+	Score                 float64             `json:"score"`
+}
+
+// ComputeLayerQuality runs every quality check on a vector layer: null and invalid geometries,
+// duplicate features, empty attributes per field and coordinate precision. layerName selects a
+// layer in multi-layer formats and may be empty.
+func (a *App) ComputeLayerQuality(filePath string, layerName string) (QualityReport, error) {
+	filePath, err := gdalInputPath(filePath)
+	if err != nil {
+		return QualityReport{}, err
+	}
+	if fileType := a.determineFileType(strings.ToLower(filepath.Ext(filePath))); fileType != "vector" {
+		return QualityReport{}, fmt.Errorf("quality checks need a vector layer, %s is %s", filePath, fileType)
+	}
+
+	report, err := a.computeLayerQuality(context.Background(), filePath, layerName, true)
+	if err != nil {
+		return QualityReport{}, err
+	}
+	a.logInfo("Quality of %s: score %.0f, %d features, %d null and %d invalid geometries, %d duplicates",
+		filePath, report.Score, report.FeatureCount, report.NullGeometries, report.InvalidGeometries, report.DuplicateFeatures)
+	return report, nil
+}
+
+// GetIndexQualityChecks returns the quality checks indexing runs: off, basic or full
+func (a *App) GetIndexQualityChecks() string {
+	return a.indexQualityChecks()
+}
+
+// SetIndexQualityChecks chooses the quality checks indexing runs on vector files. Indexing stores
+// the score as quality_score in the file's metadata. Off, the default, keeps indexing fast;
+// basic reads every feature once; full also validates geometries and looks for duplicates.
+func (a *App) SetIndexQualityChecks(level string) error {
+	switch level {
+	case qualityChecksOff, qualityChecksBasic, qualityChecksFull:
+	default:
+		return fmt.Errorf("unknown quality check level %q (expected off, basic or full)", level)
+	}
+	return a.setSetting(qualityChecksSettingKey, level)
+}
+
+// indexQualityChecks reads the quality check level, defaulting to off
+func (a *App) indexQualityChecks() string {
+	if value, found, err := a.getSetting(qualityChecksSettingKey); err == nil && found {
+		switch value {
+		case qualityChecksBasic, qualityChecksFull:
+			return value
+		}
+	}
+	return qualityChecksOff
+}
+
+// scoreIndexedLayer stores the quality score of a vector file in its index metadata. It is given
+// at most timeout, so one huge file can't stall indexing; a file that isn't scored in time keeps
+// no score.
+func (a *App) scoreIndexedLayer(ctx context.Context, filePath string, metadata *FileMetadata, level string, timeout time.Duration) {
+	if level == qualityChecksOff || metadata.FileType != "vector" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	report, err := a.computeLayerQuality(ctx, filePath, "", level == qualityChecksFull)
+	if err != nil {
+		a.logWarn("Quality checks of %s failed: %v", filePath, err)
+		return
+	}
+	if metadata.Metadata == nil {
+		metadata.Metadata = map[string]interface{}{}
+	}
+	metadata.Metadata["quality_score"] = report.Score
+	metadata.Metadata["quality_checks"] = level
+}
+
+// computeLayerQuality reads the layer once, running the geometry validation and duplicate
+// detection only when full is set
+func (a *App) computeLayerQuality(ctx context.Context, filePath string, layerName string, full bool) (QualityReport, error) {
+	report := QualityReport{
+		FilePath:              filePath,
+		LayerName:             layerName,
+		EmptyAttributes:       []FieldCompleteness{},
+		PrecisionDistribution: make([]int, maxPrecisionBucket+1),
+		FullChecks:            full,
+	}
+	filled := map[string]int{}
+	fields := []string{}
+	seen := map[uint64]struct{}{}
+
+	err := a.readLayerFeatures(ctx, filePath, layerName, func(feature map[string]interface{}) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		report.FeatureCount++
+
+		properties, _ := feature["properties"].(map[string]interface{})
+		for name, value := range properties {
+			count, known := filled[name]
+			if !known {
+				fields = append(fields, name)
+			}
+			if !emptyAttribute(value) {
+				count++
+			}
+			filled[name] = count
+		}
+
+		geometry, _ := feature["geometry"].(map[string]interface{})
+		if geometry == nil || geometry["type"] == nil {
+			report.NullGeometries++
+		} else {
+			countCoordinatePrecision(geometry, report.PrecisionDistribution)
+			if full && invalidGeometry(geometry) {
+				report.InvalidGeometries++
+			}
+		}
+
+		if full {
+			// encoding/json sorts map keys, so equal features encode identically
+			data, err := json.Marshal(map[string]interface{}{"geometry": geometry, "properties": properties})
+			if err == nil {
+				h := fnv.New64a()
+				h.Write(data)
+				key := h.Sum64()
+				if _, dup := seen[key]; dup {
+					report.DuplicateFeatures++
+				} else {
+					seen[key] = struct{}{}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return QualityReport{}, fmt.Errorf("quality checks stopped after %d features: %v", report.FeatureCount, ctxErr)
+		}
+		return QualityReport{}, fmt.Errorf("failed to read features: %v", err)
+	}
+
+	// A field missing from a feature counts as empty there
+	for _, name := range fields {
+		empty := report.FeatureCount - filled[name]
+		report.EmptyAttributes = append(report.EmptyAttributes, FieldCompleteness{
+			Field:        name,
+			EmptyCount:   empty,
+			EmptyPercent: percentOf(empty, report.FeatureCount),
+		})
+	}
+	sort.Slice(report.EmptyAttributes, func(i, j int) bool {
+		fi, fj := report.EmptyAttributes[i], report.EmptyAttributes[j]
+		if fi.EmptyCount != fj.EmptyCount {
+			return fi.EmptyCount > fj.EmptyCount
+		}
+		return fi.Field < fj.Field
+	})
+
+	report.Score = qualityScore(report)
+	return report, nil
+}
+
+// qualityScore weighs the share of features failing each check that ran
+func qualityScore(report QualityReport) float64 {
+	if report.FeatureCount == 0 {
+		return 0
+	}
+	total := float64(report.FeatureCount)
+	penalty := nullGeometryWeight * float64(report.NullGeometries) / total
+	weight := nullGeometryWeight
+
+	if len(report.EmptyAttributes) > 0 {
+		var sum float64
+		for _, field := range report.EmptyAttributes {
+			sum += field.EmptyPercent / 100
+		}
+		penalty += emptyAttributeWeight * sum / float64(len(report.EmptyAttributes))
+		weight += emptyAttributeWeight
+	}
+	if report.FullChecks {
+		penalty += invalidGeometryWeight*float64(report.InvalidGeometries)/total +
+			duplicateWeight*float64(report.DuplicateFeatures)/total
+		weight += invalidGeometryWeight + duplicateWeight
+	}
+	return math.Round(100*(1-penalty/weight)*10) / 10
+}
+
+// emptyAttribute reports whether an attribute value carries no information
+func emptyAttribute(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	}
+	return false
+}
+
+// invalidGeometry reports whether a geometry has problems beyond ring orientation, which
+// doesn't change what the geometry covers
+func invalidGeometry(geometry map[string]interface{}) bool {
+	for _, issue := range validateGeometryObject(geometry, 0) {
+		if issue.Issue != issueWrongWinding {
+			return true
+		}
+	}
+	return false
+}
+
+// countCoordinatePrecision adds the decimal places of every x and y in a geometry to buckets
+func countCoordinatePrecision(geometry map[string]interface{}, buckets []int) {
+	if members, ok := geometry["geometries"].([]interface{}); ok {
+		for _, member := range members {
+			if m, ok := member.(map[string]interface{}); ok {
+				countCoordinatePrecision(m, buckets)
+			}
+		}
+		return
+	}
+	var walk func(interface{})
+	walk = func(value interface{}) {
+		items, ok := value.([]interface{})
+		if !ok || len(items) == 0 {
+			return
+		}
+		if _, ok := items[0].(float64); ok {
+			for _, item := range items[:min(len(items), 2)] {
+				if v, ok := item.(float64); ok {
+					buckets[min(decimalPlaces(v), len(buckets)-1)]++
+				}
+			}
+			return
+		}
+		for _, item := range items {
+			walk(item)
+		}
+	}
+	walk(geometry["coordinates"])
+}
+
+// decimalPlaces counts the digits after the point in the shortest representation of v
+func decimalPlaces(v float64) int {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return len(s) - i - 1
+	}
+	return 0
+}
+
+// percentOf returns part as a percentage of total, rounded to one decimal
+func percentOf(part int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 10
+}
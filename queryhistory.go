@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Where a query in the history came from. openai and fallback queries were generated from a
+// description, by OpenAI or from keywords; category queries were built by QueryOverpassAround.
+const (
+	querySourceManual   = "manual"
+	querySourceTemplate = "template"
+	querySourceOpenAI   = "openai"
+	querySourceFallback = "fallback"
+	querySourceCategory = "category"
+)
+
+// maxQueryHistory is how many queries the history keeps; older ones are dropped
+const maxQueryHistory = 500
+
+// defaultQueryHistoryLimit is how many entries GetQueryHistory returns when no limit is given
+const defaultQueryHistoryLimit = 50
+
+// maxRememberedQueries caps the generated queries kept to recognise them when they're run
+const maxRememberedQueries = 100
+
+// QueryHistoryEntry is one executed Overpass query. BBox is [west, south, east, north] when
+// known and Tiles is set for tiled queries. AIGenerated is set for queries written by OpenAI,
+// including ones the user ran unchanged after generating them.
+type QueryHistoryEntry struct {
+	ID           int       `json:"id"`
+	Query        string    `json:"query"`
+	Source       string    `json:"source"`
+	AIGenerated  bool      `json:"ai_generated"`
+	Description  string    `json:"description,omitempty"`
+	BBox         []float64 `json:"bbox,omitempty"`
+	Tiles        int       `json:"tiles,omitempty"`
+	ExecutedAt   string    `json:"executed_at"`
+	DurationMs   int64     `json:"duration_ms"`
+	Success      bool      `json:"success"`
+	FeatureCount int       `json:"feature_count"`
+	Error        string    `json:"error,omitempty"`
+}
+
+// generatedQuery is how a remembered query was generated
+type generatedQuery struct {
+	source      string
+	description string
+}
+
+// queryHistoryState remembers the queries generated recently, so running one after reviewing
+// it in the editor still counts as generated
+type queryHistoryState struct {
+	mu        sync.Mutex
+	generated map[string]generatedQuery
+}
+
+// rememberGeneratedQuery notes that query was generated from description
+func (a *App) rememberGeneratedQuery(query string, source string, description string) {
+	a.queries.mu.Lock()
+	defer a.queries.mu.Unlock()
+	if a.queries.generated == nil || len(a.queries.generated) >= maxRememberedQueries {
+		a.queries.generated = map[string]generatedQuery{}
+	}
+	a.queries.generated[strings.TrimSpace(query)] = generatedQuery{source, description}
+}
+
+// queryHistoryEntry works out where a query came from: a recently generated query, one of the
+// templates with its bbox filled in, or else the user's own
+func (a *App) queryHistoryEntry(query string) QueryHistoryEntry {
+	entry := QueryHistoryEntry{Query: query, Source: querySourceManual, BBox: overpassQueryBBox(query)}
+
+	a.queries.mu.Lock()
+	generated, ok := a.queries.generated[strings.TrimSpace(query)]
+	a.queries.mu.Unlock()
+	if ok {
+		entry.Source, entry.Description = generated.source, generated.description
+		return entry
+	}
+
+	normalized := strings.Join(strings.Fields(query), " ")
+	for _, template := range a.GetOverpassQueryTemplates() {
+		text, _ := template["query"].(string)
+		pattern := regexp.QuoteMeta(strings.Join(strings.Fields(text), " "))
+		pattern = strings.ReplaceAll(pattern, regexp.QuoteMeta("{{bbox}}"), `[^)]*`)
+		if matched, _ := regexp.MatchString("^"+pattern+"$", normalized); matched {
+			entry.Source = querySourceTemplate
+			entry.Description, _ = template["name"].(string)
+			break
+		}
+	}
+	return entry
+}
+
+// overpassQueryBBox reads the first literal bbox of a query as [west, south, east, north]
+func overpassQueryBBox(query string) []float64 {
+	match := overpassBBoxFilter.FindString(query)
+	if match == "" {
+		match = overpassGlobalBBox.FindString(query)
+	}
+	parts := strings.Split(strings.Trim(match, "()[]bbox: "), ",")
+	if len(parts) != 4 {
+		return nil
+	}
+	var values [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil
+		}
+		values[i] = value
+	}
+	// Overpass orders bboxes south, west, north, east
+	return []float64{values[1], values[0], values[3], values[2]}
+}
+
+// recordQuery adds an executed query to the history and drops the oldest entries beyond
+// maxQueryHistory. Failing to record never fails the query.
+func (a *App) recordQuery(entry QueryHistoryEntry, start time.Time, resp *OverpassResponse, queryErr error) {
+	if a.db == nil {
+		return
+	}
+	switch {
+	case queryErr != nil:
+		entry.Error = queryErr.Error()
+	case resp != nil && !resp.Success:
+		entry.Error = resp.Error
+	case resp != nil:
+		entry.Success = true
+		if features, ok := resp.Data["features"].([]interface{}); ok {
+			entry.FeatureCount = len(features)
+		} else if count, ok := resp.Metadata["feature_count"].(int); ok {
+			entry.FeatureCount = count
+		}
+	}
+	var bbox interface{}
+	if len(entry.BBox) == 4 {
+		data, _ := json.Marshal(entry.BBox)
+		bbox = string(data)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err := a.db.Exec(`
+		INSERT INTO query_history (query, source, description, bbox, tiles, executed_at, duration_ms,
+			success, feature_count, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Query, entry.Source, entry.Description, bbox, entry.Tiles, start.Format(time.RFC3339),
+		time.Since(start).Milliseconds(), entry.Success, entry.FeatureCount, entry.Error)
+	if err == nil {
+		_, err = a.db.Exec(`DELETE FROM query_history WHERE id NOT IN
+			(SELECT id FROM query_history ORDER BY id DESC LIMIT ?)`, maxQueryHistory)
+	}
+	if err != nil {
+		a.logWarn("Could not record query history: %v", err)
+	}
+}
+
+// GetQueryHistory returns the most recently executed Overpass queries, newest first. A limit of
+// 0 returns the last 50.
+func (a *App) GetQueryHistory(limit int) ([]QueryHistoryEntry, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if limit <= 0 {
+		limit = defaultQueryHistoryLimit
+	}
+	if limit > maxQueryHistory {
+		limit = maxQueryHistory
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	rows, err := a.db.Query("SELECT "+queryHistoryColumns+" FROM query_history ORDER BY id DESC LIMIT ?", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query history: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []QueryHistoryEntry{}
+	for rows.Next() {
+		entry, err := scanQueryHistoryEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read query history: %v", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read query history: %v", err)
+	}
+	return entries, nil
+}
+
+// ReplayQuery runs a query from the history again, tiled as before if it was, and records the
+// new run in the history
+func (a *App) ReplayQuery(historyID int) (*OverpassResponse, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	entry, err := scanQueryHistoryEntry(a.db.QueryRow("SELECT "+queryHistoryColumns+" FROM query_history WHERE id = ?", historyID))
+	a.mu.RUnlock()
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("query %d not found in history", historyID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query history: %v", err)
+	}
+
+	a.logInfo("Replaying query %d from history", historyID)
+	start := time.Now()
+	var resp *OverpassResponse
+	if entry.Tiles > 0 {
+		resp, err = a.queryOverpassTiled(entry.Query, entry.BBox, entry.Tiles)
+	} else {
+		resp, err = a.runOverpassQuery(context.Background(), entry.Query)
+	}
+	a.recordQuery(QueryHistoryEntry{Query: entry.Query, Source: entry.Source, Description: entry.Description,
+		BBox: entry.BBox, Tiles: entry.Tiles}, start, resp, err)
+	return resp, err
+}
+
+// ClearQueryHistory deletes every entry of the query history
+func (a *App) ClearQueryHistory() error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, err := a.db.Exec("DELETE FROM query_history"); err != nil {
+		return fmt.Errorf("failed to clear query history: %v", err)
+	}
+	return nil
+}
+
+// queryHistoryColumns is the column list scanned by scanQueryHistoryEntry
+const queryHistoryColumns = `id, query, source, description, bbox, tiles, executed_at, duration_ms,
+	success, feature_count, error`
+
+// scanQueryHistoryEntry scans one query_history row selected with queryHistoryColumns
+func scanQueryHistoryEntry(row rowScanner) (QueryHistoryEntry, error) {
+	var entry QueryHistoryEntry
+	var description, bbox, errorText sql.NullString
+	err := row.Scan(&entry.ID, &entry.Query, &entry.Source, &description, &bbox, &entry.Tiles,
+		&entry.ExecutedAt, &entry.DurationMs, &entry.Success, &entry.FeatureCount, &errorText)
+	if err != nil {
+		return entry, err
+	}
+	entry.AIGenerated = entry.Source == querySourceOpenAI
+	entry.Description = description.String
+	entry.Error = errorText.String
+	if bbox.Valid {
+		json.Unmarshal([]byte(bbox.String), &entry.BBox)
+	}
+	return entry, nil
+}
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxInProcessBandMathPixels is the largest raster ComputeBandMath evaluates itself; bigger
+	// ones need gdal_calc.py
+	maxInProcessBandMathPixels = 100_000_000
+	// bandMathNoData marks output pixels whose inputs were NoData or that divided by zero
+	bandMathNoData = -math.MaxFloat32
+)
+
+var enviByteOrderPattern = regexp.MustCompile(`(?m)^\s*byte order\s*=\s*(\d)`)
+
+// BandMathResult is the value range of a band math output. NoDataPixels counts pixels with NoData
+// inputs or a division by zero.
+type BandMathResult struct {
+	Path         string  `json:"path"`
+	Min          float64 `json:"min"`
+	Max          float64 `json:"max"`
+	ValidPixels  int64   `json:"valid_pixels"`
+	NoDataPixels int64   `json:"nodata_pixels"`
+	Engine       string  `json:"engine"`
+}
+
+// ComputeBandMath evaluates a per-pixel expression over the bands of a raster, such as
+// (B4-B3)/(B4+B3) for NDVI, and writes the result as a single-band Float32 GeoTIFF
+func (a *App) ComputeBandMath(srcPath, dstPath, expression string) error {
+	result, err := a.ComputeBandMathDetailed(srcPath, dstPath, expression)
+	if err != nil {
+		return err
+	}
+	a.logInfo("Computed %s from %s into %s, values %g to %g", expression, srcPath, result.Path, result.Min, result.Max)
+	return nil
+}
+
+// ComputeBandMathDetailed is ComputeBandMath returning the output's value range. Expressions use
+// B1, B2, ... for bands, numbers, + - * / and parentheses. Rasters up to
+// maxInProcessBandMathPixels are evaluated in-process; larger ones go through gdal_calc.py.
+func (a *App) ComputeBandMathDetailed(srcPath, dstPath, expression string) (*BandMathResult, error) {
+	srcPath, err := gdalInputPath(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	dstPath, err = geotiffOutputPath(srcPath, dstPath)
+	if err != nil {
+		return nil, err
+	}
+	expr, err := parseBandMath(expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression: %v", err)
+	}
+	info, err := a.readRasterInfo(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	bands := expr.bands(nil)
+	for _, band := range bands {
+		if band > len(info.Bands) {
+			return nil, fmt.Errorf("B%d does not exist; %s has %d bands", band, filepath.Base(srcPath), len(info.Bands))
+		}
+	}
+	if len(info.Size) != 2 || len(info.GeoTransform) != 6 {
+		return nil, fmt.Errorf("%s has no pixel grid", filepath.Base(srcPath))
+	}
+
+	if pixels := int64(info.Size[0]) * int64(info.Size[1]); pixels > maxInProcessBandMathPixels {
+		calc := findGDALBinary("gdal_calc.py")
+		if calc == "" {
+			return nil, fmt.Errorf("%s has %d pixels, too many to compute in-process; install gdal_calc.py (part of GDAL's Python utilities) for rasters this large",
+				filepath.Base(srcPath), pixels)
+		}
+		return a.bandMathWithGDALCalc(calc, srcPath, dstPath, expr, bands)
+	}
+	return a.bandMathInProcess(srcPath, dstPath, expr, bands, info)
+}
+
+// bandMathInProcess extracts the referenced bands as raw Float32 with gdal_translate, evaluates
+// the expression pixel by pixel and converts the result back to GeoTIFF through a raw VRT
+func (a *App) bandMathInProcess(srcPath, dstPath string, expr *bandMathNode, bands []int, info *gdalRasterInfo) (*BandMathResult, error) {
+	tmpDir, err := os.MkdirTemp("", "terrabox-bandmath-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Inputs are indexed by band number; unreferenced bands stay nil
+	inputs := make([]*enviBand, len(info.Bands)+1)
+	for _, band := range bands {
+		rawPath := filepath.Join(tmpDir, fmt.Sprintf("band%d.bin", band))
+		if _, err := a.runGDALTool("gdal_translate", "-q", "-of", "ENVI", "-ot", "Float32",
+			"-b", strconv.Itoa(band), srcPath, rawPath); err != nil {
+			return nil, err
+		}
+		input, err := openENVIBand(rawPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read band %d: %v", band, err)
+		}
+		defer input.Close()
+		if noData := gdalNoData(info.Bands[band-1].NoDataValue); noData != nil {
+			input.noData, input.hasNoData = float32(*noData), true
+		}
+		inputs[band] = input
+	}
+
+	outPath := filepath.Join(tmpDir, "result.bin")
+	out, err := os.Create(outPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	writer := bufio.NewWriter(out)
+
+	result := &BandMathResult{Path: dstPath, Min: math.Inf(1), Max: math.Inf(-1), Engine: "terrabox"}
+	values := make([]float64, len(inputs))
+	pixels := int64(info.Size[0]) * int64(info.Size[1])
+	var buf [4]byte
+	for i := int64(0); i < pixels; i++ {
+		valid := true
+		for _, band := range bands {
+			v, ok, err := inputs[band].next()
+			if err != nil {
+				out.Close()
+				return nil, fmt.Errorf("failed to read band %d: %v", band, err)
+			}
+			values[band] = v
+			valid = valid && ok
+		}
+
+		value := float64(bandMathNoData)
+		if valid {
+			// Division by zero gives NaN or ±Inf, which become NoData
+			if v := expr.eval(values); !math.IsNaN(v) && !math.IsInf(v, 0) && math.Abs(v) <= math.MaxFloat32 {
+				value = v
+			}
+		}
+		if value == bandMathNoData {
+			result.NoDataPixels++
+		} else {
+			result.ValidPixels++
+			result.Min, result.Max = math.Min(result.Min, value), math.Max(result.Max, value)
+		}
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(float32(value)))
+		if _, err := writer.Write(buf[:]); err != nil {
+			out.Close()
+			return nil, fmt.Errorf("failed to write result: %v", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		out.Close()
+		return nil, fmt.Errorf("failed to write result: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write result: %v", err)
+	}
+
+	vrtPath := filepath.Join(tmpDir, "result.vrt")
+	if err := os.WriteFile(vrtPath, []byte(rawFloat32VRT(info, "result.bin")), 0644); err != nil {
+		return nil, fmt.Errorf("failed to create VRT file: %v", err)
+	}
+	err = writeViaTempFile(dstPath, func(tmpPath string) error {
+		_, err := a.runGDALTool("gdal_translate", "-q", "-of", "GTiff", "-co", "COMPRESS=DEFLATE", "-co", "TILED=YES",
+			vrtPath, tmpPath)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.ValidPixels == 0 {
+		result.Min, result.Max = 0, 0
+	}
+	return result, nil
+}
+
+// bandMathWithGDALCalc runs the expression through gdal_calc.py, mapping bands to its A, B, ...
+// inputs, then reads the value range back with gdalinfo
+func (a *App) bandMathWithGDALCalc(calc, srcPath, dstPath string, expr *bandMathNode, bands []int) (*BandMathResult, error) {
+	if len(bands) > 26 {
+		return nil, fmt.Errorf("gdal_calc.py supports at most 26 bands in one expression")
+	}
+	letters := map[int]string{}
+	var args []string
+	for i, band := range bands {
+		letter := string(rune('A' + i))
+		letters[band] = letter
+		args = append(args, "-"+letter, srcPath, fmt.Sprintf("--%s_band=%d", letter, band))
+	}
+	noData := strconv.FormatFloat(bandMathNoData, 'g', -1, 64)
+
+	err := writeViaTempFile(dstPath, func(tmpPath string) error {
+		args = append(args, "--calc="+expr.python(letters, noData), "--outfile="+tmpPath, "--format=GTiff",
+			"--type=Float32", "--NoDataValue="+noData, "--co=COMPRESS=DEFLATE", "--co=TILED=YES", "--quiet")
+		_, stderr, err := a.execGDAL(context.Background(), calc, "", args...)
+		if err != nil {
+			return fmt.Errorf("gdal_calc.py failed: %w: %s", err, strings.TrimSpace(string(stderr)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := a.computeBandStats(dstPath)
+	if err != nil || len(stats) == 0 {
+		return nil, fmt.Errorf("failed to read the result's value range: %v", err)
+	}
+	result := &BandMathResult{Path: dstPath, Min: stats[0].Min, Max: stats[0].Max, Engine: "gdal_calc"}
+	a.logDebug("gdal_calc.py result is %.1f%% valid", stats[0].ValidPercent)
+	return result, nil
+}
+
+// rawFloat32VRT describes a little-endian Float32 file with the source raster's grid and CRS
+func rawFloat32VRT(info *gdalRasterInfo, rawName string) string {
+	gt := make([]string, len(info.GeoTransform))
+	for i, v := range info.GeoTransform {
+		gt[i] = strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprintf(`<VRTDataset rasterXSize="%d" rasterYSize="%d">
+    <SRS>%s</SRS>
+    <GeoTransform>%s</GeoTransform>
+    <VRTRasterBand dataType="Float32" band="1" subClass="VRTRawRasterBand">
+        <NoDataValue>%s</NoDataValue>
+        <SourceFilename relativeToVRT="1">%s</SourceFilename>
+        <ImageOffset>0</ImageOffset>
+        <PixelOffset>4</PixelOffset>
+        <LineOffset>%d</LineOffset>
+        <ByteOrder>LSB</ByteOrder>
+    </VRTRasterBand>
+</VRTDataset>`, info.Size[0], info.Size[1], xmlEscape(info.CoordinateSystem.WKT), strings.Join(gt, ", "),
+		strconv.FormatFloat(bandMathNoData, 'g', -1, 64), xmlEscape(rawName), info.Size[0]*4)
+}
+
+// enviBand reads Float32 pixels from a band written by gdal_translate -of ENVI
+type enviBand struct {
+	file      *os.File
+	reader    *bufio.Reader
+	order     binary.ByteOrder
+	noData    float32
+	hasNoData bool
+	buf       [4]byte
+}
+
+func openENVIBand(rawPath string) (*enviBand, error) {
+	header, err := os.ReadFile(strings.TrimSuffix(rawPath, filepath.Ext(rawPath)) + ".hdr")
+	if err != nil {
+		return nil, err
+	}
+	var order binary.ByteOrder = binary.LittleEndian
+	if m := enviByteOrderPattern.FindSubmatch(header); m != nil && string(m[1]) == "1" {
+		order = binary.BigEndian
+	}
+	file, err := os.Open(rawPath)
+	if err != nil {
+		return nil, err
+	}
+	return &enviBand{file: file, reader: bufio.NewReader(file), order: order}, nil
+}
+
+// next returns the next pixel and whether it holds data
+func (b *enviBand) next() (float64, bool, error) {
+	if _, err := io.ReadFull(b.reader, b.buf[:]); err != nil {
+		return 0, false, err
+	}
+	v := math.Float32frombits(b.order.Uint32(b.buf[:]))
+	if (b.hasNoData && v == b.noData) || v != v {
+		return 0, false, nil
+	}
+	return float64(v), true, nil
+}
+
+func (b *enviBand) Close() error {
+	return b.file.Close()
+}
+
+// bandMathNode is a parsed band math expression: a number, a band reference, a unary minus or
+// a binary operator
+type bandMathNode struct {
+	op          byte // 'n' number, 'b' band, 'u' negation, or + - * /
+	value       float64
+	band        int
+	left, right *bandMathNode
+}
+
+// bands lists the band numbers an expression refers to, in order of first use
+func (n *bandMathNode) bands(seen []int) []int {
+	switch n.op {
+	case 'n':
+	case 'b':
+		for _, band := range seen {
+			if band == n.band {
+				return seen
+			}
+		}
+		seen = append(seen, n.band)
+	case 'u':
+		seen = n.left.bands(seen)
+	default:
+		seen = n.right.bands(n.left.bands(seen))
+	}
+	return seen
+}
+
+// eval computes the expression for one pixel; values is indexed by band number
+func (n *bandMathNode) eval(values []float64) float64 {
+	switch n.op {
+	case 'n':
+		return n.value
+	case 'b':
+		return values[n.band]
+	case 'u':
+		return -n.left.eval(values)
+	case '+':
+		return n.left.eval(values) + n.right.eval(values)
+	case '-':
+		return n.left.eval(values) - n.right.eval(values)
+	case '*':
+		return n.left.eval(values) * n.right.eval(values)
+	}
+	divisor := n.right.eval(values)
+	if divisor == 0 {
+		return math.NaN()
+	}
+	return n.left.eval(values) / divisor
+}
+
+// python writes the expression for gdal_calc.py, guarding divisions so zero divisors give noData
+func (n *bandMathNode) python(letters map[int]string, noData string) string {
+	switch n.op {
+	case 'n':
+		return strconv.FormatFloat(n.value, 'g', -1, 64)
+	case 'b':
+		return letters[n.band] + ".astype('float64')"
+	case 'u':
+		return "(-" + n.left.python(letters, noData) + ")"
+	case '/':
+		divisor := n.right.python(letters, noData)
+		return fmt.Sprintf("where((%s)==0, %s, (%s)/where((%s)==0, 1, (%s)))",
+			divisor, noData, n.left.python(letters, noData), divisor, divisor)
+	}
+	return "(" + n.left.python(letters, noData) + string(n.op) + n.right.python(letters, noData) + ")"
+}
+
+// parseBandMath parses an expression of numbers, B1..Bn, + - * / and parentheses
+func parseBandMath(expression string) (*bandMathNode, error) {
+	p := &bandMathParser{input: strings.TrimSpace(expression)}
+	if p.input == "" {
+		return nil, fmt.Errorf("expression is empty")
+	}
+	node, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.input[p.pos], p.pos+1)
+	}
+	if len(node.bands(nil)) == 0 {
+		return nil, fmt.Errorf("expression does not use any band; refer to bands as B1, B2, ...")
+	}
+	return node, nil
+}
+
+type bandMathParser struct {
+	input string
+	pos   int
+}
+
+func (p *bandMathParser) skipSpaces() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *bandMathParser) peek() byte {
+	p.skipSpaces()
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *bandMathParser) expr() (*bandMathNode, error) {
+	left, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '+' || op == '-'; op = p.peek() {
+		p.pos++
+		right, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		left = &bandMathNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *bandMathParser) term() (*bandMathNode, error) {
+	left, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == '*' || op == '/'; op = p.peek() {
+		p.pos++
+		right, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		left = &bandMathNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *bandMathParser) factor() (*bandMathNode, error) {
+	switch c := p.peek(); {
+	case c == 0:
+		return nil, fmt.Errorf("expression ends unexpectedly")
+	case c == '-' || c == '+':
+		p.pos++
+		operand, err := p.factor()
+		if err != nil || c == '+' {
+			return operand, err
+		}
+		return &bandMathNode{op: 'u', left: operand}, nil
+	case c == '(':
+		p.pos++
+		node, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ) at position %d", p.pos+1)
+		}
+		p.pos++
+		return node, nil
+	case c == 'B' || c == 'b':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+			p.pos++
+		}
+		band, err := strconv.Atoi(p.input[start+1 : p.pos])
+		if err != nil || band < 1 {
+			return nil, fmt.Errorf("invalid band reference at position %d; use B1, B2, ...", start+1)
+		}
+		return &bandMathNode{op: 'b', band: band}, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		for p.pos < len(p.input) && (p.input[p.pos] == '.' || (p.input[p.pos] >= '0' && p.input[p.pos] <= '9')) {
+			p.pos++
+		}
+		value, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", p.input[start:p.pos])
+		}
+		return &bandMathNode{op: 'n', value: value}, nil
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", c, p.pos+1)
+	}
+}
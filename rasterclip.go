@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+)
+
+// ClipRasterOptions controls how ClipRasterWithOptions masks a raster. With CropToCutline the
+// output covers only the polygon's extent; otherwise it keeps the source grid. Pixels outside the
+// polygon become NoData, or the source's NoData when NoData is nil, or transparent through an
+// alpha band when the source has none.
+type ClipRasterOptions struct {
+	CropToCutline bool     `json:"crop_to_cutline"`
+	NoData        *float64 `json:"nodata,omitempty"`
+	ProgressID    string   `json:"progress_id,omitempty"`
+}
+
+// ClipRaster masks a raster to a polygon and crops it to the polygon's extent, writing a GeoTIFF
+func (a *App) ClipRaster(srcPath string, clipGeoJSON map[string]interface{}, dstPath string) error {
+	return a.ClipRasterWithOptions(srcPath, clipGeoJSON, dstPath, ClipRasterOptions{CropToCutline: true})
+}
+
+// ClipRasterWithOptions masks every band of a raster to the Polygon and MultiPolygon features of
+// clipGeoJSON with gdalwarp -cutline. The polygon is read as WGS84 unless it carries a legacy
+// "crs" member; gdalwarp reprojects it to the raster's CRS.
+func (a *App) ClipRasterWithOptions(srcPath string, clipGeoJSON map[string]interface{}, dstPath string, options ClipRasterOptions) error {
+	srcPath, err := gdalInputPath(srcPath)
+	if err != nil {
+		return err
+	}
+	dstPath, err = geotiffOutputPath(srcPath, dstPath)
+	if err != nil {
+		return err
+	}
+
+	cutline, bbox, err := clipPolygons(clipGeoJSON)
+	if err != nil {
+		return err
+	}
+	cutlineCRS := "EPSG:4326"
+	if name := legacyGeoJSONCRS(clipGeoJSON); name != "" {
+		cutline["crs"] = clipGeoJSON["crs"]
+		cutlineCRS = name
+	}
+
+	info, err := a.readRasterInfo(srcPath)
+	if err != nil {
+		return err
+	}
+	if rasterBBox, ok := info.bounds(); ok {
+		polygonBBox, err := a.bboxToWGS84(bbox, cutlineCRS)
+		if err != nil {
+			return fmt.Errorf("the clip polygon's coordinates don't fit its CRS %s: %v", cutlineCRS, err)
+		}
+		if polygonBBox[0] > rasterBBox[2] || polygonBBox[2] < rasterBBox[0] ||
+			polygonBBox[1] > rasterBBox[3] || polygonBBox[3] < rasterBBox[1] {
+			return fmt.Errorf("the clip polygon does not overlap %s", filepath.Base(srcPath))
+		}
+	}
+
+	tmpDir, err := os.MkdirTemp("", "terrabox-clip-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+	cutlinePath := filepath.Join(tmpDir, "cutline.geojson")
+	data, err := json.Marshal(cutline)
+	if err != nil {
+		return fmt.Errorf("failed to encode clip polygon: %v", err)
+	}
+	if err := os.WriteFile(cutlinePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write clip polygon: %v", err)
+	}
+
+	args := []string{"-of", "GTiff", "-co", "COMPRESS=DEFLATE", "-co", "TILED=YES", "-cutline", cutlinePath}
+	if options.CropToCutline {
+		args = append(args, "-crop_to_cutline")
+	}
+	switch {
+	case options.NoData != nil:
+		args = append(args, "-dstnodata", strconv.FormatFloat(*options.NoData, 'g', -1, 64))
+	case !rasterHasNoData(info):
+		// gdalwarp copies the source NoData; without one, mask through an alpha band
+		args = append(args, "-dstalpha")
+	}
+
+	err = writeViaTempFile(dstPath, func(tmpPath string) error {
+		args = append(args, srcPath, tmpPath)
+		if options.ProgressID != "" {
+			return a.runGDALToolWithProgress("gdalwarp", options.ProgressID, args...)
+		}
+		_, err := a.runGDALTool("gdalwarp", append([]string{"-q"}, args...)...)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	a.logInfo("Clipped %s (%d bands) to %s", srcPath, len(info.Bands), dstPath)
+	return nil
+}
+
+// clipPolygons collects the polygon features of a GeoJSON object into a FeatureCollection for
+// -cutline, with their combined bbox in the polygons' own coordinates
+func clipPolygons(geojson map[string]interface{}) (map[string]interface{}, []float64, error) {
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var polygons []interface{}
+	var bound orb.Bound
+	for _, feature := range features {
+		geometry, err := orbGeometry(feature["geometry"])
+		if err != nil {
+			return nil, nil, err
+		}
+		switch geometry.(type) {
+		case orb.Polygon, orb.MultiPolygon:
+		default:
+			continue
+		}
+		if len(polygons) == 0 {
+			bound = geometry.Bound()
+		} else {
+			bound = bound.Union(geometry.Bound())
+		}
+		polygons = append(polygons, map[string]interface{}{
+			"type":       "Feature",
+			"properties": map[string]interface{}{},
+			"geometry":   geometryToMap(geometry),
+		})
+	}
+	if len(polygons) == 0 {
+		return nil, nil, fmt.Errorf("the clip area must contain a Polygon or MultiPolygon")
+	}
+
+	cutline := map[string]interface{}{"type": "FeatureCollection", "features": polygons}
+	return cutline, []float64{bound.Min[0], bound.Min[1], bound.Max[0], bound.Max[1]}, nil
+}
+
+// legacyGeoJSONCRS returns the CRS named by a pre-RFC 7946 "crs" member, such as
+// urn:ogc:def:crs:EPSG::32633, or "" when there is none or it is plain WGS84
+func legacyGeoJSONCRS(geojson map[string]interface{}) string {
+	crs, _ := geojson["crs"].(map[string]interface{})
+	properties, _ := crs["properties"].(map[string]interface{})
+	name, _ := properties["name"].(string)
+	if name == "" || strings.HasSuffix(name, "CRS84") {
+		return ""
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	normalized, err := normalizeCRS(name)
+	if err != nil || normalized == "EPSG:4326" {
+		return ""
+	}
+	return normalized
+}
+
+// rasterHasNoData reports whether any band of a raster declares a NoData value
+func rasterHasNoData(info *gdalRasterInfo) bool {
+	for _, band := range info.Bands {
+		// NaN NoData is a string in gdalinfo output, so any value counts
+		if band.NoDataValue != nil {
+			return true
+		}
+	}
+	return false
+}
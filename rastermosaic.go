@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RasterTileGroup is a set of indexed rasters that share a CRS, pixel size and band count and
+// whose extents touch, so they can be mosaicked into one layer
+type RasterTileGroup struct {
+	Files      []string  `json:"files"`
+	CRS        string    `json:"crs"`
+	Resolution []float64 `json:"resolution"`
+	NumBands   int       `json:"num_bands"`
+	BBox       []float64 `json:"bbox"`
+}
+
+// rasterTile is the part of gdalinfo used to group tiles
+type rasterTile struct {
+	path  string
+	wkt   string
+	pixel []float64
+	bands int
+	bbox  []float64
+}
+
+// BuildRasterMosaic combines rasters into a GDAL virtual mosaic at dstVRTPath with gdalbuildvrt
+// and adds it to the index as a single raster. The inputs must share a CRS and band count.
+func (a *App) BuildRasterMosaic(filePaths []string, dstVRTPath string) error {
+	return a.buildRasterMosaicContext(context.Background(), filePaths, dstVRTPath)
+}
+
+// buildRasterMosaicContext is BuildRasterMosaic with its GDAL processes killed once ctx is cancelled
+func (a *App) buildRasterMosaicContext(ctx context.Context, filePaths []string, dstVRTPath string) error {
+	if len(filePaths) < 2 {
+		return fmt.Errorf("a mosaic needs at least two rasters")
+	}
+	if strings.ToLower(filepath.Ext(dstVRTPath)) != ".vrt" {
+		return fmt.Errorf("the mosaic must be saved as a .vrt file")
+	}
+	dstVRTPath, err := filepath.Abs(dstVRTPath)
+	if err != nil {
+		return fmt.Errorf("invalid output path: %v", err)
+	}
+
+	var first *rasterTile
+	inputs := make([]string, len(filePaths))
+	for i, filePath := range filePaths {
+		tile, err := a.readRasterTile(ctx, filePath)
+		if err != nil {
+			return err
+		}
+		if first == nil {
+			first = tile
+		} else if tile.wkt != first.wkt {
+			return fmt.Errorf("%s is in a different CRS from %s", filepath.Base(tile.path), filepath.Base(first.path))
+		} else if tile.bands != first.bands {
+			return fmt.Errorf("%s has %d bands but %s has %d", filepath.Base(tile.path), tile.bands,
+				filepath.Base(first.path), first.bands)
+		}
+		inputs[i] = tile.path
+	}
+
+	// A file list keeps large mosaics within the command-line length limit
+	list, err := os.CreateTemp("", "terrabox-mosaic-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(list.Name())
+	_, err = list.WriteString(strings.Join(inputs, "\n") + "\n")
+	if closeErr := list.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write file list: %v", err)
+	}
+
+	// Build next to the destination so source paths relative to the VRT stay valid after the rename
+	err = writeViaTempFile(dstVRTPath, func(tmpPath string) error {
+		_, err := a.runGDALToolContext(ctx, "gdalbuildvrt", "", "-input_file_list", list.Name(), tmpPath)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	a.logInfo("Built mosaic %s from %d rasters", dstVRTPath, len(inputs))
+
+	if err := a.indexFile(ctx, dstVRTPath); err != nil {
+		a.logWarn("Could not index mosaic %s: %v", dstVRTPath, err)
+	}
+	return nil
+}
+
+// FindRasterTileGroups looks through the indexed rasters for tiles of the same dataset: rasters
+// with the same CRS, pixel size and band count whose extents touch or overlap. Each group of two
+// or more is a candidate for BuildRasterMosaic.
+func (a *App) FindRasterTileGroups() ([]RasterTileGroup, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	rows, err := a.db.Query(`SELECT DISTINCT file_path FROM geo_file_index
+		WHERE file_type = 'raster' AND file_extension != '.vrt' ORDER BY file_path`)
+	if err != nil {
+		a.mu.RUnlock()
+		return nil, fmt.Errorf("failed to list rasters: %v", err)
+	}
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			rows.Close()
+			a.mu.RUnlock()
+			return nil, fmt.Errorf("failed to list rasters: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	rows.Close()
+	a.mu.RUnlock()
+
+	// Tiles can only join tiles with the same grid
+	grids := map[string][]*rasterTile{}
+	var keys []string
+	for _, path := range paths {
+		tile, err := a.readRasterTile(context.Background(), path)
+		if err != nil {
+			a.logDebug("Skipping %s when looking for tiles: %v", path, err)
+			continue
+		}
+		key := fmt.Sprintf("%s|%.9g|%.9g|%d", tile.wkt, tile.pixel[0], tile.pixel[1], tile.bands)
+		if _, ok := grids[key]; !ok {
+			keys = append(keys, key)
+		}
+		grids[key] = append(grids[key], tile)
+	}
+
+	groups := []RasterTileGroup{}
+	for _, key := range keys {
+		for _, tiles := range connectedTiles(grids[key]) {
+			if len(tiles) < 2 {
+				continue
+			}
+			group := RasterTileGroup{
+				CRS:        wktCRS(tiles[0].wkt),
+				Resolution: tiles[0].pixel,
+				NumBands:   tiles[0].bands,
+				BBox:       append([]float64(nil), tiles[0].bbox...),
+			}
+			for _, tile := range tiles {
+				group.Files = append(group.Files, tile.path)
+				group.BBox[0], group.BBox[1] = math.Min(group.BBox[0], tile.bbox[0]), math.Min(group.BBox[1], tile.bbox[1])
+				group.BBox[2], group.BBox[3] = math.Max(group.BBox[2], tile.bbox[2]), math.Max(group.BBox[3], tile.bbox[3])
+			}
+			sort.Strings(group.Files)
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// readRasterTile reads the grid of a georeferenced raster
+func (a *App) readRasterTile(ctx context.Context, filePath string) (*rasterTile, error) {
+	info, err := a.readRasterInfoContext(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	bbox, ok := info.nativeBounds()
+	if !ok || strings.TrimSpace(info.CoordinateSystem.WKT) == "" {
+		return nil, fmt.Errorf("%s is not georeferenced", filepath.Base(filePath))
+	}
+	path, _ := gdalInputPath(filePath)
+	return &rasterTile{
+		path:  path,
+		wkt:   info.CoordinateSystem.WKT,
+		pixel: []float64{math.Abs(info.GeoTransform[1]), math.Abs(info.GeoTransform[5])},
+		bands: len(info.Bands),
+		bbox:  bbox,
+	}, nil
+}
+
+// connectedTiles splits tiles on one grid into groups whose extents touch, allowing half a
+// pixel for rounding at the edges
+func connectedTiles(tiles []*rasterTile) [][]*rasterTile {
+	parent := make([]int, len(tiles))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range tiles {
+		for j := i + 1; j < len(tiles); j++ {
+			a, b := tiles[i].bbox, tiles[j].bbox
+			tolX, tolY := tiles[i].pixel[0]/2, tiles[i].pixel[1]/2
+			if a[0] <= b[2]+tolX && b[0] <= a[2]+tolX && a[1] <= b[3]+tolY && b[1] <= a[3]+tolY {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	byRoot := map[int][]*rasterTile{}
+	var roots []int
+	for i, tile := range tiles {
+		root := find(i)
+		if _, ok := byRoot[root]; !ok {
+			roots = append(roots, root)
+		}
+		byRoot[root] = append(byRoot[root], tile)
+	}
+	groups := make([][]*rasterTile, len(roots))
+	for i, root := range roots {
+		groups[i] = byRoot[root]
+	}
+	return groups
+}
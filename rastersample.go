@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// gdalRasterInfo is the subset of `gdalinfo -json` output used for sampling and rendering
+type gdalRasterInfo struct {
+	Files            []string       `json:"files"`
+	Size             []int          `json:"size"`
+	GeoTransform     []float64      `json:"geoTransform"`
+	Bands            []gdalInfoBand `json:"bands"`
+	CoordinateSystem struct {
+		WKT string `json:"wkt"`
+	} `json:"coordinateSystem"`
+	WGS84Extent *struct {
+		Coordinates [][][]float64 `json:"coordinates"`
+	} `json:"wgs84Extent"`
+}
+
+// bounds returns the raster's lon/lat extent as [minLon, minLat, maxLon, maxLat]
+func (info *gdalRasterInfo) bounds() ([]float64, bool) {
+	if info.WGS84Extent == nil || len(info.WGS84Extent.Coordinates) == 0 {
+		return nil, false
+	}
+	bbox := []float64{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)}
+	for _, p := range info.WGS84Extent.Coordinates[0] {
+		if len(p) < 2 {
+			continue
+		}
+		bbox[0], bbox[1] = math.Min(bbox[0], p[0]), math.Min(bbox[1], p[1])
+		bbox[2], bbox[3] = math.Max(bbox[2], p[0]), math.Max(bbox[3], p[1])
+	}
+	return bbox, !math.IsInf(bbox[0], 0)
+}
+
+// nativeBounds returns the extent in the raster's own CRS from its geotransform, ignoring rotation
+func (info *gdalRasterInfo) nativeBounds() ([]float64, bool) {
+	if len(info.GeoTransform) != 6 || len(info.Size) != 2 {
+		return nil, false
+	}
+	gt := info.GeoTransform
+	x0, y0 := gt[0], gt[3]
+	x1, y1 := gt[0]+gt[1]*float64(info.Size[0]), gt[3]+gt[5]*float64(info.Size[1])
+	return []float64{math.Min(x0, x1), math.Min(y0, y1), math.Max(x0, x1), math.Max(y0, y1)}, true
+}
+
+// contains reports whether a lon/lat position falls inside the raster's extent
+func (info *gdalRasterInfo) contains(lon, lat float64) bool {
+	bbox, ok := info.bounds()
+	if !ok {
+		// Unknown extent; let gdallocationinfo decide
+		return true
+	}
+	return lon >= bbox[0] && lon <= bbox[2] && lat >= bbox[1] && lat <= bbox[3]
+}
+
+// readRasterInfo runs gdalinfo -json on a raster
+func (a *App) readRasterInfo(filePath string) (*gdalRasterInfo, error) {
+	return a.readRasterInfoContext(context.Background(), filePath)
+}
+
+// readRasterInfoContext is readRasterInfo with gdalinfo killed once ctx is cancelled
+func (a *App) readRasterInfoContext(ctx context.Context, filePath string) (*gdalRasterInfo, error) {
+	filePath, err := gdalInputPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	output, err := a.runGDALToolContext(ctx, "gdalinfo", "", "-json", filePath)
+	if err != nil {
+		return nil, err
+	}
+	var info gdalRasterInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse gdalinfo output: %v", err)
+	}
+	if len(info.Bands) == 0 {
+		return nil, fmt.Errorf("%s has no raster bands", filePath)
+	}
+	return &info, nil
+}
+
+// SampleRasterValue returns the value of every band at a lon/lat position, e.g. the elevation of
+// a DEM. Bands whose pixel is NoData are returned as null.
+func (a *App) SampleRasterValue(filePath string, lon, lat float64) ([]*float64, error) {
+	filePath, err := gdalInputPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := a.readRasterInfo(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.contains(lon, lat) {
+		return nil, fmt.Errorf("%.6f, %.6f is outside the raster", lon, lat)
+	}
+
+	values, err := a.sampleRaster(filePath, info, [][]float64{{lon, lat}})
+	if err != nil {
+		return nil, err
+	}
+	if values[0] == nil {
+		return nil, fmt.Errorf("%.6f, %.6f is outside the raster", lon, lat)
+	}
+	return values[0], nil
+}
+
+// sampleRaster reads the band values at many lon/lat positions with one gdallocationinfo call.
+// Positions off the raster get a nil entry; NoData pixels get nil band values.
+func (a *App) sampleRaster(filePath string, info *gdalRasterInfo, positions [][]float64) ([][]*float64, error) {
+	var input strings.Builder
+	for _, p := range positions {
+		fmt.Fprintf(&input, "%.10f %.10f\n", p[0], p[1])
+	}
+
+	// gdallocationinfo exits non-zero when any position is off the raster but still prints an
+	// empty line per band for it, so the output is only rejected if it doesn't line up
+	output, runErr := a.runGDALToolInput("gdallocationinfo", input.String(), "-valonly", "-wgs84", filePath)
+
+	bands := len(info.Bands)
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	if len(lines) != len(positions)*bands {
+		if runErr != nil {
+			return nil, runErr
+		}
+		return nil, fmt.Errorf("gdallocationinfo returned %d values for %d positions", len(lines), len(positions))
+	}
+
+	samples := make([][]*float64, len(positions))
+	for i := range positions {
+		values := make([]*float64, bands)
+		off := true
+		for b := 0; b < bands; b++ {
+			text := strings.TrimSpace(lines[i*bands+b])
+			if text == "" {
+				continue
+			}
+			off = false
+			v, err := strconv.ParseFloat(text, 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) || isNoData(v, info.Bands[b]) {
+				continue
+			}
+			values[b] = &v
+		}
+		if !off {
+			samples[i] = values
+		}
+	}
+	return samples, nil
+}
+
+// isNoData compares a pixel value with the band's NoData value
+func isNoData(v float64, band gdalInfoBand) bool {
+	nodata := gdalNoData(band.NoDataValue)
+	if nodata == nil {
+		return false
+	}
+	// Values are printed as text, so compare with a tolerance relative to the magnitude
+	return math.Abs(v-*nodata) <= 1e-9*math.Max(1, math.Abs(*nodata))
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// BandStats summarizes one raster band, excluding NoData pixels
+type BandStats struct {
+	Band         int      `json:"band"`
+	DataType     string   `json:"data_type"`
+	ColorInterp  string   `json:"color_interpretation,omitempty"`
+	Min          float64  `json:"min"`
+	Max          float64  `json:"max"`
+	Mean         float64  `json:"mean"`
+	StdDev       float64  `json:"std_dev"`
+	NoData       *float64 `json:"nodata,omitempty"`
+	ValidPercent float64  `json:"valid_percent,omitempty"`
+	Histogram    []int64  `json:"histogram,omitempty"`
+	HistogramMin float64  `json:"histogram_min,omitempty"`
+	HistogramMax float64  `json:"histogram_max,omitempty"`
+}
+
+// bandStatsCache remembers GetRasterBandStats results per file until the file changes, whether or
+// not the file is indexed
+type bandStatsCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedBandStats
+}
+
+type cachedBandStats struct {
+	modTime time.Time
+	size    int64
+	stats   []BandStats
+}
+
+// gdalInfoBand is the subset of a `gdalinfo -json` band entry used here
+type gdalInfoBand struct {
+	Band                int                          `json:"band"`
+	Type                string                       `json:"type"`
+	ColorInterpretation string                       `json:"colorInterpretation"`
+	Minimum             *float64                     `json:"minimum"`
+	Maximum             *float64                     `json:"maximum"`
+	Mean                *float64                     `json:"mean"`
+	StdDev              *float64                     `json:"stdDev"`
+	NoDataValue         interface{}                  `json:"noDataValue"`
+	Metadata            map[string]map[string]string `json:"metadata"`
+	Histogram           *gdalHistogram               `json:"histogram"`
+}
+
+// gdalHistogram is the histogram gdalinfo -hist reports for a band
+type gdalHistogram struct {
+	Count   int     `json:"count"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Buckets []int64 `json:"buckets"`
+}
+
+// GetRasterBandStats returns min/max/mean/stddev and a histogram for every band of a raster.
+// NoData pixels are excluded. Results are cached in memory until the file changes, and in the
+// index metadata of indexed files so they survive a restart.
+func (a *App) GetRasterBandStats(filePath string) ([]BandStats, error) {
+	filePath, err := gdalInputPath(filePath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read raster: %v", err)
+	}
+
+	a.bandStats.mu.Lock()
+	cached, ok := a.bandStats.entries[filePath]
+	a.bandStats.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return append([]BandStats(nil), cached.stats...), nil
+	}
+
+	mtime := info.ModTime().Unix()
+	stats, ok := a.cachedBandStats(filePath, mtime)
+	if !ok {
+		if stats, err = a.computeBandStats(filePath); err != nil {
+			return nil, err
+		}
+		if err := a.storeBandStats(filePath, mtime, stats); err != nil {
+			a.logWarn("Could not cache band statistics for %s: %v", filePath, err)
+		}
+	}
+
+	a.bandStats.mu.Lock()
+	if a.bandStats.entries == nil {
+		a.bandStats.entries = map[string]cachedBandStats{}
+	}
+	a.bandStats.entries[filePath] = cachedBandStats{modTime: info.ModTime(), size: info.Size(), stats: stats}
+	a.bandStats.mu.Unlock()
+	return append([]BandStats(nil), stats...), nil
+}
+
+// computeBandStats runs gdalinfo with exact statistics and a histogram. PAM is disabled so
+// gdalinfo doesn't save them to a .aux.xml sidecar in the user's folder.
+func (a *App) computeBandStats(filePath string) ([]BandStats, error) {
+	output, err := a.runGDALTool("gdalinfo", "--config", "GDAL_PAM_ENABLED", "NO", "-json", "-stats", "-hist", filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Bands []gdalInfoBand `json:"bands"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse gdalinfo output: %v", err)
+	}
+
+	stats := make([]BandStats, 0, len(info.Bands))
+	for _, band := range info.Bands {
+		s := BandStats{
+			Band:        band.Band,
+			DataType:    band.Type,
+			ColorInterp: band.ColorInterpretation,
+			NoData:      gdalNoData(band.NoDataValue),
+		}
+		if band.Minimum != nil {
+			s.Min = *band.Minimum
+		}
+		if band.Maximum != nil {
+			s.Max = *band.Maximum
+		}
+		if band.Mean != nil {
+			s.Mean = *band.Mean
+		}
+		if band.StdDev != nil {
+			s.StdDev = *band.StdDev
+		}
+		if domain, ok := band.Metadata[""]; ok {
+			fmt.Sscanf(domain["STATISTICS_VALID_PERCENT"], "%g", &s.ValidPercent)
+		}
+		if band.Histogram != nil {
+			s.Histogram = band.Histogram.Buckets
+			s.HistogramMin = band.Histogram.Min
+			s.HistogramMax = band.Histogram.Max
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// gdalNoData reads a band's NoData value, which gdalinfo writes as a number or as "nan"/"inf"
+func gdalNoData(value interface{}) *float64 {
+	var v float64
+	switch n := value.(type) {
+	case float64:
+		v = n
+	case string:
+		switch n {
+		case "nan", "NaN":
+			v = math.NaN()
+		case "inf", "Infinity":
+			v = math.Inf(1)
+		case "-inf", "-Infinity":
+			v = math.Inf(-1)
+		default:
+			if _, err := fmt.Sscanf(n, "%g", &v); err != nil {
+				return nil
+			}
+		}
+	default:
+		return nil
+	}
+	// NaN and infinities can't be sent as JSON numbers
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+// cachedBandStats returns statistics stored in the index metadata if the file hasn't changed since
+func (a *App) cachedBandStats(filePath string, mtime int64) ([]BandStats, bool) {
+	metadata, ok := a.indexedMetadata(filePath)
+	if !ok {
+		return nil, false
+	}
+	cachedAt, _ := metadata["band_stats_mtime"].(float64)
+	if int64(cachedAt) != mtime {
+		return nil, false
+	}
+
+	data, err := json.Marshal(metadata["band_stats"])
+	if err != nil {
+		return nil, false
+	}
+	var stats []BandStats
+	if err := json.Unmarshal(data, &stats); err != nil || len(stats) == 0 {
+		return nil, false
+	}
+	return stats, true
+}
+
+// storeBandStats saves statistics into the index metadata of an indexed raster
+func (a *App) storeBandStats(filePath string, mtime int64, stats []BandStats) error {
+	return a.updateIndexedMetadata(filePath, func(metadata map[string]interface{}) {
+		metadata["band_stats"] = stats
+		metadata["band_stats_mtime"] = mtime
+	})
+}
+
+// indexedMetadata decodes the metadata JSON of an indexed file
+func (a *App) indexedMetadata(filePath string) (map[string]interface{}, bool) {
+	if a.db == nil {
+		return nil, false
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var raw string
+	if err := a.db.QueryRow("SELECT COALESCE(metadata, '') FROM geo_file_index WHERE file_path = ? LIMIT 1", filePath).Scan(&raw); err != nil {
+		return nil, false
+	}
+	metadata := map[string]interface{}{}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			return nil, false
+		}
+	}
+	return metadata, true
+}
+
+// updateIndexedMetadata applies fn to the metadata JSON of every index row for filePath.
+// Files that aren't indexed are left alone.
+func (a *App) updateIndexedMetadata(filePath string, fn func(metadata map[string]interface{})) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rows, err := a.db.Query("SELECT id, COALESCE(metadata, '') FROM geo_file_index WHERE file_path = ?", filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read file metadata: %v", err)
+	}
+	updates := map[int]string{}
+	for rows.Next() {
+		var id int
+		var raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read file metadata: %v", err)
+		}
+		metadata := map[string]interface{}{}
+		if raw != "" {
+			json.Unmarshal([]byte(raw), &metadata)
+		}
+		fn(metadata)
+		data, err := json.Marshal(metadata)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to encode file metadata: %v", err)
+		}
+		updates[id] = string(data)
+	}
+	rows.Close()
+
+	for id, data := range updates {
+		if _, err := a.db.Exec("UPDATE geo_file_index SET metadata = ? WHERE id = ?", data, id); err != nil {
+			return fmt.Errorf("failed to update file metadata: %v", err)
+		}
+	}
+	return nil
+}
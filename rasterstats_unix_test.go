@@ -0,0 +1,66 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGetRasterBandStatsCache(t *testing.T) {
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	// ogrinfo answers the version probe; gdalinfo logs its arguments and reports one band
+	tools := map[string]string{
+		"ogrinfo": "#!/bin/sh\necho 'GDAL 3.8.4, released 2024/02/08'\n",
+		"ogr2ogr": "#!/bin/sh\nexit 0\n",
+		"gdalinfo": "#!/bin/sh\necho \"$@\" >> " + calls + "\n" +
+			`echo '{"bands":[{"band":1,"type":"Byte","minimum":1,"maximum":9,"mean":5,"stdDev":2}]}'` + "\n",
+	}
+	for name, script := range tools {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// The raster isn't indexed, so only the in-memory cache can answer repeat calls
+	raster := filepath.Join(dir, "dem.tif")
+	if err := os.WriteFile(raster, []byte("tiff"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a := NewApp()
+	for i := 0; i < 2; i++ {
+		stats, err := a.GetRasterBandStats(raster)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stats) != 1 || stats[0].Max != 9 {
+			t.Fatalf("stats = %+v, want one band with max 9", stats)
+		}
+	}
+	// Changing the file invalidates its entry
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(raster, later, later)
+	if _, err := a.GetRasterBandStats(raster); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(runs) != 2 {
+		t.Errorf("gdalinfo ran %d times, want 2: %q", len(runs), runs)
+	}
+	for _, run := range runs {
+		if !strings.Contains(run, "GDAL_PAM_ENABLED NO") {
+			t.Errorf("gdalinfo %s: want PAM disabled so no .aux.xml is written", run)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// warpResamplingMethods are the gdalwarp -r methods WarpRaster accepts
+var warpResamplingMethods = map[string]bool{"nearest": true, "bilinear": true, "cubic": true, "average": true}
+
+// WarpResult describes a raster written by WarpRasterDetailed
+type WarpResult struct {
+	Path       string    `json:"path"`
+	CRS        string    `json:"crs"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	NumBands   int       `json:"num_bands"`
+	Resolution []float64 `json:"resolution"`
+	BBox       []float64 `json:"bbox"`
+}
+
+// WarpRaster reprojects a raster to targetCRS and resamples it to resolution (in the target CRS's
+// units; 0 lets GDAL choose) with nearest, bilinear, cubic or average resampling
+func (a *App) WarpRaster(srcPath, dstPath, targetCRS string, resolution float64, resampling string) error {
+	result, err := a.WarpRasterDetailed(srcPath, dstPath, targetCRS, resolution, resampling)
+	if err != nil {
+		return err
+	}
+	a.logInfo("Warped %s to %s: %dx%d pixels in %s", srcPath, result.Path, result.Width, result.Height, result.CRS)
+	return nil
+}
+
+// WarpRasterDetailed is WarpRaster returning the output's dimensions, pixel size and extent
+func (a *App) WarpRasterDetailed(srcPath, dstPath, targetCRS string, resolution float64, resampling string) (*WarpResult, error) {
+	srcPath, err := gdalInputPath(srcPath)
+	if err != nil {
+		return nil, err
+	}
+	dstPath, err = geotiffOutputPath(srcPath, dstPath)
+	if err != nil {
+		return nil, err
+	}
+
+	resampling = strings.ToLower(strings.TrimSpace(resampling))
+	if resampling == "" {
+		resampling = "nearest"
+	}
+	if !warpResamplingMethods[resampling] {
+		return nil, fmt.Errorf("unsupported resampling method %q; use nearest, bilinear, cubic or average", resampling)
+	}
+
+	crs, err := normalizeCRS(targetCRS)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := epsgCode(crs); !ok {
+		return nil, fmt.Errorf("target CRS must be an EPSG code such as EPSG:32633, got %q", targetCRS)
+	}
+	info, err := a.GetCRSInfo(crs)
+	if err != nil && findProjDB() != "" {
+		// Without proj.db only the built-in list is known, so other codes are left to GDAL
+		return nil, err
+	}
+
+	if resolution < 0 {
+		return nil, fmt.Errorf("resolution must be positive")
+	}
+	if info != nil && info.Unit == "degree" && resolution > 1 {
+		return nil, fmt.Errorf("%s is in degrees; a resolution of %g degrees is probably meant as metres", crs, resolution)
+	}
+
+	args := []string{"-q", "-of", "GTiff", "-co", "COMPRESS=DEFLATE", "-co", "TILED=YES",
+		"-t_srs", crs, "-r", resampling}
+	if resolution > 0 {
+		value := strconv.FormatFloat(resolution, 'f', -1, 64)
+		args = append(args, "-tr", value, value)
+	}
+	err = writeViaTempFile(dstPath, func(tmpPath string) error {
+		_, err := a.runGDALTool("gdalwarp", append(args, srcPath, tmpPath)...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := a.readRasterInfo(dstPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read warped raster: %v", err)
+	}
+	result := &WarpResult{Path: dstPath, CRS: crs, NumBands: len(output.Bands)}
+	if len(output.Size) == 2 {
+		result.Width, result.Height = output.Size[0], output.Size[1]
+	}
+	if len(output.GeoTransform) == 6 {
+		result.Resolution = []float64{output.GeoTransform[1], -output.GeoTransform[5]}
+	}
+	if bbox, ok := output.nativeBounds(); ok {
+		result.BBox = bbox
+	}
+	return result, nil
+}
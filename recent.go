@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Kinds of entries in recent_items
+const (
+	recentFile      = "file"
+	recentDirectory = "directory"
+)
+
+// maxRecentItems is how many files and how many directories are remembered
+const maxRecentItems = 50
+
+// RecentItem is a recently opened file or indexed directory
+type RecentItem struct {
+	Path   string `json:"path"`
+	Name   string `json:"name"`
+	UsedAt string `json:"used_at"`
+}
+
+// recordRecentItem moves path to the top of its recent list, dropping the oldest entries beyond
+// maxRecentItems. Failures are only logged since the list is a convenience.
+func (a *App) recordRecentItem(path string, kind string) {
+	if a.db == nil {
+		return
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, err := a.db.Exec(`
+		INSERT INTO recent_items (path, kind, used_at) VALUES (?, ?, ?)
+		ON CONFLICT(path, kind) DO UPDATE SET used_at = excluded.used_at`,
+		path, kind, time.Now().UnixNano())
+	if err == nil {
+		_, err = a.db.Exec(`
+			DELETE FROM recent_items WHERE kind = ? AND path NOT IN (
+				SELECT path FROM recent_items WHERE kind = ? ORDER BY used_at DESC LIMIT ?)`,
+			kind, kind, maxRecentItems)
+	}
+	if err != nil {
+		a.logWarn("Failed to record recent %s %s: %v", kind, path, err)
+	}
+}
+
+// GetRecentFiles returns recently loaded files, newest first
+func (a *App) GetRecentFiles(limit int) ([]RecentItem, error) {
+	return a.recentItems(recentFile, limit)
+}
+
+// GetRecentDirectories returns recently indexed directories, newest first
+func (a *App) GetRecentDirectories(limit int) ([]RecentItem, error) {
+	return a.recentItems(recentDirectory, limit)
+}
+
+// recentItems lists one kind of recent item, removing entries whose path no longer exists
+func (a *App) recentItems(kind string, limit int) ([]RecentItem, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+	if limit <= 0 || limit > maxRecentItems {
+		limit = maxRecentItems
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rows, err := a.db.Query("SELECT path, used_at FROM recent_items WHERE kind = ? ORDER BY used_at DESC", kind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recent items: %v", err)
+	}
+
+	items := []RecentItem{}
+	var missing []string
+	for rows.Next() {
+		var path string
+		var usedAt int64
+		if err := rows.Scan(&path, &usedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read recent items: %v", err)
+		}
+		if _, err := os.Stat(path); err != nil {
+			missing = append(missing, path)
+			continue
+		}
+		if len(items) < limit {
+			items = append(items, RecentItem{
+				Path:   path,
+				Name:   filepath.Base(path),
+				UsedAt: time.Unix(0, usedAt).Format(time.RFC3339),
+			})
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recent items: %v", err)
+	}
+
+	for _, path := range missing {
+		if _, err := a.db.Exec("DELETE FROM recent_items WHERE path = ? AND kind = ?", path, kind); err != nil {
+			a.logWarn("Failed to prune recent item %s: %v", path, err)
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// resolutionUnit is how metadata reports a CRS axis unit, with the unit's length in meters
+type resolutionUnit struct {
+	name   string
+	meters float64
+}
+
+// resolutionUnits maps proj.db and WKT unit names to resolution units; degrees have no fixed
+// length
+var resolutionUnits = map[string]resolutionUnit{
+	"metre":          {"meters", 1},
+	"meter":          {"meters", 1},
+	"kilometre":      {"kilometers", 1000},
+	"foot":           {"feet", 0.3048},
+	"us survey foot": {"us_survey_feet", 1200.0 / 3937},
+	"degree":         {"degrees", 0},
+}
+
+var (
+	wktLengthUnitPattern = regexp.MustCompile(`LENGTHUNIT\["([^"]+)"`)
+	wktUnitPattern       = regexp.MustCompile(`UNIT\["([^"]+)"`)
+)
+
+// storeResolutionUnit records the unit of metadata.Resolution, which is in CRS units, as
+// resolution_unit, and the resolution in meters as resolution_meters. For geographic CRSs the
+// meters are the east-west pixel size at the dataset's centroid.
+func (a *App) storeResolutionUnit(metadata *FileMetadata) {
+	if metadata.Resolution <= 0 {
+		return
+	}
+	unit, ok := resolutionUnits[strings.ToLower(a.crsUnit(metadata.CRS))]
+	if !ok {
+		return
+	}
+	metadata.Metadata["resolution_unit"] = unit.name
+	if unit.meters > 0 {
+		metadata.Metadata["resolution_meters"] = metadata.Resolution * unit.meters
+		return
+	}
+
+	bbox := metadata.BBox
+	if isDefaultBBox(bbox) {
+		bbox, _ = metadata.Metadata["native_bbox"].([]float64)
+	}
+	if !validBBox(bbox) {
+		return
+	}
+	lat := (bbox[1] + bbox[3]) / 2
+	metadata.Metadata["resolution_meters"] = metadata.Resolution * math.Pi / 180 * earthRadiusMeters * math.Cos(lat*math.Pi/180)
+}
+
+// crsUnit returns the axis unit name of a CRS code or WKT, or "" when it can't be told
+func (a *App) crsUnit(crs string) string {
+	crs = strings.TrimSpace(crs)
+	if crs == "" {
+		return ""
+	}
+	if !strings.Contains(crs, "[") {
+		if info, err := a.GetCRSInfo(crs); err == nil {
+			return info.Unit
+		}
+		return ""
+	}
+	if isGeographicWKT(crs) {
+		return "degree"
+	}
+	// WKT2 names the axis unit LENGTHUNIT; in WKT1 the projected CRS's UNIT comes after its GEOGCS
+	if match := wktLengthUnitPattern.FindStringSubmatch(crs); match != nil {
+		return match[1]
+	}
+	if matches := wktUnitPattern.FindAllStringSubmatch(crs, -1); len(matches) > 0 {
+		return matches[len(matches)-1][1]
+	}
+	return ""
+}
+
+// indexedResolutionUnit reads resolution_unit and resolution_meters back from an index row's
+// metadata JSON
+func indexedResolutionUnit(metadataJSON string) (string, *float64) {
+	var metadata struct {
+		ResolutionUnit   string   `json:"resolution_unit"`
+		ResolutionMeters *float64 `json:"resolution_meters"`
+	}
+	if metadataJSON == "" || json.Unmarshal([]byte(metadataJSON), &metadata) != nil {
+		return "", nil
+	}
+	return metadata.ResolutionUnit, metadata.ResolutionMeters
+}
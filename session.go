@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// sessionSettingKey is the settings row holding the last saved session
+const sessionSettingKey = "session"
+
+// currentSessionVersion is bumped whenever Session gains fields that need migrating
+const currentSessionVersion = 1
+
+// Session is the map view and layer state restored when the app starts
+type Session struct {
+	Version       int            `json:"version"`
+	Center        []float64      `json:"center"` // [lon, lat]
+	Zoom          float64        `json:"zoom"`
+	Layers        []SessionLayer `json:"layers"`
+	OverpassQuery string         `json:"overpass_query"`
+	SavedAt       string         `json:"saved_at"`
+}
+
+// SessionLayer is an open layer, referenced by its geo_file_index ID
+type SessionLayer struct {
+	IndexID  int    `json:"index_id"`
+	FilePath string `json:"file_path"`
+	Visible  bool   `json:"visible"`
+	Order    int    `json:"order"`
+}
+
+// SaveSession stores the current map view, open layers and Overpass query
+func (a *App) SaveSession(session Session) error {
+	session.Version = currentSessionVersion
+	session.SavedAt = time.Now().Format(time.RFC3339)
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %v", err)
+	}
+	return a.setSetting(sessionSettingKey, string(data))
+}
+
+// LoadSession returns the last saved session, or an empty one if nothing has been saved yet
+func (a *App) LoadSession() (Session, error) {
+	empty := Session{Version: currentSessionVersion, Layers: []SessionLayer{}}
+
+	value, found, err := a.getSetting(sessionSettingKey)
+	if err != nil {
+		return empty, err
+	}
+	if !found {
+		return empty, nil
+	}
+
+	// Unknown fields from newer versions are ignored, missing ones keep their zero value
+	var session Session
+	if err := json.Unmarshal([]byte(value), &session); err != nil {
+		return empty, fmt.Errorf("failed to decode session: %v", err)
+	}
+
+	return migrateSession(session), nil
+}
+
+// migrateSession upgrades a session saved by an older version of the app
+func migrateSession(session Session) Session {
+	// Sessions saved before versioning have no version field
+	if session.Version == 0 {
+		session.Version = 1
+	}
+
+	if session.Layers == nil {
+		session.Layers = []SessionLayer{}
+	}
+
+	return session
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// getSetting reads a value from the settings table. found is false when the key has never been set.
+func (a *App) getSetting(key string) (value string, found bool, err error) {
+	if a.db == nil {
+		return "", false, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	err = a.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read setting %s: %v", key, err)
+	}
+	return value, true, nil
+}
+
+// setSetting inserts or replaces a value in the settings table
+func (a *App) setSetting(key string, value string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	query := `
+		INSERT INTO settings (key, value, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`
+	if _, err := a.db.Exec(query, key, value, time.Now().Format(time.RFC3339)); err != nil {
+		return fmt.Errorf("failed to save setting %s: %v", key, err)
+	}
+	return nil
+}
+
+// reloadSettings drops every setting cached in memory after the settings table is replaced, so
+// each is read again on next use, and applies the stored log level
+func (a *App) reloadSettings() {
+	a.extensions.mu.Lock()
+	a.extensions.list, a.extensions.loaded = nil, false
+	a.extensions.mu.Unlock()
+
+	// Processes holding a slot release it into the channel they took it from
+	a.gdalSlotsMu.Lock()
+	a.gdalSlotsChan, a.gdalTimeoutValue = nil, 0
+	a.gdalSlotsMu.Unlock()
+
+	a.resetHTTPClient()
+
+	level := a.storedLogLevel()
+	a.logger.mu.Lock()
+	a.logger.level = level
+	a.logger.mu.Unlock()
+}
@@ -0,0 +1,574 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Shapefile shape type codes from the ESRI Shapefile Technical Description
+const (
+	shpNull        = 0
+	shpPoint       = 1
+	shpPolyLine    = 3
+	shpPolygon     = 5
+	shpMultiPoint  = 8
+	shpPointZ      = 11
+	shpPolyLineZ   = 13
+	shpPolygonZ    = 15
+	shpMultiPointZ = 18
+	shpPointM      = 21
+	shpPolyLineM   = 23
+	shpPolygonM    = 25
+	shpMultiPointM = 28
+)
+
+// shpHeader holds the fields of the 100-byte .shp main file header we care about
+type shpHeader struct {
+	ShapeType int
+	BBox      [4]float64 // [minX, minY, maxX, maxY]
+}
+
+// dbfField describes a single column of a dBASE attribute table
+type dbfField struct {
+	Name     string
+	Type     byte
+	Length   int
+	Decimals int
+}
+
+// shapeTypeName maps a shapefile shape type code to its GeoJSON geometry type
+func shapeTypeName(shapeType int) string {
+	switch shapeType {
+	case shpPoint, shpPointZ, shpPointM:
+		return "Point"
+	case shpPolyLine, shpPolyLineZ, shpPolyLineM:
+		return "LineString"
+	case shpPolygon, shpPolygonZ, shpPolygonM:
+		return "Polygon"
+	case shpMultiPoint, shpMultiPointZ, shpMultiPointM:
+		return "MultiPoint"
+	}
+	return ""
+}
+
+// shapeTypeHasZ reports whether records of this type carry a Z array
+func shapeTypeHasZ(shapeType int) bool {
+	return shapeType == shpPointZ || shapeType == shpPolyLineZ || shapeType == shpPolygonZ || shapeType == shpMultiPointZ
+}
+
+// readShapefileHeader parses the main header of a .shp file
+func readShapefileHeader(data []byte) (*shpHeader, error) {
+	if len(data) < 100 {
+		return nil, fmt.Errorf("file too small to be a shapefile")
+	}
+	if binary.BigEndian.Uint32(data[0:4]) != 9994 {
+		return nil, fmt.Errorf("invalid shapefile file code")
+	}
+
+	header := &shpHeader{
+		ShapeType: int(binary.LittleEndian.Uint32(data[32:36])),
+	}
+	for i := 0; i < 4; i++ {
+		header.BBox[i] = readFloat64LE(data[36+i*8:])
+	}
+	return header, nil
+}
+
+// readShapefile reads a shapefile (.shp + .dbf + .prj) and converts it to a GeoJSON FeatureCollection
+// without using GDAL. Point, PolyLine, Polygon and MultiPoint shapes (including Z/M variants) are supported.
+func readShapefile(filePath string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shapefile: %v", err)
+	}
+
+	header, err := readShapefileHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attributes are optional - a shapefile without a .dbf still has geometry
+	var fields []dbfField
+	var records [][]interface{}
+	if dbfPath := shapefileSidecar(filePath, ".dbf"); dbfPath != "" {
+		fields, records, err = readDBF(dbfPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	features := []interface{}{}
+	offset := 100
+	index := 0
+	for offset+8 <= len(data) {
+		contentLength := int(binary.BigEndian.Uint32(data[offset+4:offset+8])) * 2
+		start := offset + 8
+		end := start + contentLength
+		if contentLength < 4 || end > len(data) {
+			break
+		}
+		offset = end
+
+		geometry, err := parseShapeRecord(data[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse shape record %d: %v", index+1, err)
+		}
+
+		properties := map[string]interface{}{}
+		if index < len(records) {
+			for i, field := range fields {
+				properties[field.Name] = records[index][i]
+			}
+		}
+		index++
+
+		feature := map[string]interface{}{
+			"type":       "Feature",
+			"properties": properties,
+			"geometry":   nil,
+		}
+		if geometry != nil {
+			feature["geometry"] = geometry
+		}
+		features = append(features, feature)
+	}
+
+	result := map[string]interface{}{
+		"type":     "FeatureCollection",
+		"name":     strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)),
+		"features": features,
+		"bbox":     []float64{header.BBox[0], header.BBox[1], header.BBox[2], header.BBox[3]},
+	}
+
+	if crs := readShapefileCRS(filePath); crs != "" {
+		result["crs"] = map[string]interface{}{
+			"type":       "name",
+			"properties": map[string]interface{}{"name": crs},
+		}
+	}
+
+	return result, nil
+}
+
+// parseShapeRecord decodes a single record's content into a GeoJSON geometry
+func parseShapeRecord(content []byte) (map[string]interface{}, error) {
+	shapeType := int(binary.LittleEndian.Uint32(content[0:4]))
+	body := content[4:]
+	hasZ := shapeTypeHasZ(shapeType)
+
+	switch shapeType {
+	case shpNull:
+		return nil, nil
+
+	case shpPoint, shpPointZ, shpPointM:
+		if len(body) < 16 {
+			return nil, fmt.Errorf("truncated point")
+		}
+		coord := []float64{readFloat64LE(body[0:]), readFloat64LE(body[8:])}
+		if hasZ && len(body) >= 24 {
+			coord = append(coord, readFloat64LE(body[16:]))
+		}
+		return map[string]interface{}{"type": "Point", "coordinates": coord}, nil
+
+	case shpMultiPoint, shpMultiPointZ, shpMultiPointM:
+		if len(body) < 36 {
+			return nil, fmt.Errorf("truncated multipoint")
+		}
+		numPoints := int(binary.LittleEndian.Uint32(body[32:36]))
+		points, err := readShapePoints(body, 36, numPoints, hasZ)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "MultiPoint", "coordinates": points}, nil
+
+	case shpPolyLine, shpPolyLineZ, shpPolyLineM, shpPolygon, shpPolygonZ, shpPolygonM:
+		if len(body) < 40 {
+			return nil, fmt.Errorf("truncated shape")
+		}
+		numParts := int(binary.LittleEndian.Uint32(body[32:36]))
+		numPoints := int(binary.LittleEndian.Uint32(body[36:40]))
+		partsEnd := 40 + numParts*4
+		if numParts < 0 || partsEnd > len(body) {
+			return nil, fmt.Errorf("invalid part count %d", numParts)
+		}
+
+		points, err := readShapePoints(body, partsEnd, numPoints, hasZ)
+		if err != nil {
+			return nil, err
+		}
+
+		// Split the flat point list into parts
+		parts := make([][][]float64, 0, numParts)
+		for i := 0; i < numParts; i++ {
+			from := int(binary.LittleEndian.Uint32(body[40+i*4:]))
+			to := numPoints
+			if i+1 < numParts {
+				to = int(binary.LittleEndian.Uint32(body[40+(i+1)*4:]))
+			}
+			if from < 0 || to > numPoints || from > to {
+				return nil, fmt.Errorf("invalid part offsets")
+			}
+			parts = append(parts, points[from:to])
+		}
+
+		if shapeTypeName(shapeType) == "LineString" {
+			if len(parts) == 1 {
+				return map[string]interface{}{"type": "LineString", "coordinates": parts[0]}, nil
+			}
+			return map[string]interface{}{"type": "MultiLineString", "coordinates": parts}, nil
+		}
+		return assembleShapePolygon(parts), nil
+	}
+
+	return nil, fmt.Errorf("unsupported shape type %d", shapeType)
+}
+
+// readShapePoints reads numPoints XY pairs starting at offset, followed by the optional Z array
+func readShapePoints(body []byte, offset int, numPoints int, hasZ bool) ([][]float64, error) {
+	if numPoints < 0 || offset+numPoints*16 > len(body) {
+		return nil, fmt.Errorf("invalid point count %d", numPoints)
+	}
+
+	points := make([][]float64, numPoints)
+	for i := 0; i < numPoints; i++ {
+		p := body[offset+i*16:]
+		points[i] = []float64{readFloat64LE(p[0:]), readFloat64LE(p[8:])}
+	}
+
+	// Z values follow the XY array after a 16-byte Z range
+	zStart := offset + numPoints*16 + 16
+	if hasZ && zStart+numPoints*8 <= len(body) {
+		for i := 0; i < numPoints; i++ {
+			points[i] = append(points[i], readFloat64LE(body[zStart+i*8:]))
+		}
+	}
+
+	return points, nil
+}
+
+// assembleShapePolygon groups shapefile rings into polygons. Shapefile outer rings are clockwise
+// and holes are counter-clockwise; each hole is attached to the outer ring that contains it.
+func assembleShapePolygon(rings [][][]float64) map[string]interface{} {
+	var outers [][][][]float64
+	var holes [][][]float64
+
+	for _, ring := range rings {
+		if len(ring) < 4 {
+			continue
+		}
+		if ringSignedArea(ring) <= 0 {
+			outers = append(outers, [][][]float64{ring})
+		} else {
+			holes = append(holes, ring)
+		}
+	}
+
+	for _, hole := range holes {
+		attached := false
+		for i := range outers {
+			if pointInRing(hole[0], outers[i][0]) {
+				outers[i] = append(outers[i], hole)
+				attached = true
+				break
+			}
+		}
+		// An orphan hole is most likely a mis-oriented outer ring
+		if !attached {
+			outers = append(outers, [][][]float64{hole})
+		}
+	}
+
+	// Every ring was degenerate, so the record has no geometry
+	if len(outers) == 0 {
+		return nil
+	}
+	if len(outers) == 1 {
+		return map[string]interface{}{"type": "Polygon", "coordinates": outers[0]}
+	}
+	return map[string]interface{}{"type": "MultiPolygon", "coordinates": outers}
+}
+
+// ringSignedArea returns the shoelace signed area of a ring (positive when counter-clockwise)
+func ringSignedArea(ring [][]float64) float64 {
+	area := 0.0
+	for i := 0; i < len(ring)-1; i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return area / 2
+}
+
+// pointInRing tests whether a point lies inside a ring using ray casting
+func pointInRing(point []float64, ring [][]float64) bool {
+	inside := false
+	x, y := point[0], point[1]
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// readDBF reads the field descriptors and all records of a dBASE III table
+func readDBF(dbfPath string) ([]dbfField, [][]interface{}, error) {
+	data, err := os.ReadFile(dbfPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read DBF: %v", err)
+	}
+
+	fields, headerLength, recordLength, numRecords, err := parseDBFHeader(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	records := make([][]interface{}, 0, numRecords)
+	for i := 0; i < numRecords; i++ {
+		start := headerLength + i*recordLength
+		if start+recordLength > len(data) {
+			break
+		}
+		record := data[start : start+recordLength]
+
+		// Byte 0 is the deletion flag; values follow back to back
+		values := make([]interface{}, len(fields))
+		pos := 1
+		for j, field := range fields {
+			if pos+field.Length > len(record) {
+				break
+			}
+			values[j] = parseDBFValue(field, record[pos:pos+field.Length])
+			pos += field.Length
+		}
+		records = append(records, values)
+	}
+
+	return fields, records, nil
+}
+
+// parseDBFHeader decodes the DBF header and field descriptor array
+func parseDBFHeader(data []byte) ([]dbfField, int, int, int, error) {
+	if len(data) < 32 {
+		return nil, 0, 0, 0, fmt.Errorf("DBF file too small")
+	}
+
+	numRecords := int(binary.LittleEndian.Uint32(data[4:8]))
+	headerLength := int(binary.LittleEndian.Uint16(data[8:10]))
+	recordLength := int(binary.LittleEndian.Uint16(data[10:12]))
+
+	var fields []dbfField
+	for offset := 32; offset+32 <= len(data) && data[offset] != 0x0D; offset += 32 {
+		desc := data[offset : offset+32]
+		name := strings.TrimRight(string(desc[0:11]), "\x00 ")
+		fields = append(fields, dbfField{
+			Name:     decodeDBFString(name),
+			Type:     desc[11],
+			Length:   int(desc[16]),
+			Decimals: int(desc[17]),
+		})
+	}
+
+	if recordLength <= 0 || headerLength > len(data) {
+		return nil, 0, 0, 0, fmt.Errorf("invalid DBF header")
+	}
+
+	return fields, headerLength, recordLength, numRecords, nil
+}
+
+// readDBFFileHeader reads only the header of an open DBF, leaving the file positioned after it
+func readDBFFileHeader(file *os.File) ([]dbfField, int, int, int, error) {
+	// The header length is stored in bytes 8-9 of the fixed 32-byte prefix
+	data := make([]byte, 32)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, 0, 0, 0, fmt.Errorf("DBF file too small")
+	}
+	headerLength := int(binary.LittleEndian.Uint16(data[8:10]))
+	if headerLength > 32 {
+		rest := make([]byte, headerLength-32)
+		n, _ := io.ReadFull(file, rest)
+		data = append(data, rest[:n]...)
+	}
+	return parseDBFHeader(data)
+}
+
+// parseDBFValue converts a raw fixed-width DBF value to a Go value based on the field type
+func parseDBFValue(field dbfField, raw []byte) interface{} {
+	value := strings.TrimSpace(strings.TrimRight(string(raw), "\x00"))
+
+	switch field.Type {
+	case 'N', 'F':
+		if value == "" || strings.Trim(value, "*") == "" {
+			return nil
+		}
+		if field.Decimals == 0 {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				return n
+			}
+		}
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+		return nil
+	case 'L':
+		switch strings.ToUpper(value) {
+		case "T", "Y":
+			return true
+		case "F", "N":
+			return false
+		}
+		return nil
+	case 'D':
+		// YYYYMMDD -> YYYY-MM-DD
+		if len(value) == 8 {
+			return value[0:4] + "-" + value[4:6] + "-" + value[6:8]
+		}
+		if value == "" {
+			return nil
+		}
+		return value
+	}
+
+	return decodeDBFString(value)
+}
+
+// decodeDBFString returns the string as-is when it is valid UTF-8, otherwise decodes it as Latin-1
+// which is what most legacy shapefiles without a .cpg use
+func decodeDBFString(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	runes := make([]rune, len(s))
+	for i := 0; i < len(s); i++ {
+		runes[i] = rune(s[i])
+	}
+	return string(runes)
+}
+
+var prjAuthorityPattern = regexp.MustCompile(`AUTHORITY\["EPSG",\s*"?(\d+)"?\]\]\s*$`)
+
+// readShapefileCRS reads the .prj next to a shapefile and returns an EPSG identifier when one can be
+// determined, otherwise the raw WKT
+func readShapefileCRS(filePath string) string {
+	prjPath := shapefileSidecar(filePath, ".prj")
+	if prjPath == "" {
+		return ""
+	}
+
+	content, err := os.ReadFile(prjPath)
+	if err != nil {
+		return ""
+	}
+	wkt := strings.TrimSpace(string(content))
+
+	// The outermost AUTHORITY closes the WKT and names the CRS itself
+	if match := prjAuthorityPattern.FindStringSubmatch(wkt); match != nil {
+		return "EPSG:" + match[1]
+	}
+
+	upper := strings.ToUpper(wkt)
+	if strings.HasPrefix(upper, "GEOGCS") && strings.Contains(upper, "WGS_1984") {
+		return "EPSG:4326"
+	}
+	if strings.Contains(upper, "WGS_1984_WEB_MERCATOR") || strings.Contains(upper, "PSEUDO-MERCATOR") {
+		return "EPSG:3857"
+	}
+
+	return wkt
+}
+
+// shapefileSidecar finds a sidecar file (.dbf, .prj, ...) for a shapefile, tolerating upper-case extensions
+func shapefileSidecar(shpPath string, ext string) string {
+	base := strings.TrimSuffix(shpPath, filepath.Ext(shpPath))
+	for _, candidate := range []string{base + strings.ToLower(ext), base + strings.ToUpper(ext)} {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// shapefileComponentExts are the files making up a shapefile; .shx and .dbf are required
+// alongside .shp, the rest are optional
+var shapefileComponentExts = []string{".shp", ".shx", ".dbf", ".prj", ".cpg", ".sbn", ".sbx", ".qix", ".fix", ".shp.xml"}
+
+// requiredShapefileExts are the components GIS software needs to open a shapefile
+var requiredShapefileExts = []string{".shp", ".shx", ".dbf"}
+
+// shapefileComponentExt returns the component extension of path (".dbf", ".shp.xml", ...) or ""
+// when path is not part of a shapefile
+func shapefileComponentExt(path string) string {
+	lower := strings.ToLower(path)
+	// .shp.xml must win over .xml
+	for i := len(shapefileComponentExts) - 1; i >= 0; i-- {
+		if strings.HasSuffix(lower, shapefileComponentExts[i]) {
+			return shapefileComponentExts[i]
+		}
+	}
+	return ""
+}
+
+// resolveShapefileComponents returns every existing file of the shapefile that path belongs to,
+// .shp first, given the path of any of its components. Other paths are returned on their own.
+func resolveShapefileComponents(path string) []string {
+	ext := shapefileComponentExt(path)
+	if ext == "" {
+		return []string{path}
+	}
+	base := path[:len(path)-len(ext)]
+	var components []string
+	for _, componentExt := range shapefileComponentExts {
+		if component := shapefileSidecar(base+".shp", componentExt); component != "" {
+			components = append(components, component)
+		}
+	}
+	if len(components) == 0 {
+		return []string{path}
+	}
+	return components
+}
+
+// shapefileMainFile returns the .shp of the shapefile that path belongs to, or "" when path is
+// not a shapefile component or its .shp doesn't exist
+func shapefileMainFile(path string) string {
+	ext := shapefileComponentExt(path)
+	if ext == "" {
+		return ""
+	}
+	return shapefileSidecar(path[:len(path)-len(ext)]+".shp", ".shp")
+}
+
+// missingShapefileComponents lists the required components absent from shpPath's set
+func missingShapefileComponents(shpPath string) []string {
+	var missing []string
+	for _, ext := range requiredShapefileExts {
+		if shapefileSidecar(shpPath, ext) == "" {
+			missing = append(missing, ext)
+		}
+	}
+	return missing
+}
+
+// combinedFileSize adds up the sizes of paths, skipping any that can't be read
+func combinedFileSize(paths []string) int64 {
+	var total int64
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// readFloat64LE decodes a little-endian IEEE 754 double
+func readFloat64LE(b []byte) float64 {
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
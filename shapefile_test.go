@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// polygonRecord encodes a single-part shpPolygon record's content
+func polygonRecord(ring [][2]float64) []byte {
+	// Shape type, bounding box, part and point counts, then the single part's offset
+	content := make([]byte, 48+len(ring)*16)
+	binary.LittleEndian.PutUint32(content[0:], shpPolygon)
+	binary.LittleEndian.PutUint32(content[36:], 1)
+	binary.LittleEndian.PutUint32(content[40:], uint32(len(ring)))
+	for i, p := range ring {
+		binary.LittleEndian.PutUint64(content[48+i*16:], math.Float64bits(p[0]))
+		binary.LittleEndian.PutUint64(content[56+i*16:], math.Float64bits(p[1]))
+	}
+	return content
+}
+
+func TestReadShapefileDegeneratePolygon(t *testing.T) {
+	records := [][]byte{
+		// Clockwise square
+		polygonRecord([][2]float64{{0, 0}, {0, 1}, {1, 1}, {1, 0}, {0, 0}}),
+		// A ring of three points can't enclose anything and is dropped
+		polygonRecord([][2]float64{{0, 0}, {1, 1}, {0, 0}}),
+	}
+
+	data := make([]byte, 100)
+	binary.BigEndian.PutUint32(data[0:], 9994)
+	binary.LittleEndian.PutUint32(data[32:], shpPolygon)
+	for i, content := range records {
+		header := make([]byte, 8)
+		binary.BigEndian.PutUint32(header[0:], uint32(i+1))
+		binary.BigEndian.PutUint32(header[4:], uint32(len(content)/2))
+		data = append(append(data, header...), content...)
+	}
+	binary.BigEndian.PutUint32(data[24:], uint32(len(data)/2))
+
+	path := filepath.Join(t.TempDir(), "parcels.shp")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := readShapefile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	features := result["features"].([]interface{})
+	if len(features) != 2 {
+		t.Fatalf("got %d features, want 2", len(features))
+	}
+
+	encoded, err := json.Marshal(features[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	json.Unmarshal(encoded, &decoded)
+	if geometry, ok := decoded["geometry"]; !ok || geometry != nil {
+		t.Errorf("degenerate polygon: geometry = %s, want null", encoded)
+	}
+	if geometry := features[0].(map[string]interface{})["geometry"].(map[string]interface{}); geometry["type"] != "Polygon" {
+		t.Errorf("square: geometry type %v, want Polygon", geometry["type"])
+	}
+}
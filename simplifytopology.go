@@ -0,0 +1,323 @@
+package main
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SimplifyTopology simplifies polygons and lines like mapshaper: features are converted to shared
+// TopoJSON arcs, each arc is simplified once with Visvalingam's effective area and the features
+// are rebuilt from the simplified arcs. A boundary shared by two polygons is therefore simplified
+// identically on both sides, so no gaps or slivers open between neighbours. percent is the share
+// of removable vertices to keep, from 0 to 100; arc endpoints are always kept and every ring keeps
+// at least three distinct vertices. The collection also carries original_vertices,
+// simplified_vertices and vertex_reduction_percent.
+func (a *App) SimplifyTopology(geojson map[string]interface{}, percent float64) (map[string]interface{}, error) {
+	if math.IsNaN(percent) || percent < 0 || percent > 100 {
+		return nil, fmt.Errorf("percent must be between 0 and 100, got %v", percent)
+	}
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, err
+	}
+
+	// No quantization, so arcs keep their full-precision coordinates
+	topo, err := encodeTopology(geojson, 0)
+	if err != nil {
+		return nil, err
+	}
+	// encodeTopology puts every feature, in order, into its single object
+	var members []*topoGeometry
+	for _, object := range topo.Objects {
+		members = object.Geometries
+	}
+
+	kept := keepArcVertices(topo.Arcs, percent)
+	for _, member := range members {
+		if err := keepRingVertices(member, topo.Arcs, kept); err != nil {
+			return nil, err
+		}
+	}
+	simplified := make([][][]float64, len(topo.Arcs))
+	for i, arc := range topo.Arcs {
+		simplified[i] = make([][]float64, 0, len(arc))
+		for j, p := range arc {
+			if kept[i][j] {
+				simplified[i] = append(simplified[i], p)
+			}
+		}
+	}
+
+	original := &topoDecoder{arcs: topo.Arcs}
+	decoder := &topoDecoder{arcs: simplified}
+	out := make([]interface{}, len(features))
+	before, after := 0, 0
+	for i, feature := range features {
+		if g, err := original.geometry(members[i]); err == nil {
+			before += geometryPositionCount(g)
+		}
+		geometry, err := decoder.geometry(members[i])
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+		after += geometryPositionCount(geometry)
+
+		rebuilt := make(map[string]interface{}, len(feature))
+		for k, v := range feature {
+			rebuilt[k] = v
+		}
+		rebuilt["geometry"] = nil
+		if geometry != nil {
+			rebuilt["geometry"] = geometry
+		}
+		out[i] = rebuilt
+	}
+
+	result := map[string]interface{}{
+		"type":                     "FeatureCollection",
+		"features":                 out,
+		"original_vertices":        before,
+		"simplified_vertices":      after,
+		"vertex_reduction_percent": percentOf(before-after, before),
+	}
+	if name, ok := geojson["name"].(string); ok && name != "" {
+		result["name"] = name
+	}
+	a.logInfo("Simplified %d features at %.1f%%: %d of %d vertices kept", len(features), percent, after, before)
+	return result, nil
+}
+
+// keepArcVertices ranks the interior vertices of every arc together by effective area and keeps
+// the top percent of them. Endpoints are junctions between arcs and are always kept.
+func keepArcVertices(arcs [][][]float64, percent float64) [][]bool {
+	type vertex struct {
+		arc, index int
+		area       float64
+	}
+	kept := make([][]bool, len(arcs))
+	var interior []vertex
+	for i, arc := range arcs {
+		kept[i] = make([]bool, len(arc))
+		if len(arc) == 0 {
+			continue
+		}
+		kept[i][0], kept[i][len(arc)-1] = true, true
+		for j, area := range effectiveAreas(arc) {
+			if j > 0 && j < len(arc)-1 {
+				interior = append(interior, vertex{i, j, area})
+			}
+		}
+	}
+
+	sort.SliceStable(interior, func(i, j int) bool { return interior[i].area > interior[j].area })
+	keep := int(math.Round(float64(len(interior)) * percent / 100))
+	for _, v := range interior[:keep] {
+		kept[v.arc][v.index] = true
+	}
+	return kept
+}
+
+// keepRingVertices restores vertices, largest effective area first, to any ring of a geometry
+// that simplification left with fewer than four positions. The vertices are restored on the
+// shared arc, so neighbouring rings stay coincident.
+func keepRingVertices(g *topoGeometry, arcs [][][]float64, kept [][]bool) error {
+	var rings [][]int
+	switch g.Type {
+	case "Polygon":
+		if err := json.Unmarshal(g.Arcs, &rings); err != nil {
+			return err
+		}
+	case "MultiPolygon":
+		var polygons [][][]int
+		if err := json.Unmarshal(g.Arcs, &polygons); err != nil {
+			return err
+		}
+		for _, polygon := range polygons {
+			rings = append(rings, polygon...)
+		}
+	case "GeometryCollection":
+		for _, member := range g.Geometries {
+			if err := keepRingVertices(member, arcs, kept); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, ring := range rings {
+		for ringPositions(ring, kept) < 4 {
+			restored := false
+			for _, ref := range ring {
+				index := ref
+				if ref < 0 {
+					index = ^ref
+				}
+				if index < len(arcs) && restoreVertex(arcs[index], kept[index]) {
+					restored = true
+					if ringPositions(ring, kept) >= 4 {
+						break
+					}
+				}
+			}
+			if !restored {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// ringPositions counts the positions a ring will have, each arc sharing its first position with
+// the end of the previous one
+func ringPositions(ring []int, kept [][]bool) int {
+	count := 1
+	for _, ref := range ring {
+		index := ref
+		if ref < 0 {
+			index = ^ref
+		}
+		if index >= len(kept) {
+			continue
+		}
+		for _, k := range kept[index] {
+			if k {
+				count++
+			}
+		}
+		count--
+	}
+	return count
+}
+
+// restoreVertex keeps the dropped vertex of an arc with the largest effective area, reporting
+// false when none is left
+func restoreVertex(arc [][]float64, kept []bool) bool {
+	best, bestArea := -1, -1.0
+	for j, area := range effectiveAreas(arc) {
+		if !kept[j] && area > bestArea {
+			best, bestArea = j, area
+		}
+	}
+	if best < 0 {
+		return false
+	}
+	kept[best] = true
+	return true
+}
+
+// effectiveAreas runs Visvalingam–Whyatt on one arc and returns, for each vertex, the triangle
+// area at which it is removed. Areas never decrease along the removal order, so keeping the
+// vertices above any threshold gives a consistent simplification. Endpoints get +Inf.
+func effectiveAreas(arc [][]float64) []float64 {
+	n := len(arc)
+	areas := make([]float64, n)
+	if n == 0 {
+		return areas
+	}
+	areas[0], areas[n-1] = math.Inf(1), math.Inf(1)
+	if n < 3 {
+		return areas
+	}
+
+	prev := make([]int, n)
+	next := make([]int, n)
+	queue := make(areaQueue, 0, n-2)
+	items := make([]*areaItem, n)
+	for i := 1; i < n-1; i++ {
+		prev[i], next[i] = i-1, i+1
+		items[i] = &areaItem{vertex: i, area: triangleArea(arc[i-1], arc[i], arc[i+1]), index: len(queue)}
+		queue = append(queue, items[i])
+	}
+	heap.Init(&queue)
+
+	last := 0.0
+	for queue.Len() > 0 {
+		item := heap.Pop(&queue).(*areaItem)
+		area := math.Max(item.area, last)
+		last = area
+		areas[item.vertex] = area
+
+		p, q := prev[item.vertex], next[item.vertex]
+		next[p], prev[q] = q, p
+		if p > 0 {
+			items[p].area = triangleArea(arc[prev[p]], arc[p], arc[q])
+			heap.Fix(&queue, items[p].index)
+		}
+		if q < n-1 {
+			items[q].area = triangleArea(arc[p], arc[q], arc[next[q]])
+			heap.Fix(&queue, items[q].index)
+		}
+	}
+	return areas
+}
+
+// triangleArea is the planar area of the triangle a, b, c
+func triangleArea(a, b, c []float64) float64 {
+	return math.Abs((b[0]-a[0])*(c[1]-a[1])-(c[0]-a[0])*(b[1]-a[1])) / 2
+}
+
+// areaItem is one arc vertex waiting in an areaQueue
+type areaItem struct {
+	vertex int
+	area   float64
+	index  int
+}
+
+// areaQueue is a min-heap of vertices by triangle area
+type areaQueue []*areaItem
+
+func (q areaQueue) Len() int           { return len(q) }
+func (q areaQueue) Less(i, j int) bool { return q[i].area < q[j].area }
+func (q areaQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+func (q *areaQueue) Push(x interface{}) {
+	item := x.(*areaItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+func (q *areaQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// geometryPositionCount counts the positions of a geometry decoded from TopoJSON
+func geometryPositionCount(geometry map[string]interface{}) int {
+	if geometry == nil {
+		return 0
+	}
+	switch coords := geometry["coordinates"].(type) {
+	case []float64:
+		return 1
+	case [][]float64:
+		return len(coords)
+	case [][][]float64:
+		count := 0
+		for _, line := range coords {
+			count += len(line)
+		}
+		return count
+	case [][][][]float64:
+		count := 0
+		for _, polygon := range coords {
+			for _, ring := range polygon {
+				count += len(ring)
+			}
+		}
+		return count
+	}
+	count := 0
+	if members, ok := geometry["geometries"].([]interface{}); ok {
+		for _, member := range members {
+			if m, ok := member.(map[string]interface{}); ok {
+				count += geometryPositionCount(m)
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// syncFileRTree adds index rows missing from geo_file_rtree and drops entries whose row is gone.
+// The triggers keep it current; this covers catalogs created before the R*Tree existed and
+// restored backups.
+func syncFileRTree(db *sql.DB) error {
+	_, err := db.Exec(`
+		DELETE FROM geo_file_rtree WHERE id NOT IN (SELECT id FROM geo_file_index);
+		INSERT INTO geo_file_rtree
+		SELECT id, json_extract(bbox, '$[0]'), json_extract(bbox, '$[2]'),
+			json_extract(bbox, '$[1]'), json_extract(bbox, '$[3]')
+		FROM geo_file_index
+		WHERE id NOT IN (SELECT id FROM geo_file_rtree)
+			AND json_valid(bbox) AND json_array_length(bbox) = 4
+			AND NOT (json_extract(bbox, '$[0]') = -180 AND json_extract(bbox, '$[1]') = -90
+				AND json_extract(bbox, '$[2]') = 180 AND json_extract(bbox, '$[3]') = 90)`)
+	if err != nil {
+		return fmt.Errorf("failed to update spatial index: %v", err)
+	}
+	return nil
+}
+
+// FindFilesInBBox returns the indexed files whose extent intersects a [minLon, minLat, maxLon,
+// maxLat] bbox, looked up through the geo_file_rtree R*Tree. A bbox with minLon > maxLon crosses
+// the antimeridian. Files whose extent is unknown are not returned.
+func (a *App) FindFilesInBBox(bbox []float64) ([]GeoFileIndex, error) {
+	if len(bbox) != 4 || bbox[1] > bbox[3] {
+		return nil, fmt.Errorf("bbox must be [minLon, minLat, maxLon, maxLat]")
+	}
+
+	// The R*Tree only uses constraints joined by AND, so an antimeridian crossing is two lookups
+	lookup := `SELECT id FROM geo_file_rtree WHERE min_x <= ? AND max_x >= ? AND min_y <= ? AND max_y >= ?`
+	ranges := lookup
+	args := []interface{}{bbox[2], bbox[0], bbox[3], bbox[1]}
+	if bbox[0] > bbox[2] {
+		ranges += " UNION " + lookup
+		args = []interface{}{180.0, bbox[0], bbox[3], bbox[1], bbox[2], -180.0, bbox[3], bbox[1]}
+	}
+	query := `SELECT ` + geoFileIndexColumns + ` FROM geo_file_index WHERE id IN (` + ranges + `)
+		ORDER BY file_path, layer_name`
+	return a.queryIndex(query, args...)
+}
+
+const (
+	defaultNearestDatasets = 10
+	maxNearestDatasets     = 100
+	// nearestSearchStart is the half-size, in degrees, of the first window FindNearestDatasets tries
+	nearestSearchStart = 0.25
+	metersPerDegreeLat = 111320.0
+)
+
+// FindNearestDatasets returns up to limit indexed datasets nearest to a lon/lat point, closest
+// first, with DistanceMeters set to the geodesic distance to their bbox (0 when the point is
+// inside it). Ties, such as several files containing the point, go to the nearest bbox centre.
+// Candidates come from the R*Tree in growing windows around the point.
+func (a *App) FindNearestDatasets(lon, lat float64, limit int) ([]GeoFileIndex, error) {
+	if lon < -180 || lon > 180 || lat < -90 || lat > 90 {
+		return nil, fmt.Errorf("position must be longitude -180..180 and latitude -90..90")
+	}
+	if limit <= 0 {
+		limit = defaultNearestDatasets
+	}
+	if limit > maxNearestDatasets {
+		limit = maxNearestDatasets
+	}
+
+	radius := nearestSearchStart
+	var candidates []nearbyDataset
+	for {
+		files, err := a.FindFilesInBBox(searchWindow(lon, lat, radius))
+		if err != nil {
+			return nil, err
+		}
+		candidates = rankByDistance(files, lon, lat)
+		if len(candidates) >= limit || radius >= 180 {
+			break
+		}
+		radius *= 4
+	}
+
+	// The window is square in degrees, so a file just outside it may still beat the limit-th
+	// candidate; widen it to that candidate's distance once to be sure
+	if len(candidates) >= limit && radius < 180 {
+		needed := candidates[limit-1].distance / metersPerDegreeLat
+		if cos := math.Cos(math.Min(math.Abs(lat)+needed, 89) * math.Pi / 180); cos > 0 {
+			needed /= cos
+		}
+		if needed > radius {
+			files, err := a.FindFilesInBBox(searchWindow(lon, lat, needed))
+			if err != nil {
+				return nil, err
+			}
+			candidates = rankByDistance(files, lon, lat)
+		}
+	}
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	results := make([]GeoFileIndex, len(candidates))
+	for i, candidate := range candidates {
+		distance := candidate.distance
+		results[i] = candidate.file
+		results[i].DistanceMeters = &distance
+	}
+	return results, nil
+}
+
+type nearbyDataset struct {
+	file     GeoFileIndex
+	distance float64
+	centre   float64
+}
+
+// rankByDistance sorts files by distance to their bbox, then to its centre
+func rankByDistance(files []GeoFileIndex, lon, lat float64) []nearbyDataset {
+	ranked := make([]nearbyDataset, 0, len(files))
+	for _, file := range files {
+		var bbox []float64
+		if json.Unmarshal([]byte(file.BBox), &bbox) != nil || !validBBox(bbox) {
+			continue
+		}
+		// The nearest edge may be across the antimeridian
+		nearLat := math.Max(bbox[1], math.Min(bbox[3], lat))
+		distance := math.Inf(1)
+		for _, x := range []float64{lon, lon - 360, lon + 360} {
+			nearLon := math.Max(bbox[0], math.Min(bbox[2], x))
+			distance = math.Min(distance, haversineMeters(x, lat, nearLon, nearLat))
+		}
+		ranked = append(ranked, nearbyDataset{
+			file:     file,
+			distance: distance,
+			centre:   haversineMeters(lon, lat, (bbox[0]+bbox[2])/2, (bbox[1]+bbox[3])/2),
+		})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].distance != ranked[j].distance {
+			return ranked[i].distance < ranked[j].distance
+		}
+		return ranked[i].centre < ranked[j].centre
+	})
+	return ranked
+}
+
+// searchWindow is a bbox reaching radius degrees around a point, wrapping at the antimeridian
+func searchWindow(lon, lat, radius float64) []float64 {
+	minLat, maxLat := math.Max(-90, lat-radius), math.Min(90, lat+radius)
+	if radius >= 180 {
+		return []float64{-180, minLat, 180, maxLat}
+	}
+	minLon, maxLon := lon-radius, lon+radius
+	if minLon < -180 {
+		minLon += 360
+	}
+	if maxLon > 180 {
+		maxLon -= 360
+	}
+	return []float64{minLon, minLat, maxLon, maxLat}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// benchmarkIndexRows matches the catalog size quoted when the R*Tree was added
+const benchmarkIndexRows = 100000
+
+func TestFindFilesInBBox(t *testing.T) {
+	a := newTestApp(t)
+	batch := &indexBatch{db: a.db}
+	extents := [][]float64{
+		{10, 10, 12, 12},
+		{178, 0, 179, 1},
+		{-179, 0, -178, 1},
+		{-180, -90, 180, 90}, // placeholder extent, never indexed
+	}
+	for i, bbox := range extents {
+		if err := batch.insert(testIndexRow(i, bbox)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batch.commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		bbox []float64
+		want int
+	}{
+		{"overlapping", []float64{11, 11, 20, 20}, 1},
+		{"disjoint", []float64{20, 20, 30, 30}, 0},
+		{"antimeridian", []float64{170, -5, -170, 5}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := a.FindFilesInBBox(tt.bbox)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tt.want {
+				t.Errorf("FindFilesInBBox(%v) returned %d files, want %d", tt.bbox, len(files), tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkFindFilesInBBox compares the R*Tree lookup with scanning the catalog and decoding
+// every bbox, for a 10x10 degree query over benchmarkIndexRows files
+func BenchmarkFindFilesInBBox(b *testing.B) {
+	a := newTestApp(b)
+	seedIndex(b, a, benchmarkIndexRows)
+	query := []float64{0, 0, 10, 10}
+
+	b.Run("rtree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := a.FindFilesInBBox(query); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			files, err := a.queryIndex(`SELECT ` + geoFileIndexColumns + ` FROM geo_file_index ORDER BY file_path, layer_name`)
+			if err != nil {
+				b.Fatal(err)
+			}
+			matches := 0
+			for _, file := range files {
+				var bbox []float64
+				if json.Unmarshal([]byte(file.BBox), &bbox) != nil || len(bbox) != 4 {
+					continue
+				}
+				if bbox[0] <= query[2] && bbox[2] >= query[0] && bbox[1] <= query[3] && bbox[3] >= query[1] {
+					matches++
+				}
+			}
+		}
+	})
+}
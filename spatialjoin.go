@@ -0,0 +1,409 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/planar"
+)
+
+// Spatial join predicates, read as "target <predicate> join feature"
+const (
+	joinIntersects = "intersects"
+	joinWithin     = "within"
+	joinContains   = "contains"
+)
+
+// SpatialJoin copies onto each target feature the attributes of the first join feature (in
+// join-layer order) that satisfies predicate: intersects, within (the target lies inside the
+// join feature, e.g. a point in its county) or contains; boundaries count as inside. Every
+// target feature is kept; each one gets join_count, the number of join features that matched,
+// and an attribute the target already has is added as join_<name> instead of overwriting it.
+// The collection also carries target_count and matched_count.
+func (a *App) SpatialJoin(targetGeoJSON map[string]interface{}, joinGeoJSON map[string]interface{}, predicate string) (map[string]interface{}, error) {
+	switch predicate {
+	case "":
+		predicate = joinIntersects
+	case joinIntersects, joinWithin, joinContains:
+	default:
+		return nil, fmt.Errorf("unknown predicate %q (expected intersects, within or contains)", predicate)
+	}
+
+	targets, err := geojsonFeatures(targetGeoJSON)
+	if err != nil {
+		return nil, fmt.Errorf("target layer: %v", err)
+	}
+	joins, err := geojsonFeatures(joinGeoJSON)
+	if err != nil {
+		return nil, fmt.Errorf("join layer: %v", err)
+	}
+
+	shapes := make([]*joinShape, len(joins))
+	for i, feature := range joins {
+		g, err := orbGeometry(feature["geometry"])
+		if err != nil {
+			return nil, fmt.Errorf("join feature %d: %v", i, err)
+		}
+		shapes[i] = newJoinShape(g)
+	}
+	index := newJoinIndex(shapes)
+
+	out := make([]interface{}, len(targets))
+	matched := 0
+	for i, feature := range targets {
+		properties := map[string]interface{}{}
+		if props, ok := feature["properties"].(map[string]interface{}); ok {
+			for k, v := range props {
+				properties[k] = v
+			}
+		}
+
+		count := 0
+		g, err := orbGeometry(feature["geometry"])
+		if err != nil {
+			return nil, fmt.Errorf("target feature %d: %v", i, err)
+		}
+		if target := newJoinShape(g); target != nil {
+			for _, j := range index.candidates(target.bound) {
+				if !joinMatches(target, shapes[j], predicate) {
+					continue
+				}
+				if count == 0 {
+					joinProps, _ := joins[j]["properties"].(map[string]interface{})
+					for k, v := range joinProps {
+						if _, taken := properties[k]; taken {
+							k = "join_" + k
+						}
+						properties[k] = v
+					}
+				}
+				count++
+			}
+		}
+		if count > 0 {
+			matched++
+		}
+		properties["join_count"] = count
+
+		joined := make(map[string]interface{}, len(feature))
+		for k, v := range feature {
+			joined[k] = v
+		}
+		joined["properties"] = properties
+		out[i] = joined
+	}
+
+	a.logInfo("Spatial join (%s): %d of %d features matched %d join features", predicate, matched, len(targets), len(joins))
+	return map[string]interface{}{
+		"type":          "FeatureCollection",
+		"features":      out,
+		"target_count":  len(targets),
+		"matched_count": matched,
+	}, nil
+}
+
+// joinMatches evaluates "target <predicate> join"
+func joinMatches(target, join *joinShape, predicate string) bool {
+	if join == nil || !target.bound.Intersects(join.bound) {
+		return false
+	}
+	switch predicate {
+	case joinWithin:
+		return join.covers(target)
+	case joinContains:
+		return target.covers(join)
+	}
+	return target.intersects(join)
+}
+
+// joinShape breaks a geometry into the parts the predicates test: standalone points, line and
+// ring segments, and polygons
+type joinShape struct {
+	bound    orb.Bound
+	points   []orb.Point
+	vertices []orb.Point
+	segments [][2]orb.Point
+	polygons []orb.Polygon
+}
+
+// newJoinShape returns nil for a null or empty geometry
+func newJoinShape(g orb.Geometry) *joinShape {
+	if g == nil {
+		return nil
+	}
+	s := &joinShape{}
+	s.add(g)
+	if len(s.points) == 0 && len(s.vertices) == 0 {
+		return nil
+	}
+	s.bound = g.Bound()
+	return s
+}
+
+func (s *joinShape) add(g orb.Geometry) {
+	switch g := g.(type) {
+	case orb.Point:
+		s.points = append(s.points, g)
+	case orb.MultiPoint:
+		s.points = append(s.points, g...)
+	case orb.LineString:
+		s.addLine(g)
+	case orb.MultiLineString:
+		for _, line := range g {
+			s.addLine(line)
+		}
+	case orb.Ring:
+		s.add(orb.Polygon{g})
+	case orb.Polygon:
+		for _, ring := range g {
+			s.addLine(orb.LineString(ring))
+		}
+		if len(g) > 0 && len(g[0]) > 0 {
+			s.polygons = append(s.polygons, g)
+		}
+	case orb.MultiPolygon:
+		for _, polygon := range g {
+			s.add(polygon)
+		}
+	case orb.Collection:
+		for _, member := range g {
+			s.add(member)
+		}
+	case orb.Bound:
+		s.add(g.ToPolygon())
+	}
+}
+
+func (s *joinShape) addLine(line orb.LineString) {
+	s.vertices = append(s.vertices, line...)
+	for i := 1; i < len(line); i++ {
+		s.segments = append(s.segments, [2]orb.Point{line[i-1], line[i]})
+	}
+}
+
+// intersects reports whether the shapes share any point: crossing or touching segments, a part
+// of one inside a polygon of the other, or a point on the other shape
+func (s *joinShape) intersects(other *joinShape) bool {
+	for _, a := range s.segments {
+		for _, b := range other.segments {
+			if segmentsIntersect(a[0][:], a[1][:], b[0][:], b[1][:]) {
+				return true
+			}
+		}
+	}
+	for _, p := range s.points {
+		if other.coversPoint(p) {
+			return true
+		}
+	}
+	for _, p := range other.points {
+		if s.coversPoint(p) {
+			return true
+		}
+	}
+	// With no crossing, a shape touching the other's polygons lies wholly inside them
+	for _, p := range s.vertices {
+		if other.inPolygon(p) {
+			return true
+		}
+	}
+	for _, p := range other.vertices {
+		if s.inPolygon(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers reports whether every point of other lies in or on s. Each segment of other is split
+// where it crosses the boundary of s or passes through one of its vertices, and every piece's
+// midpoint is tested, so a line can't leave s between covered points. A polygon of other must
+// also not contain any hole of s.
+func (s *joinShape) covers(other *joinShape) bool {
+	for _, p := range other.points {
+		if !s.coversPoint(p) {
+			return false
+		}
+	}
+	for _, p := range other.vertices {
+		if !s.coversPoint(p) {
+			return false
+		}
+	}
+	for _, seg := range other.segments {
+		for _, p := range s.pieceMidpoints(seg) {
+			if !s.coversPoint(p) {
+				return false
+			}
+		}
+	}
+	if len(other.polygons) > 0 {
+		for _, polygon := range s.polygons {
+			for _, hole := range polygon[1:] {
+				if p, ok := ringInteriorPoint(hole); ok && other.inPolygon(p) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// pieceMidpoints splits seg where it crosses a segment of s or passes through a vertex of s and
+// returns the midpoint of each piece
+func (s *joinShape) pieceMidpoints(seg [2]orb.Point) []orb.Point {
+	a, b := seg[0], seg[1]
+	cuts := []float64{0, 1}
+	for _, edge := range s.segments {
+		if segmentsCross(a[:], b[:], edge[0][:], edge[1][:]) {
+			d1 := orientation(edge[0][:], edge[1][:], a[:])
+			d2 := orientation(edge[0][:], edge[1][:], b[:])
+			cuts = append(cuts, d1/(d1-d2))
+		}
+	}
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	if length2 := dx*dx + dy*dy; length2 > 0 {
+		for _, v := range s.vertices {
+			if v != a && v != b && orientation(a[:], b[:], v[:]) == 0 && onSegment(a[:], b[:], v[:]) {
+				cuts = append(cuts, ((v[0]-a[0])*dx+(v[1]-a[1])*dy)/length2)
+			}
+		}
+	}
+	sort.Float64s(cuts)
+
+	midpoints := make([]orb.Point, 0, len(cuts)-1)
+	for i := 1; i < len(cuts); i++ {
+		t := (cuts[i-1] + cuts[i]) / 2
+		midpoints = append(midpoints, orb.Point{a[0] + t*dx, a[1] + t*dy})
+	}
+	return midpoints
+}
+
+// ringInteriorPoint returns a point just inside a ring, beside the midpoint of its first edge
+func ringInteriorPoint(ring orb.Ring) (orb.Point, bool) {
+	for i := 1; i < len(ring); i++ {
+		a, b := ring[i-1], ring[i]
+		if a == b {
+			continue
+		}
+		// The interior is left of the edges of a counter-clockwise ring
+		ex, ey := b[0]-a[0], b[1]-a[1]
+		if ring.Orientation() == orb.CW {
+			ex, ey = -ex, -ey
+		}
+		return orb.Point{(a[0]+b[0])/2 - ey*1e-5, (a[1]+b[1])/2 + ex*1e-5}, true
+	}
+	return orb.Point{}, false
+}
+
+// coversPoint reports whether p lies in a polygon, on a segment or on a point of s
+func (s *joinShape) coversPoint(p orb.Point) bool {
+	if !s.bound.Contains(p) {
+		return false
+	}
+	if s.inPolygon(p) {
+		return true
+	}
+	for _, seg := range s.segments {
+		if orientation(seg[0][:], seg[1][:], p[:]) == 0 && onSegment(seg[0][:], seg[1][:], p[:]) {
+			return true
+		}
+	}
+	for _, q := range s.points {
+		if q == p {
+			return true
+		}
+	}
+	return false
+}
+
+// inPolygon reports whether p lies inside or on the boundary of a polygon of s
+func (s *joinShape) inPolygon(p orb.Point) bool {
+	for _, polygon := range s.polygons {
+		if planar.PolygonContains(polygon, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinIndex is a uniform grid over the join layer with about one feature per cell, so each target
+// is only tested against the join features near it
+type joinIndex struct {
+	bound      orb.Bound
+	cols, rows int
+	cellW      float64
+	cellH      float64
+	cells      map[int][]int
+}
+
+func newJoinIndex(shapes []*joinShape) *joinIndex {
+	index := &joinIndex{cells: map[int][]int{}}
+	first := true
+	for _, s := range shapes {
+		if s == nil {
+			continue
+		}
+		if first {
+			index.bound, first = s.bound, false
+		} else {
+			index.bound = index.bound.Union(s.bound)
+		}
+	}
+	if first {
+		return index
+	}
+
+	side := int(math.Ceil(math.Sqrt(float64(len(shapes)))))
+	index.cols, index.rows = side, side
+	index.cellW = math.Max(index.bound.Max[0]-index.bound.Min[0], 1e-12) / float64(side)
+	index.cellH = math.Max(index.bound.Max[1]-index.bound.Min[1], 1e-12) / float64(side)
+	for i, s := range shapes {
+		if s == nil {
+			continue
+		}
+		minCol, minRow, maxCol, maxRow := index.cellRange(s.bound)
+		for row := minRow; row <= maxRow; row++ {
+			for col := minCol; col <= maxCol; col++ {
+				key := row*index.cols + col
+				index.cells[key] = append(index.cells[key], i)
+			}
+		}
+	}
+	return index
+}
+
+// cellRange returns the cells a bound overlaps, clamped to the grid
+func (x *joinIndex) cellRange(b orb.Bound) (int, int, int, int) {
+	clamp := func(v float64, n int) int {
+		return max(0, min(int(v), n-1))
+	}
+	return clamp((b.Min[0]-x.bound.Min[0])/x.cellW, x.cols),
+		clamp((b.Min[1]-x.bound.Min[1])/x.cellH, x.rows),
+		clamp((b.Max[0]-x.bound.Min[0])/x.cellW, x.cols),
+		clamp((b.Max[1]-x.bound.Min[1])/x.cellH, x.rows)
+}
+
+// candidates returns, in join-layer order, the features whose cells overlap b
+func (x *joinIndex) candidates(b orb.Bound) []int {
+	if x.cols == 0 || !x.bound.Intersects(b) {
+		return nil
+	}
+	minCol, minRow, maxCol, maxRow := x.cellRange(b)
+	seen := map[int]bool{}
+	var found []int
+	for row := minRow; row <= maxRow; row++ {
+		for col := minCol; col <= maxCol; col++ {
+			for _, i := range x.cells[row*x.cols+col] {
+				if !seen[i] {
+					seen[i] = true
+					found = append(found, i)
+				}
+			}
+		}
+	}
+	sort.Ints(found)
+	return found
+}
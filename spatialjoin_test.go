@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+func TestJoinShapeCovers(t *testing.T) {
+	square := func(minX, minY, maxX, maxY float64) orb.Ring {
+		return orb.Ring{{minX, minY}, {maxX, minY}, {maxX, maxY}, {minX, maxY}, {minX, minY}}
+	}
+	// comb is a 10x10 square with two notches cut down from the top edge
+	comb := orb.Polygon{{{0, 0}, {10, 0}, {10, 10}, {8, 10}, {8, 5}, {7, 5}, {7, 10},
+		{3, 10}, {3, 5}, {2, 5}, {2, 10}, {0, 10}, {0, 0}}}
+	donut := orb.Polygon{square(0, 0, 10, 10), square(4, 4, 6, 6)}
+	// vNotch is a 20x10 rectangle with a notch cut down from the top to a V between (4,5) and (6,5)
+	vNotch := orb.Polygon{{{0, 0}, {20, 0}, {20, 10}, {6, 10}, {6, 5}, {5, 4}, {4, 5}, {4, 10}, {0, 10}, {0, 0}}}
+
+	tests := []struct {
+		name     string
+		covering orb.Geometry
+		covered  orb.Geometry
+		want     bool
+	}{
+		{
+			name:     "line inside",
+			covering: comb,
+			covered:  orb.LineString{{1, 1}, {9, 1}},
+			want:     true,
+		},
+		{
+			// Both ends and the midpoint are inside, but the line crosses both notches
+			name:     "line crossing notches",
+			covering: comb,
+			covered:  orb.LineString{{1, 7}, {9, 7}},
+			want:     false,
+		},
+		{
+			// The line only touches the boundary at the notch corners, so nothing crosses
+			name:     "line through notch corners",
+			covering: vNotch,
+			covered:  orb.LineString{{1, 5}, {19, 5}},
+			want:     false,
+		},
+		{
+			name:     "polygon sharing the boundary",
+			covering: comb,
+			covered:  orb.Polygon{square(0, 0, 10, 2)},
+			want:     true,
+		},
+		{
+			name:     "polygon around the hole",
+			covering: donut,
+			covered:  orb.Polygon{square(2, 2, 8, 8)},
+			want:     false,
+		},
+		{
+			name:     "polygon filling the hole",
+			covering: donut,
+			covered:  orb.Polygon{square(4, 4, 6, 6)},
+			want:     false,
+		},
+		{
+			name:     "polygon beside the hole",
+			covering: donut,
+			covered:  orb.Polygon{square(1, 1, 3, 9)},
+			want:     true,
+		},
+		{
+			name:     "line around the hole",
+			covering: donut,
+			covered:  orb.LineString(square(2, 2, 8, 8)),
+			want:     true,
+		},
+		{
+			// Crossing the edge shared by two parts stays inside
+			name:     "line across adjacent parts",
+			covering: orb.MultiPolygon{{square(0, 0, 5, 10)}, {square(5, 0, 10, 10)}},
+			covered:  orb.LineString{{1, 5}, {9, 5}},
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			covering, covered := newJoinShape(tt.covering), newJoinShape(tt.covered)
+			if got := covering.covers(covered); got != tt.want {
+				t.Errorf("covers = %v, want %v", got, tt.want)
+			}
+			predicate := joinWithin
+			if got := joinMatches(covered, covering, predicate); got != tt.want {
+				t.Errorf("joinMatches(%s) = %v, want %v", predicate, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// StorageInfo reports the disk space used by Terrabox and what is left on the data volume.
+// Sizes are in bytes; a missing directory counts as 0.
+type StorageInfo struct {
+	DataDir       string `json:"data_dir"`
+	DatabaseBytes int64  `json:"database_bytes"`
+	DuckDBBytes   int64  `json:"duckdb_bytes"`
+	CacheBytes    int64  `json:"cache_bytes"`
+	BasemapBytes  int64  `json:"basemap_bytes"`
+	LogBytes      int64  `json:"log_bytes"`
+	OutputDir     string `json:"output_dir"`
+	OutputBytes   int64  `json:"output_bytes"`
+	FreeBytes     uint64 `json:"free_bytes"`
+}
+
+// GetStorageInfo returns the size of the catalog database, the DuckDB workspace, the render
+// caches under ~/.terrabox/cache, the offline basemap tiles, the logs, the OSM output directory
+// and the free space on the volume holding ~/.terrabox
+func (a *App) GetStorageInfo() (StorageInfo, error) {
+	dir, err := terraboxDir()
+	if err != nil {
+		return StorageInfo{}, fmt.Errorf("failed to locate data directory: %v", err)
+	}
+	outputDir, err := a.outputDir()
+	if err != nil {
+		return StorageInfo{}, err
+	}
+
+	info := StorageInfo{
+		DataDir:       dir,
+		DatabaseBytes: sqliteFileSize(filepath.Join(dir, "terrabox.db")),
+		DuckDBBytes:   sqliteFileSize(filepath.Join(dir, "terrabox.duckdb")),
+		CacheBytes:    directorySize(filepath.Join(dir, "cache")),
+		BasemapBytes:  directorySize(filepath.Join(dir, "basemap_cache")),
+		LogBytes:      directorySize(filepath.Join(dir, "logs")),
+		OutputDir:     outputDir,
+	}
+	info.OutputBytes = directorySize(info.OutputDir)
+
+	free, err := diskFreeBytes(dir)
+	if err != nil {
+		return info, fmt.Errorf("failed to determine free space: %v", err)
+	}
+	info.FreeBytes = free
+	return info, nil
+}
+
+// VacuumDatabase rebuilds the catalog database to return pages freed by deletes and re-indexing
+// to the filesystem, and returns the number of bytes reclaimed
+func (a *App) VacuumDatabase() (int64, error) {
+	if a.db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+	dir, err := terraboxDir()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate data directory: %v", err)
+	}
+	dbPath := filepath.Join(dir, "terrabox.db")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	before := sqliteFileSize(dbPath)
+	if _, err := a.db.Exec("VACUUM"); err != nil {
+		return 0, fmt.Errorf("failed to vacuum database: %v", err)
+	}
+	reclaimed := before - sqliteFileSize(dbPath)
+	if reclaimed < 0 {
+		reclaimed = 0
+	}
+	a.logInfo("Vacuumed catalog database, reclaimed %d bytes", reclaimed)
+	return reclaimed, nil
+}
+
+// sqliteFileSize is the size of a database file together with its journal and WAL files
+func sqliteFileSize(path string) int64 {
+	var total int64
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		if info, err := os.Stat(path + suffix); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// directorySize adds up the sizes of the regular files under dir, skipping unreadable entries
+func directorySize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if entry.Type().IsRegular() {
+			if info, err := entry.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
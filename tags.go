@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TagCount is a tag and the number of indexed layers carrying it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagFile adds a tag to an indexed layer. Tags are keyed by path and layer name rather than
+// index ID, so they survive re-indexing. Tags compare case-insensitively.
+func (a *App) TagFile(filePath string, layerName string, tag string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return fmt.Errorf("tag is required")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	_, err := a.db.Exec(`INSERT OR IGNORE INTO file_tags (file_path, layer_name, tag, created_at)
+		VALUES (?, ?, ?, ?)`, filePath, layerName, tag, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to tag file: %v", err)
+	}
+	return nil
+}
+
+// UntagFile removes a tag from an indexed layer
+func (a *App) UntagFile(filePath string, layerName string, tag string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result, err := a.db.Exec("DELETE FROM file_tags WHERE file_path = ? AND layer_name = ? AND tag = ?",
+		filePath, layerName, strings.TrimSpace(tag))
+	if err != nil {
+		return fmt.Errorf("failed to untag file: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("tag %q not found on %s", tag, filePath)
+	}
+	return nil
+}
+
+// ListTags returns every tag in use with its number of layers, most used first
+func (a *App) ListTags() ([]TagCount, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	rows, err := a.db.Query(`SELECT MIN(tag), COUNT(*) FROM file_tags
+		GROUP BY tag ORDER BY COUNT(*) DESC, MIN(tag) COLLATE NOCASE`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %v", err)
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var t TagCount
+		if err := rows.Scan(&t.Tag, &t.Count); err != nil {
+			return nil, fmt.Errorf("failed to read tag: %v", err)
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// SetFavorite marks or unmarks an indexed layer as a favorite
+func (a *App) SetFavorite(filePath string, layerName string, fav bool) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var err error
+	if fav {
+		_, err = a.db.Exec(`INSERT OR IGNORE INTO file_favorites (file_path, layer_name, created_at)
+			VALUES (?, ?, ?)`, filePath, layerName, time.Now().Format(time.RFC3339))
+	} else {
+		_, err = a.db.Exec("DELETE FROM file_favorites WHERE file_path = ? AND layer_name = ?", filePath, layerName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update favorite: %v", err)
+	}
+	return nil
+}
+
+// FindFilesByTag returns the indexed layers carrying tag
+func (a *App) FindFilesByTag(tag string) ([]GeoFileIndex, error) {
+	return a.queryIndex(`
+		SELECT `+geoFileIndexColumns+` FROM geo_file_index g
+		WHERE EXISTS (SELECT 1 FROM file_tags t
+			WHERE t.file_path = g.file_path AND t.layer_name = g.layer_name AND t.tag = ?)
+		ORDER BY file_name, layer_name`, strings.TrimSpace(tag))
+}
+
+// SearchIndex returns the indexed layers whose file name, layer name, path or tags contain
+// every word of query
+func (a *App) SearchIndex(query string) ([]GeoFileIndex, error) {
+	var conditions []string
+	var args []interface{}
+	for _, word := range strings.Fields(query) {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(word) + "%"
+		conditions = append(conditions, `(file_name LIKE ? ESCAPE '\' OR layer_name LIKE ? ESCAPE '\'
+			OR file_path LIKE ? ESCAPE '\' OR EXISTS (SELECT 1 FROM file_tags t
+				WHERE t.file_path = g.file_path AND t.layer_name = g.layer_name AND t.tag LIKE ? ESCAPE '\'))`)
+		args = append(args, pattern, pattern, pattern, pattern)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	return a.queryIndex("SELECT "+geoFileIndexColumns+" FROM geo_file_index g "+where+" ORDER BY modified_at DESC", args...)
+}
+
+// queryIndex runs a geo_file_index query selecting geoFileIndexColumns and attaches tags
+func (a *App) queryIndex(query string, args ...interface{}) ([]GeoFileIndex, error) {
+	if a.db == nil {
+		return nil, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	rows, err := a.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search index: %v", err)
+	}
+	defer rows.Close()
+
+	files := []GeoFileIndex{}
+	for rows.Next() {
+		file, err := scanGeoFileIndex(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read index entry: %v", err)
+		}
+		files = append(files, file)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search index: %v", err)
+	}
+	return files, a.attachTags(files)
+}
+
+// attachTags fills in Tags and Favorite. The caller holds a.mu.
+func (a *App) attachTags(files []GeoFileIndex) error {
+	if len(files) == 0 {
+		return nil
+	}
+	type layerKey struct{ path, layer string }
+	tags := map[layerKey][]string{}
+	favorites := map[layerKey]bool{}
+
+	rows, err := a.db.Query("SELECT file_path, layer_name, tag FROM file_tags")
+	if err != nil {
+		return fmt.Errorf("failed to read tags: %v", err)
+	}
+	for rows.Next() {
+		var key layerKey
+		var tag string
+		if err := rows.Scan(&key.path, &key.layer, &tag); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read tags: %v", err)
+		}
+		tags[key] = append(tags[key], tag)
+	}
+	rows.Close()
+
+	rows, err = a.db.Query("SELECT file_path, layer_name FROM file_favorites")
+	if err != nil {
+		return fmt.Errorf("failed to read favorites: %v", err)
+	}
+	for rows.Next() {
+		var key layerKey
+		if err := rows.Scan(&key.path, &key.layer); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read favorites: %v", err)
+		}
+		favorites[key] = true
+	}
+	rows.Close()
+
+	for i := range files {
+		key := layerKey{files[i].FilePath, files[i].LayerName}
+		files[i].Tags = tags[key]
+		if files[i].Tags == nil {
+			files[i].Tags = []string{}
+		}
+		sort.Slice(files[i].Tags, func(x, y int) bool {
+			return strings.ToLower(files[i].Tags[x]) < strings.ToLower(files[i].Tags[y])
+		})
+		files[i].Favorite = favorites[key]
+	}
+	return nil
+}
@@ -0,0 +1,708 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+)
+
+// defaultTopoJSONQuantization matches the topojson reference implementation's default
+const defaultTopoJSONQuantization = 10000
+
+// topology is the root TopoJSON object
+type topology struct {
+	Type      string                   `json:"type"`
+	BBox      []float64                `json:"bbox,omitempty"`
+	Transform *topoTransform           `json:"transform,omitempty"`
+	Objects   map[string]*topoGeometry `json:"objects"`
+	Arcs      [][][]float64            `json:"arcs"`
+}
+
+type topoTransform struct {
+	Scale     [2]float64 `json:"scale"`
+	Translate [2]float64 `json:"translate"`
+}
+
+// topoGeometry is a TopoJSON geometry object; Arcs/Coordinates depend on the geometry type
+type topoGeometry struct {
+	Type        string                 `json:"type"`
+	ID          interface{}            `json:"id,omitempty"`
+	Properties  map[string]interface{} `json:"properties,omitempty"`
+	Coordinates json.RawMessage        `json:"coordinates,omitempty"`
+	Arcs        json.RawMessage        `json:"arcs,omitempty"`
+	Geometries  []*topoGeometry        `json:"geometries,omitempty"`
+}
+
+// readTopoJSON loads a .topojson file as a GeoJSON FeatureCollection
+func readTopoJSON(filePath string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TopoJSON file: %v", err)
+	}
+
+	fc, err := decodeTopoJSON(content)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := fc["name"]; !ok {
+		fc["name"] = strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	}
+	return fc, nil
+}
+
+// extractTopoJSONMetadata extracts object names, feature count and bbox from TopoJSON files
+func (a *App) extractTopoJSONMetadata(filePath string, metadata *FileMetadata) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	var topo topology
+	if err := json.Unmarshal(content, &topo); err != nil {
+		return fmt.Errorf("failed to parse TopoJSON: %v", err)
+	}
+
+	names := make([]string, 0, len(topo.Objects))
+	count := 0
+	for name, obj := range topo.Objects {
+		if obj == nil {
+			return fmt.Errorf("TopoJSON object %q is null", name)
+		}
+		names = append(names, name)
+		if obj.Type == "GeometryCollection" {
+			count += len(obj.Geometries)
+		} else {
+			count++
+		}
+	}
+	sort.Strings(names)
+
+	metadata.NumFeatures = count
+	metadata.Metadata["format"] = "TopoJSON"
+	metadata.Metadata["objects"] = names
+	metadata.Metadata["arc_count"] = len(topo.Arcs)
+	if len(topo.BBox) >= 4 {
+		metadata.BBox = []float64{topo.BBox[0], topo.BBox[1], topo.BBox[2], topo.BBox[3]}
+	}
+	return nil
+}
+
+// decodeTopoJSON reconstructs GeoJSON features from a TopoJSON topology. Features from every
+// object are merged; when there is more than one object each feature records its source in
+// the "topojson_object" property.
+func decodeTopoJSON(data []byte) (map[string]interface{}, error) {
+	var topo topology
+	if err := json.Unmarshal(data, &topo); err != nil {
+		return nil, fmt.Errorf("failed to parse TopoJSON: %v", err)
+	}
+	if topo.Type != "Topology" {
+		return nil, fmt.Errorf("not a TopoJSON topology (type=%q)", topo.Type)
+	}
+
+	d := &topoDecoder{transform: topo.Transform}
+	d.arcs = make([][][]float64, len(topo.Arcs))
+	for i, arc := range topo.Arcs {
+		d.arcs[i] = d.decodeArc(arc)
+	}
+
+	names := make([]string, 0, len(topo.Objects))
+	for name := range topo.Objects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	features := []interface{}{}
+	for _, name := range names {
+		obj := topo.Objects[name]
+		if obj == nil {
+			return nil, fmt.Errorf("object %q is null", name)
+		}
+		members := []*topoGeometry{obj}
+		if obj.Type == "GeometryCollection" {
+			members = obj.Geometries
+		}
+
+		for _, member := range members {
+			geometry, err := d.geometry(member)
+			if err != nil {
+				return nil, fmt.Errorf("object %q: %v", name, err)
+			}
+
+			properties := map[string]interface{}{}
+			for k, v := range member.Properties {
+				properties[k] = v
+			}
+			if len(names) > 1 {
+				properties["topojson_object"] = name
+			}
+
+			feature := map[string]interface{}{
+				"type":       "Feature",
+				"properties": properties,
+				"geometry":   geometry,
+			}
+			if member.ID != nil {
+				feature["id"] = member.ID
+			}
+			features = append(features, feature)
+		}
+	}
+
+	result := map[string]interface{}{
+		"type":     "FeatureCollection",
+		"features": features,
+	}
+	if len(names) == 1 {
+		result["name"] = names[0]
+	}
+	if len(topo.BBox) >= 4 {
+		result["bbox"] = topo.BBox
+	}
+	return result, nil
+}
+
+// topoDecoder resolves arc references against a topology's decoded arcs
+type topoDecoder struct {
+	transform *topoTransform
+	arcs      [][][]float64
+}
+
+// decodeArc undoes delta encoding and quantization for a single arc
+func (d *topoDecoder) decodeArc(arc [][]float64) [][]float64 {
+	out := make([][]float64, len(arc))
+	x, y := 0.0, 0.0
+	for i, p := range arc {
+		if len(p) < 2 {
+			continue
+		}
+		if d.transform == nil {
+			out[i] = []float64{p[0], p[1]}
+			continue
+		}
+		x += p[0]
+		y += p[1]
+		out[i] = []float64{
+			x*d.transform.Scale[0] + d.transform.Translate[0],
+			y*d.transform.Scale[1] + d.transform.Translate[1],
+		}
+	}
+	return out
+}
+
+// position transforms a quantized position (used by Point/MultiPoint, which are not delta encoded)
+func (d *topoDecoder) position(p []float64) []float64 {
+	if len(p) < 2 {
+		return p
+	}
+	if d.transform == nil {
+		return []float64{p[0], p[1]}
+	}
+	return []float64{
+		p[0]*d.transform.Scale[0] + d.transform.Translate[0],
+		p[1]*d.transform.Scale[1] + d.transform.Translate[1],
+	}
+}
+
+// line stitches arc references into a single coordinate sequence; negative indexes (~i) mean
+// arc i traversed in reverse
+func (d *topoDecoder) line(refs []int) ([][]float64, error) {
+	coords := [][]float64{}
+	for _, ref := range refs {
+		index := ref
+		if ref < 0 {
+			index = ^ref
+		}
+		if index >= len(d.arcs) {
+			return nil, fmt.Errorf("arc index %d out of range", ref)
+		}
+
+		arc := d.arcs[index]
+		if ref < 0 {
+			reversed := make([][]float64, len(arc))
+			for i := range arc {
+				reversed[len(arc)-1-i] = arc[i]
+			}
+			arc = reversed
+		}
+
+		// Consecutive arcs share their joining point
+		if len(coords) > 0 && len(arc) > 0 {
+			arc = arc[1:]
+		}
+		coords = append(coords, arc...)
+	}
+	return coords, nil
+}
+
+func (d *topoDecoder) rings(refs [][]int) ([][][]float64, error) {
+	rings := make([][][]float64, 0, len(refs))
+	for _, ring := range refs {
+		coords, err := d.line(ring)
+		if err != nil {
+			return nil, err
+		}
+		rings = append(rings, coords)
+	}
+	return rings, nil
+}
+
+// geometry converts a TopoJSON geometry object to a GeoJSON geometry map
+func (d *topoDecoder) geometry(g *topoGeometry) (map[string]interface{}, error) {
+	if g == nil {
+		return nil, fmt.Errorf("geometry object is null")
+	}
+	switch g.Type {
+	case "", "null":
+		return nil, nil
+
+	case "Point":
+		var p []float64
+		if err := json.Unmarshal(g.Coordinates, &p); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "Point", "coordinates": d.position(p)}, nil
+
+	case "MultiPoint":
+		var points [][]float64
+		if err := json.Unmarshal(g.Coordinates, &points); err != nil {
+			return nil, err
+		}
+		coords := make([][]float64, len(points))
+		for i, p := range points {
+			coords[i] = d.position(p)
+		}
+		return map[string]interface{}{"type": "MultiPoint", "coordinates": coords}, nil
+
+	case "LineString":
+		var refs []int
+		if err := json.Unmarshal(g.Arcs, &refs); err != nil {
+			return nil, err
+		}
+		coords, err := d.line(refs)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "LineString", "coordinates": coords}, nil
+
+	case "MultiLineString", "Polygon":
+		var refs [][]int
+		if err := json.Unmarshal(g.Arcs, &refs); err != nil {
+			return nil, err
+		}
+		coords, err := d.rings(refs)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": g.Type, "coordinates": coords}, nil
+
+	case "MultiPolygon":
+		var refs [][][]int
+		if err := json.Unmarshal(g.Arcs, &refs); err != nil {
+			return nil, err
+		}
+		polygons := make([][][][]float64, 0, len(refs))
+		for _, polygon := range refs {
+			rings, err := d.rings(polygon)
+			if err != nil {
+				return nil, err
+			}
+			polygons = append(polygons, rings)
+		}
+		return map[string]interface{}{"type": "MultiPolygon", "coordinates": polygons}, nil
+
+	case "GeometryCollection":
+		geometries := []interface{}{}
+		for _, member := range g.Geometries {
+			geometry, err := d.geometry(member)
+			if err != nil {
+				return nil, err
+			}
+			if geometry != nil {
+				geometries = append(geometries, geometry)
+			}
+		}
+		return map[string]interface{}{"type": "GeometryCollection", "geometries": geometries}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported TopoJSON geometry type %q", g.Type)
+}
+
+// ExportTopoJSON topology-encodes a GeoJSON FeatureCollection using the default quantization
+func (a *App) ExportTopoJSON(geojson map[string]interface{}) ([]byte, error) {
+	return a.ExportTopoJSONQuantized(geojson, defaultTopoJSONQuantization)
+}
+
+// ExportTopoJSONQuantized topology-encodes a GeoJSON FeatureCollection. Shared boundaries are
+// stored once as arcs. quantization is the number of distinct grid positions per axis
+// (e.g. 1e4 or 1e5); 0 disables quantization and keeps full-precision coordinates.
+func (a *App) ExportTopoJSONQuantized(geojson map[string]interface{}, quantization int) ([]byte, error) {
+	if quantization < 0 || quantization == 1 {
+		return nil, fmt.Errorf("quantization must be 0 (disabled) or at least 2, got %d", quantization)
+	}
+
+	topo, err := encodeTopology(geojson, quantization)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(topo)
+}
+
+// topoLine is one LineString or ring fed to the topology builder
+type topoLine struct {
+	coords []orb.Point
+	ring   bool
+	arcs   []int
+}
+
+// topoEncoder builds shared arcs from the lines and rings of a set of features
+type topoEncoder struct {
+	lines     []*topoLine
+	junctions map[orb.Point]bool
+	arcs      [][]orb.Point
+	arcIndex  map[string]int
+	quantize  func(orb.Point) orb.Point
+}
+
+// encodeTopology converts features to a topology with a single "collection" object
+func encodeTopology(geojson map[string]interface{}, quantization int) (*topology, error) {
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, err
+	}
+
+	geometries := make([]orb.Geometry, len(features))
+	bound := orb.Bound{Min: orb.Point{math.Inf(1), math.Inf(1)}, Max: orb.Point{math.Inf(-1), math.Inf(-1)}}
+	for i, feature := range features {
+		g, err := orbGeometry(feature["geometry"])
+		if err != nil {
+			return nil, fmt.Errorf("feature %d: %v", i, err)
+		}
+		geometries[i] = g
+		if g != nil {
+			b := g.Bound()
+			bound = bound.Union(b)
+		}
+	}
+	if math.IsInf(bound.Min[0], 1) {
+		bound = orb.Bound{}
+	}
+
+	topo := &topology{
+		Type:    "Topology",
+		BBox:    []float64{bound.Min[0], bound.Min[1], bound.Max[0], bound.Max[1]},
+		Objects: map[string]*topoGeometry{},
+		Arcs:    [][][]float64{},
+	}
+
+	enc := &topoEncoder{
+		junctions: map[orb.Point]bool{},
+		arcIndex:  map[string]int{},
+		quantize:  func(p orb.Point) orb.Point { return p },
+	}
+
+	if quantization > 0 {
+		kx, ky := 1.0, 1.0
+		if bound.Max[0] > bound.Min[0] {
+			kx = float64(quantization-1) / (bound.Max[0] - bound.Min[0])
+		}
+		if bound.Max[1] > bound.Min[1] {
+			ky = float64(quantization-1) / (bound.Max[1] - bound.Min[1])
+		}
+		topo.Transform = &topoTransform{
+			Scale:     [2]float64{1 / kx, 1 / ky},
+			Translate: [2]float64{bound.Min[0], bound.Min[1]},
+		}
+		enc.quantize = func(p orb.Point) orb.Point {
+			return orb.Point{math.Round((p[0] - bound.Min[0]) * kx), math.Round((p[1] - bound.Min[1]) * ky)}
+		}
+	}
+
+	// First pass registers every line so junctions can be found across all features
+	encoders := make([]func() *topoGeometry, len(features))
+	for i, g := range geometries {
+		encoders[i] = enc.register(g)
+	}
+	enc.findJunctions()
+	for _, line := range enc.lines {
+		enc.cut(line)
+	}
+
+	collection := &topoGeometry{Type: "GeometryCollection"}
+	for i, feature := range features {
+		member := encoders[i]()
+		if props, ok := feature["properties"].(map[string]interface{}); ok && len(props) > 0 {
+			member.Properties = props
+		}
+		if id, ok := feature["id"]; ok {
+			member.ID = id
+		}
+		collection.Geometries = append(collection.Geometries, member)
+	}
+
+	name := "collection"
+	if n, ok := geojson["name"].(string); ok && n != "" {
+		name = n
+	}
+	topo.Objects[name] = collection
+
+	for _, arc := range enc.arcs {
+		topo.Arcs = append(topo.Arcs, enc.encodeArc(arc, topo.Transform != nil))
+	}
+
+	return topo, nil
+}
+
+// register records the lines of a geometry and returns a closure that builds its TopoJSON
+// object once arcs have been cut
+func (e *topoEncoder) register(g orb.Geometry) func() *topoGeometry {
+	switch g := g.(type) {
+	case nil:
+		return func() *topoGeometry { return &topoGeometry{Type: "null"} }
+
+	case orb.Point:
+		p := e.quantize(g)
+		return func() *topoGeometry {
+			return &topoGeometry{Type: "Point", Coordinates: mustJSON([]float64{p[0], p[1]})}
+		}
+
+	case orb.MultiPoint:
+		coords := make([][]float64, len(g))
+		for i, p := range g {
+			q := e.quantize(p)
+			coords[i] = []float64{q[0], q[1]}
+		}
+		return func() *topoGeometry {
+			return &topoGeometry{Type: "MultiPoint", Coordinates: mustJSON(coords)}
+		}
+
+	case orb.LineString:
+		line := e.addLine(g, false)
+		return func() *topoGeometry {
+			return &topoGeometry{Type: "LineString", Arcs: mustJSON(line.arcs)}
+		}
+
+	case orb.MultiLineString:
+		lines := make([]*topoLine, len(g))
+		for i, ls := range g {
+			lines[i] = e.addLine(ls, false)
+		}
+		return func() *topoGeometry {
+			return &topoGeometry{Type: "MultiLineString", Arcs: mustJSON(lineArcs(lines))}
+		}
+
+	case orb.Polygon:
+		rings := e.addPolygon(g)
+		return func() *topoGeometry {
+			return &topoGeometry{Type: "Polygon", Arcs: mustJSON(lineArcs(rings))}
+		}
+
+	case orb.MultiPolygon:
+		polygons := make([][]*topoLine, len(g))
+		for i, p := range g {
+			polygons[i] = e.addPolygon(p)
+		}
+		return func() *topoGeometry {
+			refs := make([][][]int, len(polygons))
+			for i, rings := range polygons {
+				refs[i] = lineArcs(rings)
+			}
+			return &topoGeometry{Type: "MultiPolygon", Arcs: mustJSON(refs)}
+		}
+
+	case orb.Collection:
+		members := make([]func() *topoGeometry, len(g))
+		for i, member := range g {
+			members[i] = e.register(member)
+		}
+		return func() *topoGeometry {
+			collection := &topoGeometry{Type: "GeometryCollection"}
+			for _, member := range members {
+				collection.Geometries = append(collection.Geometries, member())
+			}
+			return collection
+		}
+	}
+
+	return func() *topoGeometry { return &topoGeometry{Type: "null"} }
+}
+
+func (e *topoEncoder) addPolygon(p orb.Polygon) []*topoLine {
+	rings := make([]*topoLine, len(p))
+	for i, ring := range p {
+		rings[i] = e.addLine(orb.LineString(ring), true)
+	}
+	return rings
+}
+
+// addLine quantizes a line, drops repeated points and registers it for junction detection
+func (e *topoEncoder) addLine(ls orb.LineString, ring bool) *topoLine {
+	coords := make([]orb.Point, 0, len(ls))
+	for _, p := range ls {
+		q := e.quantize(p)
+		if len(coords) == 0 || coords[len(coords)-1] != q {
+			coords = append(coords, q)
+		}
+	}
+	if ring && len(coords) > 0 && coords[0] != coords[len(coords)-1] {
+		coords = append(coords, coords[0])
+	}
+	// Arcs need at least two positions even when quantization collapsed the line
+	for len(coords) > 0 && len(coords) < 2 {
+		coords = append(coords, coords[0])
+	}
+
+	line := &topoLine{coords: coords, ring: ring}
+	e.lines = append(e.lines, line)
+	return line
+}
+
+// findJunctions marks every point where lines meet with different neighbours, plus the
+// endpoints of open lines. Arcs are cut at these points so shared stretches are stored once.
+func (e *topoEncoder) findJunctions() {
+	type neighbours [2]orb.Point
+	seen := map[orb.Point]neighbours{}
+
+	order := func(a, b orb.Point) neighbours {
+		if a[0] < b[0] || (a[0] == b[0] && a[1] < b[1]) {
+			return neighbours{a, b}
+		}
+		return neighbours{b, a}
+	}
+
+	visit := func(p orb.Point, n neighbours) {
+		if prev, ok := seen[p]; ok {
+			if prev != n {
+				e.junctions[p] = true
+			}
+			return
+		}
+		seen[p] = n
+	}
+
+	for _, line := range e.lines {
+		c := line.coords
+		if len(c) == 0 {
+			continue
+		}
+		if line.ring {
+			n := len(c) - 1 // closing point duplicates the first
+			for i := 0; i < n; i++ {
+				prev := c[(i-1+n)%n]
+				next := c[(i+1)%n]
+				visit(c[i], order(prev, next))
+			}
+			continue
+		}
+
+		e.junctions[c[0]] = true
+		e.junctions[c[len(c)-1]] = true
+		for i := 1; i < len(c)-1; i++ {
+			visit(c[i], order(c[i-1], c[i+1]))
+		}
+	}
+}
+
+// cut splits a line at its junctions and records the resulting (deduplicated) arc references
+func (e *topoEncoder) cut(line *topoLine) {
+	c := line.coords
+	if line.ring && len(c) > 1 {
+		n := len(c) - 1
+		start := -1
+		for i := 0; i < n; i++ {
+			if e.junctions[c[i]] {
+				start = i
+				break
+			}
+		}
+
+		if start == -1 {
+			// A ring without junctions is one arc; rotate it to a canonical start so identical
+			// rings from different features are still shared
+			start = 0
+			for i := 1; i < n; i++ {
+				if c[i][0] < c[start][0] || (c[i][0] == c[start][0] && c[i][1] < c[start][1]) {
+					start = i
+				}
+			}
+			rotated := append(append([]orb.Point{}, c[start:n]...), c[:start+1]...)
+			line.arcs = []int{e.arc(rotated)}
+			return
+		}
+
+		c = append(append([]orb.Point{}, c[start:n]...), c[:start+1]...)
+	}
+
+	from := 0
+	for i := 1; i < len(c); i++ {
+		if i == len(c)-1 || e.junctions[c[i]] {
+			line.arcs = append(line.arcs, e.arc(c[from:i+1]))
+			from = i
+		}
+	}
+}
+
+// arc returns the reference for a coordinate sequence, reusing an existing arc (possibly reversed)
+func (e *topoEncoder) arc(coords []orb.Point) int {
+	key := arcKey(coords, false)
+	if index, ok := e.arcIndex[key]; ok {
+		return index
+	}
+	if index, ok := e.arcIndex[arcKey(coords, true)]; ok {
+		return ^index
+	}
+
+	index := len(e.arcs)
+	e.arcs = append(e.arcs, append([]orb.Point{}, coords...))
+	e.arcIndex[key] = index
+	return index
+}
+
+// encodeArc delta-encodes a quantized arc
+func (e *topoEncoder) encodeArc(arc []orb.Point, quantized bool) [][]float64 {
+	out := make([][]float64, len(arc))
+	var prev orb.Point
+	for i, p := range arc {
+		if quantized {
+			out[i] = []float64{p[0] - prev[0], p[1] - prev[1]}
+			prev = p
+		} else {
+			out[i] = []float64{p[0], p[1]}
+		}
+	}
+	return out
+}
+
+func arcKey(coords []orb.Point, reverse bool) string {
+	var b strings.Builder
+	for i := range coords {
+		p := coords[i]
+		if reverse {
+			p = coords[len(coords)-1-i]
+		}
+		b.WriteString(strconv.FormatFloat(p[0], 'g', -1, 64))
+		b.WriteByte(',')
+		b.WriteString(strconv.FormatFloat(p[1], 'g', -1, 64))
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+func lineArcs(lines []*topoLine) [][]int {
+	refs := make([][]int, len(lines))
+	for i, line := range lines {
+		refs[i] = line.arcs
+	}
+	return refs
+}
+
+func mustJSON(v interface{}) json.RawMessage {
+	data, _ := json.Marshal(v)
+	return data
+}
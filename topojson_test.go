@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/paulmach/orb"
+)
+
+// topoJSONFixture has polygons sharing an edge, a hole, lines, points and a null geometry
+const topoJSONFixture = `{
+	"type": "FeatureCollection",
+	"name": "parcels",
+	"features": [
+		{"type": "Feature", "id": 1, "properties": {"name": "west", "area": 100, "tags": ["a", "b"]},
+			"geometry": {"type": "Polygon", "coordinates": [[[0,0],[10,0],[10,10],[0,10],[0,0]]]}},
+		{"type": "Feature", "id": "east", "properties": {"name": "east", "owner": {"id": 7}},
+			"geometry": {"type": "Polygon", "coordinates": [[[10,0],[20,0],[20,10],[10,10],[10,0]],
+				[[14,4],[14,6],[16,6],[16,4],[14,4]]]}},
+		{"type": "Feature", "properties": {"kind": "road"},
+			"geometry": {"type": "LineString", "coordinates": [[0,-5],[10,-5],[20,-5]]}},
+		{"type": "Feature", "properties": {"kind": "rivers"},
+			"geometry": {"type": "MultiLineString", "coordinates": [[[0,12],[5,15]],[[5,15],[20,12]]]}},
+		{"type": "Feature", "properties": {"kind": "islands"},
+			"geometry": {"type": "MultiPolygon", "coordinates": [[[[30,0],[32,0],[32,2],[30,0]]],
+				[[[34,0],[36,0],[36,2],[34,0]]]]}},
+		{"type": "Feature", "properties": {"kind": "well"},
+			"geometry": {"type": "Point", "coordinates": [5,5]}},
+		{"type": "Feature", "properties": {"kind": "trees"},
+			"geometry": {"type": "MultiPoint", "coordinates": [[1,1],[2,2]]}},
+		{"type": "Feature", "properties": {"kind": "unknown"}, "geometry": null}
+	]
+}`
+
+func TestTopoJSONRoundTrip(t *testing.T) {
+	var original map[string]interface{}
+	if err := json.Unmarshal([]byte(topoJSONFixture), &original); err != nil {
+		t.Fatal(err)
+	}
+	originalFeatures, _ := geojsonFeatures(original)
+
+	for _, quantization := range []int{0, defaultTopoJSONQuantization} {
+		// Quantization moves positions by at most half a grid cell
+		tolerance := 1e-9
+		if quantization > 0 {
+			tolerance = 36.0 / float64(quantization-1) / 2
+		}
+
+		data, err := NewApp().ExportTopoJSONQuantized(original, quantization)
+		if err != nil {
+			t.Fatalf("quantization %d: %v", quantization, err)
+		}
+		decoded, err := decodeTopoJSON(data)
+		if err != nil {
+			t.Fatalf("quantization %d: %v", quantization, err)
+		}
+		if decoded["name"] != "parcels" {
+			t.Errorf("quantization %d: name = %v, want parcels", quantization, decoded["name"])
+		}
+
+		features, _ := geojsonFeatures(decoded)
+		if len(features) != len(originalFeatures) {
+			t.Fatalf("quantization %d: %d features, want %d", quantization, len(features), len(originalFeatures))
+		}
+		for i, feature := range features {
+			want := originalFeatures[i]
+			if !reflect.DeepEqual(feature["id"], want["id"]) {
+				t.Errorf("quantization %d, feature %d: id = %v, want %v", quantization, i, feature["id"], want["id"])
+			}
+			if !reflect.DeepEqual(feature["properties"], want["properties"]) {
+				t.Errorf("quantization %d, feature %d: properties = %v, want %v", quantization, i, feature["properties"], want["properties"])
+			}
+
+			got, err := orbGeometry(feature["geometry"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := orbGeometry(want["geometry"])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sameTopoGeometry(got, expected, tolerance) {
+				t.Errorf("quantization %d, feature %d: geometry = %v, want %v", quantization, i, got, expected)
+			}
+		}
+	}
+}
+
+func TestEncodeTopologySharesArcs(t *testing.T) {
+	var squares map[string]interface{}
+	json.Unmarshal([]byte(`{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[0,0],[10,0],[10,10],[0,10],[0,0]]]}},
+		{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[10,0],[20,0],[20,10],[10,10],[10,0]]]}}
+	]}`), &squares)
+
+	topo, err := encodeTopology(squares, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The shared edge is one arc, referenced forwards by one square and reversed by the other
+	if len(topo.Arcs) != 3 {
+		t.Errorf("encoded %d arcs, want 3: %v", len(topo.Arcs), topo.Arcs)
+	}
+}
+
+// sameTopoGeometry compares geometries position by position within tolerance. TopoJSON may
+// start a ring at a different vertex, so rings only need to match up to rotation.
+func sameTopoGeometry(a, b orb.Geometry, tolerance float64) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a.GeoJSONType() != b.GeoJSONType() {
+		return false
+	}
+	samePoint := func(p, q orb.Point) bool {
+		return math.Abs(p[0]-q[0]) <= tolerance && math.Abs(p[1]-q[1]) <= tolerance
+	}
+	sameLine := func(p, q orb.LineString) bool {
+		if len(p) != len(q) {
+			return false
+		}
+		for i := range p {
+			if !samePoint(p[i], q[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	sameRing := func(p, q orb.Ring) bool {
+		if len(p) != len(q) || len(p) == 0 {
+			return len(p) == len(q)
+		}
+		n := len(p) - 1
+		for start := 0; start < n; start++ {
+			match := true
+			for i := 0; i < n && match; i++ {
+				match = samePoint(p[(start+i)%n], q[i])
+			}
+			if match {
+				return true
+			}
+		}
+		return false
+	}
+	samePolygon := func(p, q orb.Polygon) bool {
+		if len(p) != len(q) {
+			return false
+		}
+		for i := range p {
+			if !sameRing(p[i], q[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	switch a := a.(type) {
+	case orb.Point:
+		return samePoint(a, b.(orb.Point))
+	case orb.MultiPoint:
+		return sameLine(orb.LineString(a), orb.LineString(b.(orb.MultiPoint)))
+	case orb.LineString:
+		return sameLine(a, b.(orb.LineString))
+	case orb.MultiLineString:
+		other := b.(orb.MultiLineString)
+		if len(a) != len(other) {
+			return false
+		}
+		for i := range a {
+			if !sameLine(a[i], other[i]) {
+				return false
+			}
+		}
+		return true
+	case orb.Polygon:
+		return samePolygon(a, b.(orb.Polygon))
+	case orb.MultiPolygon:
+		other := b.(orb.MultiPolygon)
+		if len(a) != len(other) {
+			return false
+		}
+		for i := range a {
+			if !samePolygon(a[i], other[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func TestDecodeTopoJSONMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "null object", body: `{"type":"Topology","objects":{"a":null},"arcs":[]}`},
+		{name: "null member", body: `{"type":"Topology","objects":{"a":{"type":"GeometryCollection","geometries":[null]}},"arcs":[]}`},
+		{name: "null nested member", body: `{"type":"Topology","objects":{"a":{"type":"GeometryCollection","geometries":[
+			{"type":"GeometryCollection","geometries":[null]}]}},"arcs":[]}`},
+		{name: "arc out of range", body: `{"type":"Topology","objects":{"a":{"type":"LineString","arcs":[3]}},"arcs":[[[0,0],[1,1]]]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeTopoJSON([]byte(tt.body)); err == nil {
+				t.Error("decodeTopoJSON succeeded, want an error")
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "null.topojson")
+	if err := os.WriteFile(path, []byte(tests[0].body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewApp().extractTopoJSONMetadata(path, &FileMetadata{Metadata: map[string]interface{}{}}); err == nil {
+		t.Error("extractTopoJSONMetadata succeeded on a null object, want an error")
+	}
+}
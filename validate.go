@@ -0,0 +1,565 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Geometry issue codes reported by ValidateGeometry
+const (
+	issueInvalidCoordinates = "invalid_coordinates"
+	issueTooFewPoints       = "too_few_points"
+	issueRingNotClosed      = "ring_not_closed"
+	issueDuplicatePoints    = "duplicate_points"
+	issueWrongWinding       = "wrong_winding"
+	issueSelfIntersection   = "self_intersection"
+)
+
+// GeometryIssue describes one problem found in a feature's geometry. Part is the index within a
+// Multi* geometry or GeometryCollection and Ring the ring index within a polygon (0 = exterior).
+type GeometryIssue struct {
+	FeatureIndex int         `json:"feature_index"`
+	FeatureID    interface{} `json:"feature_id,omitempty"`
+	GeometryType string      `json:"geometry_type"`
+	Part         int         `json:"part"`
+	Ring         int         `json:"ring"`
+	Issue        string      `json:"issue"`
+	Message      string      `json:"message"`
+	Fixable      bool        `json:"fixable"`
+}
+
+// ValidateGeometry checks every feature for unclosed rings, duplicate consecutive points,
+// self-intersecting rings, non-RFC 7946 winding and malformed coordinates
+func (a *App) ValidateGeometry(geojson map[string]interface{}) ([]GeometryIssue, error) {
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := []GeometryIssue{}
+	for i, feature := range features {
+		geometry, _ := feature["geometry"].(map[string]interface{})
+		if geometry == nil {
+			continue
+		}
+
+		found := validateGeometryObject(geometry, 0)
+		for j := range found {
+			found[j].FeatureIndex = i
+			found[j].FeatureID = feature["id"]
+		}
+		issues = append(issues, found...)
+	}
+
+	return issues, nil
+}
+
+// RepairGeometry closes rings, removes duplicate consecutive points and enforces RFC 7946 winding.
+// Self-intersections can't be fixed safely and are left for the user. The returned collection
+// carries repaired_features and remaining_issues counts.
+func (a *App) RepairGeometry(geojson map[string]interface{}) (map[string]interface{}, error) {
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return nil, err
+	}
+
+	repairedFeatures := make([]interface{}, 0, len(features))
+	repaired := 0
+	remaining := 0
+	for _, feature := range features {
+		out := make(map[string]interface{}, len(feature))
+		for k, v := range feature {
+			out[k] = v
+		}
+
+		if geometry, ok := feature["geometry"].(map[string]interface{}); ok && geometry != nil {
+			fixed, changed := repairGeometryObject(geometry)
+			if changed {
+				repaired++
+				out["geometry"] = fixed
+			}
+			remaining += len(validateGeometryObject(fixed, 0))
+		}
+		repairedFeatures = append(repairedFeatures, out)
+	}
+
+	result := map[string]interface{}{}
+	for k, v := range geojson {
+		if k != "features" && k != "geometry" && k != "coordinates" && k != "geometries" {
+			result[k] = v
+		}
+	}
+	result["type"] = "FeatureCollection"
+	result["features"] = repairedFeatures
+	result["repaired_features"] = repaired
+	result["remaining_issues"] = remaining
+
+	return result, nil
+}
+
+// validateGeometryObject returns the issues of one GeoJSON geometry; part is the index within a parent collection
+func validateGeometryObject(geometry map[string]interface{}, part int) []GeometryIssue {
+	geomType, _ := geometry["type"].(string)
+	issues := []GeometryIssue{}
+	add := func(p, ring int, issue string, fixable bool, format string, args ...interface{}) {
+		issues = append(issues, GeometryIssue{
+			GeometryType: geomType,
+			Part:         p,
+			Ring:         ring,
+			Issue:        issue,
+			Message:      fmt.Sprintf(format, args...),
+			Fixable:      fixable,
+		})
+	}
+
+	switch geomType {
+	case "Point":
+		var coord []float64
+		if decodeCoordinates(geometry["coordinates"], &coord) != nil || !validPosition(coord) {
+			add(part, 0, issueInvalidCoordinates, false, "point has invalid coordinates")
+		}
+	case "MultiPoint":
+		var coords [][]float64
+		if decodeCoordinates(geometry["coordinates"], &coords) != nil {
+			add(part, 0, issueInvalidCoordinates, false, "multipoint has invalid coordinates")
+			break
+		}
+		for i, coord := range coords {
+			if !validPosition(coord) {
+				add(i, 0, issueInvalidCoordinates, false, "point %d has invalid coordinates", i)
+			}
+		}
+	case "LineString":
+		var line [][]float64
+		if decodeCoordinates(geometry["coordinates"], &line) != nil || !validPositions(line) {
+			add(part, 0, issueInvalidCoordinates, false, "line has invalid coordinates")
+			break
+		}
+		issues = append(issues, validateLine(line, geomType, part)...)
+	case "MultiLineString":
+		var lines [][][]float64
+		if decodeCoordinates(geometry["coordinates"], &lines) != nil {
+			add(part, 0, issueInvalidCoordinates, false, "multilinestring has invalid coordinates")
+			break
+		}
+		for i, line := range lines {
+			if !validPositions(line) {
+				add(i, 0, issueInvalidCoordinates, false, "line %d has invalid coordinates", i)
+				continue
+			}
+			issues = append(issues, validateLine(line, geomType, i)...)
+		}
+	case "Polygon":
+		var rings [][][]float64
+		if decodeCoordinates(geometry["coordinates"], &rings) != nil {
+			add(part, 0, issueInvalidCoordinates, false, "polygon has invalid coordinates")
+			break
+		}
+		issues = append(issues, validatePolygon(rings, geomType, part)...)
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if decodeCoordinates(geometry["coordinates"], &polygons) != nil {
+			add(part, 0, issueInvalidCoordinates, false, "multipolygon has invalid coordinates")
+			break
+		}
+		for i, rings := range polygons {
+			issues = append(issues, validatePolygon(rings, geomType, i)...)
+		}
+	case "GeometryCollection":
+		geometries, _ := geometry["geometries"].([]interface{})
+		for i, g := range geometries {
+			if child, ok := g.(map[string]interface{}); ok {
+				issues = append(issues, validateGeometryObject(child, i)...)
+			}
+		}
+	default:
+		add(part, 0, issueInvalidCoordinates, false, "unknown geometry type %q", geomType)
+	}
+
+	return issues
+}
+
+func validateLine(line [][]float64, geomType string, part int) []GeometryIssue {
+	var issues []GeometryIssue
+	if n := countDuplicatePoints(line); n > 0 {
+		issues = append(issues, GeometryIssue{
+			GeometryType: geomType, Part: part, Issue: issueDuplicatePoints, Fixable: true,
+			Message: fmt.Sprintf("line has %d duplicate consecutive points", n),
+		})
+	}
+	if len(dedupePositions(line)) < 2 {
+		issues = append(issues, GeometryIssue{
+			GeometryType: geomType, Part: part, Issue: issueTooFewPoints,
+			Message: "line needs at least 2 distinct points",
+		})
+	}
+	return issues
+}
+
+func validatePolygon(rings [][][]float64, geomType string, part int) []GeometryIssue {
+	var issues []GeometryIssue
+	add := func(ring int, issue string, fixable bool, format string, args ...interface{}) {
+		issues = append(issues, GeometryIssue{
+			GeometryType: geomType, Part: part, Ring: ring, Issue: issue, Fixable: fixable,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	for r, ring := range rings {
+		label := "exterior ring"
+		if r > 0 {
+			label = fmt.Sprintf("hole %d", r)
+		}
+
+		if !validPositions(ring) {
+			add(r, issueInvalidCoordinates, false, "%s has invalid coordinates", label)
+			continue
+		}
+		if len(ring) > 0 && !samePosition(ring[0], ring[len(ring)-1]) {
+			add(r, issueRingNotClosed, true, "%s is not closed", label)
+		}
+		if n := countDuplicatePoints(ring); n > 0 {
+			add(r, issueDuplicatePoints, true, "%s has %d duplicate consecutive points", label, n)
+		}
+
+		closed := closeRing(dedupePositions(ring))
+		if len(closed) < 4 {
+			add(r, issueTooFewPoints, false, "%s needs at least 3 distinct points", label)
+			continue
+		}
+		if ringSelfIntersects(closed) {
+			add(r, issueSelfIntersection, false, "%s intersects itself", label)
+		}
+
+		// RFC 7946: exterior rings counter-clockwise, holes clockwise
+		area := ringSignedArea(closed)
+		if (r == 0 && area < 0) || (r > 0 && area > 0) {
+			add(r, issueWrongWinding, true, "%s has the wrong winding order", label)
+		}
+	}
+	return issues
+}
+
+// repairGeometryObject returns a fixed copy of a geometry and whether anything changed
+func repairGeometryObject(geometry map[string]interface{}) (map[string]interface{}, bool) {
+	geomType, _ := geometry["type"].(string)
+	out := make(map[string]interface{}, len(geometry))
+	for k, v := range geometry {
+		out[k] = v
+	}
+
+	switch geomType {
+	case "LineString":
+		var line [][]float64
+		if decodeCoordinates(geometry["coordinates"], &line) != nil {
+			return geometry, false
+		}
+		if countDuplicatePoints(line) == 0 {
+			return geometry, false
+		}
+		out["coordinates"] = dedupePositions(line)
+	case "MultiLineString":
+		var lines [][][]float64
+		if decodeCoordinates(geometry["coordinates"], &lines) != nil {
+			return geometry, false
+		}
+		changed := false
+		for i, line := range lines {
+			if countDuplicatePoints(line) > 0 {
+				lines[i] = dedupePositions(line)
+				changed = true
+			}
+		}
+		if !changed {
+			return geometry, false
+		}
+		out["coordinates"] = lines
+	case "Polygon":
+		var rings [][][]float64
+		if decodeCoordinates(geometry["coordinates"], &rings) != nil {
+			return geometry, false
+		}
+		fixed, changed := repairPolygonRings(rings)
+		if !changed {
+			return geometry, false
+		}
+		out["coordinates"] = fixed
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if decodeCoordinates(geometry["coordinates"], &polygons) != nil {
+			return geometry, false
+		}
+		changed := false
+		for i, rings := range polygons {
+			if fixed, c := repairPolygonRings(rings); c {
+				polygons[i] = fixed
+				changed = true
+			}
+		}
+		if !changed {
+			return geometry, false
+		}
+		out["coordinates"] = polygons
+	case "GeometryCollection":
+		geometries, _ := geometry["geometries"].([]interface{})
+		fixedGeometries := make([]interface{}, len(geometries))
+		changed := false
+		for i, g := range geometries {
+			fixedGeometries[i] = g
+			if child, ok := g.(map[string]interface{}); ok {
+				if fixed, c := repairGeometryObject(child); c {
+					fixedGeometries[i] = fixed
+					changed = true
+				}
+			}
+		}
+		if !changed {
+			return geometry, false
+		}
+		out["geometries"] = fixedGeometries
+	default:
+		return geometry, false
+	}
+
+	return out, true
+}
+
+// repairPolygonRings dedupes and closes every ring, drops holes that collapse, and enforces winding
+func repairPolygonRings(rings [][][]float64) ([][][]float64, bool) {
+	fixed := make([][][]float64, 0, len(rings))
+	changed := false
+	for r, ring := range rings {
+		if !validPositions(ring) {
+			fixed = append(fixed, ring)
+			continue
+		}
+
+		if countDuplicatePoints(ring) > 0 || (len(ring) > 0 && !samePosition(ring[0], ring[len(ring)-1])) {
+			changed = true
+		}
+		clean := closeRing(dedupePositions(ring))
+		if len(clean) < 4 && r > 0 {
+			// A degenerate hole covers no area; dropping it is the only sensible fix
+			changed = true
+			continue
+		}
+		fixed = append(fixed, clean)
+	}
+
+	if enforceWinding(fixed) {
+		changed = true
+	}
+	return fixed, changed
+}
+
+// NormalizeGeoJSONWinding rewrites polygon rings to follow the RFC 7946 right-hand rule
+// (exterior rings counter-clockwise, holes clockwise)
+func (a *App) NormalizeGeoJSONWinding(geojson map[string]interface{}) (map[string]interface{}, error) {
+	if _, err := normalizeWinding(geojson); err != nil {
+		return nil, err
+	}
+	return geojson, nil
+}
+
+// normalizeWinding orients every Polygon and MultiPolygon ring in place and returns how many
+// features were changed
+func normalizeWinding(geojson map[string]interface{}) (int, error) {
+	features, err := geojsonFeatures(geojson)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, feature := range features {
+		if geometry, ok := feature["geometry"].(map[string]interface{}); ok && geometry != nil {
+			if orientGeometry(geometry) {
+				changed++
+			}
+		}
+	}
+	return changed, nil
+}
+
+// orientGeometry applies enforceWinding to a geometry's rings, rewriting its coordinates in place
+func orientGeometry(geometry map[string]interface{}) bool {
+	switch geometry["type"] {
+	case "Polygon":
+		var rings [][][]float64
+		if decodeCoordinates(geometry["coordinates"], &rings) != nil || !enforceWinding(rings) {
+			return false
+		}
+		geometry["coordinates"] = rings
+		return true
+	case "MultiPolygon":
+		var polygons [][][][]float64
+		if decodeCoordinates(geometry["coordinates"], &polygons) != nil {
+			return false
+		}
+		changed := false
+		for _, rings := range polygons {
+			if enforceWinding(rings) {
+				changed = true
+			}
+		}
+		if changed {
+			geometry["coordinates"] = polygons
+		}
+		return changed
+	case "GeometryCollection":
+		geometries, _ := geometry["geometries"].([]interface{})
+		changed := false
+		for _, g := range geometries {
+			if child, ok := g.(map[string]interface{}); ok && orientGeometry(child) {
+				changed = true
+			}
+		}
+		return changed
+	}
+	return false
+}
+
+// enforceWinding orients a polygon's rings per RFC 7946 (exterior CCW, holes CW) in place,
+// reporting whether any ring was reversed
+func enforceWinding(rings [][][]float64) bool {
+	changed := false
+	for r, ring := range rings {
+		if len(ring) < 4 {
+			continue
+		}
+		area := ringSignedArea(ring)
+		if (r == 0 && area < 0) || (r > 0 && area > 0) {
+			reversePositions(ring)
+			changed = true
+		}
+	}
+	return changed
+}
+
+// decodeCoordinates converts a GeoJSON coordinates value, whether decoded from JSON ([]interface{})
+// or built in Go, into a typed slice. Every dimension of each position is kept.
+func decodeCoordinates(value interface{}, out interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func validPosition(p []float64) bool {
+	if len(p) < 2 {
+		return false
+	}
+	for _, v := range p {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func validPositions(positions [][]float64) bool {
+	for _, p := range positions {
+		if !validPosition(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// samePosition compares positions in 2D; a repeated vertex differing only in Z is still a duplicate
+func samePosition(a, b []float64) bool {
+	return a[0] == b[0] && a[1] == b[1]
+}
+
+func countDuplicatePoints(positions [][]float64) int {
+	n := 0
+	for i := 1; i < len(positions); i++ {
+		if samePosition(positions[i-1], positions[i]) {
+			n++
+		}
+	}
+	return n
+}
+
+// dedupePositions drops consecutive repeated positions
+func dedupePositions(positions [][]float64) [][]float64 {
+	out := make([][]float64, 0, len(positions))
+	for i, p := range positions {
+		if i > 0 && samePosition(positions[i-1], p) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// closeRing appends the first position when a ring isn't closed
+func closeRing(ring [][]float64) [][]float64 {
+	if len(ring) > 0 && !samePosition(ring[0], ring[len(ring)-1]) {
+		closed := make([]float64, len(ring[0]))
+		copy(closed, ring[0])
+		ring = append(ring, closed)
+	}
+	return ring
+}
+
+func reversePositions(positions [][]float64) {
+	for i, j := 0, len(positions)-1; i < j; i, j = i+1, j-1 {
+		positions[i], positions[j] = positions[j], positions[i]
+	}
+}
+
+// ringSelfIntersects tests every pair of non-adjacent edges of a closed ring for intersection
+func ringSelfIntersects(ring [][]float64) bool {
+	edges := len(ring) - 1
+	for i := 0; i < edges; i++ {
+		for j := i + 1; j < edges; j++ {
+			// Adjacent edges share a vertex, including the last and first edge of the ring
+			if j == i+1 || (i == 0 && j == edges-1) {
+				continue
+			}
+			if segmentsIntersect(ring[i], ring[i+1], ring[j], ring[j+1]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// segmentsIntersect reports whether segments p1-p2 and p3-p4 touch or cross
+func segmentsIntersect(p1, p2, p3, p4 []float64) bool {
+	d1 := orientation(p3, p4, p1)
+	d2 := orientation(p3, p4, p2)
+	d3 := orientation(p1, p2, p3)
+	d4 := orientation(p1, p2, p4)
+
+	if ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0)) {
+		return true
+	}
+	return (d1 == 0 && onSegment(p3, p4, p1)) ||
+		(d2 == 0 && onSegment(p3, p4, p2)) ||
+		(d3 == 0 && onSegment(p1, p2, p3)) ||
+		(d4 == 0 && onSegment(p1, p2, p4))
+}
+
+// segmentsCross reports whether p1-p2 and p3-p4 cross at a single point inside both. Unlike
+// segmentsIntersect, touching at an end or running along each other doesn't count.
+func segmentsCross(p1, p2, p3, p4 []float64) bool {
+	d1 := orientation(p3, p4, p1)
+	d2 := orientation(p3, p4, p2)
+	d3 := orientation(p1, p2, p3)
+	d4 := orientation(p1, p2, p4)
+	return ((d1 > 0 && d2 < 0) || (d1 < 0 && d2 > 0)) && ((d3 > 0 && d4 < 0) || (d3 < 0 && d4 > 0))
+}
+
+// orientation is the cross product of (b-a) and (c-a): positive when a, b, c turn counter-clockwise
+func orientation(a, b, c []float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+// onSegment reports whether p, known to be collinear with a-b, lies within the segment's bounds
+func onSegment(a, b, p []float64) bool {
+	return math.Min(a[0], b[0]) <= p[0] && p[0] <= math.Max(a[0], b[0]) &&
+		math.Min(a[1], b[1]) <= p[1] && p[1] <= math.Max(a[1], b[1])
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// Rings used by the winding tests: a 10x10 square with a 2x2 hole, in both orientations
+const (
+	exteriorCW  = `[[0,0],[0,10],[10,10],[10,0],[0,0]]`
+	exteriorCCW = `[[0,0],[10,0],[10,10],[0,10],[0,0]]`
+	holeCW      = `[[4,4],[4,6],[6,6],[6,4],[4,4]]`
+	holeCCW     = `[[4,4],[6,4],[6,6],[4,6],[4,4]]`
+)
+
+func TestNormalizeWinding(t *testing.T) {
+	tests := []struct {
+		name     string
+		geometry string
+		changed  int
+	}{
+		{
+			name:     "clockwise exterior and counter-clockwise hole",
+			geometry: `{"type":"Polygon","coordinates":[` + exteriorCW + `,` + holeCCW + `]}`,
+			changed:  1,
+		},
+		{
+			name:     "already RFC 7946",
+			geometry: `{"type":"Polygon","coordinates":[` + exteriorCCW + `,` + holeCW + `]}`,
+			changed:  0,
+		},
+		{
+			name:     "only the hole is wrong",
+			geometry: `{"type":"Polygon","coordinates":[` + exteriorCCW + `,` + holeCCW + `]}`,
+			changed:  1,
+		},
+		{
+			name:     "multipolygon",
+			geometry: `{"type":"MultiPolygon","coordinates":[[` + exteriorCCW + `],[` + exteriorCW + `,` + holeCCW + `]]}`,
+			changed:  1,
+		},
+		{
+			name:     "geometry collection",
+			geometry: `{"type":"GeometryCollection","geometries":[{"type":"Polygon","coordinates":[` + exteriorCW + `]}]}`,
+			changed:  1,
+		},
+		{
+			name:     "not a polygon",
+			geometry: `{"type":"LineString","coordinates":[[0,0],[1,1]]}`,
+			changed:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var geometry map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.geometry), &geometry); err != nil {
+				t.Fatal(err)
+			}
+			geojson := map[string]interface{}{
+				"type": "FeatureCollection",
+				"features": []interface{}{
+					map[string]interface{}{"type": "Feature", "geometry": geometry, "properties": map[string]interface{}{}},
+				},
+			}
+
+			changed, err := normalizeWinding(geojson)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed != tt.changed {
+				t.Errorf("normalizeWinding changed %d features, want %d", changed, tt.changed)
+			}
+			checkWinding(t, geometry)
+		})
+	}
+}
+
+// checkWinding fails unless every polygon in geometry has a counter-clockwise exterior and
+// clockwise holes
+func checkWinding(t *testing.T, geometry map[string]interface{}) {
+	t.Helper()
+	var polygons [][][][]float64
+	switch geometry["type"] {
+	case "Polygon":
+		var rings [][][]float64
+		if err := decodeCoordinates(geometry["coordinates"], &rings); err != nil {
+			t.Fatal(err)
+		}
+		polygons = append(polygons, rings)
+	case "MultiPolygon":
+		if err := decodeCoordinates(geometry["coordinates"], &polygons); err != nil {
+			t.Fatal(err)
+		}
+	case "GeometryCollection":
+		for _, g := range geometry["geometries"].([]interface{}) {
+			checkWinding(t, g.(map[string]interface{}))
+		}
+	}
+
+	for p, rings := range polygons {
+		for r, ring := range rings {
+			area := ringSignedArea(ring)
+			if r == 0 && area <= 0 {
+				t.Errorf("polygon %d exterior is clockwise: %v", p, ring)
+			}
+			if r > 0 && area >= 0 {
+				t.Errorf("polygon %d hole %d is counter-clockwise: %v", p, r, ring)
+			}
+		}
+	}
+}
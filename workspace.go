@@ -0,0 +1,315 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Workspace is a named collection of layers and saved Overpass queries
+type Workspace struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+	LayerCount int    `json:"layer_count"`
+	QueryCount int    `json:"query_count"`
+}
+
+// WorkspaceQuery is an Overpass query saved in a workspace
+type WorkspaceQuery struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Query     string `json:"query"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CreateWorkspace creates an empty workspace and returns its ID
+func (a *App) CreateWorkspace(name string) (int, error) {
+	if a.db == nil {
+		return 0, fmt.Errorf("database not initialized")
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, fmt.Errorf("workspace name is required")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := time.Now().Format(time.RFC3339)
+	result, err := a.db.Exec("INSERT INTO workspaces (name, created_at, updated_at) VALUES (?, ?, ?)", name, now, now)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			return 0, fmt.Errorf("workspace %q already exists", name)
+		}
+		return 0, fmt.Errorf("failed to create workspace: %v", err)
+	}
+
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// DeleteWorkspace removes a workspace along with its layer and query lists
+func (a *App) DeleteWorkspace(name string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result, err := a.db.Exec("DELETE FROM workspaces WHERE name = ?", name)
+	if err != nil {
+		return fmt.Errorf("failed to delete workspace: %v", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("workspace %q not found", name)
+	}
+	return nil
+}
+
+// AddLayerToWorkspace adds an indexed file to a workspace. Layers are stored by path and layer
+// name rather than index ID because re-indexing rebuilds geo_file_index with new IDs.
+func (a *App) AddLayerToWorkspace(workspaceName string, fileIndexID int) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	workspaceID, err := a.workspaceID(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	var filePath, layerName string
+	err = a.db.QueryRow("SELECT file_path, layer_name FROM geo_file_index WHERE id = ?", fileIndexID).Scan(&filePath, &layerName)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("indexed file %d not found", fileIndexID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up indexed file: %v", err)
+	}
+
+	// New layers go on top of the existing stack
+	query := `
+		INSERT OR IGNORE INTO workspace_layers (workspace_id, file_path, layer_name, position)
+		SELECT ?, ?, ?, COALESCE(MAX(position), -1) + 1 FROM workspace_layers WHERE workspace_id = ?
+	`
+	if _, err := a.db.Exec(query, workspaceID, filePath, layerName, workspaceID); err != nil {
+		return fmt.Errorf("failed to add layer to workspace: %v", err)
+	}
+
+	return a.touchWorkspace(workspaceID)
+}
+
+// RemoveLayerFromWorkspace removes a layer from a workspace by file path
+func (a *App) RemoveLayerFromWorkspace(workspaceName string, filePath string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	workspaceID, err := a.workspaceID(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	if _, err := a.db.Exec("DELETE FROM workspace_layers WHERE workspace_id = ? AND file_path = ?", workspaceID, filePath); err != nil {
+		return fmt.Errorf("failed to remove layer from workspace: %v", err)
+	}
+
+	return a.touchWorkspace(workspaceID)
+}
+
+// AddQueryToWorkspace saves an Overpass query in a workspace
+func (a *App) AddQueryToWorkspace(workspaceName string, queryName string, query string) error {
+	if a.db == nil {
+		return fmt.Errorf("database not initialized")
+	}
+
+	if strings.TrimSpace(query) == "" {
+		return fmt.Errorf("query is empty")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	workspaceID, err := a.workspaceID(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.db.Exec(
+		"INSERT INTO workspace_queries (workspace_id, name, query, created_at) VALUES (?, ?, ?, ?)",
+		workspaceID, queryName, query, time.Now().Format(time.RFC3339),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save query to workspace: %v", err)
+	}
+
+	return a.touchWorkspace(workspaceID)
+}
+
+// ListWorkspaces returns all workspaces with their layer and query counts
+func (a *App) ListWorkspaces() ([]Workspace, error) {
+	if a.db == nil {
+		return []Workspace{}, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	query := `
+		SELECT w.id, w.name, w.created_at, w.updated_at,
+		       (SELECT COUNT(*) FROM workspace_layers l WHERE l.workspace_id = w.id),
+		       (SELECT COUNT(*) FROM workspace_queries q WHERE q.workspace_id = w.id)
+		FROM workspaces w
+		ORDER BY w.updated_at DESC
+	`
+
+	rows, err := a.db.Query(query)
+	if err != nil {
+		return []Workspace{}, fmt.Errorf("failed to list workspaces: %v", err)
+	}
+	defer rows.Close()
+
+	workspaces := []Workspace{}
+	for rows.Next() {
+		var w Workspace
+		if err := rows.Scan(&w.ID, &w.Name, &w.CreatedAt, &w.UpdatedAt, &w.LayerCount, &w.QueryCount); err != nil {
+			return workspaces, fmt.Errorf("failed to read workspace: %v", err)
+		}
+		workspaces = append(workspaces, w)
+	}
+
+	return workspaces, nil
+}
+
+// LoadWorkspace resolves a workspace's layers to their index records, in stacking order.
+// Layers whose file is gone from disk are returned with Missing set instead of failing the load.
+func (a *App) LoadWorkspace(name string) ([]GeoFileIndex, error) {
+	if a.db == nil {
+		return []GeoFileIndex{}, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	workspaceID, err := a.workspaceID(name)
+	if err != nil {
+		return []GeoFileIndex{}, err
+	}
+
+	rows, err := a.db.Query(
+		"SELECT file_path, layer_name FROM workspace_layers WHERE workspace_id = ? ORDER BY position",
+		workspaceID,
+	)
+	if err != nil {
+		return []GeoFileIndex{}, fmt.Errorf("failed to load workspace layers: %v", err)
+	}
+
+	type layerRef struct{ filePath, layerName string }
+	var refs []layerRef
+	for rows.Next() {
+		var ref layerRef
+		if err := rows.Scan(&ref.filePath, &ref.layerName); err != nil {
+			rows.Close()
+			return []GeoFileIndex{}, fmt.Errorf("failed to read workspace layer: %v", err)
+		}
+		refs = append(refs, ref)
+	}
+	rows.Close()
+
+	layers := []GeoFileIndex{}
+	for _, ref := range refs {
+		row := a.db.QueryRow(
+			"SELECT "+geoFileIndexColumns+" FROM geo_file_index WHERE file_path = ? AND layer_name = ?",
+			ref.filePath, ref.layerName,
+		)
+		layer, err := scanGeoFileIndex(row)
+		if err != nil {
+			// No longer indexed (e.g. after re-indexing another folder); keep what we know
+			ext := filepath.Ext(ref.filePath)
+			layer = GeoFileIndex{
+				FilePath:  ref.filePath,
+				FileName:  filepath.Base(ref.filePath),
+				LayerName: ref.layerName,
+				FileExt:   ext,
+				FileType:  a.determineFileType(strings.ToLower(ext)),
+			}
+		}
+
+		if _, err := os.Stat(ref.filePath); err != nil {
+			layer.Missing = true
+		}
+		layers = append(layers, layer)
+	}
+
+	return layers, nil
+}
+
+// GetWorkspaceQueries returns the Overpass queries saved in a workspace
+func (a *App) GetWorkspaceQueries(name string) ([]WorkspaceQuery, error) {
+	if a.db == nil {
+		return []WorkspaceQuery{}, fmt.Errorf("database not initialized")
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	workspaceID, err := a.workspaceID(name)
+	if err != nil {
+		return []WorkspaceQuery{}, err
+	}
+
+	rows, err := a.db.Query(
+		"SELECT id, name, query, created_at FROM workspace_queries WHERE workspace_id = ? ORDER BY id",
+		workspaceID,
+	)
+	if err != nil {
+		return []WorkspaceQuery{}, fmt.Errorf("failed to load workspace queries: %v", err)
+	}
+	defer rows.Close()
+
+	queries := []WorkspaceQuery{}
+	for rows.Next() {
+		var q WorkspaceQuery
+		if err := rows.Scan(&q.ID, &q.Name, &q.Query, &q.CreatedAt); err != nil {
+			return queries, fmt.Errorf("failed to read workspace query: %v", err)
+		}
+		queries = append(queries, q)
+	}
+
+	return queries, nil
+}
+
+// workspaceID looks up a workspace by name. Callers must hold a.mu.
+func (a *App) workspaceID(name string) (int, error) {
+	var id int
+	err := a.db.QueryRow("SELECT id FROM workspaces WHERE name = ?", name).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("workspace %q not found", name)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up workspace: %v", err)
+	}
+	return id, nil
+}
+
+// touchWorkspace bumps a workspace's updated_at. Callers must hold a.mu.
+func (a *App) touchWorkspace(workspaceID int) error {
+	_, err := a.db.Exec("UPDATE workspaces SET updated_at = ? WHERE id = ?", time.Now().Format(time.RFC3339), workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to update workspace: %v", err)
+	}
+	return nil
+}